@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/discovery"
+)
+
+// runDiscover implements `snmpcollector discover`: sweep CIDR ranges for SNMP
+// agents and write the responders to a device file.
+//
+// Usage:
+//
+//	snmpcollector discover -targets=10.0.0.0/24,10.0.1.0/24 -communities=public,private [flags]
+//	snmpcollector discover -targets=10.0.0.0/24 -credentials=corp_v3 -communities='${SNMP_COMMUNITY}' [flags]
+func runDiscover(args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	var (
+		logLevel    string
+		logFmt      string
+		targets     string
+		profiles    string
+		communities string
+		v3File      string
+		port        int
+		timeoutMs   int
+		retries     int
+		concurrency int
+		rate        float64
+		maxHosts    int
+		output      string
+		dryRun      bool
+
+		cfgDevices   string
+		cfgVendors   string
+		cfgProfiles  string
+		cfgCreds     string
+		cfgTemplates string
+	)
+	fs.StringVar(&logLevel, "log.level", "info", "Log level: debug, info, warn, error")
+	fs.StringVar(&logFmt, "log.fmt", "text", "Log format: json, text")
+	fs.StringVar(&targets, "targets", "", "Comma-separated CIDR prefixes or addresses to sweep")
+	fs.StringVar(&profiles, "credentials", "", "Comma-separated credential profiles to try first, in order; responders are written with credentials: <profile>")
+	fs.StringVar(&communities, "communities", "public", "Comma-separated v2c communities to try after the profiles, in order; secret references are written as given")
+	fs.StringVar(&v3File, "v3.credentials", "", "YAML file with a list of SNMPv3 credential sets to try after the communities; secret references are written as given")
+	fs.IntVar(&port, "port", 161, "SNMP UDP port")
+	fs.IntVar(&timeoutMs, "timeout", 1000, "Per-probe timeout in milliseconds")
+	fs.IntVar(&retries, "retries", 0, "Retries per probe")
+	fs.IntVar(&concurrency, "concurrency", 32, "Hosts probed at once")
+	fs.Float64Var(&rate, "rate", 50, "Maximum probes per second")
+	fs.IntVar(&maxHosts, "max.hosts", 65536, "Refuse to sweep more addresses than this")
+	fs.StringVar(&output, "output", "", "Device file to create or refresh (default: <devices dir>/discovered.yml)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Print the discovered devices to stdout instead of writing the device file")
+	fs.StringVar(&cfgDevices, "config.devices", "", "Override INPUT_SNMP_DEVICE_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgVendors, "config.vendors", "", "Override INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgProfiles, "config.device.profiles", "", "Override INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgCreds, "config.credentials", "", "Override INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgTemplates, "config.device.templates", "", "Override INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	logger, err := buildLogger(logLevel, logFmt)
+	if err != nil {
+		return err
+	}
+	if targets == "" {
+		return fmt.Errorf("discover: -targets is required")
+	}
+
+	paths := config.PathsFromEnv()
+	applyPathOverrides(&paths, cfgDevices, "", "", "", "", cfgVendors, cfgProfiles, cfgCreds, cfgTemplates)
+	loaded, err := config.Load(paths, logger)
+	if err != nil {
+		return fmt.Errorf("discover: %w", err)
+	}
+
+	var v3 []config.V3Credentials
+	if v3File != "" {
+		data, err := os.ReadFile(v3File)
+		if err != nil {
+			return fmt.Errorf("discover: read v3 credentials: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &v3); err != nil {
+			return fmt.Errorf("discover: parse v3 credentials %q: %w", v3File, err)
+		}
+	}
+
+	sweeper, err := discovery.NewSweeper(discovery.Options{
+		Targets:            splitList(targets),
+		Port:               port,
+		Timeout:            time.Duration(timeoutMs) * time.Millisecond,
+		Retries:            retries,
+		CredentialProfiles: splitList(profiles),
+		Credentials:        loaded.Credentials,
+		Communities:        splitList(communities),
+		V3Credentials:      v3,
+		Concurrency:        concurrency,
+		Rate:               rate,
+		MaxHosts:           maxHosts,
+		Vendors:            loaded.Vendors,
+		Profiles:           loaded.Profiles,
+	}, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	results, err := sweeper.Run(ctx)
+	if err != nil {
+		logger.Warn("discover: sweep interrupted, keeping partial results", "error", err.Error())
+	}
+
+	if dryRun {
+		devices := make(map[string]config.DeviceConfig, len(results))
+		for _, r := range results {
+			devices[r.Hostname] = r.Device
+		}
+		data, err := config.EncodeDevices(devices)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if output == "" {
+		output = filepath.Join(paths.Devices, "discovered.yml")
+	}
+	if _, err := discovery.WriteDevices(output, loaded.Devices, results, logger); err != nil {
+		return fmt.Errorf("discover: write %q: %w", output, err)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
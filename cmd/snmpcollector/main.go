@@ -2,11 +2,17 @@
 //
 // It loads YAML configuration from directories specified by environment
 // variables (or command-line flags), builds the full pipeline, and runs until
-// interrupted (SIGINT / SIGTERM).
+// interrupted (SIGINT / SIGTERM). SIGHUP reloads the configuration.
 //
 // Usage:
 //
 //	snmpcollector [flags]
+//	snmpcollector discover -targets=<cidr,...> [flags]
+//	snmpcollector validate [-strict] [flags]
+//	snmpcollector mib2yaml -object=<table,...> [flags] FILE...
+//	snmpcollector replay [flags] CAPTURE
+//	snmpcollector get|walk -device=<hostname> [flags] OID...
+//	snmpcollector plan [-format=text|json] [flags]
 //
 // See snmp-collector-architecture.md §Command-Line Configuration for the full
 // flag reference.
@@ -17,18 +23,38 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/app"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/scheduler"
 )
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "discover":
+		err = runDiscover(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "validate":
+		err = runValidate(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "mib2yaml":
+		err = runMIB2YAML(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "replay":
+		err = runReplay(os.Args[2:])
+	case len(os.Args) > 1 && (os.Args[1] == "get" || os.Args[1] == "walk"):
+		err = runQuery(os.Args[1], os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "plan":
+		err = runPlan(os.Args[2:])
+	default:
+		err = run()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "snmpcollector: %v\n", err)
 		os.Exit(1)
 	}
@@ -52,6 +78,46 @@ func run() error {
 		poolMaxIdle int
 		poolIdleSec int
 
+		// System group polling
+		sysInfoOn  bool
+		sysInfoSec int
+
+		// Request rate limits
+		ratePerDevice float64
+		rateGlobal    float64
+
+		// Auto-profile rediscovery
+		autoProfileSec int
+
+		// Scheduler spreading and overflow
+		schedSpread      bool
+		schedJitterSec   float64
+		schedOverflow    string
+		schedOverflowSec float64
+
+		// Config hot reload
+		cfgWatch    bool
+		cfgWatchSec int
+
+		// Inventory sources
+		invCSV         string
+		invHTTP        string
+		invHTTPToken   string
+		invNetBox      string
+		invNetBoxToken string
+		invNetBoxQuery string
+		invSec         int
+
+		// Admin API
+		adminAddr       string
+		adminTimeoutSec int
+
+		// OID name registry
+		mibPaths string
+
+		// Poll result capture
+		captureFile string
+
 		// Split-file transport
 		splitFile      bool
 		metricFilePath string
@@ -65,6 +131,10 @@ func run() error {
 		cfgObjectGroups string
 		cfgObjects      string
 		cfgEnums        string
+		cfgVendors      string
+		cfgProfiles     string
+		cfgCredentials  string
+		cfgTemplates    string
 	)
 
 	flag.StringVar(&logLevel, "log.level", "info", "Log level: debug, info, warn, error")
@@ -79,6 +149,26 @@ func run() error {
 	flag.BoolVar(&counterOn, "processor.counter.delta", true, "Enable counter delta computation")
 	flag.IntVar(&poolMaxIdle, "snmp.pool.max.idle", 2, "Max idle connections per device")
 	flag.IntVar(&poolIdleSec, "snmp.pool.idle.timeout", 30, "Idle connection timeout in seconds")
+	flag.BoolVar(&sysInfoOn, "poller.sysinfo.enable", true, "Poll the SNMPv2-MIB system group per device and attach vendor/model to metrics")
+	flag.IntVar(&sysInfoSec, "poller.sysinfo.interval", 3600, "System group refresh interval in seconds")
+	flag.Float64Var(&ratePerDevice, "poller.rate.limit.per.device", 0, "Max SNMP requests per second to each device (0=unlimited)")
+	flag.Float64Var(&rateGlobal, "poller.global.rate.limit", 0, "Max SNMP requests per second across all devices (0=unlimited)")
+	flag.IntVar(&autoProfileSec, "scheduler.autoprofile.interval", 3600, "Re-probe interval in seconds for devices with device_groups: [auto]")
+	flag.BoolVar(&schedSpread, "scheduler.spread", true, "Spread devices across their poll interval with a per-hostname phase offset")
+	flag.Float64Var(&schedJitterSec, "scheduler.jitter", 0, "Maximum random delay in seconds added to each poll cycle (0=disabled)")
+	flag.StringVar(&schedOverflow, "scheduler.overflow", "drop", "Policy when the poll job queue is full: drop, block, defer")
+	flag.Float64Var(&schedOverflowSec, "scheduler.overflow.timeout", 1, "Maximum seconds one poll cycle waits for queue space with -scheduler.overflow=block")
+	flag.StringVar(&invCSV, "inventory.csv", "", "Comma-separated CSV inventory files merged with the device files")
+	flag.StringVar(&invHTTP, "inventory.http", "", "URL of an HTTP endpoint returning devices as JSON")
+	flag.StringVar(&invHTTPToken, "inventory.http.token", "", "Bearer token for -inventory.http (may be a secret reference)")
+	flag.StringVar(&invNetBox, "inventory.netbox.url", "", "NetBox base URL to read devices from")
+	flag.StringVar(&invNetBoxToken, "inventory.netbox.token", "", "NetBox API token (may be a secret reference)")
+	flag.StringVar(&invNetBoxQuery, "inventory.netbox.filter", "", "NetBox device filter as a query string, e.g. status=active&site=hcm")
+	flag.IntVar(&invSec, "inventory.interval", 300, "Inventory refresh interval in seconds")
+	flag.StringVar(&adminAddr, "admin.listen", "", "HTTP address of the admin API for on-demand polls, e.g. 127.0.0.1:9161 (empty=disabled); unauthenticated, bind to localhost only")
+	flag.IntVar(&adminTimeoutSec, "admin.poll.timeout", 30, "Maximum seconds one on-demand poll may take")
+	flag.StringVar(&mibPaths, "mibs.path", "", "Comma-separated directories of MIB files used to name OIDs in traps, debug logs and the admin API")
+	flag.StringVar(&captureFile, "capture.file", "", "Record every poll result to this file for snmpcollector replay (.gz = gzip; empty=disabled)")
 
 	flag.BoolVar(&splitFile, "transport.file.split", false, "Split output: metrics and traps to separate files")
 	flag.StringVar(&metricFilePath, "transport.file.metrics", "snmp_metrics.json", "Output file for SNMP poll metrics")
@@ -91,6 +181,12 @@ func run() error {
 	flag.StringVar(&cfgObjectGroups, "config.object.groups", "", "Override INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH")
 	flag.StringVar(&cfgObjects, "config.objects", "", "Override INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH")
 	flag.StringVar(&cfgEnums, "config.enums", "", "Override PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH")
+	flag.StringVar(&cfgVendors, "config.vendors", "", "Override INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH")
+	flag.StringVar(&cfgProfiles, "config.device.profiles", "", "Override INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH")
+	flag.StringVar(&cfgCredentials, "config.credentials", "", "Override INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH")
+	flag.StringVar(&cfgTemplates, "config.device.templates", "", "Override INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH")
+	flag.BoolVar(&cfgWatch, "config.watch", false, "Reload automatically when files in the config directories change")
+	flag.IntVar(&cfgWatchSec, "config.watch.interval", 5, "Config directory scan interval in seconds for -config.watch")
 
 	flag.Parse()
 
@@ -100,9 +196,21 @@ func run() error {
 		return err
 	}
 
+	overflow := scheduler.OverflowPolicy(schedOverflow)
+	switch overflow {
+	case scheduler.OverflowDrop, scheduler.OverflowBlock, scheduler.OverflowDefer:
+	default:
+		return fmt.Errorf("unknown -scheduler.overflow %q (want drop, block or defer)", schedOverflow)
+	}
+
 	// ── Config paths ─────────────────────────────────────────────────────
 	paths := config.PathsFromEnv()
-	applyPathOverrides(&paths, cfgDevices, cfgDeviceGroups, cfgObjectGroups, cfgObjects, cfgEnums)
+	applyPathOverrides(&paths, cfgDevices, cfgDeviceGroups, cfgObjectGroups, cfgObjects, cfgEnums, cfgVendors, cfgProfiles, cfgCredentials, cfgTemplates)
+
+	inventory, err := inventorySources(invCSV, invHTTP, invHTTPToken, invNetBox, invNetBoxToken, invNetBoxQuery)
+	if err != nil {
+		return err
+	}
 
 	// ── Build App ────────────────────────────────────────────────────────
 	cfg := app.Config{
@@ -124,6 +232,25 @@ func run() error {
 			MaxIdlePerDevice: poolMaxIdle,
 			IdleTimeout:      secondsToDuration(poolIdleSec),
 		},
+		RateLimitPerDevice: ratePerDevice,
+		GlobalRateLimit:    rateGlobal,
+		SchedulerOptions: scheduler.Options{
+			Spread:       schedSpread,
+			Jitter:       time.Duration(schedJitterSec * float64(time.Second)),
+			Overflow:     overflow,
+			BlockTimeout: time.Duration(schedOverflowSec * float64(time.Second)),
+		},
+		WatchConfig:         cfgWatch,
+		WatchInterval:       secondsToDuration(cfgWatchSec),
+		Inventory:           inventory,
+		InventoryInterval:   secondsToDuration(invSec),
+		AdminListenAddr:     adminAddr,
+		AdminPollTimeout:    secondsToDuration(adminTimeoutSec),
+		MIBPaths:            splitList(mibPaths),
+		CaptureFile:         captureFile,
+		SystemInfoEnabled:   sysInfoOn,
+		SystemInfoInterval:  secondsToDuration(sysInfoSec),
+		AutoProfileInterval: secondsToDuration(autoProfileSec),
 	}
 
 	application := app.New(cfg, logger)
@@ -132,14 +259,31 @@ func run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Catch SIGHUP before starting: a SIGHUP during a slow start (inventory
+	// fetch, auto-profile probes) must not kill the process. It is handled as
+	// a reload once Start returns.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
 	if err := application.Start(ctx); err != nil {
 		return fmt.Errorf("start: %w", err)
 	}
 
-	logger.Info("snmpcollector: running — press Ctrl-C to stop")
+	logger.Info("snmpcollector: running — press Ctrl-C to stop, send SIGHUP to reload")
 
-	// Block until signal.
-	<-ctx.Done()
+	// Block until a shutdown signal, reloading on each SIGHUP.
+	for running := true; running; {
+		select {
+		case <-hup:
+			logger.Info("snmpcollector: received SIGHUP")
+			if err := application.Reload(); err != nil {
+				logger.Error("snmpcollector: reload failed — keeping running configuration", "error", err.Error())
+			}
+		case <-ctx.Done():
+			running = false
+		}
+	}
 	logger.Info("snmpcollector: received shutdown signal")
 
 	application.Stop()
@@ -180,7 +324,7 @@ func buildLogger(level, format string) (*slog.Logger, error) {
 	return slog.New(handler), nil
 }
 
-func applyPathOverrides(p *config.Paths, devices, dgroups, ogroups, objects, enums string) {
+func applyPathOverrides(p *config.Paths, devices, dgroups, ogroups, objects, enums, vendors, profiles, creds, templates string) {
 	if devices != "" {
 		p.Devices = devices
 	}
@@ -196,6 +340,40 @@ func applyPathOverrides(p *config.Paths, devices, dgroups, ogroups, objects, enu
 	if enums != "" {
 		p.Enums = enums
 	}
+	if vendors != "" {
+		p.Vendors = vendors
+	}
+	if profiles != "" {
+		p.Profiles = profiles
+	}
+	if creds != "" {
+		p.Credentials = creds
+	}
+	if templates != "" {
+		p.Templates = templates
+	}
+}
+
+// inventorySources builds the inventory sources selected by the -inventory.*
+// flags.
+func inventorySources(csvFiles, httpURL, httpToken, netboxURL, netboxToken, netboxFilter string) ([]config.InventorySource, error) {
+	var sources []config.InventorySource
+	for _, path := range strings.Split(csvFiles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			sources = append(sources, config.CSVSource{Path: path})
+		}
+	}
+	if httpURL != "" {
+		sources = append(sources, config.HTTPSource{URL: httpURL, Token: httpToken})
+	}
+	if netboxURL != "" {
+		filter, err := url.ParseQuery(netboxFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -inventory.netbox.filter: %w", err)
+		}
+		sources = append(sources, config.NetBoxSource{URL: netboxURL, Token: netboxToken, Filter: filter})
+	}
+	return sources, nil
 }
 
 func secondsToDuration(sec int) time.Duration {
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vpbank/snmp_collector/mibs/compiler"
+	"github.com/vpbank/snmp_collector/mibs/parser"
+)
+
+// runMIB2YAML implements `snmpcollector mib2yaml`: parse MIB files and emit
+// object definitions, plus enum definitions for INTEGER enumerations and
+// BITS, for the named tables and scalar groups.
+//
+// Usage:
+//
+//	snmpcollector mib2yaml -object=ifTable,ifXTable [-mibs=dir,...] [-prefix=netif] [-output=file] [-enums=file] FILE...
+func runMIB2YAML(args []string) error {
+	fs := flag.NewFlagSet("mib2yaml", flag.ContinueOnError)
+	var (
+		objects string
+		mibDirs string
+		prefix  string
+		output  string
+		enums   string
+	)
+	fs.StringVar(&objects, "object", "", "Comma-separated tables, rows or scalar groups to generate, e.g. ifTable,system or IF-MIB::ifXEntry")
+	fs.StringVar(&mibDirs, "mibs", "", "Comma-separated directories searched for imported MIB modules (default: the directories of the MIB files)")
+	fs.StringVar(&prefix, "prefix", "", "Prefix for attribute output names, e.g. netif → netif.ifDescr")
+	fs.StringVar(&output, "output", "", "Object definition file to write (default: stdout)")
+	fs.StringVar(&enums, "enums", "", "Enum definition file to write for EnumInteger / EnumBitmap attributes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		return fmt.Errorf("mib2yaml: no MIB files given")
+	}
+	if objects == "" {
+		return fmt.Errorf("mib2yaml: -object is required")
+	}
+
+	path := splitList(mibDirs)
+	if len(path) == 0 {
+		for _, f := range files {
+			if dir := filepath.Dir(f); !slices.Contains(path, dir) {
+				path = append(path, dir)
+			}
+		}
+	}
+	set := parser.NewSet(path...)
+	for _, f := range files {
+		if _, err := set.AddFile(f); err != nil {
+			return fmt.Errorf("mib2yaml: %w", err)
+		}
+	}
+	// Unresolved imports or nodes elsewhere in the modules rarely matter to
+	// the requested objects; report them and carry on.
+	if err := set.Resolve(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "mib2yaml: warning: %s\n", line)
+		}
+	}
+
+	res, err := compiler.Compile(set, splitList(objects), compiler.Options{Prefix: prefix})
+	if err != nil {
+		return fmt.Errorf("mib2yaml: %w", err)
+	}
+	data, err := res.ObjectsYAML()
+	if err != nil {
+		return fmt.Errorf("mib2yaml: %w", err)
+	}
+	header := fmt.Sprintf("# Generated by snmpcollector mib2yaml from %s.\n# Review attribute names, tags and metric kinds before use.\n\n",
+		strings.Join(res.Modules, ", "))
+	if err := writeOutput(output, append([]byte(header), data...)); err != nil {
+		return fmt.Errorf("mib2yaml: %w", err)
+	}
+
+	enumData, err := res.EnumsYAML()
+	if err != nil {
+		return fmt.Errorf("mib2yaml: %w", err)
+	}
+	switch {
+	case len(enumData) == 0:
+	case enums == "":
+		fmt.Fprintf(os.Stderr, "mib2yaml: %d attribute(s) have enumerations; pass -enums=FILE to write them\n", len(res.Enums))
+	default:
+		header := fmt.Sprintf("# Generated by snmpcollector mib2yaml from %s.\n\n", strings.Join(res.Modules, ", "))
+		if err := writeOutput(enums, append([]byte(header), enumData...)); err != nil {
+			return fmt.Errorf("mib2yaml: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeOutput writes data to path, or to stdout when path is empty.
+func writeOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
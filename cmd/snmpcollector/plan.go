@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/plan"
+)
+
+// runPlan implements `snmpcollector plan`: resolve the configuration trees
+// the way the scheduler does and print, per device, the jobs it would run,
+// the SNMP operation of each, request estimates and walk overlap warnings.
+// Nothing is polled. Credentials are redacted.
+//
+// Usage:
+//
+//	snmpcollector plan [-format=text|json] [-rows=N] [-config.* overrides]
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	var (
+		logLevel string
+		format   string
+		rows     int
+
+		cfgDevices      string
+		cfgDeviceGroups string
+		cfgObjectGroups string
+		cfgObjects      string
+		cfgEnums        string
+		cfgCredentials  string
+		cfgTemplates    string
+	)
+	fs.StringVar(&logLevel, "log.level", "warn", "Log level: debug, info, warn, error")
+	fs.StringVar(&format, "format", "text", "Output format: text, json")
+	fs.IntVar(&rows, "rows", 1, "Rows assumed per walked table for request estimates")
+	fs.StringVar(&cfgDevices, "config.devices", "", "Override INPUT_SNMP_DEVICE_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgDeviceGroups, "config.device.groups", "", "Override INPUT_SNMP_DEVICE_GROUP_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgObjectGroups, "config.object.groups", "", "Override INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgObjects, "config.objects", "", "Override INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgEnums, "config.enums", "", "Override PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgCredentials, "config.credentials", "", "Override INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgTemplates, "config.device.templates", "", "Override INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("plan: unknown -format %q (want text or json)", format)
+	}
+
+	logger, err := buildLogger(logLevel, "text")
+	if err != nil {
+		return err
+	}
+
+	paths := config.PathsFromEnv()
+	applyPathOverrides(&paths, cfgDevices, cfgDeviceGroups, cfgObjectGroups, cfgObjects, cfgEnums, "", "", cfgCredentials, cfgTemplates)
+	loaded, err := config.Load(paths, logger)
+	if err != nil {
+		return fmt.Errorf("plan: load config: %w", err)
+	}
+
+	p := plan.Build(loaded, plan.Options{Rows: rows}, logger)
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(p)
+	}
+	return p.WriteText(os.Stdout)
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gosnmp/gosnmp"
+
+	jsonformat "github.com/vpbank/snmp_collector/format/json"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/producer/metrics"
+	"github.com/vpbank/snmp_collector/snmp/decoder"
+)
+
+// runQuery implements `snmpcollector get` and `snmpcollector walk` (op is
+// "get" or "walk"): query one device through the collector's own session
+// stack, with the address, version, timeouts and credentials it would use.
+//
+// The target is a configured device (-device) or an ad-hoc one (-ip and the
+// credential flags, resolved against the defaults template and credential
+// profiles like a device file entry). Given OIDs are fetched with Get, or
+// walked with BulkWalk (Walk for SNMPv1 or -bulk=false) by SNMPPoller.Walk —
+// so -poller.rate.limit.per.device and the tooBig fallback apply as they do
+// to polls — and each varbind is printed after decoder.ConvertValue with
+// -syntax. With -object instead, the
+// object definition is polled exactly as the collector would and the
+// SNMPMetric it produces is printed as JSON, so object YAML can be tried
+// against a real device before it is deployed.
+//
+// Usage:
+//
+//	snmpcollector get  -device=core-sw-01 [-syntax=Counter64] OID...
+//	snmpcollector walk -ip=10.0.0.9 -community=public [-bulk=false] OID...
+//	snmpcollector walk -device=core-sw-01 -object=IF-MIB::ifEntry [-config.objects=dir]
+func runQuery(op string, args []string) error {
+	fs := flag.NewFlagSet(op, flag.ContinueOnError)
+	var (
+		logLevel string
+		hostname string
+		object   string
+		syntax   string
+		bulk     bool
+		enumOn   bool
+		pretty   bool
+
+		ratePerDevice float64
+
+		// Ad-hoc target
+		ip          string
+		port        int
+		version     string
+		community   string
+		credentials string
+		timeoutMs   int
+		retries     int
+		v3User      string
+		v3AuthProto string
+		v3AuthPass  string
+		v3PrivProto string
+		v3PrivPass  string
+
+		cfgDevices      string
+		cfgDeviceGroups string
+		cfgObjectGroups string
+		cfgObjects      string
+		cfgEnums        string
+		cfgCredentials  string
+		cfgTemplates    string
+	)
+	fs.StringVar(&logLevel, "log.level", "warn", "Log level: debug, info, warn, error")
+	fs.StringVar(&hostname, "device", "", "Configured device to query, by hostname")
+	fs.StringVar(&object, "object", "", "Object definition to poll, e.g. IF-MIB::ifEntry; prints the SNMPMetric it produces")
+	fs.StringVar(&syntax, "syntax", "", "Syntax passed to decoder.ConvertValue for OID arguments (default: by PDU type)")
+	fs.BoolVar(&bulk, "bulk", true, "walk: use GetBulk (v2c / v3)")
+	fs.Float64Var(&ratePerDevice, "poller.rate.limit.per.device", 0, "Max SNMP requests per second to the device (0=unlimited)")
+	fs.BoolVar(&enumOn, "processor.enum.enable", false, "-object: enable enum resolution")
+	fs.BoolVar(&pretty, "format.pretty", true, "-object: pretty-print JSON output")
+	fs.StringVar(&ip, "ip", "", "Ad-hoc target address (instead of -device)")
+	fs.IntVar(&port, "port", 0, "Ad-hoc target UDP port (default 161)")
+	fs.StringVar(&version, "version", "", "Ad-hoc SNMP version: 1, 2c, 3 (default 2c)")
+	fs.StringVar(&community, "community", "", "Ad-hoc v1 / v2c community (may be a secret reference)")
+	fs.StringVar(&credentials, "credentials", "", "Ad-hoc credential profile name")
+	fs.IntVar(&timeoutMs, "timeout", 0, "Ad-hoc request timeout in milliseconds (default 3000)")
+	fs.IntVar(&retries, "retries", 0, "Ad-hoc retries on timeout (default 2)")
+	fs.StringVar(&v3User, "v3.user", "", "Ad-hoc SNMPv3 username")
+	fs.StringVar(&v3AuthProto, "v3.auth.proto", "", "Ad-hoc SNMPv3 authentication protocol: md5, sha, sha224, sha256, sha384, sha512")
+	fs.StringVar(&v3AuthPass, "v3.auth.pass", "", "Ad-hoc SNMPv3 authentication passphrase (may be a secret reference)")
+	fs.StringVar(&v3PrivProto, "v3.priv.proto", "", "Ad-hoc SNMPv3 privacy protocol: des, aes, aes192, aes256, aes192c, aes256c")
+	fs.StringVar(&v3PrivPass, "v3.priv.pass", "", "Ad-hoc SNMPv3 privacy passphrase (may be a secret reference)")
+	fs.StringVar(&cfgDevices, "config.devices", "", "Override INPUT_SNMP_DEVICE_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgDeviceGroups, "config.device.groups", "", "Override INPUT_SNMP_DEVICE_GROUP_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgObjectGroups, "config.object.groups", "", "Override INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgObjects, "config.objects", "", "Override INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgEnums, "config.enums", "", "Override PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgCredentials, "config.credentials", "", "Override INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgTemplates, "config.device.templates", "", "Override INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	oids := fs.Args()
+
+	if (hostname == "") == (ip == "") {
+		return fmt.Errorf("%s: set exactly one of -device and -ip", op)
+	}
+	if (object == "") == (len(oids) == 0) {
+		return fmt.Errorf("%s: give either -object or OIDs", op)
+	}
+	if syntax != "" && !decoder.KnownSyntax(syntax) {
+		return fmt.Errorf("%s: unknown syntax %q", op, syntax)
+	}
+
+	logger, err := buildLogger(logLevel, "text")
+	if err != nil {
+		return err
+	}
+
+	paths := config.PathsFromEnv()
+	applyPathOverrides(&paths, cfgDevices, cfgDeviceGroups, cfgObjectGroups, cfgObjects, cfgEnums, "", "", cfgCredentials, cfgTemplates)
+	loaded, err := config.Load(paths, logger)
+	if err != nil {
+		return fmt.Errorf("%s: load config: %w", op, err)
+	}
+
+	// ── Resolve the target ───────────────────────────────────────────────
+	adhoc := config.DeviceConfig{
+		IP:          ip,
+		Port:        port,
+		Version:     version,
+		Timeout:     timeoutMs,
+		Retries:     retries,
+		Credentials: credentials,
+	}
+	if community != "" {
+		adhoc.Communities = []string{community}
+	}
+	if v3User != "" {
+		adhoc.V3Credentials = []config.V3Credentials{{
+			Username:                 v3User,
+			AuthenticationProtocol:   v3AuthProto,
+			AuthenticationPassphrase: v3AuthPass,
+			PrivacyProtocol:          v3PrivProto,
+			PrivacyPassphrase:        v3PrivPass,
+		}}
+	}
+	hostname, dev, err := resolveTarget(loaded, hostname, adhoc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	var def models.ObjectDefinition
+	if object != "" {
+		if def, err = lookupObject(loaded, object); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool := poller.NewConnectionPool(poller.PoolOptions{MaxIdlePerDevice: 1}, logger)
+	defer pool.Close()
+	p := poller.NewSNMPPoller(pool, poller.PollerOptions{
+		RateLimiter: poller.NewRateLimiter(ratePerDevice, 0),
+	}, logger)
+
+	if object != "" {
+		return pollObject(ctx, os.Stdout, logger, p, loaded, hostname, dev, def, enumOn, pretty)
+	}
+	n, err := queryOIDs(ctx, os.Stdout, p, op, hostname, dev, oids, bulk, syntax)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", op, hostname, err)
+	}
+	fmt.Fprintf(os.Stderr, "%d varbind(s)\n", n)
+	return nil
+}
+
+// resolveTarget returns the hostname and resolved configuration of the device
+// to query: the configured device hostname when it is set, else adhoc
+// resolved like a device file entry and named after its address.
+func resolveTarget(loaded *config.LoadedConfig, hostname string, adhoc config.DeviceConfig) (string, config.DeviceConfig, error) {
+	if hostname != "" {
+		dev, ok := loaded.Devices[hostname]
+		if !ok {
+			return "", config.DeviceConfig{}, fmt.Errorf("unknown device %q", hostname)
+		}
+		return hostname, dev, nil
+	}
+	dev, err := loaded.ResolveDevice(adhoc)
+	if err != nil {
+		return "", config.DeviceConfig{}, err
+	}
+	return adhoc.IP, dev, nil
+}
+
+// lookupObject returns the object definition keyed name, e.g.
+// "IF-MIB::ifEntry".
+func lookupObject(loaded *config.LoadedConfig, name string) (models.ObjectDefinition, error) {
+	def, ok := loaded.ObjectDefs[name]
+	if !ok {
+		return models.ObjectDefinition{}, fmt.Errorf("unknown object %q", name)
+	}
+	return def, nil
+}
+
+// queryOIDs fetches oids from the device through p — with Get for op "get",
+// else by walking each of them — and writes every varbind read with
+// printVarbind, including those read before an error. It returns how many
+// were written.
+func queryOIDs(ctx context.Context, w io.Writer, p *poller.SNMPPoller, op, hostname string,
+	dev config.DeviceConfig, oids []string, bulk bool, syntax string) (int, error) {
+	job := poller.PollJob{Hostname: hostname, DeviceConfig: dev}
+	var pdus []gosnmp.SnmpPDU
+	var err error
+	if op == "get" {
+		job.OIDs = oids
+		var raw decoder.RawPollResult
+		raw, err = p.Poll(ctx, job)
+		pdus = raw.Varbinds
+	} else {
+		pdus, err = p.Walk(ctx, job, oids, bulk)
+	}
+	for _, pdu := range pdus {
+		printVarbind(w, pdu, syntax)
+	}
+	return len(pdus), err
+}
+
+// printVarbind writes pdu in the form "OID = Type: value", with the value
+// converted as the decoder would for syntax.
+func printVarbind(w io.Writer, pdu gosnmp.SnmpPDU, syntax string) {
+	typ := decoder.PDUTypeString(pdu.Type)
+	if decoder.IsErrorType(pdu.Type) {
+		fmt.Fprintf(w, "%s = %s\n", pdu.Name, typ)
+		return
+	}
+	value, err := decoder.ConvertValue(pdu.Type, pdu.Value, syntax)
+	if err != nil {
+		fmt.Fprintf(w, "%s = %s: <%v>\n", pdu.Name, typ, err)
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		fmt.Fprintf(w, "%s = %s: %q\n", pdu.Name, typ, v)
+	case []byte:
+		fmt.Fprintf(w, "%s = %s: 0x%X\n", pdu.Name, typ, v)
+	default:
+		fmt.Fprintf(w, "%s = %s: %v\n", pdu.Name, typ, v)
+	}
+}
+
+// pollObject polls def from the device through p, the decoder and the
+// producer, as the collector would, and writes the resulting SNMPMetric as
+// JSON. Counters are raw cumulative values.
+func pollObject(ctx context.Context, w io.Writer, logger *slog.Logger, p *poller.SNMPPoller,
+	loaded *config.LoadedConfig, hostname string, dev config.DeviceConfig, def models.ObjectDefinition, enumOn, pretty bool) error {
+	raw, err := p.Poll(ctx, poller.PollJob{
+		Hostname:     hostname,
+		DeviceConfig: dev,
+		ObjectDef:    def,
+		Device: models.Device{
+			Hostname:    hostname,
+			IPAddress:   dev.IP,
+			SNMPVersion: dev.Version,
+			Tags:        loaded.DeviceTags(dev),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	decoded, err := decoder.NewSNMPDecoder(logger).Decode(raw)
+	if err != nil {
+		return err
+	}
+	metric, err := metrics.New(metrics.Config{
+		EnumEnabled: enumOn,
+		Enums:       loaded.Enums,
+	}, logger).Produce(decoded)
+	if err != nil {
+		return err
+	}
+	out, err := jsonformat.New(jsonformat.Config{PrettyPrint: pretty}, logger).Format(&metric)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(out, '\n')); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d varbind(s), %d metric(s)\n", len(raw.Varbinds), len(metric.Metrics))
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/utils/snmptest"
+)
+
+func TestPrintVarbind(t *testing.T) {
+	const oid = ".1.3.6.1.2.1.2.2.1.2.1"
+	tests := []struct {
+		name   string
+		pdu    gosnmp.SnmpPDU
+		syntax string
+		want   string
+	}{
+		{"octet string quoted", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.OctetString, Value: []byte("Gi0/1\x00")}, "DisplayString", oid + ` = OctetString: "Gi0/1"` + "\n"},
+		{"counter by PDU type", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.Counter64, Value: uint64(42)}, "", oid + " = Counter64: 42\n"},
+		{"syntax conversion", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.Gauge32, Value: uint(10)}, "BandwidthMBits", oid + " = Gauge32: 1e+07\n"},
+		{"physical address", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.OctetString, Value: []byte{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e}}, "PhysAddress", oid + ` = OctetString: "00:1a:2b:3c:4d:5e"` + "\n"},
+		{"no such instance", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchInstance}, "", oid + " = NoSuchInstance\n"},
+		{"end of MIB view", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView}, "Counter64", oid + " = EndOfMibView\n"},
+		{"conversion error", gosnmp.SnmpPDU{Name: oid, Type: gosnmp.OctetString, Value: []byte("up")}, "Counter64", oid + " = OctetString: <cannot convert []uint8 to uint64>\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printVarbind(&buf, tc.pdu, tc.syntax)
+			if got := buf.String(); got != tc.want {
+				t.Errorf("printVarbind = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	loaded := &config.LoadedConfig{
+		Devices: map[string]config.DeviceConfig{
+			"core-sw-01": {IP: "10.0.0.1", Port: 161, Version: "2c"},
+		},
+	}
+	tests := []struct {
+		name     string
+		hostname string
+		adhoc    config.DeviceConfig
+		wantHost string
+		wantIP   string
+		wantErr  string
+	}{
+		{name: "configured device", hostname: "core-sw-01", wantHost: "core-sw-01", wantIP: "10.0.0.1"},
+		{name: "unknown device", hostname: "nosuch", wantErr: `unknown device "nosuch"`},
+		{name: "ad-hoc device", adhoc: config.DeviceConfig{IP: "10.0.0.9", Communities: []string{"public"}}, wantHost: "10.0.0.9", wantIP: "10.0.0.9"},
+		{name: "ad-hoc unknown credentials", adhoc: config.DeviceConfig{IP: "10.0.0.9", Credentials: "nosuch"}, wantErr: `unknown credential profile "nosuch"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host, dev, err := resolveTarget(loaded, tc.hostname, tc.adhoc)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveTarget: %v", err)
+			}
+			if host != tc.wantHost || dev.IP != tc.wantIP {
+				t.Errorf("target = %s (%s), want %s (%s)", host, dev.IP, tc.wantHost, tc.wantIP)
+			}
+			if dev.Port != 161 || dev.Version != "2c" {
+				t.Errorf("port / version = %d / %s, want defaults 161 / 2c", dev.Port, dev.Version)
+			}
+		})
+	}
+}
+
+func TestLookupObject(t *testing.T) {
+	loaded := &config.LoadedConfig{
+		ObjectDefs: map[string]models.ObjectDefinition{
+			"IF-MIB::ifEntry": {Key: "IF-MIB::ifEntry", MIB: "IF-MIB", Object: "ifEntry"},
+		},
+	}
+	tests := []struct {
+		name    string
+		object  string
+		wantErr bool
+	}{
+		{"known object", "IF-MIB::ifEntry", false},
+		{"unknown object", "IF-MIB::ifXEntry", true},
+		{"object without MIB", "ifEntry", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			def, err := lookupObject(loaded, tc.object)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && def.Key != tc.object {
+				t.Errorf("key = %s, want %s", def.Key, tc.object)
+			}
+		})
+	}
+}
+
+func TestQueryOIDs_Simulator(t *testing.T) {
+	sim := snmptest.NewSimulator(snmptest.NewSwitch(), snmptest.Options{MaxMessageSize: 300, BulkTooBig: true}, nil)
+	if err := sim.Start(); err != nil {
+		t.Fatalf("simulator: %v", err)
+	}
+	t.Cleanup(sim.Stop)
+	dev, err := (&config.LoadedConfig{}).ResolveDevice(config.DeviceConfig{
+		IP: "127.0.0.1", Port: sim.Port(), Communities: []string{"public"},
+	})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	rows := len(sim.Device().Walk(snmptest.OIDIfDescr))
+
+	tests := []struct {
+		name    string
+		op      string
+		oids    []string
+		bulk    bool
+		version string
+		want    int
+	}{
+		{"get", "get", []string{snmptest.OIDSysDescr, snmptest.OIDSysName}, true, "2c", 2},
+		{"bulk walk with tooBig fallback", "walk", []string{snmptest.OIDIfDescr}, true, "2c", rows},
+		{"walk without bulk", "walk", []string{snmptest.OIDIfDescr}, false, "2c", rows},
+		{"walk on SNMPv1", "walk", []string{snmptest.OIDIfDescr}, true, "1", rows},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+			defer pool.Close()
+			limiter := poller.NewRateLimiter(1000, 0)
+			p := poller.NewSNMPPoller(pool, poller.PollerOptions{RateLimiter: limiter}, nil)
+			cfg := dev
+			cfg.Version = tc.version
+
+			var buf bytes.Buffer
+			n, err := queryOIDs(context.Background(), &buf, p, tc.op, "sw1", cfg, tc.oids, tc.bulk, "")
+			if err != nil {
+				t.Fatalf("queryOIDs: %v", err)
+			}
+			if n != tc.want || strings.Count(buf.String(), "\n") != tc.want {
+				t.Errorf("varbinds = %d, lines = %d, want %d", n, strings.Count(buf.String(), "\n"), tc.want)
+			}
+			if got := limiter.Stats().Requests; got == 0 {
+				t.Error("requests bypassed the rate limiter")
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// errInvalidConfig makes `snmpcollector validate` exit non-zero after it has
+// printed the issues.
+var errInvalidConfig = errors.New("configuration is invalid")
+
+// runValidate implements `snmpcollector validate`: check every configuration
+// tree and print each issue as file:line. It fails on errors, and with
+// -strict on warnings too, so it can gate CI.
+//
+// Usage:
+//
+//	snmpcollector validate [-strict] [-config.* overrides]
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	var (
+		strict bool
+		quiet  bool
+
+		cfgDevices      string
+		cfgDeviceGroups string
+		cfgObjectGroups string
+		cfgObjects      string
+		cfgEnums        string
+		cfgVendors      string
+		cfgProfiles     string
+		cfgCredentials  string
+		cfgTemplates    string
+	)
+	fs.BoolVar(&strict, "strict", false, "Fail on warnings as well as errors")
+	fs.BoolVar(&quiet, "quiet", false, "Print errors only")
+	fs.StringVar(&cfgDevices, "config.devices", "", "Override INPUT_SNMP_DEVICE_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgDeviceGroups, "config.device.groups", "", "Override INPUT_SNMP_DEVICE_GROUP_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgObjectGroups, "config.object.groups", "", "Override INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgObjects, "config.objects", "", "Override INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgEnums, "config.enums", "", "Override PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgVendors, "config.vendors", "", "Override INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgProfiles, "config.device.profiles", "", "Override INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgCredentials, "config.credentials", "", "Override INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH")
+	fs.StringVar(&cfgTemplates, "config.device.templates", "", "Override INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths := config.PathsFromEnv()
+	applyPathOverrides(&paths, cfgDevices, cfgDeviceGroups, cfgObjectGroups, cfgObjects, cfgEnums, cfgVendors, cfgProfiles, cfgCredentials, cfgTemplates)
+	issues, err := config.Validate(paths)
+	if err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	var errCount, warnCount int
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityWarning {
+			warnCount++
+			if quiet {
+				continue
+			}
+		} else {
+			errCount++
+		}
+		fmt.Fprintln(os.Stdout, issue)
+	}
+	fmt.Fprintf(os.Stderr, "%d error(s), %d warning(s)\n", errCount, warnCount)
+
+	if errCount > 0 || (strict && warnCount > 0) {
+		return errInvalidConfig
+	}
+	return nil
+}
@@ -3,6 +3,6 @@ module github.com/vpbank/snmp_collector
 go 1.25.7
 
 require (
-	github.com/gosnmp/gosnmp v1.43.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	github.com/gosnmp/gosnmp v1.43.2
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,362 @@
+// Package compiler turns MIB objects resolved by mibs/parser into the
+// collector's object definitions and enum definitions, ready to be written
+// as INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH and
+// PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH YAML by the generator.
+package compiler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vpbank/snmp_collector/mibs/parser"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/snmp/decoder"
+)
+
+// Options tunes the generated definitions.
+type Options struct {
+	// Prefix, when set, is prepended to every attribute's output name:
+	// "netif" names ifDescr "netif.ifDescr". Empty keeps the MIB names.
+	Prefix string
+}
+
+// Result is the output of Compile.
+type Result struct {
+	// Objects holds one definition per requested table or scalar group, in
+	// request order.
+	Objects []models.ObjectDefinition
+
+	// Enums maps an attribute OID (leading dot) to its labels: INTEGER
+	// enumeration values for EnumInteger attributes, bit positions for
+	// EnumBitmap attributes.
+	Enums map[string]map[int64]string
+
+	// Sources maps an attribute or index OID to the MIB type it was written
+	// with, when that differs from the syntax it was mapped to, e.g.
+	// SnmpAdminString for a DisplayString attribute.
+	Sources map[string]string
+
+	// Modules lists the MIB modules the objects come from, in first-use
+	// order.
+	Modules []string
+}
+
+// Compile builds an object definition for each name, which may be a table
+// (ifTable), its row (ifEntry) or a node whose children are scalars
+// (system, or IF-MIB::interfaces), optionally qualified as MODULE::name.
+// set must already be resolved.
+func Compile(set *parser.Set, names []string, opts Options) (*Result, error) {
+	c := &compiler{
+		set:  set,
+		opts: opts,
+		res: &Result{
+			Enums:   make(map[string]map[int64]string),
+			Sources: make(map[string]string),
+		},
+	}
+	for _, name := range names {
+		o, err := set.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		def, err := c.object(o)
+		if err != nil {
+			return nil, err
+		}
+		c.res.Objects = append(c.res.Objects, def)
+		if !slices.Contains(c.res.Modules, o.Module) {
+			c.res.Modules = append(c.res.Modules, o.Module)
+		}
+	}
+	return c.res, nil
+}
+
+type compiler struct {
+	set  *parser.Set
+	opts Options
+	res  *Result
+}
+
+func (c *compiler) object(o *parser.Object) (models.ObjectDefinition, error) {
+	if o.Kind != parser.KindObjectType {
+		return c.scalars(o)
+	}
+	switch {
+	case o.Type != nil && o.Type.Base == "SEQUENCE OF":
+		for _, child := range preferModule(o.Children, o.Module) {
+			if child.Kind == parser.KindObjectType && (len(child.Index) > 0 || child.Augments != "") {
+				return c.table(child)
+			}
+		}
+		return models.ObjectDefinition{}, fmt.Errorf("%s: table has no row with an INDEX or AUGMENTS clause", o)
+	case len(o.Index) > 0 || o.Augments != "":
+		return c.table(o)
+	case o.Parent != nil:
+		return models.ObjectDefinition{}, fmt.Errorf("%s is a scalar or column; name its table or its group %s", o, o.Parent)
+	}
+	return models.ObjectDefinition{}, fmt.Errorf("%s is not a table, row or scalar group", o)
+}
+
+// table compiles a conceptual row: its INDEX objects become the index, or
+// its AUGMENTS target is referenced, and its readable columns become the
+// attributes.
+func (c *compiler) table(entry *parser.Object) (models.ObjectDefinition, error) {
+	def := newDefinition(entry)
+
+	indexOIDs := make(map[string]bool)
+	if entry.Augments != "" {
+		base, err := c.find(entry.Module, entry.Augments)
+		if err != nil {
+			return def, fmt.Errorf("%s: AUGMENTS: %w", entry, err)
+		}
+		def.Augments = base.String()
+	} else {
+		for _, item := range entry.Index {
+			idx, err := c.find(entry.Module, item.Name)
+			if err != nil {
+				return def, fmt.Errorf("%s: INDEX: %w", entry, err)
+			}
+			if idx.Type == nil {
+				return def, fmt.Errorf("%s: INDEX: %s has no SYNTAX", entry, idx)
+			}
+			typ, err := IndexType(idx.Type, item.Implied)
+			if err != nil {
+				return def, fmt.Errorf("%s: INDEX %s: %w", entry, idx.Name, err)
+			}
+			syntax, source := Syntax(idx.Type)
+			oid := idx.OIDString()
+			def.Index = append(def.Index, models.IndexDefinition{
+				Type:   typ,
+				OID:    oid,
+				Name:   idx.Name,
+				Syntax: syntax,
+			})
+			if source != "" {
+				c.res.Sources[oid] = source
+			}
+			indexOIDs[oid] = true
+		}
+	}
+
+	for _, col := range preferModule(entry.Children, entry.Module) {
+		if !readable(col) || indexOIDs[col.OIDString()] {
+			continue
+		}
+		c.attribute(&def, col)
+	}
+	if len(def.Attributes) == 0 {
+		return def, fmt.Errorf("%s: row has no readable columns", entry)
+	}
+	return def, nil
+}
+
+// scalars compiles the readable scalar objects directly under group.
+func (c *compiler) scalars(group *parser.Object) (models.ObjectDefinition, error) {
+	def := newDefinition(group)
+	for _, o := range preferModule(group.Children, group.Module) {
+		if !readable(o) || o.Type.Base == "SEQUENCE OF" || len(o.Children) > 0 {
+			continue
+		}
+		c.attribute(&def, o)
+	}
+	if len(def.Attributes) == 0 {
+		return def, fmt.Errorf("%s has no readable scalar objects", group)
+	}
+	return def, nil
+}
+
+func newDefinition(o *parser.Object) models.ObjectDefinition {
+	return models.ObjectDefinition{
+		Key:        o.String(),
+		MIB:        o.Module,
+		Object:     o.Name,
+		Attributes: make(map[string]models.AttributeDefinition),
+	}
+}
+
+// attribute adds o to def. The first attribute, in OID order, becomes the
+// discovery attribute.
+func (c *compiler) attribute(def *models.ObjectDefinition, o *parser.Object) {
+	syntax, source := Syntax(o.Type)
+	oid := o.OIDString()
+	name := o.Name
+	if c.opts.Prefix != "" {
+		name = c.opts.Prefix + "." + o.Name
+	}
+	def.Attributes[o.Name] = models.AttributeDefinition{
+		OID:    oid,
+		Name:   name,
+		Syntax: syntax,
+		IsTag:  isTagSyntax(syntax),
+	}
+	if def.DiscoveryAttribute == "" {
+		def.DiscoveryAttribute = o.Name
+	}
+	if source != "" {
+		c.res.Sources[oid] = source
+	}
+	if syntax == "EnumInteger" || syntax == "EnumBitmap" {
+		labels := make(map[int64]string, len(o.Type.Enums))
+		for _, e := range o.Type.Enums {
+			labels[e.Value] = e.Name
+		}
+		c.res.Enums[oid] = labels
+	}
+}
+
+// find looks name up in module first, then anywhere in the set.
+func (c *compiler) find(module, name string) (*parser.Object, error) {
+	if o, err := c.set.Lookup(module + "::" + name); err == nil {
+		return o, nil
+	}
+	if strings.Contains(name, " ") || name == "INTEGER" {
+		return nil, fmt.Errorf("%s is a bare SMIv1 type, not an object; write this index by hand", name)
+	}
+	return c.set.Lookup(name)
+}
+
+// readable reports whether o is an OBJECT-TYPE a GET can return.
+func readable(o *parser.Object) bool {
+	if o.Kind != parser.KindObjectType || o.Type == nil {
+		return false
+	}
+	switch o.Access {
+	case "not-accessible", "accessible-for-notify":
+		return false
+	}
+	return true
+}
+
+// preferModule drops objects another module defines at the same OID as one
+// of module's, e.g. RFC1213-MIB's ifDescr when IF-MIB's is also loaded.
+func preferModule(objs []*parser.Object, module string) []*parser.Object {
+	own := make(map[string]bool)
+	for _, o := range objs {
+		if o.Module == module {
+			own[o.OIDString()] = true
+		}
+	}
+	var out []*parser.Object
+	seen := make(map[string]bool)
+	for _, o := range objs {
+		key := o.OIDString()
+		if seen[key] || (own[key] && o.Module != module) {
+			continue
+		}
+		seen[key] = true
+		out = append(out, o)
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Syntax mapping
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Syntax maps a resolved MIB type to the collector's syntax name and
+// returns, as source, the MIB type name when it differs and is worth a
+// comment.
+//
+// The nearest textual convention the decoder knows wins (InterfaceIndex,
+// TruthValue, DisplayString, …). Otherwise enumerated INTEGERs map to
+// EnumInteger, BITS to EnumBitmap, OCTET STRINGs by display hint, and the
+// SMI application types to themselves.
+func Syntax(t *parser.Type) (syntax, source string) {
+	syntax = baseSyntax(t)
+	for _, tc := range t.Conventions {
+		if decoder.KnownSyntax(tc) {
+			syntax = tc
+			break
+		}
+	}
+	if len(t.Conventions) > 0 && t.Name != syntax {
+		source = t.Name
+	}
+	return syntax, source
+}
+
+func baseSyntax(t *parser.Type) string {
+	switch t.Base {
+	case "BITS":
+		return "EnumBitmap"
+	case "INTEGER", "Integer32":
+		if len(t.Enums) > 0 {
+			return "EnumInteger"
+		}
+		return "Integer32"
+	case "Counter":
+		return "Counter32"
+	case "Gauge":
+		return "Gauge32"
+	case "NetworkAddress":
+		return "IpAddress"
+	case "OBJECT IDENTIFIER":
+		return "ObjectIdentifier"
+	case "OCTET STRING":
+		hint := t.DisplayHint
+		switch {
+		case strings.HasSuffix(hint, "a") || strings.HasSuffix(hint, "t"):
+			return "DisplayString"
+		case (hint == "1x:" || hint == "1x-") && t.FixedSize() == 6:
+			return "MacAddress"
+		case hint == "1x:" || hint == "1x-":
+			return "PhysAddress"
+		}
+		return "OctetString"
+	}
+	// Unsigned32, Gauge32, Counter32, Counter64, TimeTicks, IpAddress, Opaque.
+	return t.Base
+}
+
+// IndexType maps the type of an INDEX object to the index encoding the
+// object definitions use. implied is the IMPLIED keyword.
+func IndexType(t *parser.Type, implied bool) (string, error) {
+	switch t.Base {
+	case "INTEGER", "Integer32", "Unsigned32", "Gauge32", "Counter32", "TimeTicks", "Counter", "Gauge":
+		return "Integer", nil
+	case "OCTET STRING":
+		switch {
+		case implied:
+			return "ImplicitOctetString", nil
+		case t.FixedSize() == 6:
+			return "MacAddress", nil
+		}
+		return "OctetString", nil
+	case "OBJECT IDENTIFIER":
+		if implied {
+			return "ImplicitObjectIdentifier", nil
+		}
+		return "ObjectIdentifier", nil
+	case "IpAddress", "NetworkAddress":
+		return "IpAddress", nil
+	case "Opaque":
+		return "Opaque", nil
+	}
+	return "", fmt.Errorf("type %s cannot index a table", t.Name)
+}
+
+// isTagSyntax reports whether attributes of syntax are labels rather than
+// measurements: text, addresses and OIDs.
+func isTagSyntax(syntax string) bool {
+	switch syntax {
+	case "DisplayString", "OctetString", "PhysAddress", "MacAddress", "IpAddress",
+		"ObjectIdentifier", "AutonomousType", "RowPointer", "VariablePointer",
+		"TDomain", "TAddress", "DateAndTime", "IANAifType":
+		return true
+	}
+	return false
+}
+
+// MetricKind returns "counter" for monotonic syntaxes (counters and
+// TimeTicks, as the object library marks them), "gauge" for other numeric
+// syntaxes and "" for enumerations, tags and text.
+func MetricKind(syntax string) string {
+	switch syntax {
+	case "Counter32", "Counter64", "TimeTicks", "TimeStamp":
+		return "counter"
+	case "Integer32", "Unsigned32", "Gauge32", "TimeInterval", "CounterBasedGauge64":
+		return "gauge"
+	}
+	return ""
+}
@@ -0,0 +1,363 @@
+package compiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vpbank/snmp_collector/mibs/compiler"
+	"github.com/vpbank/snmp_collector/mibs/parser"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Shared fixtures
+// ─────────────────────────────────────────────────────────────────────────────
+
+const switchMIB = `
+SWITCH-MIB DEFINITIONS ::= BEGIN
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, Counter64, Gauge32, IpAddress,
+    enterprises                                  FROM SNMPv2-SMI
+    DisplayString, MacAddress, TruthValue        FROM SNMPv2-TC
+    SnmpAdminString                              FROM SNMP-FRAMEWORK-MIB;
+
+switchMIB MODULE-IDENTITY
+    LAST-UPDATED "202601010000Z"
+    ORGANIZATION "Example"
+    CONTACT-INFO "ops@example.com"
+    DESCRIPTION  "Switch."
+    ::= { enterprises 99999 }
+
+switchObjects OBJECT IDENTIFIER ::= { switchMIB 1 }
+switchSystem  OBJECT IDENTIFIER ::= { switchObjects 1 }
+
+switchVersion OBJECT-TYPE
+    SYNTAX      SnmpAdminString
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Software version."
+    ::= { switchSystem 1 }
+
+switchFans OBJECT-TYPE
+    SYNTAX      Gauge32
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Fans."
+    ::= { switchSystem 2 }
+
+switchFdbTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF SwitchFdbEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Forwarding database."
+    ::= { switchObjects 2 }
+
+switchFdbEntry OBJECT-TYPE
+    SYNTAX      SwitchFdbEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "An FDB entry."
+    INDEX       { switchFdbMac, IMPLIED switchFdbVlan }
+    ::= { switchFdbTable 1 }
+
+SwitchFdbEntry ::= SEQUENCE {
+    switchFdbMac      MacAddress,
+    switchFdbVlan     SnmpAdminString,
+    switchFdbPort     DisplayString,
+    switchFdbStatus   INTEGER,
+    switchFdbFlags    BITS,
+    switchFdbHits     Counter64,
+    switchFdbStatic   TruthValue,
+    switchFdbNextHop  IpAddress
+}
+
+switchFdbMac OBJECT-TYPE
+    SYNTAX      MacAddress
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "MAC."
+    ::= { switchFdbEntry 1 }
+
+switchFdbVlan OBJECT-TYPE
+    SYNTAX      SnmpAdminString (SIZE (1..32))
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "VLAN name."
+    ::= { switchFdbEntry 2 }
+
+switchFdbPort OBJECT-TYPE
+    SYNTAX      DisplayString
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Port."
+    ::= { switchFdbEntry 3 }
+
+switchFdbStatus OBJECT-TYPE
+    SYNTAX      INTEGER { other(1), invalid(2), learned(3), self(4), mgmt(5) }
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Status."
+    ::= { switchFdbEntry 4 }
+
+switchFdbFlags OBJECT-TYPE
+    SYNTAX      BITS { sticky(0), secure(1) }
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Flags."
+    ::= { switchFdbEntry 5 }
+
+switchFdbHits OBJECT-TYPE
+    SYNTAX      Counter64
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Hits."
+    ::= { switchFdbEntry 10 }
+
+switchFdbStatic OBJECT-TYPE
+    SYNTAX      TruthValue
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Static entry."
+    ::= { switchFdbEntry 11 }
+
+switchFdbNextHop OBJECT-TYPE
+    SYNTAX      IpAddress
+    MAX-ACCESS  accessible-for-notify
+    STATUS      current
+    DESCRIPTION "Next hop, in notifications only."
+    ::= { switchFdbEntry 12 }
+
+switchFdbExtTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF SwitchFdbExtEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "FDB extension."
+    ::= { switchObjects 3 }
+
+switchFdbExtEntry OBJECT-TYPE
+    SYNTAX      SwitchFdbExtEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "An FDB extension."
+    AUGMENTS    { switchFdbEntry }
+    ::= { switchFdbExtTable 1 }
+
+SwitchFdbExtEntry ::= SEQUENCE { switchFdbAge Gauge32 }
+
+switchFdbAge OBJECT-TYPE
+    SYNTAX      Gauge32
+    UNITS       "seconds"
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Age."
+    ::= { switchFdbExtEntry 1 }
+END
+`
+
+const frameworkMIB = `
+SNMP-FRAMEWORK-MIB DEFINITIONS ::= BEGIN
+IMPORTS TEXTUAL-CONVENTION FROM SNMPv2-TC;
+
+SnmpAdminString ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "255t"
+    STATUS       current
+    DESCRIPTION  "An octet string containing administrative information."
+    SYNTAX       OCTET STRING (SIZE (0..255))
+END
+`
+
+func compile(t *testing.T, names ...string) *compiler.Result {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range map[string]string{"SWITCH-MIB.txt": switchMIB, "SNMP-FRAMEWORK-MIB.txt": frameworkMIB} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set := parser.NewSet(dir)
+	if _, err := set.AddFile(filepath.Join(dir, "SWITCH-MIB.txt")); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	if err := set.Resolve(); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	res, err := compiler.Compile(set, names, compiler.Options{Prefix: "switch"})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	return res
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Compile
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestCompile_Table(t *testing.T) {
+	res := compile(t, "switchFdbTable")
+	if len(res.Objects) != 1 {
+		t.Fatalf("objects = %d, want 1", len(res.Objects))
+	}
+	def := res.Objects[0]
+	if def.Key != "SWITCH-MIB::switchFdbEntry" || def.MIB != "SWITCH-MIB" || def.Object != "switchFdbEntry" {
+		t.Errorf("key = %s, mib = %s, object = %s", def.Key, def.MIB, def.Object)
+	}
+
+	wantIndex := []models.IndexDefinition{
+		{Type: "MacAddress", OID: ".1.3.6.1.4.1.99999.1.2.1.1", Name: "switchFdbMac", Syntax: "MacAddress"},
+		{Type: "ImplicitOctetString", OID: ".1.3.6.1.4.1.99999.1.2.1.2", Name: "switchFdbVlan", Syntax: "DisplayString"},
+	}
+	if len(def.Index) != len(wantIndex) {
+		t.Fatalf("index = %+v", def.Index)
+	}
+	for i, want := range wantIndex {
+		if def.Index[i] != want {
+			t.Errorf("index[%d] = %+v, want %+v", i, def.Index[i], want)
+		}
+	}
+
+	wantAttrs := map[string]models.AttributeDefinition{
+		"switchFdbPort":   {OID: ".1.3.6.1.4.1.99999.1.2.1.3", Name: "switch.switchFdbPort", Syntax: "DisplayString", IsTag: true},
+		"switchFdbStatus": {OID: ".1.3.6.1.4.1.99999.1.2.1.4", Name: "switch.switchFdbStatus", Syntax: "EnumInteger"},
+		"switchFdbFlags":  {OID: ".1.3.6.1.4.1.99999.1.2.1.5", Name: "switch.switchFdbFlags", Syntax: "EnumBitmap"},
+		"switchFdbHits":   {OID: ".1.3.6.1.4.1.99999.1.2.1.10", Name: "switch.switchFdbHits", Syntax: "Counter64"},
+		"switchFdbStatic": {OID: ".1.3.6.1.4.1.99999.1.2.1.11", Name: "switch.switchFdbStatic", Syntax: "TruthValue"},
+	}
+	if len(def.Attributes) != len(wantAttrs) {
+		t.Errorf("attributes = %v, want index and notify-only columns left out", def.Attributes)
+	}
+	for name, want := range wantAttrs {
+		if got := def.Attributes[name]; got != want {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+	if def.DiscoveryAttribute != "switchFdbPort" {
+		t.Errorf("discovery_attribute = %q, want switchFdbPort", def.DiscoveryAttribute)
+	}
+
+	if got := res.Enums[".1.3.6.1.4.1.99999.1.2.1.4"]; len(got) != 5 || got[3] != "learned" {
+		t.Errorf("status enum = %v", got)
+	}
+	if got := res.Enums[".1.3.6.1.4.1.99999.1.2.1.5"]; len(got) != 2 || got[0] != "sticky" || got[1] != "secure" {
+		t.Errorf("flags enum = %v, want bit positions", got)
+	}
+	if got := res.Sources[".1.3.6.1.4.1.99999.1.2.1.2"]; got != "SnmpAdminString" {
+		t.Errorf("vlan source = %q, want SnmpAdminString", got)
+	}
+}
+
+func TestCompile_AugmentsAndScalars(t *testing.T) {
+	res := compile(t, "SWITCH-MIB::switchFdbExtEntry", "switchSystem")
+
+	ext := res.Objects[0]
+	if ext.Augments != "SWITCH-MIB::switchFdbEntry" || len(ext.Index) != 0 {
+		t.Errorf("augments = %q, index = %v", ext.Augments, ext.Index)
+	}
+
+	sys := res.Objects[1]
+	if sys.Key != "SWITCH-MIB::switchSystem" || len(sys.Index) != 0 || sys.DiscoveryAttribute != "switchVersion" {
+		t.Errorf("scalars = %+v", sys)
+	}
+	if a := sys.Attributes["switchVersion"]; a.OID != ".1.3.6.1.4.1.99999.1.1.1" || a.Syntax != "DisplayString" {
+		t.Errorf("switchVersion = %+v", a)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "SWITCH-MIB.txt")
+	_ = os.WriteFile(path, []byte(switchMIB), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "fw.mib"), []byte(frameworkMIB), 0o644)
+	set := parser.NewSet(dir)
+	if _, err := set.AddFile(path); err != nil {
+		t.Fatal(err)
+	}
+	_ = set.Resolve()
+
+	for name, want := range map[string]string{
+		"switchFdbPort": "is a scalar or column; name its table or its group SWITCH-MIB::switchFdbEntry",
+		"switchObjects": "SWITCH-MIB::switchObjects has no readable scalar objects",
+		"noSuchTable":   "noSuchTable: no such object",
+	} {
+		_, err := compiler.Compile(set, []string{name}, compiler.Options{})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Compile(%s) err = %v, want %q", name, err, want)
+		}
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Generated YAML
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestYAML_LoadsAndValidates(t *testing.T) {
+	res := compile(t, "switchFdbTable", "switchFdbExtTable", "switchSystem")
+	objects, err := res.ObjectsYAML()
+	if err != nil {
+		t.Fatalf("ObjectsYAML: %v", err)
+	}
+	enums, err := res.EnumsYAML()
+	if err != nil {
+		t.Fatalf("EnumsYAML: %v", err)
+	}
+
+	for _, want := range []string{
+		"SWITCH-MIB::switchFdbEntry:\n  mib: SWITCH-MIB\n  object: switchFdbEntry\n  index:\n",
+		"      syntax: DisplayString # SnmpAdminString\n",
+		"    switchFdbHits:\n      oid: .1.3.6.1.4.1.99999.1.2.1.10\n      name: switch.switchFdbHits\n      syntax: Counter64\n      metric: counter\n",
+		"    switchFdbPort:\n      oid: .1.3.6.1.4.1.99999.1.2.1.3\n      name: switch.switchFdbPort\n      syntax: DisplayString\n      tag: true\n",
+		"\n\nSWITCH-MIB::switchFdbExtEntry:\n",
+	} {
+		if !strings.Contains(string(objects), want) {
+			t.Errorf("objects YAML missing %q:\n%s", want, objects)
+		}
+	}
+	// Attributes are in OID order: .10 after .5.
+	if strings.Index(string(objects), "switchFdbFlags:") > strings.Index(string(objects), "switchFdbHits:") {
+		t.Errorf("attributes not in OID order:\n%s", objects)
+	}
+	if !strings.Contains(string(enums), ".1.3.6.1.4.1.99999.1.2.1.5:\n  0: sticky\n  1: secure\n") {
+		t.Errorf("enums YAML:\n%s", enums)
+	}
+
+	root := t.TempDir()
+	paths := config.Paths{}
+	for _, d := range []*string{&paths.Devices, &paths.DeviceGroups, &paths.ObjectGroups, &paths.Objects,
+		&paths.Enums, &paths.Vendors, &paths.Profiles, &paths.Credentials, &paths.Templates} {
+		*d = filepath.Join(root, "missing")
+	}
+	paths.Objects = filepath.Join(root, "objects")
+	paths.Enums = filepath.Join(root, "enums")
+	for dir, data := range map[string][]byte{paths.Objects: objects, paths.Enums: enums} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "SWITCH-MIB.yml"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := config.Load(paths, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	def, ok := cfg.ObjectDefs["SWITCH-MIB::switchFdbEntry"]
+	if !ok || len(def.Attributes) != 5 || def.Index[1].Type != "ImplicitOctetString" {
+		t.Errorf("loaded switchFdbEntry = %+v", def)
+	}
+	if label := cfg.Enums.Resolve("1.3.6.1.4.1.99999.1.2.1.4", int64(3)); label != "learned" {
+		t.Errorf("enum resolve = %v, want learned", label)
+	}
+
+	issues, err := config.Validate(paths)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError || strings.Contains(issue.File, "SWITCH-MIB") {
+			t.Errorf("issue: %s", issue)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package compiler
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vpbank/snmp_collector/models"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// YAML generation
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ObjectsYAML renders r.Objects in the object definition file format, laid
+// out like the hand-written library: one blank line between objects, keys
+// in the order mib, object, augments, index, discovery_attribute,
+// attributes, and attributes in OID order. Numeric attributes get a
+// `metric:` kind, and a syntax mapped from a differently named MIB type is
+// annotated with that name, e.g. `syntax: DisplayString # SnmpAdminString`.
+func (r *Result) ObjectsYAML() ([]byte, error) {
+	var out bytes.Buffer
+	for i, def := range r.Objects {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		doc := mapping()
+		doc.Content = append(doc.Content, scalar(def.Key), r.objectNode(def))
+		if err := encode(&out, doc); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func (r *Result) objectNode(def models.ObjectDefinition) *yaml.Node {
+	n := mapping()
+	addPair(n, "mib", def.MIB)
+	addPair(n, "object", def.Object)
+	if def.Augments != "" {
+		addPair(n, "augments", def.Augments)
+	}
+	if len(def.Index) > 0 {
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, idx := range def.Index {
+			item := mapping()
+			addPair(item, "type", idx.Type)
+			addPair(item, "oid", idx.OID)
+			addPair(item, "name", idx.Name)
+			addPair(item, "syntax", idx.Syntax).LineComment = r.Sources[idx.OID]
+			seq.Content = append(seq.Content, item)
+		}
+		n.Content = append(n.Content, scalar("index"), seq)
+	}
+	addPair(n, "discovery_attribute", def.DiscoveryAttribute)
+
+	names := make([]string, 0, len(def.Attributes))
+	for name := range def.Attributes {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return compareOIDs(def.Attributes[a].OID, def.Attributes[b].OID)
+	})
+	attrs := mapping()
+	for _, name := range names {
+		a := def.Attributes[name]
+		item := mapping()
+		addPair(item, "oid", a.OID)
+		addPair(item, "name", a.Name)
+		addPair(item, "syntax", a.Syntax).LineComment = r.Sources[a.OID]
+		if a.IsTag {
+			addPair(item, "tag", "true").Tag = "!!bool"
+		}
+		if kind := MetricKind(a.Syntax); kind != "" {
+			addPair(item, "metric", kind)
+		}
+		attrs.Content = append(attrs.Content, scalar(name), item)
+	}
+	n.Content = append(n.Content, scalar("attributes"), attrs)
+	return n
+}
+
+// EnumsYAML renders r.Enums in the enum definition file format: attribute
+// OID → value → label, OIDs and values in numeric order.
+func (r *Result) EnumsYAML() ([]byte, error) {
+	oids := make([]string, 0, len(r.Enums))
+	for oid := range r.Enums {
+		oids = append(oids, oid)
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+	slices.SortFunc(oids, compareOIDs)
+
+	doc := mapping()
+	for _, oid := range oids {
+		labels := r.Enums[oid]
+		values := make([]int64, 0, len(labels))
+		for v := range labels {
+			values = append(values, v)
+		}
+		slices.Sort(values)
+		m := mapping()
+		for _, v := range values {
+			m.Content = append(m.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v, 10)},
+				scalar(labels[v]))
+		}
+		doc.Content = append(doc.Content, scalar(oid), m)
+	}
+	var out bytes.Buffer
+	if err := encode(&out, doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func mapping() *yaml.Node { return &yaml.Node{Kind: yaml.MappingNode} }
+
+func scalar(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+// addPair appends key: value to m and returns the value node.
+func addPair(m *yaml.Node, key, value string) *yaml.Node {
+	v := scalar(value)
+	m.Content = append(m.Content, scalar(key), v)
+	return v
+}
+
+func encode(buf *bytes.Buffer, n *yaml.Node) error {
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// compareOIDs orders dotted OIDs arc by arc, so .1.10 sorts after .1.9.
+func compareOIDs(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "."), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "."), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.ParseUint(as[i], 10, 64)
+		y, _ := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return len(as) - len(bs)
+}
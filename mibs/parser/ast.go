@@ -0,0 +1,137 @@
+package parser
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Abstract syntax tree
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Module is one parsed MIB module, e.g. IF-MIB. A file may hold several.
+type Module struct {
+	// Name is the module name, e.g. "IF-MIB".
+	Name string
+
+	// File is the path the module was parsed from; empty for the built-in
+	// base modules.
+	File string
+
+	// Imports lists the IMPORTS clauses in declaration order.
+	Imports []Import
+
+	// Types holds the type assignments, textual conventions included, in
+	// declaration order.
+	Types []*TypeDef
+
+	// Nodes holds the OID value assignments (OBJECT IDENTIFIER, OBJECT-TYPE,
+	// MODULE-IDENTITY, …) in declaration order.
+	Nodes []*Node
+}
+
+// Import is one `symbols FROM module` clause of IMPORTS.
+type Import struct {
+	Module  string
+	Symbols []string
+}
+
+// NodeKind is the macro an OID value was assigned with.
+type NodeKind int
+
+const (
+	KindObjectIdentifier NodeKind = iota // name OBJECT IDENTIFIER ::= { … }
+	KindObjectIdentity                   // OBJECT-IDENTITY
+	KindModuleIdentity                   // MODULE-IDENTITY
+	KindObjectType                       // OBJECT-TYPE: scalars, tables, rows and columns
+	KindNotification                     // NOTIFICATION-TYPE
+	KindGroup                            // OBJECT-GROUP, NOTIFICATION-GROUP
+	KindCompliance                       // MODULE-COMPLIANCE, AGENT-CAPABILITIES
+)
+
+var kindNames = [...]string{
+	KindObjectIdentifier: "OBJECT IDENTIFIER",
+	KindObjectIdentity:   "OBJECT-IDENTITY",
+	KindModuleIdentity:   "MODULE-IDENTITY",
+	KindObjectType:       "OBJECT-TYPE",
+	KindNotification:     "NOTIFICATION-TYPE",
+	KindGroup:            "OBJECT-GROUP",
+	KindCompliance:       "MODULE-COMPLIANCE",
+}
+
+func (k NodeKind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// Node is an OID value assignment.
+type Node struct {
+	Name string
+	Kind NodeKind
+	Line int
+
+	// OID is the value as written, e.g. { ifEntry 2 } or
+	// { iso org(3) dod(6) 1 }. The first component usually names the parent.
+	OID []OIDComponent
+
+	// The remaining fields are set for OBJECT-TYPE only, except Status and
+	// Description which every macro may carry.
+	Syntax      *Syntax
+	Units       string
+	Access      string // MAX-ACCESS, or ACCESS in SMIv1
+	Status      string
+	Description string
+	Index       []IndexItem
+	Augments    string
+}
+
+// OIDComponent is one arc of an OID value: a name, a number, or both as in
+// org(3). Number is -1 when only a name is given.
+type OIDComponent struct {
+	Name   string
+	Number int64
+}
+
+// IndexItem is one entry of an INDEX clause.
+type IndexItem struct {
+	Name    string
+	Implied bool
+}
+
+// TypeDef is a type assignment: `Name ::= TEXTUAL-CONVENTION …` or a plain
+// `Name ::= INTEGER (0..255)`.
+type TypeDef struct {
+	Name              string
+	Line              int
+	TextualConvention bool
+	DisplayHint       string
+	Status            string
+	Description       string
+	Syntax            *Syntax
+}
+
+// Syntax is a SYNTAX clause or the right-hand side of a type assignment.
+type Syntax struct {
+	// Type is INTEGER, OCTET STRING, OBJECT IDENTIFIER, BITS, SEQUENCE,
+	// SEQUENCE OF, CHOICE, or the name of another type such as Counter32 or
+	// DisplayString.
+	Type string
+
+	// Enums holds INTEGER enumerations and BITS positions, in order.
+	Enums []NamedNumber
+
+	// Size and Range hold the SIZE (…) and value (…) constraints.
+	Size  []Range
+	Range []Range
+
+	// Of is the row type of a SEQUENCE OF.
+	Of string
+}
+
+// NamedNumber is an enumeration label, e.g. up(1), or a named bit.
+type NamedNumber struct {
+	Name  string
+	Value int64
+}
+
+// Range is one alternative of a constraint; Min == Max for a single value.
+type Range struct {
+	Min, Max int64
+}
@@ -0,0 +1,116 @@
+package parser
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Built-in base modules
+// ─────────────────────────────────────────────────────────────────────────────
+
+// baseModules are the SMI modules every MIB imports from. Set serves these
+// copies in place of files on the search path: the originals consist mostly
+// of MACRO definitions, and the application types they define (Counter32,
+// TimeTicks, …) are understood natively.
+var baseModules = map[string]string{
+	"SNMPv2-SMI": `
+SNMPv2-SMI DEFINITIONS ::= BEGIN
+iso              OBJECT IDENTIFIER ::= { 1 }
+ccitt            OBJECT IDENTIFIER ::= { 0 }
+joint-iso-ccitt  OBJECT IDENTIFIER ::= { 2 }
+org              OBJECT IDENTIFIER ::= { iso 3 }
+dod              OBJECT IDENTIFIER ::= { org 6 }
+internet         OBJECT IDENTIFIER ::= { dod 1 }
+directory        OBJECT IDENTIFIER ::= { internet 1 }
+mgmt             OBJECT IDENTIFIER ::= { internet 2 }
+mib-2            OBJECT IDENTIFIER ::= { mgmt 1 }
+transmission     OBJECT IDENTIFIER ::= { mib-2 10 }
+experimental     OBJECT IDENTIFIER ::= { internet 3 }
+private          OBJECT IDENTIFIER ::= { internet 4 }
+enterprises      OBJECT IDENTIFIER ::= { private 1 }
+security         OBJECT IDENTIFIER ::= { internet 5 }
+snmpV2           OBJECT IDENTIFIER ::= { internet 6 }
+snmpDomains      OBJECT IDENTIFIER ::= { snmpV2 1 }
+snmpProxys       OBJECT IDENTIFIER ::= { snmpV2 2 }
+snmpModules      OBJECT IDENTIFIER ::= { snmpV2 3 }
+zeroDotZero      OBJECT IDENTIFIER ::= { 0 0 }
+END
+`,
+	"RFC1155-SMI": `
+RFC1155-SMI DEFINITIONS ::= BEGIN
+internet         OBJECT IDENTIFIER ::= { iso org(3) dod(6) 1 }
+directory        OBJECT IDENTIFIER ::= { internet 1 }
+mgmt             OBJECT IDENTIFIER ::= { internet 2 }
+experimental     OBJECT IDENTIFIER ::= { internet 3 }
+private          OBJECT IDENTIFIER ::= { internet 4 }
+enterprises      OBJECT IDENTIFIER ::= { private 1 }
+END
+`,
+	"SNMPv2-TC": `
+SNMPv2-TC DEFINITIONS ::= BEGIN
+DisplayString ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "255a"
+    STATUS       current
+    SYNTAX       OCTET STRING (SIZE (0..255))
+PhysAddress ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "1x:"
+    STATUS       current
+    SYNTAX       OCTET STRING
+MacAddress ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "1x:"
+    STATUS       current
+    SYNTAX       OCTET STRING (SIZE (6))
+TruthValue ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       INTEGER { true(1), false(2) }
+TestAndIncr ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       INTEGER (0..2147483647)
+AutonomousType ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       OBJECT IDENTIFIER
+InstancePointer ::= TEXTUAL-CONVENTION
+    STATUS       obsolete
+    SYNTAX       OBJECT IDENTIFIER
+VariablePointer ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       OBJECT IDENTIFIER
+RowPointer ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       OBJECT IDENTIFIER
+RowStatus ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       INTEGER { active(1), notInService(2), notReady(3),
+                           createAndGo(4), createAndWait(5), destroy(6) }
+TimeStamp ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       TimeTicks
+TimeInterval ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       INTEGER (0..2147483647)
+DateAndTime ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "2d-1d-1d,1d:1d:1d.1d,1a1d:1d"
+    STATUS       current
+    SYNTAX       OCTET STRING (SIZE (8 | 11))
+StorageType ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       INTEGER { other(1), volatile(2), nonVolatile(3),
+                           permanent(4), readOnly(5) }
+TDomain ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       OBJECT IDENTIFIER
+TAddress ::= TEXTUAL-CONVENTION
+    STATUS       current
+    SYNTAX       OCTET STRING (SIZE (1..255))
+END
+`,
+	"SNMPv2-CONF": "SNMPv2-CONF DEFINITIONS ::= BEGIN END",
+	"RFC-1212":    "RFC-1212 DEFINITIONS ::= BEGIN END",
+	"RFC-1215":    "RFC-1215 DEFINITIONS ::= BEGIN END",
+}
+
+// baseTypes are the types a syntax resolves down to: the ASN.1 built-ins
+// and the SMIv1 / SMIv2 application types.
+var baseTypes = map[string]bool{
+	"INTEGER": true, "OCTET STRING": true, "OBJECT IDENTIFIER": true, "BITS": true,
+	"SEQUENCE": true, "SEQUENCE OF": true, "CHOICE": true,
+	"Integer32": true, "Unsigned32": true, "Gauge32": true, "Counter32": true, "Counter64": true,
+	"TimeTicks": true, "IpAddress": true, "Opaque": true,
+	"Counter": true, "Gauge": true, "NetworkAddress": true,
+}
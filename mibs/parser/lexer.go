@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Tokens
+// ─────────────────────────────────────────────────────────────────────────────
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokIdent            // identifiers and keywords: ifIndex, OBJECT-TYPE, mib-2
+	tokNumber           // decimal, optionally negative: 42, -1
+	tokString           // "quoted text", may span lines
+	tokHex              // 'DEADBEEF'H
+	tokBinary           // '0101'B
+	tokPunct            // ::= { } ( ) [ ] , ; .. |
+)
+
+type token struct {
+	kind tokenKind
+	text string // without quotes for strings, without 'H / 'B for hex / binary
+	line int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// is reports whether t is the identifier or punctuation text.
+func (t token) is(text string) bool {
+	return (t.kind == tokIdent || t.kind == tokPunct) && t.text == text
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Lexer
+// ─────────────────────────────────────────────────────────────────────────────
+
+// lex splits ASN.1 source into tokens; file names it in errors. Comments run from "--" to the end of
+// the line or to the next "--", whichever comes first.
+func lex(src, file string) ([]token, error) {
+	var (
+		toks []token
+		line = 1
+		i    = 0
+	)
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			i += 2
+			for i < len(src) && src[i] != '\n' {
+				if src[i] == '-' && i+1 < len(src) && src[i+1] == '-' {
+					i += 2
+					break
+				}
+				i++
+			}
+
+		case c == '"':
+			start, startLine := i+1, line
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("%s:%d: unterminated string", file, startLine)
+			}
+			toks = append(toks, token{kind: tokString, text: src[start:i], line: startLine})
+			i++
+
+		case c == '\'':
+			end := strings.IndexByte(src[i+1:], '\'')
+			if end < 0 || i+end+2 >= len(src) {
+				return nil, fmt.Errorf("%s:%d: unterminated hex or binary string", file, line)
+			}
+			body := src[i+1 : i+1+end]
+			kind := tokHex
+			switch src[i+end+2] {
+			case 'H', 'h':
+			case 'B', 'b':
+				kind = tokBinary
+			default:
+				return nil, fmt.Errorf("%s:%d: expected 'H or 'B after quoted string", file, line)
+			}
+			toks = append(toks, token{kind: kind, text: body, line: line})
+			i += end + 3
+
+		case c == ':' && strings.HasPrefix(src[i:], "::="):
+			toks = append(toks, token{kind: tokPunct, text: "::=", line: line})
+			i += 3
+
+		case c == '.' && i+1 < len(src) && src[i+1] == '.':
+			toks = append(toks, token{kind: tokPunct, text: "..", line: line})
+			i += 2
+
+		case strings.IndexByte("{}()[],;|", c) >= 0:
+			toks = append(toks, token{kind: tokPunct, text: string(c), line: line})
+			i++
+
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			i++
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[start:i], line: line})
+
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i]) || src[i] == '_' || src[i] == '-') {
+				// A "--" inside a word starts a comment.
+				if src[i] == '-' && i+1 < len(src) && src[i+1] == '-' {
+					break
+				}
+				i++
+			}
+			toks = append(toks, token{kind: tokIdent, text: strings.TrimRight(src[start:i], "-"), line: line})
+
+		default:
+			// Stray characters (e.g. the '<' or '@' of a macro body) are
+			// kept as punctuation; the parser skips macro bodies wholesale.
+			toks = append(toks, token{kind: tokPunct, text: string(c), line: line})
+			i++
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, line: line})
+	return toks, nil
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
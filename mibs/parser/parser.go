@@ -0,0 +1,677 @@
+// Package parser reads SMIv1 and SMIv2 MIB modules, follows their IMPORTS
+// and resolves every named node to its numeric OID.
+//
+// Only the subset of ASN.1 that MIB modules use is understood: OID value
+// assignments, the SMI macros (OBJECT-TYPE, MODULE-IDENTITY, TEXTUAL-
+// CONVENTION, …) and type assignments. MACRO definitions and the conformance
+// macros' bodies are skipped, so the SMI base modules themselves parse too,
+// although Set serves them from built-in copies.
+package parser
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseFile parses every module in the MIB file at path.
+func ParseFile(path string) ([]*Module, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(src, path)
+}
+
+// Parse parses every module in src. file names the source in errors, which
+// read "file:line: message".
+func Parse(src []byte, file string) ([]*Module, error) {
+	toks, err := lex(string(src), file)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, file: file}
+
+	var mods []*Module
+	for p.peek().kind != tokEOF {
+		m, err := p.module()
+		if err != nil {
+			return mods, err
+		}
+		m.File = file
+		mods = append(mods, m)
+	}
+	if len(mods) == 0 {
+		return nil, fmt.Errorf("%s: no MIB module found", file)
+	}
+	return mods, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Parser state
+// ─────────────────────────────────────────────────────────────────────────────
+
+type parser struct {
+	toks []token
+	pos  int
+	file string
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.file, t.line, fmt.Sprintf(format, args...))
+}
+
+// expect consumes the identifier or punctuation text.
+func (p *parser) expect(text string) (token, error) {
+	t := p.next()
+	if !t.is(text) {
+		return t, p.errorf(t, "expected %q, got %s", text, t)
+	}
+	return t, nil
+}
+
+// accept consumes the next token when it is text.
+func (p *parser) accept(text string) bool {
+	if p.peek().is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) ident() (token, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return t, p.errorf(t, "expected identifier, got %s", t)
+	}
+	return t, nil
+}
+
+func (p *parser) str() (string, error) {
+	t := p.next()
+	if t.kind != tokString {
+		return "", p.errorf(t, "expected quoted string, got %s", t)
+	}
+	return t.text, nil
+}
+
+// skipBalanced skips a bracketed group starting at the current open token.
+func (p *parser) skipBalanced(open, close string) error {
+	start := p.peek()
+	if _, err := p.expect(open); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return p.errorf(start, "unbalanced %q", open)
+		case t.is(open):
+			depth++
+		case t.is(close):
+			depth--
+		}
+	}
+	return nil
+}
+
+// skipUntil skips tokens up to, not including, the identifier or
+// punctuation text.
+func (p *parser) skipUntil(text string) error {
+	start := p.peek()
+	for !p.peek().is(text) {
+		if p.next().kind == tokEOF {
+			return p.errorf(start, "expected %q before end of file", text)
+		}
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Module structure
+// ─────────────────────────────────────────────────────────────────────────────
+
+// module parses `NAME DEFINITIONS ::= BEGIN … END`.
+func (p *parser) module() (*Module, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	m := &Module{Name: name.text}
+	if p.peek().is("{") {
+		if err := p.skipBalanced("{", "}"); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect("DEFINITIONS"); err != nil {
+		return nil, err
+	}
+	if p.accept("IMPLICIT") || p.accept("EXPLICIT") || p.accept("AUTOMATIC") {
+		if _, err := p.expect("TAGS"); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect("::="); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("BEGIN"); err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		switch {
+		case t.is("END"):
+			p.next()
+			return m, nil
+		case t.kind == tokEOF:
+			return nil, p.errorf(t, "module %s: missing END", m.Name)
+		case t.is("IMPORTS"):
+			p.next()
+			if err := p.imports(m); err != nil {
+				return nil, err
+			}
+		case t.is("EXPORTS"):
+			if err := p.skipUntil(";"); err != nil {
+				return nil, err
+			}
+			p.next()
+		case t.kind == tokIdent:
+			if err := p.assignment(m); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.errorf(t, "unexpected %s in module %s", t, m.Name)
+		}
+	}
+}
+
+// imports parses `a, b FROM MOD-A c FROM MOD-B ;`.
+func (p *parser) imports(m *Module) error {
+	var symbols []string
+	for {
+		t := p.next()
+		switch {
+		case t.is(";"):
+			if len(symbols) > 0 {
+				return p.errorf(t, "IMPORTS: symbols %s have no FROM", strings.Join(symbols, ", "))
+			}
+			return nil
+		case t.is(","):
+		case t.is("FROM"):
+			mod, err := p.ident()
+			if err != nil {
+				return err
+			}
+			m.Imports = append(m.Imports, Import{Module: mod.text, Symbols: symbols})
+			symbols = nil
+		case t.kind == tokIdent:
+			symbols = append(symbols, t.text)
+		default:
+			return p.errorf(t, "unexpected %s in IMPORTS", t)
+		}
+	}
+}
+
+// assignment parses one top-level assignment starting at an identifier.
+func (p *parser) assignment(m *Module) error {
+	name := p.next()
+	next := p.peek()
+	switch {
+	case next.is("MACRO"):
+		return p.skipMacro()
+
+	case next.is("::="):
+		p.next()
+		td, err := p.typeAssignment(name)
+		if err != nil {
+			return err
+		}
+		m.Types = append(m.Types, td)
+		return nil
+
+	case next.is("OBJECT") && p.peekAt(1).is("IDENTIFIER"):
+		p.pos += 2
+		n := &Node{Name: name.text, Kind: KindObjectIdentifier, Line: name.line}
+		if err := p.oidAssignment(n); err != nil {
+			return err
+		}
+		m.Nodes = append(m.Nodes, n)
+		return nil
+
+	case next.is("OBJECT-TYPE"):
+		p.next()
+		n, err := p.objectType(name)
+		if err != nil {
+			return err
+		}
+		m.Nodes = append(m.Nodes, n)
+		return nil
+
+	case next.is("TRAP-TYPE"):
+		// SMIv1 traps have an integer value, not an OID.
+		p.next()
+		if err := p.skipUntil("::="); err != nil {
+			return err
+		}
+		p.next()
+		p.next()
+		return nil
+	}
+
+	if kind, ok := macroKinds[next.text]; ok && next.kind == tokIdent {
+		p.next()
+		n := &Node{Name: name.text, Kind: kind, Line: name.line}
+		if err := p.macroValue(n); err != nil {
+			return err
+		}
+		m.Nodes = append(m.Nodes, n)
+		return nil
+	}
+
+	// Any other value assignment, e.g. `maxFoo INTEGER ::= 255`.
+	if err := p.skipUntil("::="); err != nil {
+		return err
+	}
+	p.next()
+	if p.peek().is("{") {
+		return p.skipBalanced("{", "}")
+	}
+	p.next()
+	return nil
+}
+
+var macroKinds = map[string]NodeKind{
+	"OBJECT-IDENTITY":    KindObjectIdentity,
+	"MODULE-IDENTITY":    KindModuleIdentity,
+	"NOTIFICATION-TYPE":  KindNotification,
+	"OBJECT-GROUP":       KindGroup,
+	"NOTIFICATION-GROUP": KindGroup,
+	"MODULE-COMPLIANCE":  KindCompliance,
+	"AGENT-CAPABILITIES": KindCompliance,
+}
+
+// skipMacro skips `NAME MACRO ::= BEGIN … END`.
+func (p *parser) skipMacro() error {
+	if err := p.skipUntil("END"); err != nil {
+		return err
+	}
+	p.next()
+	return nil
+}
+
+// oidAssignment parses `::= { parent 1 }` into n.OID.
+func (p *parser) oidAssignment(n *Node) error {
+	if _, err := p.expect("::="); err != nil {
+		return err
+	}
+	oid, err := p.oidValue()
+	if err != nil {
+		return err
+	}
+	n.OID = oid
+	return nil
+}
+
+// oidValue parses `{ iso org(3) dod(6) 1 }`.
+func (p *parser) oidValue() ([]OIDComponent, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var oid []OIDComponent
+	for !p.accept("}") {
+		t := p.next()
+		switch t.kind {
+		case tokNumber:
+			n, err := strconv.ParseInt(t.text, 10, 64)
+			if err != nil || n < 0 {
+				return nil, p.errorf(t, "invalid OID arc %s", t.text)
+			}
+			oid = append(oid, OIDComponent{Number: n})
+		case tokIdent:
+			c := OIDComponent{Name: t.text, Number: -1}
+			if p.accept("(") {
+				num := p.next()
+				n, err := strconv.ParseInt(num.text, 10, 64)
+				if num.kind != tokNumber || err != nil || n < 0 {
+					return nil, p.errorf(num, "invalid OID arc %s", num)
+				}
+				c.Number = n
+				if _, err := p.expect(")"); err != nil {
+					return nil, err
+				}
+			}
+			oid = append(oid, c)
+		default:
+			return nil, p.errorf(t, "unexpected %s in OID value", t)
+		}
+	}
+	if len(oid) == 0 {
+		return nil, p.errorf(p.toks[p.pos-1], "empty OID value")
+	}
+	return oid, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Macros
+// ─────────────────────────────────────────────────────────────────────────────
+
+// objectType parses the clauses of an OBJECT-TYPE up to and including its
+// OID value.
+func (p *parser) objectType(name token) (*Node, error) {
+	n := &Node{Name: name.text, Kind: KindObjectType, Line: name.line}
+	for {
+		t := p.next()
+		var err error
+		switch {
+		case t.is("::="):
+			p.pos--
+			return n, p.oidAssignment(n)
+		case t.is("SYNTAX"):
+			n.Syntax, err = p.syntax()
+		case t.is("UNITS"):
+			n.Units, err = p.str()
+		case t.is("MAX-ACCESS"), t.is("ACCESS"), t.is("MIN-ACCESS"):
+			var a token
+			a, err = p.ident()
+			n.Access = a.text
+		case t.is("STATUS"):
+			var s token
+			s, err = p.ident()
+			n.Status = s.text
+		case t.is("DESCRIPTION"):
+			n.Description, err = p.str()
+		case t.is("REFERENCE"):
+			_, err = p.str()
+		case t.is("INDEX"):
+			n.Index, err = p.index()
+		case t.is("AUGMENTS"):
+			if _, err = p.expect("{"); err == nil {
+				var a token
+				if a, err = p.ident(); err == nil {
+					n.Augments = a.text
+					_, err = p.expect("}")
+				}
+			}
+		case t.is("DEFVAL"):
+			err = p.skipBalanced("{", "}")
+		default:
+			return nil, p.errorf(t, "%s: unexpected %s in OBJECT-TYPE", name.text, t)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// index parses `{ [IMPLIED] name, … }`. SMIv1 also allows bare types such
+// as INTEGER, which are kept as the item name.
+func (p *parser) index() ([]IndexItem, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var items []IndexItem
+	for {
+		var item IndexItem
+		if p.accept("IMPLIED") {
+			item.Implied = true
+		}
+		t, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		item.Name = t.text
+		// OCTET STRING, OBJECT IDENTIFIER, NetworkAddress … in SMIv1.
+		for p.peek().kind == tokIdent {
+			item.Name += " " + p.next().text
+		}
+		items = append(items, item)
+		if p.accept("}") {
+			return items, nil
+		}
+		if _, err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// macroValue parses MODULE-IDENTITY, OBJECT-IDENTITY, NOTIFICATION-TYPE and
+// the conformance macros: STATUS and DESCRIPTION are kept, the other
+// clauses skipped, and the OID value parsed.
+func (p *parser) macroValue(n *Node) error {
+	for {
+		t := p.peek()
+		switch {
+		case t.is("::="):
+			return p.oidAssignment(n)
+		case t.kind == tokEOF:
+			return p.errorf(t, "%s: missing ::=", n.Name)
+		case t.is("STATUS") && p.peekAt(1).kind == tokIdent:
+			p.next()
+			n.Status = p.next().text
+		case t.is("DESCRIPTION") && p.peekAt(1).kind == tokString && n.Description == "":
+			p.next()
+			n.Description = p.next().text
+		default:
+			p.next()
+		}
+	}
+}
+
+// typeAssignment parses the right-hand side of `Name ::=`.
+func (p *parser) typeAssignment(name token) (*TypeDef, error) {
+	td := &TypeDef{Name: name.text, Line: name.line}
+	if !p.accept("TEXTUAL-CONVENTION") {
+		s, err := p.syntax()
+		td.Syntax = s
+		return td, err
+	}
+	td.TextualConvention = true
+	for {
+		t := p.next()
+		var err error
+		switch {
+		case t.is("SYNTAX"):
+			td.Syntax, err = p.syntax()
+			return td, err
+		case t.is("DISPLAY-HINT"):
+			td.DisplayHint, err = p.str()
+		case t.is("STATUS"):
+			var s token
+			s, err = p.ident()
+			td.Status = s.text
+		case t.is("DESCRIPTION"):
+			td.Description, err = p.str()
+		case t.is("REFERENCE"):
+			_, err = p.str()
+		default:
+			return nil, p.errorf(t, "%s: unexpected %s in TEXTUAL-CONVENTION", name.text, t)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Types
+// ─────────────────────────────────────────────────────────────────────────────
+
+// syntax parses a type: a built-in ASN.1 type, a named type, either with an
+// optional enumeration or constraint, SEQUENCE { … }, SEQUENCE OF or CHOICE.
+// Tags such as [APPLICATION 1] IMPLICIT are skipped.
+func (p *parser) syntax() (*Syntax, error) {
+	if p.peek().is("[") {
+		if err := p.skipBalanced("[", "]"); err != nil {
+			return nil, err
+		}
+		_ = p.accept("IMPLICIT") || p.accept("EXPLICIT")
+	}
+
+	t, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	s := &Syntax{Type: t.text}
+	switch t.text {
+	case "OCTET":
+		if _, err := p.expect("STRING"); err != nil {
+			return nil, err
+		}
+		s.Type = "OCTET STRING"
+	case "OBJECT":
+		if _, err := p.expect("IDENTIFIER"); err != nil {
+			return nil, err
+		}
+		s.Type = "OBJECT IDENTIFIER"
+	case "SEQUENCE":
+		if p.accept("OF") {
+			of, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			s.Type, s.Of = "SEQUENCE OF", of.text
+			return s, nil
+		}
+		return s, p.skipBalanced("{", "}")
+	case "CHOICE":
+		return s, p.skipBalanced("{", "}")
+	}
+
+	switch {
+	case p.peek().is("{"):
+		s.Enums, err = p.namedNumbers()
+	case p.peek().is("("):
+		err = p.constraint(s)
+	}
+	return s, err
+}
+
+// namedNumbers parses `{ up(1), down(2) }`.
+func (p *parser) namedNumbers() ([]NamedNumber, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var out []NamedNumber
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("("); err != nil {
+			return nil, err
+		}
+		num := p.next()
+		v, err := strconv.ParseInt(num.text, 10, 64)
+		if num.kind != tokNumber || err != nil {
+			return nil, p.errorf(num, "invalid enumeration value %s", num)
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		out = append(out, NamedNumber{Name: name.text, Value: v})
+		if p.accept("}") {
+			return out, nil
+		}
+		if _, err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// constraint parses `(0..255 | 1000)` or `(SIZE (0..255))` into s.
+func (p *parser) constraint(s *Syntax) error {
+	if _, err := p.expect("("); err != nil {
+		return err
+	}
+	var err error
+	if p.accept("SIZE") {
+		if _, err := p.expect("("); err != nil {
+			return err
+		}
+		if s.Size, err = p.ranges(); err != nil {
+			return err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return err
+		}
+	} else if s.Range, err = p.ranges(); err != nil {
+		return err
+	}
+	_, err = p.expect(")")
+	return err
+}
+
+// ranges parses `lo..hi | v | …`.
+func (p *parser) ranges() ([]Range, error) {
+	var out []Range
+	for {
+		lo, err := p.rangeValue()
+		if err != nil {
+			return nil, err
+		}
+		r := Range{Min: lo, Max: lo}
+		if p.accept("..") {
+			if r.Max, err = p.rangeValue(); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, r)
+		if !p.accept("|") {
+			return out, nil
+		}
+	}
+}
+
+// rangeValue parses a constraint bound. Values beyond int64, such as the
+// upper bound of Counter64, saturate.
+func (p *parser) rangeValue() (int64, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		v, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			if strings.HasPrefix(t.text, "-") {
+				return math.MinInt64, nil
+			}
+			return math.MaxInt64, nil
+		}
+		return v, nil
+	case t.kind == tokHex:
+		v, err := strconv.ParseUint(t.text, 16, 63)
+		if err != nil {
+			return math.MaxInt64, nil
+		}
+		return int64(v), nil
+	case t.kind == tokBinary:
+		v, err := strconv.ParseUint(t.text, 2, 63)
+		if err != nil {
+			return math.MaxInt64, nil
+		}
+		return int64(v), nil
+	case t.is("MIN"):
+		return math.MinInt64, nil
+	case t.is("MAX"):
+		return math.MaxInt64, nil
+	}
+	return 0, p.errorf(t, "invalid constraint value %s", t)
+}
@@ -0,0 +1,461 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vpbank/snmp_collector/mibs/parser"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Shared fixtures
+// ─────────────────────────────────────────────────────────────────────────────
+
+// testMIB exercises the SMIv2 constructs the parser must understand; it
+// imports from the built-in base modules and from TEST-TC-MIB.
+const testMIB = `
+TEST-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, Counter64, Integer32,
+    enterprises                              FROM SNMPv2-SMI
+    DisplayString, MacAddress, RowStatus     FROM SNMPv2-TC
+    MODULE-COMPLIANCE, OBJECT-GROUP          FROM SNMPv2-CONF
+    TestName                                 FROM TEST-TC-MIB;
+
+testMIB MODULE-IDENTITY
+    LAST-UPDATED "202601010000Z"
+    ORGANIZATION "Example"
+    CONTACT-INFO "ops@example.com"
+    DESCRIPTION  "A test module -- with dashes in a string."
+    ::= { enterprises 99999 1 }
+
+testObjects OBJECT IDENTIFIER ::= { testMIB 1 }
+
+TestState ::= TEXTUAL-CONVENTION
+    STATUS      current
+    DESCRIPTION "Port state."
+    SYNTAX      INTEGER { up(1), down(2) -- comment -- , unknown(-1) }
+
+testPortTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF TestPortEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Ports."
+    ::= { testObjects 1 }
+
+testPortEntry OBJECT-TYPE
+    SYNTAX      TestPortEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "A port."
+    INDEX       { testPortMac, IMPLIED testPortName }
+    ::= { testPortTable 1 }
+
+TestPortEntry ::= SEQUENCE {
+    testPortMac     MacAddress,
+    testPortName    TestName,
+    testPortState   TestState,
+    testPortOctets  Counter64,
+    testPortFlags   BITS,
+    testPortStatus  RowStatus
+}
+
+testPortMac OBJECT-TYPE
+    SYNTAX      MacAddress
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "MAC."
+    ::= { testPortEntry 1 }
+
+testPortName OBJECT-TYPE
+    SYNTAX      TestName (SIZE (1..32))
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Name."
+    ::= { testPortEntry 2 }
+
+testPortState OBJECT-TYPE
+    SYNTAX      TestState
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "State."
+    DEFVAL      { up }
+    ::= { testPortEntry 3 }
+
+testPortOctets OBJECT-TYPE
+    SYNTAX      Counter64
+    UNITS       "octets"
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Octets."
+    ::= { testPortEntry 4 }
+
+testPortFlags OBJECT-TYPE
+    SYNTAX      BITS { lldp(0), stp(1), poe(2) }
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Flags."
+    DEFVAL      { { lldp, stp } }
+    ::= { testPortEntry 5 }
+
+testPortStatus OBJECT-TYPE
+    SYNTAX      RowStatus
+    MAX-ACCESS  read-create
+    STATUS      current
+    DESCRIPTION "Row status."
+    ::= { testPortEntry 6 }
+
+testPortExtTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF TestPortExtEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Port extensions."
+    ::= { testObjects 2 }
+
+testPortExtEntry OBJECT-TYPE
+    SYNTAX      TestPortExtEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "A port extension."
+    AUGMENTS    { testPortEntry }
+    ::= { testPortExtTable 1 }
+
+TestPortExtEntry ::= SEQUENCE { testPortAlias DisplayString }
+
+testPortAlias OBJECT-TYPE
+    SYNTAX      DisplayString (SIZE (0..64))
+    MAX-ACCESS  read-write
+    STATUS      current
+    DESCRIPTION "Alias."
+    ::= { testPortExtEntry 1 }
+
+testScalars OBJECT IDENTIFIER ::= { testObjects 3 }
+
+testUptime OBJECT-TYPE
+    SYNTAX      Integer32 (0..2147483647)
+    UNITS       "seconds"
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Uptime."
+    ::= { testScalars 1 }
+
+testFirmware OBJECT-TYPE
+    SYNTAX      TestName
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Firmware version."
+    ::= { testScalars 2 }
+
+testGroups OBJECT IDENTIFIER ::= { testMIB 2 }
+
+testGroup OBJECT-GROUP
+    OBJECTS     { testPortState, testPortOctets }
+    STATUS      current
+    DESCRIPTION "Objects."
+    ::= { testGroups 1 }
+
+testCompliance MODULE-COMPLIANCE
+    STATUS      current
+    DESCRIPTION "Compliance."
+    MODULE
+        MANDATORY-GROUPS { testGroup }
+        OBJECT      testPortState
+        SYNTAX      TestState
+        DESCRIPTION "Read-only is enough."
+    ::= { testGroups 2 }
+
+END
+`
+
+// testTCMIB is found on the search path by scanning: its file name does
+// not match the module name.
+const testTCMIB = `
+TEST-TC-MIB DEFINITIONS ::= BEGIN
+IMPORTS TEXTUAL-CONVENTION FROM SNMPv2-TC;
+
+TestName ::= TEXTUAL-CONVENTION
+    DISPLAY-HINT "255t"
+    STATUS       current
+    DESCRIPTION  "A UTF-8 name."
+    SYNTAX       OCTET STRING (SIZE (0..255))
+END
+`
+
+// testV1MIB is an SMIv1 module, with a TRAP-TYPE.
+const testV1MIB = `
+TEST-V1-MIB DEFINITIONS ::= BEGIN
+IMPORTS
+    enterprises, Counter      FROM RFC1155-SMI
+    OBJECT-TYPE               FROM RFC-1212
+    TRAP-TYPE                 FROM RFC-1215;
+
+legacy        OBJECT IDENTIFIER ::= { enterprises 99998 }
+
+legacyErrors OBJECT-TYPE
+    SYNTAX  Counter
+    ACCESS  read-only
+    STATUS  mandatory
+    DESCRIPTION "Errors."
+    ::= { legacy 1 }
+
+legacyReset TRAP-TYPE
+    ENTERPRISE  legacy
+    VARIABLES   { legacyErrors }
+    DESCRIPTION "Reset."
+    ::= 1
+END
+`
+
+func mibDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func findNode(t *testing.T, m *parser.Module, name string) *parser.Node {
+	t.Helper()
+	for _, n := range m.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	t.Fatalf("%s: node %s not found", m.Name, name)
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Parse
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestParse_Module(t *testing.T) {
+	mods, err := parser.Parse([]byte(testMIB), "TEST-MIB.txt")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(mods) != 1 || mods[0].Name != "TEST-MIB" {
+		t.Fatalf("modules = %+v, want TEST-MIB", mods)
+	}
+	m := mods[0]
+
+	if len(m.Imports) != 4 || m.Imports[3].Module != "TEST-TC-MIB" || !slices.Equal(m.Imports[3].Symbols, []string{"TestName"}) {
+		t.Errorf("imports = %+v", m.Imports)
+	}
+
+	mi := findNode(t, m, "testMIB")
+	if mi.Kind != parser.KindModuleIdentity || mi.Description != "A test module -- with dashes in a string." {
+		t.Errorf("testMIB = %+v", mi)
+	}
+	if len(mi.OID) != 3 || mi.OID[0].Name != "enterprises" || mi.OID[1].Number != 99999 || mi.OID[2].Number != 1 {
+		t.Errorf("testMIB OID = %+v", mi.OID)
+	}
+
+	entry := findNode(t, m, "testPortEntry")
+	want := []parser.IndexItem{{Name: "testPortMac"}, {Name: "testPortName", Implied: true}}
+	if !slices.Equal(entry.Index, want) {
+		t.Errorf("INDEX = %+v, want %+v", entry.Index, want)
+	}
+	if ext := findNode(t, m, "testPortExtEntry"); ext.Augments != "testPortEntry" {
+		t.Errorf("AUGMENTS = %q", ext.Augments)
+	}
+
+	octets := findNode(t, m, "testPortOctets")
+	if octets.Syntax.Type != "Counter64" || octets.Units != "octets" || octets.Access != "read-only" {
+		t.Errorf("testPortOctets = %+v", octets)
+	}
+	flags := findNode(t, m, "testPortFlags")
+	if flags.Syntax.Type != "BITS" || len(flags.Syntax.Enums) != 3 || flags.Syntax.Enums[2] != (parser.NamedNumber{Name: "poe", Value: 2}) {
+		t.Errorf("testPortFlags syntax = %+v", flags.Syntax)
+	}
+	name := findNode(t, m, "testPortName")
+	if name.Syntax.Type != "TestName" || len(name.Syntax.Size) != 1 || name.Syntax.Size[0] != (parser.Range{Min: 1, Max: 32}) {
+		t.Errorf("testPortName syntax = %+v", name.Syntax)
+	}
+	if g := findNode(t, m, "testCompliance"); g.Kind != parser.KindCompliance || g.Description != "Compliance." {
+		t.Errorf("testCompliance = %+v", g)
+	}
+
+	var tc *parser.TypeDef
+	for _, td := range m.Types {
+		if td.Name == "TestState" {
+			tc = td
+		}
+	}
+	if tc == nil || !tc.TextualConvention {
+		t.Fatalf("TestState TC not parsed: %+v", m.Types)
+	}
+	wantEnums := []parser.NamedNumber{{Name: "up", Value: 1}, {Name: "down", Value: 2}, {Name: "unknown", Value: -1}}
+	if !slices.Equal(tc.Syntax.Enums, wantEnums) {
+		t.Errorf("TestState enums = %+v, want %+v", tc.Syntax.Enums, wantEnums)
+	}
+}
+
+func TestParse_SMIv1(t *testing.T) {
+	mods, err := parser.Parse([]byte(testV1MIB), "v1.mib")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	m := mods[0]
+	if len(m.Nodes) != 2 {
+		t.Errorf("nodes = %d, want 2 (TRAP-TYPE has no OID)", len(m.Nodes))
+	}
+	if n := findNode(t, m, "legacyErrors"); n.Access != "read-only" || n.Syntax.Type != "Counter" {
+		t.Errorf("legacyErrors = %+v", n)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"no module", "-- nothing here\n", "bad.mib: no MIB module found"},
+		{"missing END", "X DEFINITIONS ::= BEGIN\n", "bad.mib:2: module X: missing END"},
+		{"unterminated string", "X DEFINITIONS ::= BEGIN\nfoo OBJECT-TYPE\n DESCRIPTION \"oops\nEND\n", "bad.mib:3: unterminated string"},
+		{"bad clause", "X DEFINITIONS ::= BEGIN\nfoo OBJECT-TYPE\n  SYNTAX Integer32\n  COLOR red\n ::= { bar 1 }\nEND\n", "bad.mib:4: foo: unexpected \"COLOR\" in OBJECT-TYPE"},
+		{"bad OID", "X DEFINITIONS ::= BEGIN\nfoo OBJECT IDENTIFIER ::= { bar baz }\nEND\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.Parse([]byte(tt.src), "bad.mib")
+			if tt.want == "" {
+				// Valid syntax; the missing arc number is a resolve error.
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Set / Resolve
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestSet_Resolve(t *testing.T) {
+	dir := mibDir(t, map[string]string{
+		"TEST-MIB.txt": testMIB,
+		"tc.my":        testTCMIB,
+		"legacy.mib":   testV1MIB,
+	})
+	s := parser.NewSet(dir)
+	if _, err := s.AddFile(filepath.Join(dir, "TEST-MIB.txt")); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	if _, err := s.AddFile(filepath.Join(dir, "legacy.mib")); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	if err := s.Resolve(); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	oids := map[string]string{
+		"testMIB":              ".1.3.6.1.4.1.99999.1",
+		"testPortEntry":        ".1.3.6.1.4.1.99999.1.1.1.1",
+		"TEST-MIB::testUptime": ".1.3.6.1.4.1.99999.1.1.3.1",
+		"legacyErrors":         ".1.3.6.1.4.1.99998.1",
+		"zeroDotZero":          ".0.0",
+	}
+	for name, want := range oids {
+		o, err := s.Lookup(name)
+		if err != nil {
+			t.Errorf("Lookup(%s): %v", name, err)
+			continue
+		}
+		if got := o.OIDString(); got != want {
+			t.Errorf("%s OID = %s, want %s", name, got, want)
+		}
+	}
+
+	name, _ := s.Lookup("testPortName")
+	if name.Type.Base != "OCTET STRING" || !slices.Equal(name.Type.Conventions, []string{"TestName"}) ||
+		name.Type.DisplayHint != "255t" || name.Type.Size[0] != (parser.Range{Min: 1, Max: 32}) {
+		t.Errorf("testPortName type = %+v", name.Type)
+	}
+	mac, _ := s.Lookup("testPortMac")
+	if mac.Type.FixedSize() != 6 {
+		t.Errorf("MacAddress FixedSize = %d, want 6", mac.Type.FixedSize())
+	}
+	state, _ := s.Lookup("testPortState")
+	if state.Type.Base != "INTEGER" || len(state.Type.Enums) != 3 {
+		t.Errorf("testPortState type = %+v", state.Type)
+	}
+
+	entry, _ := s.Lookup("testPortEntry")
+	var children []string
+	for _, c := range entry.Children {
+		children = append(children, c.Name)
+	}
+	wantChildren := []string{"testPortMac", "testPortName", "testPortState", "testPortOctets", "testPortFlags", "testPortStatus"}
+	if !slices.Equal(children, wantChildren) {
+		t.Errorf("children = %v, want %v", children, wantChildren)
+	}
+	if entry.Parent == nil || entry.Parent.Name != "testPortTable" {
+		t.Errorf("parent = %v, want testPortTable", entry.Parent)
+	}
+
+	// Both SNMPv2-SMI and RFC1155-SMI define enterprises, at the same OID.
+	if _, err := s.Lookup("enterprises"); err != nil {
+		t.Errorf("Lookup(enterprises): %v", err)
+	}
+	if _, err := s.Lookup("noSuchObject"); err == nil {
+		t.Error("Lookup(noSuchObject): want error")
+	}
+
+	// Objects are ordered by OID.
+	objs := s.Objects()
+	for i := 1; i < len(objs); i++ {
+		if slices.Compare(objs[i-1].OID, objs[i].OID) > 0 {
+			t.Fatalf("Objects out of order: %s before %s", objs[i-1].OIDString(), objs[i].OIDString())
+		}
+	}
+}
+
+func TestSet_ResolveProblems(t *testing.T) {
+	dir := mibDir(t, map[string]string{
+		"BROKEN-MIB": `
+BROKEN-MIB DEFINITIONS ::= BEGIN
+IMPORTS enterprises FROM SNMPv2-SMI
+        Missing FROM MISSING-MIB;
+broken OBJECT IDENTIFIER ::= { enterprises 99997 }
+orphan OBJECT IDENTIFIER ::= { nowhere 1 }
+loopA  OBJECT IDENTIFIER ::= { loopB 1 }
+loopB  OBJECT IDENTIFIER ::= { loopA 1 }
+END
+`,
+	})
+	s := parser.NewSet(dir)
+	if _, err := s.Module("BROKEN-MIB"); err != nil {
+		t.Fatalf("Module: %v", err)
+	}
+	err := s.Resolve()
+	if err == nil {
+		t.Fatal("Resolve: want error")
+	}
+	for _, want := range []string{
+		"BROKEN-MIB imports from MISSING-MIB: module MISSING-MIB not found in " + dir,
+		"orphan: unknown parent nowhere",
+		"refers back to itself",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	// What resolved is still usable.
+	if o, err := s.Lookup("broken"); err != nil || o.OIDString() != ".1.3.6.1.4.1.99997" {
+		t.Errorf("Lookup(broken) = %v, %v", o, err)
+	}
+}
@@ -0,0 +1,508 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Resolved objects
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Object is a Node resolved to its numeric OID and, for OBJECT-TYPE, its
+// resolved syntax.
+type Object struct {
+	*Node
+
+	// Module is the name of the defining module.
+	Module string
+
+	// OID is the numeric OID, e.g. [1 3 6 1 2 1 2 2 1 2].
+	OID []uint32
+
+	// Type is the resolved SYNTAX of an OBJECT-TYPE; nil for other nodes.
+	Type *Type
+
+	// Parent is the object one arc up, nil when no module defines it.
+	Parent *Object
+
+	// Children are the objects one arc down, ordered by their last arc.
+	// When two modules define the same OID (RFC1213-MIB and IF-MIB, say)
+	// both appear.
+	Children []*Object
+}
+
+// String returns "MODULE::name".
+func (o *Object) String() string { return o.Module + "::" + o.Name }
+
+// OIDString returns the OID in the dotted form the object YAML uses, with a
+// leading dot: ".1.3.6.1.2.1.2.2.1.2".
+func (o *Object) OIDString() string {
+	var b strings.Builder
+	for _, arc := range o.OID {
+		b.WriteByte('.')
+		b.WriteString(strconv.FormatUint(uint64(arc), 10))
+	}
+	return b.String()
+}
+
+// Type is a syntax resolved through its textual conventions and type
+// assignments down to a base type.
+type Type struct {
+	// Name is the syntax as written, e.g. "InterfaceIndex", "INTEGER".
+	Name string
+
+	// Base is one of the baseTypes: INTEGER, OCTET STRING, OBJECT
+	// IDENTIFIER, BITS, SEQUENCE, SEQUENCE OF, CHOICE, or an application
+	// type such as Counter32 or TimeTicks.
+	Base string
+
+	// Conventions lists the named types crossed on the way to Base, from
+	// the one written outward, e.g. [SnmpAdminString] for an attribute
+	// written as SnmpAdminString. Empty when Name is itself a base type.
+	Conventions []string
+
+	// DisplayHint, Enums, Size and Range come from the nearest definition
+	// that sets them.
+	DisplayHint string
+	Enums       []NamedNumber
+	Size        []Range
+	Range       []Range
+}
+
+// FixedSize returns the length of an OCTET STRING whose SIZE constraint
+// allows a single value, e.g. 6 for MacAddress, and 0 otherwise.
+func (t *Type) FixedSize() int64 {
+	if len(t.Size) == 1 && t.Size[0].Min == t.Size[0].Max {
+		return t.Size[0].Min
+	}
+	return 0
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Set
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Set is a collection of MIB modules whose IMPORTS are loaded from a search
+// path and whose nodes are resolved to numeric OIDs:
+//
+//	s := parser.NewSet("/usr/share/snmp/mibs")
+//	_, err := s.AddFile("CISCO-PROCESS-MIB.my")
+//	err = s.Resolve()
+//	obj, err := s.Lookup("cpmCPUTotalEntry")
+//
+// The SMI base modules (SNMPv2-SMI, SNMPv2-TC, SNMPv2-CONF, RFC1155-SMI,
+// RFC-1212, RFC-1215) are built in. A Set is not safe for concurrent use
+// while loading; after Resolve it is read-only.
+type Set struct {
+	path    []string
+	modules map[string]*Module
+	order   []string        // module names in load order
+	parsed  map[string]bool // files already parsed
+	scanned bool            // every file on the path parsed
+
+	objects map[string]*Object   // "MODULE::name"
+	byName  map[string][]*Object // name → objects in load order
+	byOID   map[string][]*Object // OIDString → objects
+	all     []*Object
+}
+
+// NewSet returns an empty Set that looks for imported modules in the
+// directories of path.
+func NewSet(path ...string) *Set {
+	return &Set{
+		path:    path,
+		modules: make(map[string]*Module),
+		parsed:  make(map[string]bool),
+	}
+}
+
+// AddFile parses the MIB file at path and adds its modules to the Set. A
+// module already present is replaced.
+func (s *Set) AddFile(path string) ([]*Module, error) {
+	mods, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.parsed[filepath.Clean(path)] = true
+	for _, m := range mods {
+		s.add(m)
+	}
+	return mods, nil
+}
+
+func (s *Set) add(m *Module) {
+	if _, ok := s.modules[m.Name]; !ok {
+		s.order = append(s.order, m.Name)
+	}
+	s.modules[m.Name] = m
+}
+
+// Module returns the named module: one already added, a built-in base
+// module, or the first file on the search path defining it. Files named
+// after the module (IF-MIB, IF-MIB.txt, IF-MIB.mib, IF-MIB.my) are tried
+// before every other file on the path is parsed.
+func (s *Set) Module(name string) (*Module, error) {
+	if m, ok := s.modules[name]; ok {
+		return m, nil
+	}
+	if src, ok := baseModules[name]; ok {
+		mods, err := Parse([]byte(src), "builtin:"+name)
+		if err != nil {
+			return nil, err
+		}
+		s.add(mods[0])
+		return mods[0], nil
+	}
+
+	for _, dir := range s.path {
+		for _, ext := range []string{"", ".txt", ".mib", ".my", ".smi", ".MIB", ".TXT"} {
+			path := filepath.Join(dir, name+ext)
+			if s.parsed[path] {
+				continue
+			}
+			if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+				continue
+			}
+			_, _ = s.AddFile(path)
+			if m, ok := s.modules[name]; ok {
+				return m, nil
+			}
+		}
+	}
+
+	if !s.scanned {
+		s.scanned = true
+		for _, dir := range s.path {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				path := filepath.Join(dir, e.Name())
+				if e.IsDir() || s.parsed[path] {
+					continue
+				}
+				s.parsed[path] = true
+				mods, err := ParseFile(path)
+				if err != nil {
+					continue
+				}
+				for _, m := range mods {
+					if _, ok := s.modules[m.Name]; !ok {
+						s.add(m)
+					}
+				}
+			}
+		}
+		if m, ok := s.modules[name]; ok {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("module %s not found in %s", name, pathList(s.path))
+}
+
+func pathList(path []string) string {
+	if len(path) == 0 {
+		return "an empty search path"
+	}
+	return strings.Join(path, ", ")
+}
+
+// Resolve loads every imported module, transitively, and resolves every
+// node to its numeric OID and every OBJECT-TYPE syntax to its base type.
+// Problems — a missing module, a node whose parent no module defines — are
+// returned joined, but do not stop the rest: everything that resolved is
+// available to Lookup and Objects afterwards.
+func (s *Set) Resolve() error {
+	var errs []error
+	if _, err := s.Module("SNMPv2-SMI"); err != nil {
+		return err
+	}
+	for i := 0; i < len(s.order); i++ {
+		m := s.modules[s.order[i]]
+		for _, imp := range m.Imports {
+			if _, err := s.Module(imp.Module); err != nil {
+				errs = append(errs, fmt.Errorf("%s imports from %s: %w", m.Name, imp.Module, err))
+			}
+		}
+	}
+
+	r := &resolver{
+		set:   s,
+		state: make(map[*Node]int),
+		nodes: make(map[*Module]map[string]*Node),
+		types: make(map[*Module]map[string]*TypeDef),
+	}
+	s.objects = make(map[string]*Object)
+	s.byName = make(map[string][]*Object)
+	s.byOID = make(map[string][]*Object)
+	s.all = nil
+	for _, name := range s.order {
+		m := s.modules[name]
+		for _, n := range m.Nodes {
+			if r.node(m, n.Name) != n {
+				continue // defined twice in the module; the first wins
+			}
+			o := &Object{Node: n, Module: name}
+			s.objects[o.String()] = o
+			s.byName[n.Name] = append(s.byName[n.Name], o)
+		}
+	}
+	for _, name := range s.order {
+		m := s.modules[name]
+		for _, n := range m.Nodes {
+			o := s.objects[name+"::"+n.Name]
+			if o.Node != n {
+				continue
+			}
+			if _, err := r.oid(m, o); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if n.Kind == KindObjectType && n.Syntax != nil {
+				t, err := r.resolveType(m, n.Syntax)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s: %w", o, n.Syntax.Type, err))
+				}
+				o.Type = t
+			}
+			key := o.OIDString()
+			s.byOID[key] = append(s.byOID[key], o)
+			s.all = append(s.all, o)
+		}
+	}
+
+	for _, o := range s.all {
+		if len(o.OID) < 2 {
+			continue
+		}
+		parents := s.byOID[oidString(o.OID[:len(o.OID)-1])]
+		if len(parents) == 0 {
+			continue
+		}
+		o.Parent = parents[0]
+		for _, p := range parents {
+			if p.Module == o.Module {
+				o.Parent = p
+			}
+			p.Children = append(p.Children, o)
+		}
+	}
+	for _, o := range s.all {
+		slices.SortStableFunc(o.Children, func(a, b *Object) int {
+			return int64Cmp(int64(a.OID[len(a.OID)-1]), int64(b.OID[len(b.OID)-1]))
+		})
+	}
+	slices.SortStableFunc(s.all, func(a, b *Object) int { return slices.Compare(a.OID, b.OID) })
+	return errors.Join(errs...)
+}
+
+func int64Cmp(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func oidString(oid []uint32) string { return (&Object{OID: oid}).OIDString() }
+
+// Lookup finds a resolved object by "MODULE::name" or by bare name. A bare
+// name defined by several modules is an error unless they agree on the OID.
+func (s *Set) Lookup(name string) (*Object, error) {
+	if mod, obj, ok := strings.Cut(name, "::"); ok {
+		if o, ok := s.objects[mod+"::"+obj]; ok && o.OID != nil {
+			return o, nil
+		}
+		return nil, fmt.Errorf("%s: no such object", name)
+	}
+	var found *Object
+	for _, o := range s.byName[name] {
+		if o.OID == nil {
+			continue
+		}
+		if found != nil && !slices.Equal(found.OID, o.OID) {
+			return nil, fmt.Errorf("%s is defined by %s and %s; qualify it as MODULE::%s", name, found.Module, o.Module, name)
+		}
+		if found == nil {
+			found = o
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s: no such object", name)
+	}
+	return found, nil
+}
+
+// Objects returns every resolved object ordered by OID.
+func (s *Set) Objects() []*Object { return s.all }
+
+// Modules returns every loaded module, built-in ones included, in load
+// order.
+func (s *Set) Modules() []*Module {
+	out := make([]*Module, len(s.order))
+	for i, name := range s.order {
+		out[i] = s.modules[name]
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Name resolution
+// ─────────────────────────────────────────────────────────────────────────────
+
+type resolver struct {
+	set   *Set
+	state map[*Node]int // 1 while resolving, 2 once done
+	nodes map[*Module]map[string]*Node
+	types map[*Module]map[string]*TypeDef
+}
+
+// node returns the node m defines as name; the first wins if m defines it
+// twice.
+func (r *resolver) node(m *Module, name string) *Node {
+	idx, ok := r.nodes[m]
+	if !ok {
+		idx = make(map[string]*Node, len(m.Nodes))
+		for _, n := range m.Nodes {
+			if _, dup := idx[n.Name]; !dup {
+				idx[n.Name] = n
+			}
+		}
+		r.nodes[m] = idx
+	}
+	return idx[name]
+}
+
+// typeDef returns the type m assigns to name.
+func (r *resolver) typeDef(m *Module, name string) *TypeDef {
+	idx, ok := r.types[m]
+	if !ok {
+		idx = make(map[string]*TypeDef, len(m.Types))
+		for _, td := range m.Types {
+			if _, dup := idx[td.Name]; !dup && td.Syntax != nil {
+				idx[td.Name] = td
+			}
+		}
+		r.types[m] = idx
+	}
+	return idx[name]
+}
+
+// wellKnownRoots are the top-level arcs, valid in every module without an
+// import.
+var wellKnownRoots = map[string]bool{"iso": true, "ccitt": true, "joint-iso-ccitt": true}
+
+// scope returns the module that defines name as seen from m: m itself, the
+// module m imports name from, or, for MIBs that forget an import, the first
+// loaded module defining it. has reports whether a module defines name.
+func (r *resolver) scope(m *Module, name string, has func(*Module) bool) *Module {
+	if has(m) {
+		return m
+	}
+	if wellKnownRoots[name] {
+		return r.set.modules["SNMPv2-SMI"]
+	}
+	for _, imp := range m.Imports {
+		if slices.Contains(imp.Symbols, name) {
+			if src, ok := r.set.modules[imp.Module]; ok && has(src) {
+				return src
+			}
+		}
+	}
+	for _, modName := range r.set.order {
+		if src := r.set.modules[modName]; has(src) {
+			return src
+		}
+	}
+	return nil
+}
+
+// oid resolves o's numeric OID, resolving its parent first.
+func (r *resolver) oid(m *Module, o *Object) ([]uint32, error) {
+	n := o.Node
+	switch r.state[n] {
+	case 2:
+		if o.OID == nil {
+			return nil, fmt.Errorf("%s: unresolved", o)
+		}
+		return o.OID, nil
+	case 1:
+		return nil, fmt.Errorf("%s:%d: %s: OID value refers back to itself", moduleFile(m), n.Line, n.Name)
+	}
+	r.state[n] = 1
+	defer func() { r.state[n] = 2 }()
+
+	var oid []uint32
+	for i, c := range n.OID {
+		switch {
+		case c.Number >= 0:
+			oid = append(oid, uint32(c.Number))
+		case i == 0:
+			def := r.scope(m, c.Name, func(mod *Module) bool { return r.node(mod, c.Name) != nil })
+			if def == nil {
+				return nil, fmt.Errorf("%s:%d: %s: unknown parent %s", moduleFile(m), n.Line, n.Name, c.Name)
+			}
+			parent := r.set.objects[def.Name+"::"+c.Name]
+			pOID, err := r.oid(def, parent)
+			if err != nil {
+				return nil, err
+			}
+			oid = append(oid, pOID...)
+		default:
+			return nil, fmt.Errorf("%s:%d: %s: OID arc %s has no number", moduleFile(m), n.Line, n.Name, c.Name)
+		}
+	}
+	o.OID = oid
+	return oid, nil
+}
+
+// resolveType follows syn through type assignments down to a base type.
+func (r *resolver) resolveType(m *Module, syn *Syntax) (*Type, error) {
+	t := &Type{Name: syn.Type, Enums: syn.Enums, Size: syn.Size, Range: syn.Range}
+	cur := syn
+	for depth := 0; ; depth++ {
+		if baseTypes[cur.Type] {
+			t.Base = cur.Type
+			return t, nil
+		}
+		if depth == 32 {
+			return t, fmt.Errorf("type chain too deep")
+		}
+		def := r.scope(m, cur.Type, func(mod *Module) bool { return r.typeDef(mod, cur.Type) != nil })
+		if def == nil {
+			return t, fmt.Errorf("unknown type %s", cur.Type)
+		}
+		td := r.typeDef(def, cur.Type)
+		t.Conventions = append(t.Conventions, td.Name)
+		if t.DisplayHint == "" {
+			t.DisplayHint = td.DisplayHint
+		}
+		next := td.Syntax
+		if len(t.Enums) == 0 {
+			t.Enums = next.Enums
+		}
+		if len(t.Size) == 0 {
+			t.Size = next.Size
+		}
+		if len(t.Range) == 0 {
+			t.Range = next.Range
+		}
+		m, cur = def, next
+	}
+}
+
+func moduleFile(m *Module) string {
+	if m.File != "" {
+		return m.File
+	}
+	return m.Name
+}
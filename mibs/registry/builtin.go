@@ -0,0 +1,80 @@
+package registry
+
+import "github.com/vpbank/snmp_collector/mibs/parser"
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Built-in names
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Builtin returns a Registry holding the SMI tree (internet, mib-2,
+// enterprises, snmpV2, …) and the SNMPv2-MIB and IF-MIB objects that appear in
+// standard traps and system polls, so common names are available without any
+// MIB files.
+func Builtin() *Registry {
+	r := New()
+	set := parser.NewSet()
+	if _, err := set.Module("SNMPv2-SMI"); err == nil && set.Resolve() == nil {
+		r.AddSet(set)
+	}
+	for _, e := range builtinEntries {
+		r.Add(e)
+	}
+	return r
+}
+
+var (
+	ifAdminStatusEnums = map[int64]string{1: "up", 2: "down", 3: "testing"}
+	ifOperStatusEnums  = map[int64]string{
+		1: "up", 2: "down", 3: "testing", 4: "unknown",
+		5: "dormant", 6: "notPresent", 7: "lowerLayerDown",
+	}
+)
+
+var builtinEntries = []Entry{
+	// SNMPv2-MIB (RFC 3418)
+	{OID: ".1.3.6.1.2.1.1", Module: "SNMPv2-MIB", Object: "system"},
+	{OID: ".1.3.6.1.2.1.1.1", Module: "SNMPv2-MIB", Object: "sysDescr", Syntax: "DisplayString",
+		Description: "A textual description of the entity."},
+	{OID: ".1.3.6.1.2.1.1.2", Module: "SNMPv2-MIB", Object: "sysObjectID", Syntax: "OBJECT IDENTIFIER",
+		Description: "The vendor's authoritative identification of the network management subsystem."},
+	{OID: ".1.3.6.1.2.1.1.3", Module: "SNMPv2-MIB", Object: "sysUpTime", Syntax: "TimeTicks",
+		Description: "The time since the network management portion of the system was last re-initialized."},
+	{OID: ".1.3.6.1.2.1.1.4", Module: "SNMPv2-MIB", Object: "sysContact", Syntax: "DisplayString"},
+	{OID: ".1.3.6.1.2.1.1.5", Module: "SNMPv2-MIB", Object: "sysName", Syntax: "DisplayString"},
+	{OID: ".1.3.6.1.2.1.1.6", Module: "SNMPv2-MIB", Object: "sysLocation", Syntax: "DisplayString"},
+	{OID: ".1.3.6.1.2.1.1.7", Module: "SNMPv2-MIB", Object: "sysServices", Syntax: "INTEGER"},
+	{OID: ".1.3.6.1.6.3.1.1.4.1", Module: "SNMPv2-MIB", Object: "snmpTrapOID", Syntax: "OBJECT IDENTIFIER",
+		Description: "The authoritative identification of the notification currently being sent."},
+	{OID: ".1.3.6.1.6.3.1.1.4.3", Module: "SNMPv2-MIB", Object: "snmpTrapEnterprise", Syntax: "OBJECT IDENTIFIER"},
+	{OID: ".1.3.6.1.6.3.1.1.5.1", Module: "SNMPv2-MIB", Object: "coldStart",
+		Description: "The agent is reinitializing itself and its configuration may have been altered."},
+	{OID: ".1.3.6.1.6.3.1.1.5.2", Module: "SNMPv2-MIB", Object: "warmStart",
+		Description: "The agent is reinitializing itself such that its configuration is unaltered."},
+	{OID: ".1.3.6.1.6.3.1.1.5.5", Module: "SNMPv2-MIB", Object: "authenticationFailure",
+		Description: "The agent received a protocol message that is not properly authenticated."},
+
+	// IF-MIB (RFC 2863)
+	{OID: ".1.3.6.1.2.1.2", Module: "IF-MIB", Object: "interfaces"},
+	{OID: ".1.3.6.1.2.1.2.1", Module: "IF-MIB", Object: "ifNumber", Syntax: "Integer32"},
+	{OID: ".1.3.6.1.2.1.2.2", Module: "IF-MIB", Object: "ifTable"},
+	{OID: ".1.3.6.1.2.1.2.2.1", Module: "IF-MIB", Object: "ifEntry"},
+	{OID: ".1.3.6.1.2.1.2.2.1.1", Module: "IF-MIB", Object: "ifIndex", Syntax: "InterfaceIndex"},
+	{OID: ".1.3.6.1.2.1.2.2.1.2", Module: "IF-MIB", Object: "ifDescr", Syntax: "DisplayString"},
+	{OID: ".1.3.6.1.2.1.2.2.1.3", Module: "IF-MIB", Object: "ifType", Syntax: "IANAifType"},
+	{OID: ".1.3.6.1.2.1.2.2.1.4", Module: "IF-MIB", Object: "ifMtu", Syntax: "Integer32"},
+	{OID: ".1.3.6.1.2.1.2.2.1.5", Module: "IF-MIB", Object: "ifSpeed", Syntax: "Gauge32"},
+	{OID: ".1.3.6.1.2.1.2.2.1.6", Module: "IF-MIB", Object: "ifPhysAddress", Syntax: "PhysAddress"},
+	{OID: ".1.3.6.1.2.1.2.2.1.7", Module: "IF-MIB", Object: "ifAdminStatus", Syntax: "INTEGER",
+		Enums: ifAdminStatusEnums},
+	{OID: ".1.3.6.1.2.1.2.2.1.8", Module: "IF-MIB", Object: "ifOperStatus", Syntax: "INTEGER",
+		Enums: ifOperStatusEnums},
+	{OID: ".1.3.6.1.2.1.2.2.1.9", Module: "IF-MIB", Object: "ifLastChange", Syntax: "TimeTicks"},
+	{OID: ".1.3.6.1.2.1.31.1.1", Module: "IF-MIB", Object: "ifXTable"},
+	{OID: ".1.3.6.1.2.1.31.1.1.1", Module: "IF-MIB", Object: "ifXEntry"},
+	{OID: ".1.3.6.1.2.1.31.1.1.1.1", Module: "IF-MIB", Object: "ifName", Syntax: "DisplayString"},
+	{OID: ".1.3.6.1.2.1.31.1.1.1.18", Module: "IF-MIB", Object: "ifAlias", Syntax: "DisplayString"},
+	{OID: ".1.3.6.1.6.3.1.1.5.3", Module: "IF-MIB", Object: "linkDown",
+		Description: "The ifOperStatus of a communication link is about to enter the down state."},
+	{OID: ".1.3.6.1.6.3.1.1.5.4", Module: "IF-MIB", Object: "linkUp",
+		Description: "The ifOperStatus of a communication link left the down state."},
+}
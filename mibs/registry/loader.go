@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vpbank/snmp_collector/mibs/parser"
+	"github.com/vpbank/snmp_collector/models"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Loaders
+// ─────────────────────────────────────────────────────────────────────────────
+
+// AddSet registers every resolved object of s: OBJECT-TYPEs with their MIB
+// type, units, description and enumerations, and the other nodes
+// (notifications, identities, groups) by name and description. The roots
+// above internet (iso, org, dod, …) are left out, so an unknown OID never
+// comes back as a name such as "iso.2.3" that says no more than the number.
+func (r *Registry) AddSet(s *parser.Set) {
+	for _, obj := range s.Objects() {
+		if len(obj.OID) < 4 { // shallower than internet (.1.3.6.1)
+			continue
+		}
+		r.Add(entryOf(obj))
+	}
+}
+
+// entryOf converts a resolved MIB object to an Entry.
+func entryOf(obj *parser.Object) Entry {
+	e := Entry{
+		OID:         obj.OIDString(),
+		Module:      obj.Module,
+		Object:      obj.Name,
+		Description: strings.TrimSpace(obj.Description),
+		Units:       obj.Units,
+	}
+	if t := obj.Type; t != nil {
+		e.Syntax = t.Name
+		if e.Syntax == "" {
+			e.Syntax = t.Base
+		}
+		if len(t.Enums) > 0 {
+			e.Enums = make(map[int64]string, len(t.Enums))
+			for _, nn := range t.Enums {
+				e.Enums[nn.Value] = nn.Name
+			}
+		}
+	}
+	return e
+}
+
+// AddMIBDirs parses every file in dirs (not recursively), with dirs as the
+// import search path, and registers the resolved objects. Files that do not
+// parse and names that do not resolve are reported in the returned error,
+// joined; everything else is still registered.
+func (r *Registry) AddMIBDirs(dirs ...string) error {
+	set := parser.NewSet(dirs...)
+	var errs []error
+	for _, dir := range dirs {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			if _, err := set.AddFile(filepath.Join(dir, f.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := set.Resolve(); err != nil {
+		errs = append(errs, err)
+	}
+	r.AddSet(set)
+	if len(errs) > 0 {
+		return fmt.Errorf("registry: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// AddObjectDefs registers the attributes of defs under their object
+// definition's MIB, named by attribute key (the MIB column name) and with the
+// configured syntax. Index items are not registered: their names are output
+// labels, not MIB names.
+func (r *Registry) AddObjectDefs(defs map[string]models.ObjectDefinition) {
+	// Sorted, so an OID claimed by two definitions is named the same way on
+	// every load.
+	for _, key := range slices.Sorted(maps.Keys(defs)) {
+		def := defs[key]
+		for _, name := range slices.Sorted(maps.Keys(def.Attributes)) {
+			attr := def.Attributes[name]
+			r.Add(Entry{OID: attr.OID, Module: def.MIB, Object: name, Syntax: attr.Syntax})
+		}
+	}
+}
+
+// AddEnums attaches enumeration labels, keyed by attribute OID, to OIDs that
+// are already named, and names the OIDs of OID-valued enumerations (such as
+// sysObjectID values) after their label.
+func (r *Registry) AddEnums(ints map[string]map[int64]string, oids map[string]string) {
+	for oid, values := range ints {
+		r.Add(Entry{OID: oid, Enums: values})
+	}
+	for oid, label := range oids {
+		if m, ok := r.Lookup(oid); ok && m.Instance == "" {
+			continue
+		}
+		r.Add(Entry{OID: oid, Object: label})
+	}
+}
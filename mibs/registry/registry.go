@@ -0,0 +1,296 @@
+// Package registry names OIDs. It holds an OID tree built from compiled MIB
+// modules, the collector's object definitions and enum files, and answers
+// longest-prefix lookups such as
+//
+//	.1.3.6.1.2.1.2.2.1.2.3 → IF-MIB::ifDescr.3
+//
+// together with the object's syntax, description and enumeration labels.
+// The trap path, the decoder's debug logging and the admin API use it to show
+// names where the collector would otherwise print dotted numbers.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by Resolve.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrAmbiguous = errors.New("ambiguous name")
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Entry / Match
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Entry describes one named OID.
+type Entry struct {
+	// OID is the numeric OID with a leading dot, e.g. ".1.3.6.1.2.1.2.2.1.2".
+	OID string
+
+	// Module is the defining MIB module, e.g. "IF-MIB". Empty for names that
+	// come from OID enum labels.
+	Module string
+
+	// Object is the object name within Module, e.g. "ifDescr".
+	Object string
+
+	// Syntax is the MIB type of a compiled OBJECT-TYPE, e.g. "DisplayString",
+	// or the configured syntax of an object definition attribute.
+	Syntax string
+
+	// Description and Units are copied from the MIB, when known.
+	Description string
+	Units       string
+
+	// Enums maps enumerated values (or BITS positions) to their labels.
+	Enums map[int64]string
+}
+
+// Name returns "Module::Object", or Object alone when the module is unknown.
+func (e *Entry) Name() string {
+	if e.Module == "" {
+		return e.Object
+	}
+	return e.Module + "::" + e.Object
+}
+
+// Match is the result of Lookup: the entry with the longest OID that is a
+// prefix of the looked-up OID, and the arcs after it.
+type Match struct {
+	Entry
+
+	// Instance is the dotted remainder of the OID after Entry.OID, e.g. "3"
+	// for ifDescr.3. Empty for an exact match.
+	Instance string
+}
+
+// String returns "Module::Object.instance", e.g. "IF-MIB::ifDescr.3".
+func (m Match) String() string {
+	if m.Instance == "" {
+		return m.Name()
+	}
+	return m.Name() + "." + m.Instance
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Registry
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Registry is an OID tree with a name index. Build it with Add and the
+// loaders; it is not safe for concurrent Add, but once built it is read-only
+// and safe for concurrent lookups.
+type Registry struct {
+	root   node
+	byName map[string][]*Entry // "Module::Object" and bare "Object"
+	n      int
+}
+
+type node struct {
+	children map[uint32]*node
+	entry    *Entry
+}
+
+// New returns an empty Registry. Builtin returns one pre-loaded with the
+// standard SMI tree and common SNMPv2-MIB / IF-MIB objects.
+func New() *Registry {
+	return &Registry{byName: make(map[string][]*Entry)}
+}
+
+// Len returns the number of named OIDs.
+func (r *Registry) Len() int { return r.n }
+
+// Add registers e at e.OID. When the OID is already named, the non-empty
+// fields of e replace those of the existing entry, so a later, more detailed
+// source refines an earlier one. An entry without Object only adds detail to
+// an OID that is already named. Entries with an invalid OID are ignored.
+func (r *Registry) Add(e Entry) {
+	arcs, err := parseOID(e.OID)
+	if err != nil || len(arcs) == 0 {
+		return
+	}
+	n := &r.root
+	for _, arc := range arcs {
+		child := n.children[arc]
+		if child == nil {
+			if e.Object == "" {
+				return
+			}
+			if n.children == nil {
+				n.children = make(map[uint32]*node)
+			}
+			child = &node{}
+			n.children[arc] = child
+		}
+		n = child
+	}
+
+	cur := n.entry
+	if cur == nil {
+		if e.Object == "" {
+			return
+		}
+		e.OID = formatOID(arcs)
+		n.entry = &e
+		r.n++
+		r.index(n.entry)
+		return
+	}
+	if e.Object != "" && (e.Object != cur.Object || e.Module != cur.Module) {
+		r.unindex(cur)
+		cur.Module, cur.Object = e.Module, e.Object
+		r.index(cur)
+	}
+	if e.Syntax != "" {
+		cur.Syntax = e.Syntax
+	}
+	if e.Description != "" {
+		cur.Description = e.Description
+	}
+	if e.Units != "" {
+		cur.Units = e.Units
+	}
+	if len(e.Enums) > 0 {
+		cur.Enums = e.Enums
+	}
+}
+
+func (r *Registry) index(e *Entry) {
+	r.byName[e.Object] = append(r.byName[e.Object], e)
+	if e.Module != "" {
+		key := e.Name()
+		r.byName[key] = append(r.byName[key], e)
+	}
+}
+
+func (r *Registry) unindex(e *Entry) {
+	for _, key := range []string{e.Object, e.Name()} {
+		list := slices.DeleteFunc(r.byName[key], func(x *Entry) bool { return x == e })
+		if len(list) == 0 {
+			delete(r.byName, key)
+		} else {
+			r.byName[key] = list
+		}
+	}
+}
+
+// Lookup returns the entry with the longest OID that is a prefix of oid.
+// The OID may be given with or without a leading dot.
+func (r *Registry) Lookup(oid string) (Match, bool) {
+	arcs, err := parseOID(oid)
+	if err != nil {
+		return Match{}, false
+	}
+	var (
+		best  *Entry
+		depth int
+	)
+	n := &r.root
+	for i, arc := range arcs {
+		if n = n.children[arc]; n == nil {
+			break
+		}
+		if n.entry != nil {
+			best, depth = n.entry, i+1
+		}
+	}
+	if best == nil {
+		return Match{}, false
+	}
+	m := Match{Entry: *best}
+	if depth < len(arcs) {
+		m.Instance = formatOID(arcs[depth:])[1:]
+	}
+	return m, true
+}
+
+// Name returns the "Module::Object.instance" form of oid, or oid unchanged
+// when no prefix of it is named. It is the form used in log attributes.
+func (r *Registry) Name(oid string) string {
+	if r == nil {
+		return oid
+	}
+	if m, ok := r.Lookup(oid); ok {
+		return m.String()
+	}
+	return oid
+}
+
+// Resolve returns the numeric OID for a name in any of the forms Lookup
+// produces — "IF-MIB::ifDescr.3", "ifDescr.3", "ifDescr" — or a numeric OID,
+// which is returned normalised. A bare object name defined at more than one
+// OID is ErrAmbiguous; qualify it with its module.
+func (r *Registry) Resolve(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if arcs, err := parseOID(name); err == nil && len(arcs) > 0 {
+		return formatOID(arcs), nil
+	}
+
+	key, instance := name, ""
+	obj := name
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		obj = name[i+2:]
+	}
+	if i := strings.IndexByte(obj, '.'); i >= 0 {
+		key = strings.TrimSuffix(name, obj[i:])
+		instance = obj[i+1:]
+	}
+	var suffix []uint32
+	if instance != "" {
+		var err error
+		if suffix, err = parseOID(instance); err != nil {
+			return "", fmt.Errorf("registry: %q: bad instance: %w", name, err)
+		}
+	}
+
+	entries := r.byName[key]
+	if len(entries) == 0 {
+		return "", fmt.Errorf("registry: %q: %w", key, ErrNotFound)
+	}
+	if len(entries) > 1 {
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		slices.Sort(names)
+		return "", fmt.Errorf("registry: %q: %w, defined as %s", key, ErrAmbiguous, strings.Join(names, ", "))
+	}
+	arcs, _ := parseOID(entries[0].OID)
+	return formatOID(append(arcs, suffix...)), nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// OID helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// parseOID splits a dotted OID, with or without a leading dot, into arcs.
+func parseOID(oid string) ([]uint32, error) {
+	oid = strings.TrimPrefix(strings.TrimSpace(oid), ".")
+	if oid == "" {
+		return nil, fmt.Errorf("empty OID")
+	}
+	parts := strings.Split(oid, ".")
+	arcs := make([]uint32, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad OID %q", oid)
+		}
+		arcs[i] = uint32(v)
+	}
+	return arcs, nil
+}
+
+// formatOID renders arcs with a leading dot.
+func formatOID(arcs []uint32) string {
+	var b strings.Builder
+	for _, a := range arcs {
+		b.WriteByte('.')
+		b.WriteString(strconv.FormatUint(uint64(a), 10))
+	}
+	return b.String()
+}
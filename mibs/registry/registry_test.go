@@ -0,0 +1,255 @@
+package registry_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vpbank/snmp_collector/mibs/registry"
+	"github.com/vpbank/snmp_collector/models"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Shared fixtures
+// ─────────────────────────────────────────────────────────────────────────────
+
+const sensorMIB = `
+SENSOR-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, NOTIFICATION-TYPE, Integer32,
+    enterprises                              FROM SNMPv2-SMI
+    DisplayString                            FROM SNMPv2-TC;
+
+sensorMIB MODULE-IDENTITY
+    LAST-UPDATED "202601010000Z"
+    ORGANIZATION "Example"
+    CONTACT-INFO "ops@example.com"
+    DESCRIPTION  "Sensors."
+    ::= { enterprises 99999 2 }
+
+sensorTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF SensorEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Sensors."
+    ::= { sensorMIB 1 }
+
+sensorEntry OBJECT-TYPE
+    SYNTAX      SensorEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "A sensor."
+    INDEX       { sensorIndex }
+    ::= { sensorTable 1 }
+
+SensorEntry ::= SEQUENCE {
+    sensorIndex  Integer32,
+    sensorName   DisplayString,
+    sensorValue  Integer32,
+    sensorStatus INTEGER
+}
+
+sensorIndex OBJECT-TYPE
+    SYNTAX      Integer32 (1..65535)
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Index."
+    ::= { sensorEntry 1 }
+
+sensorName OBJECT-TYPE
+    SYNTAX      DisplayString
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Name."
+    ::= { sensorEntry 2 }
+
+sensorValue OBJECT-TYPE
+    SYNTAX      Integer32
+    UNITS       "celsius"
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION
+        "The current reading."
+    ::= { sensorEntry 3 }
+
+sensorStatus OBJECT-TYPE
+    SYNTAX      INTEGER { ok(1), failed(2) }
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Status."
+    ::= { sensorEntry 4 }
+
+sensorFailed NOTIFICATION-TYPE
+    OBJECTS     { sensorStatus }
+    STATUS      current
+    DESCRIPTION "A sensor failed."
+    ::= { sensorMIB 0 1 }
+
+END
+`
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestRegistry_LookupAndResolve(t *testing.T) {
+	r := registry.Builtin()
+
+	lookups := []struct {
+		oid, want, instance string
+	}{
+		{".1.3.6.1.2.1.2.2.1.2.3", "IF-MIB::ifDescr.3", "3"},
+		{"1.3.6.1.2.1.1.3.0", "SNMPv2-MIB::sysUpTime.0", "0"},
+		{".1.3.6.1.6.3.1.1.5.4", "IF-MIB::linkUp", ""},
+		{".1.3.6.1.4.1.99999.7", "SNMPv2-SMI::enterprises.99999.7", "99999.7"},
+	}
+	for _, tc := range lookups {
+		m, ok := r.Lookup(tc.oid)
+		if !ok || m.String() != tc.want || m.Instance != tc.instance {
+			t.Errorf("Lookup(%s) = %q instance %q ok %v, want %q instance %q", tc.oid, m.String(), m.Instance, ok, tc.want, tc.instance)
+		}
+	}
+	for _, oid := range []string{".1.2.3", "", "1.3.x"} {
+		if m, ok := r.Lookup(oid); ok {
+			t.Errorf("Lookup(%q) = %q, want no match", oid, m.String())
+		}
+	}
+	if got := r.Name(".2.5"); got != ".2.5" {
+		t.Errorf("Name(.2.5) = %q, want the OID unchanged", got)
+	}
+	if m, _ := r.Lookup(".1.3.6.1.2.1.2.2.1.8.1"); m.Enums[7] != "lowerLayerDown" {
+		t.Errorf("ifOperStatus enums = %v", m.Enums)
+	}
+
+	resolves := []struct {
+		name, want string
+	}{
+		{"IF-MIB::ifDescr.3", ".1.3.6.1.2.1.2.2.1.2.3"},
+		{"ifDescr", ".1.3.6.1.2.1.2.2.1.2"},
+		{"sysUpTime.0", ".1.3.6.1.2.1.1.3.0"},
+		{"1.3.6.1.2.1", ".1.3.6.1.2.1"},
+	}
+	for _, tc := range resolves {
+		if got, err := r.Resolve(tc.name); err != nil || got != tc.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+	if _, err := r.Resolve("IF-MIB::sysUpTime"); !errors.Is(err, registry.ErrNotFound) {
+		t.Errorf("Resolve(wrong module) err = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Resolve("ifDescr.x"); err == nil || errors.Is(err, registry.ErrNotFound) {
+		t.Errorf("Resolve(bad instance) err = %v, want a syntax error", err)
+	}
+}
+
+func TestRegistry_AddMergesAndRenames(t *testing.T) {
+	r := registry.New()
+	r.Add(registry.Entry{OID: "1.3.6.1.4.1.9.9.1", Module: "A-MIB", Object: "first", Syntax: "Integer32"})
+	r.Add(registry.Entry{OID: ".1.3.6.1.4.1.9.9.1", Description: "Detail."})
+	r.Add(registry.Entry{OID: ".1.3.6.1.4.1.9.9.2", Description: "No name: ignored."})
+	r.Add(registry.Entry{OID: ".1.3.6.1.4.1.9.9.3", Module: "B-MIB", Object: "first"})
+
+	if r.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", r.Len())
+	}
+	m, _ := r.Lookup(".1.3.6.1.4.1.9.9.1.0")
+	if m.Name() != "A-MIB::first" || m.Syntax != "Integer32" || m.Description != "Detail." || m.OID != ".1.3.6.1.4.1.9.9.1" {
+		t.Errorf("merged entry = %+v", m.Entry)
+	}
+	if _, ok := r.Lookup(".1.3.6.1.4.1.9.9.2"); ok {
+		t.Error("entry without Object was registered")
+	}
+	_, err := r.Resolve("first")
+	if !errors.Is(err, registry.ErrAmbiguous) || !strings.Contains(err.Error(), "A-MIB::first, B-MIB::first") {
+		t.Errorf("Resolve(first) err = %v, want ErrAmbiguous naming both", err)
+	}
+
+	// Renaming drops the old names from the index.
+	r.Add(registry.Entry{OID: ".1.3.6.1.4.1.9.9.1", Module: "A-MIB", Object: "renamed"})
+	if got, err := r.Resolve("first"); err != nil || got != ".1.3.6.1.4.1.9.9.3" {
+		t.Errorf("Resolve(first) after rename = %q, %v", got, err)
+	}
+	if _, err := r.Resolve("A-MIB::first"); !errors.Is(err, registry.ErrNotFound) {
+		t.Errorf("Resolve(A-MIB::first) after rename err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRegistry_AddMIBDirs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "SENSOR-MIB.txt"), []byte(sensorMIB), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a MIB\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := registry.Builtin()
+	err := r.AddMIBDirs(dir)
+	if err == nil || !strings.Contains(err.Error(), "README") {
+		t.Errorf("AddMIBDirs err = %v, want the README parse error", err)
+	}
+
+	m, ok := r.Lookup(".1.3.6.1.4.1.99999.2.1.1.3.12")
+	if !ok || m.String() != "SENSOR-MIB::sensorValue.12" || m.Syntax != "Integer32" ||
+		m.Units != "celsius" || m.Description != "The current reading." {
+		t.Errorf("sensorValue = %+v (%q)", m.Entry, m.String())
+	}
+	if m, _ := r.Lookup(".1.3.6.1.4.1.99999.2.1.1.4.1"); m.Enums[2] != "failed" {
+		t.Errorf("sensorStatus enums = %v", m.Enums)
+	}
+	if got := r.Name(".1.3.6.1.4.1.99999.2.0.1"); got != "SENSOR-MIB::sensorFailed" {
+		t.Errorf("notification name = %q", got)
+	}
+	if m, _ := r.Lookup(".1.3.6.1.2.1.2.2.1.2.1"); m.Name() != "IF-MIB::ifDescr" {
+		t.Errorf("built-in name lost: %q", m.Name())
+	}
+	if _, ok := r.Lookup(".1.2.3"); ok {
+		t.Error("SMI root iso registered")
+	}
+}
+
+func TestRegistry_ObjectDefsAndEnums(t *testing.T) {
+	r := registry.New()
+	r.AddObjectDefs(map[string]models.ObjectDefinition{
+		"VENDOR-MIB::fanEntry": {
+			MIB: "VENDOR-MIB",
+			Index: []models.IndexDefinition{
+				{Type: "Integer", OID: ".1.3.6.1.4.1.8888.1.1.1", Name: "fan", Syntax: "Integer32"},
+			},
+			Attributes: map[string]models.AttributeDefinition{
+				"fanState": {OID: ".1.3.6.1.4.1.8888.1.1.2", Name: "fan.state", Syntax: "EnumInteger"},
+				"fanSpeed": {OID: ".1.3.6.1.4.1.8888.1.1.3", Name: "fan.speed", Syntax: "Gauge32"},
+			},
+		},
+	})
+	r.AddEnums(
+		map[string]map[int64]string{
+			"1.3.6.1.4.1.8888.1.1.2": {1: "normal", 2: "failed"},
+			"1.3.6.1.4.1.8888.1.1.9": {1: "unnamed"},
+		},
+		map[string]string{
+			"1.3.6.1.4.1.8888.3.42":  "fanTray42",
+			"1.3.6.1.4.1.8888.1.1.3": "shadowed",
+		},
+	)
+
+	m, ok := r.Lookup(".1.3.6.1.4.1.8888.1.1.2.4")
+	if !ok || m.String() != "VENDOR-MIB::fanState.4" || m.Syntax != "EnumInteger" || m.Enums[2] != "failed" {
+		t.Errorf("fanState = %+v (%q)", m.Entry, m.String())
+	}
+	if got := r.Name(".1.3.6.1.4.1.8888.1.1.3"); got != "VENDOR-MIB::fanSpeed" {
+		t.Errorf("OID enum label replaced an object name: %q", got)
+	}
+	if got := r.Name(".1.3.6.1.4.1.8888.3.42"); got != "fanTray42" {
+		t.Errorf("OID enum name = %q, want fanTray42", got)
+	}
+	if _, ok := r.Lookup(".1.3.6.1.4.1.8888.1.1.1"); ok {
+		t.Error("index item registered under its output label")
+	}
+	if _, ok := r.Lookup(".1.3.6.1.4.1.8888.1.1.9"); ok {
+		t.Error("enum of an unnamed OID registered")
+	}
+}
@@ -28,6 +28,10 @@ type ObjectDefinition struct {
 	// Attributes is the full set of SNMP attributes (columns) within this object.
 	// Keyed by the SNMP attribute name, e.g. "ifInOctets".
 	Attributes map[string]AttributeDefinition
+
+	// PollInterval, when > 0, overrides the object group and device poll
+	// interval for this object, in seconds.
+	PollInterval int
 }
 
 // IndexDefinition describes a single component of a table's OID index.
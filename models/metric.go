@@ -33,14 +33,20 @@ type Device struct {
 // Metric represents a single resolved SNMP variable binding. The Value field is
 // already converted to a native Go type by the decoder (int64, uint64, float64,
 // string, []byte, or bool). Tags carry dimension attributes (e.g. ifDescr).
+//
+// Enum syntaxes change the shape slightly: EnumBitmap values become a []string
+// of active bit labels, and the KeepID / KeepOID variants leave Value raw and
+// carry the resolved text in Label.
 type Metric struct {
 	OID      string            `json:"oid"`
 	Name     string            `json:"name"`
-	Instance string            `json:"instance,omitempty"` // Table row index, e.g. "1" for ifIndex 1
-	Value    interface{}       `json:"value"`              // int64 | uint64 | float64 | string | []byte | bool
-	Type     string            `json:"type"`               // SNMP PDU type: "Counter64", "Integer", etc.
-	Syntax   string            `json:"syntax"`             // Config syntax: "Counter64", "BandwidthMBits", etc.
-	Tags     map[string]string `json:"tags,omitempty"`     // Dimension attributes keyed by attribute name
+	Instance string            `json:"instance,omitempty"`  // Table row index, e.g. "1" for ifIndex 1
+	Value    interface{}       `json:"value"`               // int64 | uint64 | float64 | string | []byte | bool | []string
+	Label    string            `json:"label,omitempty"`     // Enum label for EnumIntegerKeepID / EnumObjectIdentifierKeepOID
+	RawValue interface{}       `json:"raw_value,omitempty"` // Bit mask behind an EnumBitmap label list
+	Type     string            `json:"type"`                // SNMP PDU type: "Counter64", "Integer", etc.
+	Syntax   string            `json:"syntax"`              // Config syntax: "Counter64", "BandwidthMBits", etc.
+	Tags     map[string]string `json:"tags,omitempty"`      // Dimension attributes keyed by attribute name
 }
 
 // MetricMetadata carries operational metadata about the collection cycle.
@@ -48,7 +54,7 @@ type Metric struct {
 type MetricMetadata struct {
 	CollectorID    string `json:"collector_id"`
 	PollDurationMs int64  `json:"poll_duration_ms"`
-	PollStatus     string `json:"poll_status"` // "success" | "timeout" | "error"
+	PollStatus     string `json:"poll_status"` // "success" | "timeout" | "error" | "skipped" | "on_demand"
 }
 
 // SNMPTrap is the top-level payload for a received SNMP trap or inform.
@@ -66,6 +72,6 @@ type TrapInfo struct {
 	GenericTrap   int32  `json:"generic_trap,omitempty"`   // v1 only (0–6)
 	SpecificTrap  int32  `json:"specific_trap,omitempty"`  // v1 only
 	TrapOID       string `json:"trap_oid"`                 // v2c / v3 SNMPv2-MIB::snmpTrapOID.0
-	TrapName      string `json:"trap_name,omitempty"`      // Resolved MIB name, e.g. "linkDown"
+	TrapName      string `json:"trap_name,omitempty"`      // Resolved MIB name, e.g. "IF-MIB::linkDown"
 	Severity      string `json:"severity,omitempty"`       // "info" | "warning" | "critical"
 }
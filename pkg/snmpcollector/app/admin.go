@@ -0,0 +1,389 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// On-demand polls
+// ─────────────────────────────────────────────────────────────────────────────
+
+// onDemandPollStatus is the poll_status of records produced by PollNow. Their
+// counters are raw cumulative values, never deltas.
+const onDemandPollStatus = "on_demand"
+
+// Errors returned by PollNow. The admin API maps them to HTTP status codes.
+var (
+	ErrInvalidPollRequest = errors.New("invalid poll request")
+	ErrUnknownDevice      = errors.New("unknown device")
+	ErrUnknownObject      = errors.New("unknown object")
+)
+
+// PollRequest asks PollNow for one immediate poll. Exactly one of Hostname and
+// Device selects the target, and exactly one of Object and OIDs what to fetch.
+type PollRequest struct {
+	// Hostname is a configured device.
+	Hostname string `json:"hostname,omitempty"`
+
+	// Device is an ad-hoc target that need not be configured.
+	Device *AdhocDevice `json:"device,omitempty"`
+
+	// Object is an object definition key, e.g. "IF-MIB::ifEntry". It need
+	// not be in the device's object groups.
+	Object string `json:"object,omitempty"`
+
+	// OIDs are fetched with a single Get, exactly as given. Each value is
+	// reported as a metric named after its OID.
+	OIDs []string `json:"oids,omitempty"`
+
+	// Publish also sends the result through the formatter to the transport.
+	Publish bool `json:"publish,omitempty"`
+}
+
+// AdhocDevice is the address and credentials of an ad-hoc PollRequest target.
+// Zero fields take the same defaults as a device file entry.
+type AdhocDevice struct {
+	IP            string                 `json:"ip"`
+	Port          int                    `json:"port,omitempty"`
+	Version       string                 `json:"version,omitempty"`
+	Communities   []string               `json:"communities,omitempty"`
+	V3Credentials []config.V3Credentials `json:"v3_credentials,omitempty"`
+	Timeout       int                    `json:"timeout,omitempty"` // milliseconds
+	Retries       int                    `json:"retries,omitempty"`
+}
+
+func (d AdhocDevice) deviceConfig() config.DeviceConfig {
+	cfg := config.DeviceConfig{
+		IP:                 d.IP,
+		Port:               d.Port,
+		Timeout:            d.Timeout,
+		Retries:            d.Retries,
+		Version:            d.Version,
+		Communities:        d.Communities,
+		V3Credentials:      d.V3Credentials,
+		MaxConcurrentPolls: 1,
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 161
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 3000
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = 2
+	}
+	if cfg.Version == "" {
+		cfg.Version = "2c"
+	}
+	return cfg
+}
+
+// PollNow polls req's target immediately through the poller, decoder and
+// producer and returns the resulting SNMPMetric, bypassing the scheduler.
+//
+// Configured devices are polled through the shared connection pool, so
+// max_concurrent_polls still applies; ad-hoc devices get a throwaway session.
+// Counters are returned as raw cumulative values and the pipeline's delta
+// baselines are left untouched. With req.Publish the record is also sent to
+// the transport. The App must be started.
+func (a *App) PollNow(ctx context.Context, req PollRequest) (models.SNMPMetric, error) {
+	job, err := a.onDemandJob(req)
+	if err != nil {
+		return models.SNMPMetric{}, err
+	}
+
+	p := poller.Poller(a.snmpPoller)
+	if req.Device != nil {
+		pool := poller.NewConnectionPool(poller.PoolOptions{MaxIdlePerDevice: 1}, a.logger)
+		defer pool.Close()
+		p = poller.NewSNMPPoller(pool, poller.PollerOptions{RateLimiter: a.rateLimiter}, a.logger)
+	}
+
+	raw, err := p.Poll(ctx, job)
+	if err != nil {
+		return models.SNMPMetric{}, err
+	}
+	decoded, err := a.dec.Decode(raw)
+	if err != nil {
+		return models.SNMPMetric{}, err
+	}
+	metric, err := a.onDemandProd.Produce(decoded)
+	if err != nil {
+		return models.SNMPMetric{}, err
+	}
+	metric.Metadata.PollStatus = onDemandPollStatus
+
+	if req.Publish && len(metric.Metrics) > 0 {
+		select {
+		case a.metricCh <- metric:
+		case <-ctx.Done():
+			return metric, fmt.Errorf("app: publish on-demand poll: %w", ctx.Err())
+		}
+	}
+
+	a.logger.Info("app: on-demand poll",
+		"device", job.Hostname,
+		"object", job.ObjectDef.Key,
+		"metric_count", len(metric.Metrics),
+		"published", req.Publish,
+	)
+	return metric, nil
+}
+
+// onDemandJob resolves req into a PollJob.
+func (a *App) onDemandJob(req PollRequest) (poller.PollJob, error) {
+	if (req.Hostname == "") == (req.Device == nil) {
+		return poller.PollJob{}, fmt.Errorf("%w: set exactly one of hostname and device", ErrInvalidPollRequest)
+	}
+	if (req.Object == "") == (len(req.OIDs) == 0) {
+		return poller.PollJob{}, fmt.Errorf("%w: set exactly one of object and oids", ErrInvalidPollRequest)
+	}
+
+	a.cfgMu.Lock()
+	cfg := a.profiler.Expand(a.loadedCfg)
+	a.cfgMu.Unlock()
+
+	var job poller.PollJob
+	if req.Device != nil {
+		if req.Device.IP == "" {
+			return job, fmt.Errorf("%w: device.ip is required", ErrInvalidPollRequest)
+		}
+		job.Hostname = req.Device.IP
+		job.DeviceConfig = req.Device.deviceConfig()
+		job.Device = models.Device{Hostname: job.Hostname}
+	} else {
+		dev, ok := cfg.Devices[req.Hostname]
+		if !ok {
+			return job, fmt.Errorf("%w %q", ErrUnknownDevice, req.Hostname)
+		}
+		job.Hostname = req.Hostname
+		job.DeviceConfig = dev
+		job.Device = models.Device{
+			Hostname: req.Hostname,
+			Tags:     cfg.DeviceTags(dev),
+		}
+	}
+	job.Device.IPAddress = job.DeviceConfig.IP
+	job.Device.SNMPVersion = job.DeviceConfig.Version
+
+	if req.Object != "" {
+		def, ok := cfg.ObjectDefs[req.Object]
+		if !ok {
+			return job, fmt.Errorf("%w %q", ErrUnknownObject, req.Object)
+		}
+		job.ObjectDef = def
+		return job, nil
+	}
+
+	// Raw OIDs: one attribute per OID, with the syntax inferred from the PDU.
+	job.OIDs = req.OIDs
+	job.ObjectDef = models.ObjectDefinition{
+		Key:        "oids",
+		Attributes: make(map[string]models.AttributeDefinition, len(req.OIDs)),
+	}
+	for _, oid := range req.OIDs {
+		if oid == "" {
+			return job, fmt.Errorf("%w: empty oid", ErrInvalidPollRequest)
+		}
+		job.ObjectDef.Attributes[oid] = models.AttributeDefinition{OID: oid, Name: oid}
+	}
+	return job, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// HTTP admin API
+// ─────────────────────────────────────────────────────────────────────────────
+
+// AdminHandler returns the HTTP/JSON admin API:
+//
+//	POST /api/v1/poll   body: PollRequest   → 200 SNMPMetric
+//	GET  /api/v1/oid?oid=.1.3.6.1.2.1.2.2.1.2.3 → 200 OIDInfo
+//	GET  /api/v1/oid?name=IF-MIB::ifDescr.3     → 200 OIDInfo
+//	GET  /metrics                               → 200 Prometheus text
+//
+// Errors are returned as {"error": "..."} with 400 for a malformed request,
+// 404 for an unknown device, object or OID and 502 when the poll itself fails.
+// /metrics is served only here, so the rate-limit metrics are available only
+// when the admin API is enabled (Config.AdminListenAddr).
+func (a *App) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/poll", a.handlePoll)
+	mux.HandleFunc("/api/v1/oid", a.handleOID)
+	mux.HandleFunc("/metrics", a.handleMetrics)
+	return mux
+}
+
+func (a *App) handlePoll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	var req PollRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), a.cfg.AdminPollTimeout)
+	defer cancel()
+	metric, err := a.PollNow(ctx, req)
+	switch {
+	case errors.Is(err, ErrInvalidPollRequest):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, ErrUnknownDevice), errors.Is(err, ErrUnknownObject):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	data, err := a.formatter.Format(&metric)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (a *App) handleOID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	q := r.URL.Query()
+	if q.Has("oid") == q.Has("name") {
+		writeError(w, http.StatusBadRequest, errors.New("set exactly one of oid and name"))
+		return
+	}
+	var info OIDInfo
+	var err error
+	if q.Has("oid") {
+		info, err = a.LookupOID(q.Get("oid"))
+	} else {
+		info, err = a.LookupName(q.Get("name"))
+	}
+	switch {
+	case errors.Is(err, ErrUnknownOID):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(info)
+}
+
+// handleMetrics writes the poller's rate limits and their counters in the
+// Prometheus text exposition format.
+func (a *App) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	st := a.rateLimiter.Stats()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetric(w, "snmpcollector_poller_rate_limit_per_device", "gauge",
+		"Maximum SNMP requests per second to one device (0 = unlimited).",
+		sample{value: st.PerDevice})
+	writeMetric(w, "snmpcollector_poller_global_rate_limit", "gauge",
+		"Maximum SNMP requests per second in total (0 = unlimited).",
+		sample{value: st.Global})
+	writeMetric(w, "snmpcollector_poller_requests_total", "counter",
+		"SNMP requests sent by the poller.",
+		sample{value: float64(st.Requests)})
+	writeMetric(w, "snmpcollector_poller_rate_limited_requests_total", "counter",
+		"SNMP requests delayed by a rate limit.",
+		sample{`limit="device"`, float64(st.DeviceWaits)}, sample{`limit="global"`, float64(st.GlobalWaits)})
+	writeMetric(w, "snmpcollector_poller_rate_limit_wait_seconds_total", "counter",
+		"Time SNMP requests spent waiting for a rate limit.",
+		sample{value: st.WaitTime.Seconds()})
+}
+
+// sample is one value of a metric family; labels may be empty.
+type sample struct {
+	labels string
+	value  float64
+}
+
+// writeMetric writes one metric family: its HELP and TYPE lines, then one
+// line per sample.
+func writeMetric(w io.Writer, name, typ, help string, samples ...sample) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	for _, s := range samples {
+		labels := ""
+		if s.labels != "" {
+			labels = "{" + s.labels + "}"
+		}
+		fmt.Fprintf(w, "%s%s %s\n", name, labels, strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// startAdmin binds the admin API listener. The server runs until stopAdmin.
+func (a *App) startAdmin() error {
+	ln, err := net.Listen("tcp", a.cfg.AdminListenAddr)
+	if err != nil {
+		return err
+	}
+	a.adminSrv = &http.Server{
+		Handler:           a.AdminHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := a.adminSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.logger.Error("app: admin API stopped", "error", err.Error())
+		}
+	}()
+	a.logger.Info("app: admin API listening", "addr", ln.Addr().String())
+	if !isLoopback(ln.Addr()) {
+		a.logger.Warn("app: admin API is not bound to loopback; it has no authentication and can poll any address with any credentials",
+			"addr", ln.Addr().String(),
+		)
+	}
+	return nil
+}
+
+// isLoopback reports whether addr is a loopback TCP address.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// stopAdmin shuts the admin API down, waiting for in-progress polls so none
+// publishes after the pipeline channels close.
+func (a *App) stopAdmin() {
+	if a.adminSrv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.AdminPollTimeout+5*time.Second)
+	defer cancel()
+	if err := a.adminSrv.Shutdown(ctx); err != nil {
+		a.logger.Warn("app: admin API shutdown", "error", err.Error())
+	}
+}
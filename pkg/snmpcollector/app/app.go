@@ -20,16 +20,22 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	jsonformat "github.com/vpbank/snmp_collector/format/json"
+	"github.com/vpbank/snmp_collector/mibs/registry"
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/scheduler"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/trapreceiver"
 	"github.com/vpbank/snmp_collector/producer/metrics"
+	"github.com/vpbank/snmp_collector/snmp/capture"
 	"github.com/vpbank/snmp_collector/snmp/decoder"
 	filetransport "github.com/vpbank/snmp_collector/transport/file"
 )
@@ -60,6 +66,67 @@ type Config struct {
 	// PoolOptions configures the SNMP connection pool.
 	PoolOptions poller.PoolOptions
 
+	// RateLimitPerDevice caps the SNMP requests per second sent to any one
+	// device, and GlobalRateLimit those sent in total, on-demand polls
+	// included. 0 = unlimited.
+	RateLimitPerDevice float64
+	GlobalRateLimit    float64
+
+	// SchedulerOptions configures phase spreading, jitter and queue overflow
+	// handling of poll cycles. OnSkip is set by the app.
+	SchedulerOptions scheduler.Options
+
+	// SystemInfoEnabled polls the SNMPv2-MIB system group per device and
+	// attaches vendor, model, sysDescr, sysLocation and sysContact to every
+	// SNMPMetric.
+	SystemInfoEnabled bool
+
+	// SystemInfoInterval is how often each device's system group is re-read.
+	// Default: 1h.
+	SystemInfoInterval time.Duration
+
+	// AutoProfileInterval is how often devices declaring
+	// `device_groups: [auto]` are re-probed and re-matched against the device
+	// profile rules. Default: 1h.
+	AutoProfileInterval time.Duration
+
+	// WatchConfig polls the configuration directories every WatchInterval and
+	// calls Reload once a change has settled.
+	WatchConfig bool
+
+	// WatchInterval is how often the configuration directories are scanned
+	// when WatchConfig is set. Default: 5s.
+	WatchInterval time.Duration
+
+	// Inventory lists external device sources (CSV, HTTP, NetBox, …) merged
+	// with the device files. Devices defined in a file win on a hostname
+	// clash.
+	Inventory []config.InventorySource
+
+	// InventoryInterval is how often Inventory is re-fetched. Default: 5m.
+	InventoryInterval time.Duration
+
+	// AdminListenAddr is the TCP address of the HTTP admin API (on-demand
+	// polls, see AdminHandler). Empty disables it. The API has no
+	// authentication and polls any device it is given, so it must be bound to
+	// a loopback address; any other address is logged as a warning.
+	AdminListenAddr string
+
+	// AdminPollTimeout bounds one on-demand poll made through the admin API.
+	// Default: 30s.
+	AdminPollTimeout time.Duration
+
+	// MIBPaths are directories of MIB files whose objects are added to the
+	// OID name registry (see LookupOID and LookupName) on top of the built-in
+	// names, the object definitions and the enum files. They are re-read on
+	// Reload.
+	MIBPaths []string
+
+	// CaptureFile, when set, records every scheduled poll result to this file
+	// before it is decoded, for offline replay with Replay. A ".gz" name
+	// selects gzip compression. The file is truncated on Start.
+	CaptureFile string
+
 	// TrapEnabled controls whether the trap receiver starts.
 	TrapEnabled bool
 
@@ -118,6 +185,21 @@ func (c *Config) withDefaults() {
 	if c.TrapListenAddr == "" {
 		c.TrapListenAddr = "0.0.0.0:162"
 	}
+	if c.SystemInfoInterval <= 0 {
+		c.SystemInfoInterval = time.Hour
+	}
+	if c.AutoProfileInterval <= 0 {
+		c.AutoProfileInterval = time.Hour
+	}
+	if c.WatchInterval <= 0 {
+		c.WatchInterval = 5 * time.Second
+	}
+	if c.InventoryInterval <= 0 {
+		c.InventoryInterval = 5 * time.Minute
+	}
+	if c.AdminPollTimeout <= 0 {
+		c.AdminPollTimeout = 30 * time.Second
+	}
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -130,17 +212,42 @@ type App struct {
 	cfg    Config
 	logger *slog.Logger
 
-	// Loaded configuration (populated in Start).
+	// Loaded configuration (populated in Start). reloadMu serialises Reload,
+	// auto-profile rediscovery and inventory refreshes from start to finish,
+	// including their device probes; cfgMu guards loadedCfg and is held only
+	// while a new configuration is swapped in, never across network I/O.
+	// fileCfg is the configuration read from the files (guarded by reloadMu);
+	// loadedCfg adds the inventory devices.
+	reloadMu  sync.Mutex
+	cfgMu     sync.Mutex
+	fileCfg   *config.LoadedConfig
 	loadedCfg *config.LoadedConfig
+	inventory *config.Inventory // nil when Config.Inventory is empty
+
+	// trapDevices maps a device IP to its hostname, static tags and schedule
+	// so trap records can be attributed to configured devices and suppressed
+	// during maintenance windows.
+	trapMu      sync.RWMutex
+	trapDevices map[string]trapDevice
+
+	// mibs names OIDs for traps, decoder logs and the admin API. It is
+	// rebuilt by Start and Reload and read-only once stored.
+	mibs atomic.Pointer[registry.Registry]
 
 	// Pipeline components.
 	connPool     *poller.ConnectionPool
+	sysInfo      *poller.SystemInfoCache // nil when SystemInfoEnabled=false
+	rateLimiter  *poller.RateLimiter
 	snmpPoller   *poller.SNMPPoller
 	workerPool   *poller.WorkerPool
+	profiler     *scheduler.AutoProfiler
 	sched        *scheduler.Scheduler
 	trapReceiver *trapreceiver.TrapReceiver
 	dec          *decoder.SNMPDecoder
 	prod         *metrics.MetricsProducer
+	onDemandProd *metrics.MetricsProducer // PollNow: no counter deltas
+	adminSrv     *http.Server             // nil when AdminListenAddr is empty
+	capture      *capture.Writer          // nil when CaptureFile is empty
 	formatter    *jsonformat.JSONFormatter
 	transport    filetransport.Transport
 
@@ -151,6 +258,7 @@ type App struct {
 	formattedCh chan []byte
 
 	// Lifecycle.
+	ctx      context.Context
 	cancel   context.CancelFunc
 	wg       sync.WaitGroup // tracks pipeline goroutines
 	formatWg sync.WaitGroup // tracks formatters feeding formattedCh
@@ -176,11 +284,26 @@ func New(cfg Config, logger *slog.Logger) *App {
 // parent) to release resources.
 func (a *App) Start(ctx context.Context) error {
 	// ── 1. Load configuration ───────────────────────────────────────────
+	// Fingerprint the files first so an edit racing the load is reloaded.
+	var watchFP string
+	if a.cfg.WatchConfig {
+		fp, err := config.Fingerprint(a.cfg.ConfigPaths)
+		if err != nil {
+			a.logger.Warn("app: config watch scan failed", "error", err.Error())
+		}
+		watchFP = fp
+	}
 	a.logger.Info("app: loading configuration")
 	loadedCfg, err := config.Load(a.cfg.ConfigPaths, a.logger)
 	if err != nil {
 		return fmt.Errorf("app: load config: %w", err)
 	}
+	a.fileCfg = loadedCfg
+	if len(a.cfg.Inventory) > 0 {
+		a.inventory = config.NewInventory(a.cfg.Inventory, a.logger)
+		a.inventory.Refresh(ctx)
+		loadedCfg = a.withInventory(loadedCfg)
+	}
 	a.loadedCfg = loadedCfg
 	a.logger.Info("app: configuration loaded",
 		"devices", len(loadedCfg.Devices),
@@ -188,47 +311,62 @@ func (a *App) Start(ctx context.Context) error {
 	)
 
 	// ── 2. Create inter-stage channels ──────────────────────────────────
-	a.rawCh = make(chan decoder.RawPollResult, a.cfg.BufferSize)
-	a.decodedCh = make(chan decoder.DecodedPollResult, a.cfg.BufferSize)
-	a.metricCh = make(chan models.SNMPMetric, a.cfg.BufferSize)
-	a.formattedCh = make(chan []byte, a.cfg.BufferSize)
+	a.makeChannels()
 
 	// ── 3. Build pipeline components (reverse order: transport → decoder) ──
-	if a.cfg.SplitFile {
-		transport, err := a.buildSplitTransport()
+	if err := a.buildStages(loadedCfg); err != nil {
+		return err
+	}
+	if a.cfg.CaptureFile != "" {
+		w, err := capture.Create(a.cfg.CaptureFile)
 		if err != nil {
-			return fmt.Errorf("app: build split transport: %w", err)
+			_ = a.transport.Close()
+			return fmt.Errorf("app: %w", err)
 		}
-		a.transport = transport
-	} else {
-		a.transport = filetransport.New(filetransport.Config{
-			Writer: a.cfg.TransportWriter,
-		}, a.logger)
+		a.capture = w
+		a.logger.Info("app: capturing poll results", "file", a.cfg.CaptureFile)
 	}
 
-	a.formatter = jsonformat.New(jsonformat.Config{
-		PrettyPrint: a.cfg.PrettyPrint,
-	}, a.logger)
-
-	a.prod = metrics.New(metrics.Config{
-		CollectorID:         a.cfg.CollectorID,
-		EnumEnabled:         a.cfg.EnumEnabled,
-		Enums:               loadedCfg.Enums,
-		CounterDeltaEnabled: a.cfg.CounterDeltaEnabled,
-	}, a.logger)
-
-	a.dec = decoder.NewSNMPDecoder(a.logger)
-
 	a.connPool = poller.NewConnectionPool(a.cfg.PoolOptions, a.logger)
-	a.snmpPoller = poller.NewSNMPPoller(a.connPool, a.logger)
+	if a.cfg.SystemInfoEnabled {
+		a.sysInfo = poller.NewSystemInfoCache(a.cfg.SystemInfoInterval, loadedCfg.Vendors)
+	}
+	a.rateLimiter = poller.NewRateLimiter(a.cfg.RateLimitPerDevice, a.cfg.GlobalRateLimit)
+	a.snmpPoller = poller.NewSNMPPoller(a.connPool, poller.PollerOptions{
+		SystemInfo:  a.sysInfo,
+		RateLimiter: a.rateLimiter,
+	}, a.logger)
 	a.workerPool = poller.NewWorkerPool(a.cfg.PollerWorkers, a.snmpPoller, a.rawCh, a.logger)
 
-	a.sched = scheduler.New(loadedCfg, a.workerPool, a.logger)
-
 	// ── 4. Create a cancellable context for all goroutines ──────────────
 	pipeCtx, cancel := context.WithCancel(ctx)
+	a.ctx = pipeCtx
 	a.cancel = cancel
 
+	// Probe auto-profiled devices before the first schedule so they are
+	// polled from the first cycle.
+	a.profiler = scheduler.NewAutoProfiler(scheduler.AutoProfileOptions{
+		Poller:     a.snmpPoller,
+		SystemInfo: a.sysInfo,
+	}, a.logger)
+	a.profiler.Discover(pipeCtx, loadedCfg)
+	expanded := a.profiler.Expand(loadedCfg)
+	schedOpts := a.cfg.SchedulerOptions
+	schedOpts.OnSkip = a.reportSkip
+	a.sched = scheduler.New(expanded, a.workerPool, schedOpts, a.logger)
+	a.setTrapDevices(expanded)
+
+	if a.cfg.AdminListenAddr != "" {
+		if err := a.startAdmin(); err != nil {
+			cancel()
+			_ = a.transport.Close()
+			if a.capture != nil {
+				_ = a.capture.Close()
+			}
+			return fmt.Errorf("app: admin API: %w", err)
+		}
+	}
+
 	// ── 5. Optionally start trap receiver (must know before formatWg count) ──
 	trapStarted := false
 	if a.cfg.TrapEnabled {
@@ -278,6 +416,28 @@ func (a *App) Start(ctx context.Context) error {
 	}()
 	a.logger.Info("app: scheduler started", "entries", a.sched.Entries())
 
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.runAutoProfile(pipeCtx)
+	}()
+
+	if a.cfg.WatchConfig {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.runConfigWatch(pipeCtx, watchFP)
+		}()
+	}
+
+	if a.inventory != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.runInventory(pipeCtx)
+		}()
+	}
+
 	a.logger.Info("app: pipeline running",
 		"poller_workers", a.cfg.PollerWorkers,
 		"buffer_size", a.cfg.BufferSize,
@@ -289,16 +449,20 @@ func (a *App) Start(ctx context.Context) error {
 // Stop performs a graceful shutdown.
 //
 // Shutdown order:
+//  0. Shut down the admin API, waiting for in-progress on-demand polls.
 //  1. Cancel the pipeline context (stops scheduler + worker pool producers).
 //  2. Wait for the scheduler goroutine to exit.
 //  3. Drain the worker pool (waits for in-flight polls to complete).
 //  4. Close rawCh → decoder drains → closes decodedCh → producer drains →
 //     closes metricCh → formatter drains. Trap formatter also finishes.
 //  5. Close formattedCh → transport goroutine drains → exits.
-//  6. Close transport and connection pool.
+//  6. Close transport, capture file and connection pool.
 func (a *App) Stop() {
 	a.logger.Info("app: shutting down")
 
+	// 0. Stop accepting on-demand polls before channels start closing.
+	a.stopAdmin()
+
 	// 1. Signal all goroutines to stop.
 	if a.cancel != nil {
 		a.cancel()
@@ -333,6 +497,11 @@ func (a *App) Stop() {
 			a.logger.Error("app: transport close error", "error", err.Error())
 		}
 	}
+	if a.capture != nil {
+		if err := a.capture.Close(); err != nil {
+			a.logger.Error("app: capture close error", "error", err.Error())
+		}
+	}
 	if a.connPool != nil {
 		a.connPool.Close()
 	}
@@ -342,28 +511,230 @@ func (a *App) Stop() {
 
 // Reload atomically replaces the running configuration. New devices are polled
 // immediately; removed devices stop; changed intervals take effect on the next
-// cycle. Returns an error if the new configuration fails to load.
+// cycle. It also swaps the producer's enum registry and the OID name registry,
+// rebuilds the trap source index, drops counter baselines of objects no longer
+// polled and evicts pooled sessions of devices whose address or credentials
+// changed. Inventory devices from the last refresh are merged into the
+// reloaded files.
+//
+// The new configuration is validated by loading it completely first; if that
+// fails an error is returned and the running configuration is kept.
+// Concurrent reloads (SIGHUP and the config watch) run one at a time, each
+// loading and applying the files as a unit, so the last to load is the last
+// to apply.
 func (a *App) Reload() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
 	a.logger.Info("app: reloading configuration")
-	newCfg, err := config.Load(a.cfg.ConfigPaths, a.logger)
+	fileCfg, err := config.Load(a.cfg.ConfigPaths, a.logger)
 	if err != nil {
 		return fmt.Errorf("app: reload config: %w", err)
 	}
 
-	// Update producer enum registry if enums changed.
-	// (producer.MetricsProducer is rebuilt on the next Produce call automatically
-	// via its config.Enums field — but the producer is immutable, so we just
-	// update the scheduler which controls what gets polled.)
-	a.sched.Reload(newCfg)
-	a.loadedCfg = newCfg
+	a.setRegistry(fileCfg)
+
+	a.prod.SetEnums(fileCfg.Enums)
+	a.onDemandProd.SetEnums(fileCfg.Enums)
+
+	a.fileCfg = fileCfg
+	newCfg := a.withInventory(fileCfg)
+	evicted := a.applyConfig(newCfg)
 
 	a.logger.Info("app: configuration reloaded",
 		"devices", len(newCfg.Devices),
 		"object_defs", len(newCfg.ObjectDefs),
+		"sessions_evicted", evicted,
 	)
 	return nil
 }
 
+// withInventory merges the inventory devices of the last refresh into
+// fileCfg. It returns fileCfg unchanged when no sources are configured.
+func (a *App) withInventory(fileCfg *config.LoadedConfig) *config.LoadedConfig {
+	if a.inventory == nil {
+		return fileCfg
+	}
+	return fileCfg.WithInventory(a.inventory.Devices(), a.logger)
+}
+
+// applyConfig makes newCfg the running configuration: it evicts pooled
+// sessions of removed or changed devices, re-profiles and reschedules, and
+// forgets the system info and rate limit buckets of removed devices. It
+// returns the number of sessions evicted. reloadMu must be held; the
+// auto-profile probes run before cfgMu is taken.
+func (a *App) applyConfig(newCfg *config.LoadedConfig) int {
+	a.profiler.Discover(a.ctx, newCfg)
+
+	a.cfgMu.Lock()
+	defer a.cfgMu.Unlock()
+	evicted := 0
+	for hostname, old := range a.loadedCfg.Devices {
+		dev, ok := newCfg.Devices[hostname]
+		if !ok || poller.SessionChanged(old, dev) {
+			a.connPool.Evict(hostname)
+			evicted++
+		}
+		if !ok {
+			a.rateLimiter.Forget(hostname)
+		}
+	}
+
+	a.applyExpanded(a.profiler.Expand(newCfg))
+	if a.sysInfo != nil {
+		a.sysInfo.SetVendors(newCfg.Vendors)
+		for hostname := range a.loadedCfg.Devices {
+			if _, ok := newCfg.Devices[hostname]; !ok {
+				a.sysInfo.Remove(hostname)
+			}
+		}
+	}
+	a.loadedCfg = newCfg
+	return evicted
+}
+
+// applyExpanded pushes an auto-profile expanded configuration to the scheduler
+// and trap index, and drops the counter baselines of every device / metric it
+// no longer polls. cfgMu must be held.
+func (a *App) applyExpanded(expanded *config.LoadedConfig) {
+	a.sched.Reload(expanded)
+	a.setTrapDevices(expanded)
+
+	polled := make(map[metrics.CounterKey]bool)
+	for _, job := range scheduler.ResolveJobs(expanded, nil) {
+		for _, attr := range job.ObjectDef.Attributes {
+			polled[metrics.CounterKey{Device: job.Hostname, Attribute: attr.Name}] = true
+		}
+	}
+	if n := a.prod.RemoveCounters(func(k metrics.CounterKey) bool {
+		return !polled[metrics.CounterKey{Device: k.Device, Attribute: k.Attribute}]
+	}); n > 0 {
+		a.logger.Info("app: dropped counter state of unpolled objects", "counters", n)
+	}
+}
+
+// runAutoProfile re-probes auto-profiled devices every AutoProfileInterval and
+// reloads the scheduler when any device's selected groups changed. It returns
+// when ctx is cancelled.
+func (a *App) runAutoProfile(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.AutoProfileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		a.reloadMu.Lock()
+		if a.profiler.Discover(ctx, a.loadedCfg) {
+			a.cfgMu.Lock()
+			a.applyExpanded(a.profiler.Expand(a.loadedCfg))
+			a.cfgMu.Unlock()
+			a.logger.Info("app: auto-profile rediscovery changed device groups")
+		}
+		a.reloadMu.Unlock()
+	}
+}
+
+// trapDevice is a configured device as seen by the trap path.
+type trapDevice struct {
+	models.Device
+	schedule *schedule.Schedule
+}
+
+// setTrapDevices rebuilds the IP → device index used to attribute traps from
+// cfg, which must already be auto-profile expanded so group tags apply. When
+// several devices share an IP the lowest hostname wins.
+func (a *App) setTrapDevices(cfg *config.LoadedConfig) {
+	index := make(map[string]trapDevice, len(cfg.Devices))
+	for hostname, dev := range cfg.Devices {
+		if prev, ok := index[dev.IP]; ok && prev.Hostname < hostname {
+			continue
+		}
+		var sched *schedule.Schedule
+		if dev.Schedule != nil {
+			// Validated by the loader; an invalid one suppresses nothing.
+			sched, _ = schedule.Compile(*dev.Schedule)
+		}
+		index[dev.IP] = trapDevice{
+			Device: models.Device{
+				Hostname: hostname,
+				Tags:     cfg.DeviceTags(dev),
+			},
+			schedule: sched,
+		}
+	}
+	a.trapMu.Lock()
+	a.trapDevices = index
+	a.trapMu.Unlock()
+}
+
+// enrichTrap fills in the hostname and static tags of the configured device
+// that sent trap, and merges those tags into each varbind (varbind tags win).
+// Traps from unknown senders are left untouched. It returns false when the
+// trap falls in one of the device's block windows with suppress_traps set and
+// should be dropped.
+func (a *App) enrichTrap(trap *models.SNMPTrap) bool {
+	a.trapMu.RLock()
+	dev, ok := a.trapDevices[trap.Device.IPAddress]
+	a.trapMu.RUnlock()
+	if !ok {
+		return true
+	}
+	at := trap.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if dev.schedule.SuppressTraps(at) {
+		return false
+	}
+	if trap.Device.Hostname == "" {
+		trap.Device.Hostname = dev.Hostname
+	}
+	trap.Device.Tags = metrics.MergeTags(dev.Tags, trap.Device.Tags)
+	for i := range trap.Varbinds {
+		trap.Varbinds[i].Tags = metrics.MergeTags(trap.Device.Tags, trap.Varbinds[i].Tags)
+	}
+	return true
+}
+
+// skippedMetricName is the metric carried by skipped-cycle records.
+const skippedMetricName = "snmp.poll.skipped"
+
+// reportSkip turns a skipped poll cycle into an SNMPMetric with poll_status
+// "skipped" carrying the cumulative skip count for the device/object, so a
+// missing interval is visible downstream. It runs on the scheduler goroutine
+// and never blocks: if metricCh is full the record is dropped with a warning.
+func (a *App) reportSkip(ev scheduler.SkipEvent) {
+	metric := models.SNMPMetric{
+		Timestamp: time.Now(),
+		Device:    ev.Job.Device,
+		Metrics: []models.Metric{{
+			Name:   skippedMetricName,
+			Value:  ev.Count,
+			Type:   "Counter64",
+			Syntax: "Counter64",
+			Tags: metrics.MergeTags(ev.Job.Device.Tags, map[string]string{
+				"object": ev.Job.ObjectDef.Key,
+				"reason": ev.Reason,
+			}),
+		}},
+		Metadata: models.MetricMetadata{
+			CollectorID: a.cfg.CollectorID,
+			PollStatus:  "skipped",
+		},
+	}
+	select {
+	case a.metricCh <- metric:
+	default:
+		a.logger.Warn("app: metric channel full, dropping skipped-cycle record",
+			"device", ev.Job.Hostname,
+			"object", ev.Job.ObjectDef.Key,
+		)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Pipeline stage goroutines
 // ─────────────────────────────────────────────────────────────────────────────
@@ -378,6 +749,9 @@ func (a *App) startDecodeStage(_ context.Context) {
 		defer close(a.decodedCh)
 
 		for raw := range a.rawCh {
+			if a.capture != nil {
+				a.record(raw)
+			}
 			decoded, err := a.dec.Decode(raw)
 			if err != nil {
 				a.logger.Warn("app: decode error",
@@ -454,6 +828,14 @@ func (a *App) startTrapFormatStage(_ context.Context) {
 		defer a.formatWg.Done()
 
 		for trap := range a.trapReceiver.Output() {
+			if !a.enrichTrap(&trap) {
+				a.logger.Debug("app: trap suppressed by maintenance window",
+					"device", trap.Device.IPAddress,
+					"trap_oid", trap.TrapInfo.TrapOID,
+				)
+				continue
+			}
+			a.nameTrap(&trap)
 			data, err := json.Marshal(&trap)
 			if err != nil {
 				a.logger.Warn("app: trap format error",
@@ -497,6 +879,51 @@ func (a *App) startTransportStage(_ context.Context) {
 // Utilities
 // ─────────────────────────────────────────────────────────────────────────────
 
+// makeChannels creates the inter-stage channels.
+func (a *App) makeChannels() {
+	a.rawCh = make(chan decoder.RawPollResult, a.cfg.BufferSize)
+	a.decodedCh = make(chan decoder.DecodedPollResult, a.cfg.BufferSize)
+	a.metricCh = make(chan models.SNMPMetric, a.cfg.BufferSize)
+	a.formattedCh = make(chan []byte, a.cfg.BufferSize)
+}
+
+// buildStages constructs the transport, formatter, producers and decoder
+// shared by Start and Replay.
+func (a *App) buildStages(loadedCfg *config.LoadedConfig) error {
+	if a.cfg.SplitFile {
+		transport, err := a.buildSplitTransport()
+		if err != nil {
+			return fmt.Errorf("app: build split transport: %w", err)
+		}
+		a.transport = transport
+	} else {
+		a.transport = filetransport.New(filetransport.Config{
+			Writer: a.cfg.TransportWriter,
+		}, a.logger)
+	}
+
+	a.formatter = jsonformat.New(jsonformat.Config{
+		PrettyPrint: a.cfg.PrettyPrint,
+	}, a.logger)
+
+	a.prod = metrics.New(metrics.Config{
+		CollectorID:         a.cfg.CollectorID,
+		EnumEnabled:         a.cfg.EnumEnabled,
+		Enums:               loadedCfg.Enums,
+		CounterDeltaEnabled: a.cfg.CounterDeltaEnabled,
+	}, a.logger)
+
+	a.onDemandProd = metrics.New(metrics.Config{
+		CollectorID: a.cfg.CollectorID,
+		EnumEnabled: a.cfg.EnumEnabled,
+		Enums:       loadedCfg.Enums,
+	}, a.logger)
+
+	a.dec = decoder.NewSNMPDecoder(a.logger)
+	a.setRegistry(loadedCfg)
+	return nil
+}
+
 // buildSplitTransport creates a SplitWriterTransport backed by RotatingFile
 // instances for metrics and traps.
 func (a *App) buildSplitTransport() (filetransport.Transport, error) {
@@ -4,15 +4,29 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gosnmp/gosnmp"
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/scheduler"
+	"github.com/vpbank/snmp_collector/producer/metrics"
+	"github.com/vpbank/snmp_collector/snmp/capture"
+	"github.com/vpbank/snmp_collector/snmp/decoder"
+	"github.com/vpbank/snmp_collector/utils/snmptest"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -132,6 +146,32 @@ func TestStartStop_emptyConfig(t *testing.T) {
 	a.Stop()
 }
 
+func TestStart_AdminListenFailureClosesOutputs(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer busy.Close()
+	capPath := filepath.Join(t.TempDir(), "polls.jsonl.gz")
+	a := New(Config{
+		ConfigPaths:     config.Paths{},
+		PollerWorkers:   1,
+		BufferSize:      10,
+		TransportWriter: &safeBuffer{},
+		CaptureFile:     capPath,
+		AdminListenAddr: busy.Addr().String(),
+	}, nil)
+
+	if err := a.Start(context.Background()); err == nil {
+		a.Stop()
+		t.Fatal("Start with the admin address in use succeeded")
+	}
+	// Closing the gzip capture writes its header and trailer.
+	if fi, err := os.Stat(capPath); err != nil || fi.Size() == 0 {
+		t.Errorf("capture file not closed after the failed Start (stat %v)", err)
+	}
+}
+
 func TestStartStop_lifecycle(t *testing.T) {
 	paths := writeTestConfig(t)
 
@@ -209,6 +249,277 @@ func TestReload(t *testing.T) {
 	}
 }
 
+func TestReload_SwapsEnumsAndDropsCounters(t *testing.T) {
+	paths := writeTestConfig(t)
+	a := New(Config{
+		ConfigPaths:         paths,
+		PollerWorkers:       1,
+		BufferSize:          10,
+		EnumEnabled:         true,
+		CounterDeltaEnabled: true,
+		TransportWriter:     &safeBuffer{},
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer a.Stop()
+
+	at := time.Now()
+	produce := func(host string, value interface{}, syntax string) interface{} {
+		t.Helper()
+		m, err := a.prod.Produce(decoder.DecodedPollResult{
+			Device:      models.Device{Hostname: host},
+			CollectedAt: at,
+			Varbinds: []decoder.DecodedVarbind{{
+				OID: "1.3.6.1.2.1.2.2.1.8.1", AttributeName: "netif.state.oper",
+				Instance: "1", Value: value, Syntax: syntax,
+			}},
+		})
+		if err != nil || len(m.Metrics) != 1 {
+			t.Fatalf("Produce: %v %+v", err, m)
+		}
+		at = at.Add(time.Second)
+		return m.Metrics[0].Value
+	}
+
+	// Baselines for a configured and an unconfigured device.
+	produce("testdevice", uint64(100), "Counter64")
+	produce("gone", uint64(100), "Counter64")
+	if got := produce("testdevice", int64(1), "EnumInteger"); got != int64(1) {
+		t.Fatalf("enum resolved before any enum file: %v", got)
+	}
+
+	writeYAML(t, filepath.Join(paths.Enums, "ifOperStatus.yml"), ".1.3.6.1.2.1.2.2.1.8:\n  1: 'up'\n")
+	if err := a.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if got := produce("testdevice", int64(1), "EnumInteger"); got != "up" {
+		t.Errorf("enum after reload = %v, want up", got)
+	}
+	// netif.state.oper is not polled from either device, so both baselines
+	// were dropped and the next sample starts over at 0.
+	if got := produce("gone", uint64(150), "Counter64"); got != uint64(0) {
+		t.Errorf("counter of unpolled object = %v, want re-seeded 0", got)
+	}
+}
+
+func TestConfigWatch_ReloadsOnChange(t *testing.T) {
+	paths := writeTestConfig(t)
+	a := New(Config{
+		ConfigPaths:     paths,
+		PollerWorkers:   1,
+		BufferSize:      10,
+		WatchConfig:     true,
+		WatchInterval:   20 * time.Millisecond,
+		TransportWriter: &safeBuffer{},
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer a.Stop()
+
+	writeYAML(t, filepath.Join(paths.Devices, "dev2.yml"), `
+testdevice2:
+  ip: 127.0.0.251
+  poll_interval: 60
+  device_groups: ["testgroup"]
+`)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		a.cfgMu.Lock()
+		_, ok := a.loadedCfg.Devices["testdevice2"]
+		a.cfgMu.Unlock()
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("new device file was not picked up by the config watch")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := a.sched.Entries(); got != 2 {
+		t.Errorf("scheduler entries = %d, want 2", got)
+	}
+}
+
+// inventoryStub is an InventorySource whose devices tests swap at runtime.
+type inventoryStub struct {
+	mu      sync.Mutex
+	devices map[string]config.DeviceConfig
+}
+
+func (s *inventoryStub) Name() string { return "stub" }
+
+func (s *inventoryStub) Devices(context.Context) (map[string]config.DeviceConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.devices, nil
+}
+
+func (s *inventoryStub) set(devices map[string]config.DeviceConfig) {
+	s.mu.Lock()
+	s.devices = devices
+	s.mu.Unlock()
+}
+
+func TestInventory_RefreshReschedules(t *testing.T) {
+	src := &inventoryStub{devices: map[string]config.DeviceConfig{
+		"inv1": {IP: "127.0.0.251", PollInterval: 60, DeviceGroups: []string{"testgroup"}},
+	}}
+	a := New(Config{
+		ConfigPaths:       writeTestConfig(t),
+		PollerWorkers:     1,
+		BufferSize:        10,
+		Inventory:         []config.InventorySource{src},
+		InventoryInterval: 20 * time.Millisecond,
+		TransportWriter:   &safeBuffer{},
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer a.Stop()
+
+	if got := a.sched.Entries(); got != 2 {
+		t.Fatalf("scheduler entries = %d, want the device file's and the inventory device", got)
+	}
+	a.cfgMu.Lock()
+	dev := a.loadedCfg.Devices["inv1"]
+	a.cfgMu.Unlock()
+	if dev.Port != 161 || dev.Version != "2c" {
+		t.Errorf("inventory device not resolved: %+v", dev)
+	}
+
+	src.set(map[string]config.DeviceConfig{
+		"inv2": {IP: "127.0.0.252", PollInterval: 60, DeviceGroups: []string{"testgroup"}},
+	})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		a.cfgMu.Lock()
+		_, added := a.loadedCfg.Devices["inv2"]
+		_, kept := a.loadedCfg.Devices["inv1"]
+		a.cfgMu.Unlock()
+		if added && !kept {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("inventory change was not applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := a.sched.Entries(); got != 2 {
+		t.Errorf("scheduler entries = %d, want 2", got)
+	}
+
+	// A reload of the files keeps the inventory devices.
+	if err := a.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	a.cfgMu.Lock()
+	_, ok := a.loadedCfg.Devices["inv2"]
+	a.cfgMu.Unlock()
+	if !ok {
+		t.Error("Reload dropped the inventory devices")
+	}
+}
+
+func TestEnrichTrap_DeviceTags(t *testing.T) {
+	a := New(Config{}, nil)
+	a.setTrapDevices(&config.LoadedConfig{
+		Devices: map[string]config.DeviceConfig{
+			"core1": {IP: "10.0.0.1", DeviceGroups: []string{"dc"}, Tags: map[string]string{"role": "core"}},
+		},
+		DeviceGroups: map[string]config.DeviceGroup{
+			"dc": {Tags: map[string]string{"site": "dc1", "role": "generic"}},
+		},
+	})
+
+	trap := models.SNMPTrap{
+		Device:   models.Device{IPAddress: "10.0.0.1"},
+		Varbinds: []models.Metric{{Name: "ifIndex", Tags: map[string]string{"role": "varbind"}}},
+	}
+	a.enrichTrap(&trap)
+	if trap.Device.Hostname != "core1" {
+		t.Errorf("Hostname = %q, want core1", trap.Device.Hostname)
+	}
+	if trap.Device.Tags["site"] != "dc1" || trap.Device.Tags["role"] != "core" {
+		t.Errorf("Device.Tags = %v, want site=dc1 role=core", trap.Device.Tags)
+	}
+	if vb := trap.Varbinds[0].Tags; vb["site"] != "dc1" || vb["role"] != "varbind" {
+		t.Errorf("varbind Tags = %v, want site=dc1 role=varbind", vb)
+	}
+
+	unknown := models.SNMPTrap{Device: models.Device{IPAddress: "10.9.9.9"}}
+	a.enrichTrap(&unknown)
+	if unknown.Device.Hostname != "" || unknown.Device.Tags != nil {
+		t.Errorf("unknown sender enriched: %+v", unknown.Device)
+	}
+}
+
+func TestEnrichTrap_SuppressedInMaintenanceWindow(t *testing.T) {
+	a := New(Config{}, nil)
+	a.setTrapDevices(&config.LoadedConfig{
+		Devices: map[string]config.DeviceConfig{
+			"core1": {IP: "10.0.0.1", Schedule: &schedule.Spec{
+				Block: []schedule.WindowSpec{{Days: []string{"sun"}, Start: "02:00", End: "04:00", SuppressTraps: true}},
+			}},
+		},
+	})
+
+	inWindow := models.SNMPTrap{
+		Timestamp: time.Date(2026, 3, 8, 3, 0, 0, 0, time.UTC), // Sunday
+		Device:    models.Device{IPAddress: "10.0.0.1"},
+	}
+	if a.enrichTrap(&inWindow) {
+		t.Error("trap inside a suppress_traps window was kept")
+	}
+	outside := models.SNMPTrap{
+		Timestamp: time.Date(2026, 3, 9, 3, 0, 0, 0, time.UTC), // Monday
+		Device:    models.Device{IPAddress: "10.0.0.1"},
+	}
+	if !a.enrichTrap(&outside) || outside.Device.Hostname != "core1" {
+		t.Errorf("trap outside the window dropped or not enriched: %+v", outside.Device)
+	}
+}
+
+func TestReportSkip_EmitsSkippedRecord(t *testing.T) {
+	a := New(Config{CollectorID: "c1"}, nil)
+	a.metricCh = make(chan models.SNMPMetric, 1)
+
+	ev := scheduler.SkipEvent{
+		Job: poller.PollJob{
+			Hostname:  "core1",
+			Device:    models.Device{Hostname: "core1", Tags: map[string]string{"site": "dc1"}},
+			ObjectDef: models.ObjectDefinition{Key: "IF-MIB::ifEntry"},
+		},
+		Reason: scheduler.SkipInFlight,
+		Count:  3,
+	}
+	a.reportSkip(ev)
+	a.reportSkip(ev) // channel full: dropped, must not block
+
+	m := <-a.metricCh
+	if m.Metadata.PollStatus != "skipped" || m.Metadata.CollectorID != "c1" {
+		t.Errorf("Metadata = %+v, want skipped from c1", m.Metadata)
+	}
+	if len(m.Metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(m.Metrics))
+	}
+	got := m.Metrics[0]
+	if got.Name != skippedMetricName || got.Value != uint64(3) {
+		t.Errorf("metric = %s %v, want %s 3", got.Name, got.Value, skippedMetricName)
+	}
+	if got.Tags["object"] != "IF-MIB::ifEntry" || got.Tags["reason"] != "in_flight" || got.Tags["site"] != "dc1" {
+		t.Errorf("Tags = %v, want object, reason and device tags", got.Tags)
+	}
+}
+
 func TestPipelineIntegration_metricsFlowToTransport(t *testing.T) {
 	// This test bypasses the poller entirely and injects raw data directly
 	// into the pipeline channels to verify decode → produce → format → transport.
@@ -280,10 +591,293 @@ func TestPipelineIntegration_metricsFlowToTransport(t *testing.T) {
 	}
 }
 
+func TestReplay_RecordedTimestamps(t *testing.T) {
+	paths := writeTestConfig(t)
+	loaded, err := config.Load(paths, slog.Default())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Record one poll result as the decode stage would.
+	capPath := filepath.Join(t.TempDir(), "polls.jsonl.gz")
+	w, err := capture.Create(capPath)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	started := time.Date(2026, 2, 26, 10, 30, 0, 0, time.UTC)
+	if err := w.Write(decoder.RawPollResult{
+		Device:    models.Device{Hostname: "testdevice", IPAddress: "127.0.0.250", SNMPVersion: "2c"},
+		ObjectDef: loaded.ObjectDefs["SNMPv2-MIB::system"],
+		Varbinds: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.1.0", Type: gosnmp.OctetString, Value: []byte("Linux sw01")},
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(123456)},
+		},
+		PollStartedAt: started,
+		CollectedAt:   started.Add(42 * time.Millisecond),
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var buf safeBuffer
+	a := New(Config{
+		ConfigPaths:     paths,
+		BufferSize:      10,
+		TransportWriter: &buf,
+	}, slog.Default())
+	n, err := a.Replay(context.Background(), capPath)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("replayed %d results, want 1", n)
+	}
+
+	var result models.SNMPMetric
+	if err := json.Unmarshal([]byte(firstLine(buf.String())), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\nraw: %s", err, buf.String())
+	}
+	if !result.Timestamp.Equal(started.Add(42 * time.Millisecond)) {
+		t.Errorf("timestamp = %v, want the recorded collection time", result.Timestamp)
+	}
+	if result.Metadata.PollDurationMs != 42 {
+		t.Errorf("poll duration = %dms, want 42", result.Metadata.PollDurationMs)
+	}
+	if len(result.Metrics) == 0 || result.Metrics[0].Name != "sys.uptime" {
+		t.Errorf("metrics = %+v, want sys.uptime from the capture", result.Metrics)
+	}
+}
+
+func TestPollNow_AdhocOIDs(t *testing.T) {
+	port := startAgent(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.2.1.2.2.1.10.3": {Type: gosnmp.Counter32, Value: uint32(4000000000)},
+	})
+	var buf safeBuffer
+	a := New(Config{
+		ConfigPaths:         writeTestConfig(t),
+		PollerWorkers:       1,
+		BufferSize:          10,
+		CounterDeltaEnabled: true,
+		TransportWriter:     &buf,
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	metric, err := a.PollNow(ctx, PollRequest{
+		Device:  &AdhocDevice{IP: "127.0.0.1", Port: port, Communities: []string{"public"}, Timeout: 500},
+		OIDs:    []string{".1.3.6.1.2.1.2.2.1.10.3"},
+		Publish: true,
+	})
+	if err != nil {
+		t.Fatalf("PollNow: %v", err)
+	}
+	if len(metric.Metrics) != 1 || metric.Metrics[0].Name != ".1.3.6.1.2.1.2.2.1.10.3" {
+		t.Fatalf("Metrics = %+v, want one metric named after the OID", metric.Metrics)
+	}
+	// Raw cumulative value even with counter deltas enabled.
+	if got := fmt.Sprint(metric.Metrics[0].Value); got != "4000000000" {
+		t.Errorf("Value = %s, want 4000000000", got)
+	}
+	if metric.Device.Hostname != "127.0.0.1" || metric.Metadata.PollStatus != "on_demand" {
+		t.Errorf("Device = %+v, PollStatus = %q", metric.Device, metric.Metadata.PollStatus)
+	}
+
+	cancel()
+	a.Stop()
+	if !strings.Contains(buf.String(), `"poll_status":"on_demand"`) {
+		t.Errorf("published record missing from transport output: %q", buf.String())
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	port := startAgent(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.2.1.1.1.0": {Type: gosnmp.OctetString, Value: []byte("test agent")},
+		".1.3.6.1.2.1.1.3.0": {Type: gosnmp.TimeTicks, Value: uint32(4200)},
+	})
+	paths := writeTestConfig(t)
+	writeYAML(t, filepath.Join(paths.Devices, "agent.yml"), fmt.Sprintf(`
+agentdev:
+  ip: 127.0.0.1
+  port: %d
+  poll_interval: 3600
+  timeout: 500
+  communities: ["public"]
+  device_groups: ["testgroup"]
+  tags: {site: lab}
+`, port))
+	a := New(Config{ConfigPaths: paths, PollerWorkers: 1, BufferSize: 10, TransportWriter: &safeBuffer{}, RateLimitPerDevice: 100}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer a.Stop()
+	srv := httptest.NewServer(a.AdminHandler())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/v1/poll", "application/json",
+		strings.NewReader(`{"hostname":"agentdev","object":"SNMPv2-MIB::system"}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	var metric models.SNMPMetric
+	err = json.NewDecoder(resp.Body).Decode(&metric)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || err != nil {
+		t.Fatalf("status %d, decode err %v", resp.StatusCode, err)
+	}
+	if len(metric.Metrics) != 1 || metric.Metrics[0].Name != "sys.uptime" ||
+		metric.Metrics[0].Tags["sys.descr"] != "test agent" || metric.Device.Tags["site"] != "lab" {
+		t.Errorf("metric = %+v", metric)
+	}
+
+	resp, err = http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		"snmpcollector_poller_rate_limit_per_device 100\n",
+		"snmpcollector_poller_global_rate_limit 0\n",
+		`snmpcollector_poller_rate_limited_requests_total{limit="device"} 0`,
+		"# TYPE snmpcollector_poller_requests_total counter\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(string(body), "snmpcollector_poller_requests_total 0\n") {
+		t.Errorf("/metrics counts no requests after a poll:\n%s", body)
+	}
+
+	errorCases := []struct {
+		method, body string
+		want         int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, `{"hostname":`, http.StatusBadRequest},
+		{http.MethodPost, `{"hostname":"agentdev","bogus":1}`, http.StatusBadRequest},
+		{http.MethodPost, `{"hostname":"agentdev","device":{"ip":"127.0.0.1"},"oids":["1.3"]}`, http.StatusBadRequest},
+		{http.MethodPost, `{"hostname":"agentdev","object":"X::y","oids":["1.3"]}`, http.StatusBadRequest},
+		{http.MethodPost, `{"hostname":"nope","object":"SNMPv2-MIB::system"}`, http.StatusNotFound},
+		{http.MethodPost, `{"hostname":"agentdev","object":"X::y"}`, http.StatusNotFound},
+		{http.MethodPost, `{"device":{"ip":"127.0.0.1","port":1,"timeout":100,"retries":-1},"oids":["1.3.6.1.2.1.1.3.0"]}`, http.StatusBadGateway},
+	}
+	for _, tc := range errorCases {
+		req, _ := http.NewRequest(tc.method, srv.URL+"/api/v1/poll", strings.NewReader(tc.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.body, err)
+		}
+		var body map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.want || body["error"] == "" {
+			t.Errorf("%s %s: status %d body %v, want %d with an error", tc.method, tc.body, resp.StatusCode, body, tc.want)
+		}
+	}
+}
+
+func TestOIDRegistry_TrapNamesAndAdminLookup(t *testing.T) {
+	enums := metrics.NewEnumRegistry()
+	enums.RegisterOIDEnum("1.3.6.1.4.1.9.1.1208", "cat2960")
+	a := New(Config{}, nil)
+	a.dec = decoder.NewSNMPDecoder(nil)
+	a.setRegistry(&config.LoadedConfig{
+		ObjectDefs: map[string]models.ObjectDefinition{
+			"CISCO-PROCESS-MIB::cpmCPUTotalEntry": {
+				MIB: "CISCO-PROCESS-MIB",
+				Attributes: map[string]models.AttributeDefinition{
+					"cpmCPUTotal5minRev": {OID: ".1.3.6.1.4.1.9.9.109.1.1.1.1.8", Name: "cpu.5min", Syntax: "Gauge32"},
+				},
+			},
+		},
+		Enums: enums,
+	})
+
+	trap := models.SNMPTrap{
+		TrapInfo: models.TrapInfo{TrapOID: ".1.3.6.1.6.3.1.1.5.3"},
+		Varbinds: []models.Metric{
+			{OID: ".1.3.6.1.2.1.2.2.1.8.7", Name: "1.3.6.1.2.1.2.2.1.8.7", Value: int64(2)},
+			{OID: ".1.3.6.1.4.1.9.9.109.1.1.1.1.8.1", Name: "1.3.6.1.4.1.9.9.109.1.1.1.1.8.1", Value: uint64(12)},
+			{OID: ".1.2.3", Name: "1.2.3", Value: int64(1)},
+		},
+	}
+	a.nameTrap(&trap)
+	if trap.TrapInfo.TrapName != "IF-MIB::linkDown" {
+		t.Errorf("TrapName = %q, want IF-MIB::linkDown", trap.TrapInfo.TrapName)
+	}
+	if vb := trap.Varbinds[0]; vb.Name != "IF-MIB::ifOperStatus" || vb.Instance != "7" || vb.Label != "down" {
+		t.Errorf("varbind 0 = %+v, want IF-MIB::ifOperStatus instance 7 label down", vb)
+	}
+	if vb := trap.Varbinds[1]; vb.Name != "CISCO-PROCESS-MIB::cpmCPUTotal5minRev" || vb.Instance != "1" {
+		t.Errorf("varbind 1 = %+v, want CISCO-PROCESS-MIB::cpmCPUTotal5minRev instance 1", vb)
+	}
+	if vb := trap.Varbinds[2]; vb.Name != "1.2.3" || vb.Instance != "" {
+		t.Errorf("unknown varbind renamed: %+v", vb)
+	}
+
+	srv := httptest.NewServer(a.AdminHandler())
+	defer srv.Close()
+	cases := []struct {
+		query    string
+		want     int
+		wantName string
+	}{
+		{"oid=.1.3.6.1.4.1.9.1.1208", http.StatusOK, "cat2960"},
+		{"oid=1.3.6.1.2.1.1.3.0", http.StatusOK, "SNMPv2-MIB::sysUpTime.0"},
+		{"name=IF-MIB::ifDescr.3", http.StatusOK, "IF-MIB::ifDescr.3"},
+		{"name=cpmCPUTotal5minRev", http.StatusOK, "CISCO-PROCESS-MIB::cpmCPUTotal5minRev"},
+		{"name=noSuchObject", http.StatusNotFound, ""},
+		{"oid=.2.999", http.StatusNotFound, ""},
+		{"name=ifDescr.x", http.StatusBadRequest, ""},
+		{"", http.StatusBadRequest, ""},
+		{"oid=.1.3.6.1.2.1.1.3.0&name=ifDescr", http.StatusBadRequest, ""},
+		{"oid=&name=ifDescr", http.StatusBadRequest, ""},
+		{"oid=IF-MIB::ifDescr.3", http.StatusBadRequest, ""},
+		{"name=.1.3.6.1.2.1.1.3.0", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		resp, err := http.Get(srv.URL + "/api/v1/oid?" + tc.query)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.query, err)
+		}
+		var info OIDInfo
+		_ = json.NewDecoder(resp.Body).Decode(&info)
+		resp.Body.Close()
+		if resp.StatusCode != tc.want || info.Name != tc.wantName {
+			t.Errorf("GET %s: status %d name %q, want %d %q", tc.query, resp.StatusCode, info.Name, tc.want, tc.wantName)
+		}
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Utilities
 // ─────────────────────────────────────────────────────────────────────────────
 
+// startAgent runs an snmptest simulator on 127.0.0.1 that answers requests
+// with community "public" from values, keyed by OID with a leading dot. It
+// returns the agent's UDP port.
+func startAgent(t *testing.T, values map[string]gosnmp.SnmpPDU) int {
+	t.Helper()
+	dev := snmptest.NewDevice()
+	for oid, pdu := range values {
+		dev.Set(oid, pdu.Type, pdu.Value)
+	}
+	sim := snmptest.NewSimulator(dev, snmptest.Options{}, nil)
+	if err := sim.Start(); err != nil {
+		t.Fatalf("simulator: %v", err)
+	}
+	t.Cleanup(sim.Stop)
+	return sim.Port()
+}
+
 // safeBuffer is a concurrency-safe bytes.Buffer for use as a transport writer.
 type safeBuffer struct {
 	mu  sync.Mutex
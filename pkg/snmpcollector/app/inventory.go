@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// runInventory re-fetches the inventory sources every InventoryInterval and,
+// when the resolved devices differ from the running ones, applies the new
+// device set the way Reload does. Unchanged devices keep their schedule. It
+// returns when ctx is cancelled.
+func (a *App) runInventory(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.InventoryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		a.inventory.Refresh(ctx)
+
+		a.reloadMu.Lock()
+		newCfg := a.withInventory(a.fileCfg)
+		diff := config.DiffDevices(a.loadedCfg.Devices, newCfg.Devices)
+		if diff.Empty() {
+			a.reloadMu.Unlock()
+			continue
+		}
+		evicted := a.applyConfig(newCfg)
+		a.reloadMu.Unlock()
+
+		a.logger.Info("app: inventory changed",
+			"added", diff.Added,
+			"changed", diff.Changed,
+			"removed", diff.Removed,
+			"devices", len(newCfg.Devices),
+			"sessions_evicted", evicted,
+		)
+	}
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vpbank/snmp_collector/mibs/registry"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// OID name registry
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ErrUnknownOID is returned by LookupOID when no prefix of the OID is known,
+// and by LookupName when no object of the name is.
+var ErrUnknownOID = errors.New("unknown OID")
+
+// OIDInfo is the answer of LookupOID and LookupName.
+type OIDInfo struct {
+	OID         string           `json:"oid"`
+	Name        string           `json:"name"`   // "IF-MIB::ifDescr.3"
+	Object      string           `json:"object"` // "IF-MIB::ifDescr"
+	Instance    string           `json:"instance,omitempty"`
+	Syntax      string           `json:"syntax,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Units       string           `json:"units,omitempty"`
+	Enums       map[int64]string `json:"enums,omitempty"`
+}
+
+// LookupOID names a numeric OID such as ".1.3.6.1.2.1.2.2.1.2.3", using the
+// registry built from the built-in names, the object definitions, the enum
+// files and Config.MIBPaths. Anything but a numeric OID is an error.
+func (a *App) LookupOID(oid string) (OIDInfo, error) {
+	oid = strings.TrimSpace(oid)
+	if !isNumericOID(oid) {
+		return OIDInfo{}, fmt.Errorf("%q is not a numeric OID", oid)
+	}
+	reg := a.mibs.Load()
+	if reg == nil {
+		return OIDInfo{}, fmt.Errorf("%w %q", ErrUnknownOID, oid)
+	}
+	normalised, err := reg.Resolve(oid)
+	if err != nil {
+		return OIDInfo{}, err
+	}
+	return oidInfo(reg, normalised, oid)
+}
+
+// LookupName resolves a name such as "IF-MIB::ifDescr.3", "ifDescr.3" or
+// "ifDescr" to its OID with the registry LookupOID uses, and describes it. A
+// numeric OID is an error: use LookupOID.
+func (a *App) LookupName(name string) (OIDInfo, error) {
+	name = strings.TrimSpace(name)
+	if isNumericOID(name) {
+		return OIDInfo{}, fmt.Errorf("%q is a numeric OID, not a name", name)
+	}
+	reg := a.mibs.Load()
+	if reg == nil {
+		return OIDInfo{}, fmt.Errorf("%w %q", ErrUnknownOID, name)
+	}
+	oid, err := reg.Resolve(name)
+	if errors.Is(err, registry.ErrNotFound) {
+		return OIDInfo{}, fmt.Errorf("%w %q", ErrUnknownOID, name)
+	}
+	if err != nil {
+		return OIDInfo{}, err
+	}
+	return oidInfo(reg, oid, name)
+}
+
+// oidInfo describes oid from reg; query names it in errors.
+func oidInfo(reg *registry.Registry, oid, query string) (OIDInfo, error) {
+	m, ok := reg.Lookup(oid)
+	if !ok {
+		return OIDInfo{}, fmt.Errorf("%w %q", ErrUnknownOID, query)
+	}
+	return OIDInfo{
+		OID:         oid,
+		Name:        m.String(),
+		Object:      m.Name(),
+		Instance:    m.Instance,
+		Syntax:      m.Syntax,
+		Description: m.Description,
+		Units:       m.Units,
+		Enums:       m.Enums,
+	}, nil
+}
+
+// isNumericOID reports whether s is a dotted numeric OID, with or without a
+// leading dot.
+func isNumericOID(s string) bool {
+	s = strings.TrimPrefix(s, ".")
+	if s == "" {
+		return false
+	}
+	for _, arc := range strings.Split(s, ".") {
+		if arc == "" || strings.Trim(arc, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// setRegistry rebuilds the OID name registry from cfg and hands it to the
+// decoder. MIB files that fail to parse or resolve are logged and skipped.
+func (a *App) setRegistry(cfg *config.LoadedConfig) {
+	reg := registry.Builtin()
+	reg.AddObjectDefs(cfg.ObjectDefs)
+	if cfg.Enums != nil {
+		reg.AddEnums(cfg.Enums.IntEnums(), cfg.Enums.OIDEnums())
+	}
+	if len(a.cfg.MIBPaths) > 0 {
+		if err := reg.AddMIBDirs(a.cfg.MIBPaths...); err != nil {
+			a.logger.Warn("app: MIB problems — affected names stay numeric",
+				"mib_paths", strings.Join(a.cfg.MIBPaths, ","),
+				"problems", strings.Count(err.Error(), "\n")+1,
+			)
+			a.logger.Debug("app: MIB problems", "error", err.Error())
+		}
+	}
+	a.mibs.Store(reg)
+	a.dec.SetRegistry(reg)
+	a.logger.Info("app: OID name registry loaded", "names", reg.Len())
+}
+
+// nameTrap replaces the numeric trap OID and varbind names of trap with
+// registry names: TrapName becomes e.g. "IF-MIB::linkDown", and each known
+// varbind gets Name "IF-MIB::ifOperStatus", Instance "3" and, for an
+// enumerated integer, the Label of its value. Unknown OIDs keep their numbers.
+func (a *App) nameTrap(trap *models.SNMPTrap) {
+	reg := a.mibs.Load()
+	if reg == nil {
+		return
+	}
+	if trap.TrapInfo.TrapName == "" && trap.TrapInfo.TrapOID != "" {
+		if m, ok := reg.Lookup(trap.TrapInfo.TrapOID); ok {
+			trap.TrapInfo.TrapName = m.String()
+		}
+	}
+	for i := range trap.Varbinds {
+		vb := &trap.Varbinds[i]
+		m, ok := reg.Lookup(vb.OID)
+		if !ok {
+			continue
+		}
+		vb.Name = m.Name()
+		vb.Instance = m.Instance
+		if v, ok := vb.Value.(int64); ok && vb.Label == "" {
+			vb.Label = m.Enums[v]
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// runConfigWatch scans the configuration directories every WatchInterval and
+// reloads once they differ from the fingerprint current and the change has
+// settled: a new fingerprint must be seen on two
+// consecutive scans, so an editor or deploy tool rewriting several files does
+// not trigger a reload per file. A failed reload is retried only after the
+// files change again. It returns when ctx is cancelled.
+func (a *App) runConfigWatch(ctx context.Context, current string) {
+	pending := ""
+
+	ticker := time.NewTicker(a.cfg.WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fp, err := config.Fingerprint(a.cfg.ConfigPaths)
+		if err != nil {
+			a.logger.Warn("app: config watch scan failed", "error", err.Error())
+			continue
+		}
+		switch {
+		case fp == current:
+			pending = ""
+		case fp != pending:
+			pending = fp // changed; wait one more scan for it to settle
+		default:
+			current, pending = fp, ""
+			a.logger.Info("app: configuration files changed")
+			if err := a.Reload(); err != nil {
+				a.logger.Error("app: reload failed — keeping running configuration", "error", err.Error())
+			}
+		}
+	}
+}
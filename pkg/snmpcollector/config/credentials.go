@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Credential profiles
+// ─────────────────────────────────────────────────────────────────────────────
+
+// CredentialProfile is a named set of connection settings shared by many
+// devices. A device inherits one with `credentials: <name>`; every field the
+// device sets itself wins over the profile's.
+//
+//	corp_v3:
+//	  version: "3"
+//	  timeout: 5000
+//	  v3_credentials:
+//	    - username: monitor
+//	      authentication_protocol: sha256
+//	      authentication_passphrase: ${SNMP_CORP_AUTH}
+//	      privacy_protocol: aes256
+//	      privacy_passphrase: file:/run/secrets/snmp_corp_priv
+type CredentialProfile struct {
+	Version       string          `yaml:"version"`
+	Port          int             `yaml:"port"`
+	Timeout       int             `yaml:"timeout"` // milliseconds
+	Retries       int             `yaml:"retries"`
+	Communities   []string        `yaml:"communities,omitempty"`
+	V3Credentials []V3Credentials `yaml:"v3_credentials,omitempty"`
+}
+
+// apply fills the fields e leaves unset from the profile. Lists are copied
+// so devices never share the profile's backing arrays; a device's own
+// communities or v3_credentials replace the profile's list as a whole.
+func (p CredentialProfile) apply(e rawDeviceEntry) rawDeviceEntry {
+	if e.Version == "" {
+		e.Version = p.Version
+	}
+	if e.Port == 0 {
+		e.Port = p.Port
+	}
+	if e.Timeout == 0 {
+		e.Timeout = p.Timeout
+	}
+	if e.Retries == 0 {
+		e.Retries = p.Retries
+	}
+	if len(e.Communities) == 0 {
+		e.Communities = slices.Clone(p.Communities)
+	}
+	if len(e.V3Credentials) == 0 {
+		e.V3Credentials = slices.Clone(p.V3Credentials)
+	}
+	return e
+}
+
+func loadCredentials(dir string, logger *slog.Logger) (map[string]CredentialProfile, error) {
+	result := make(map[string]CredentialProfile)
+	files, err := yamlFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, fmt.Errorf("list credentials dir %q: %w", dir, err)
+	}
+
+	for _, path := range files {
+		var raw map[string]CredentialProfile
+		if err := decodeFile(path, &raw); err != nil {
+			logger.Warn("config: skip malformed credentials file", "file", path, "error", err.Error())
+			continue
+		}
+		for name, p := range raw {
+			if err := resolveSecretFields(p.Communities, p.V3Credentials); err != nil {
+				logger.Warn("config: skip credential profile with unresolved secret", "file", path, "profile", name, "error", err.Error())
+				continue
+			}
+			result[name] = p
+		}
+		logger.Debug("config: loaded credentials file", "file", path, "count", len(raw))
+	}
+	return result, nil
+}
@@ -1,8 +1,17 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/credentials"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
+	"github.com/vpbank/snmp_collector/producer/metrics"
+)
+
 // DeviceConfig is the fully-resolved configuration for a single monitored device.
-// Optional fields that are zero-valued in the YAML are filled with hard-coded
-// fallbacks during resolution.
+// Optional fields that are zero-valued in the YAML are inherited from the
+// device's templates, credential profile and the defaults template, then
+// filled with hard-coded fallbacks during resolution.
 type DeviceConfig struct {
 	// IP is the management IP address of the device.
 	IP string
@@ -26,60 +35,170 @@ type DeviceConfig struct {
 	Version string
 
 	// Communities is the list of community strings to try (v1/v2c only).
+	// Secret references in the YAML are resolved by Load.
 	Communities []string
 
 	// V3Credentials is the list of SNMPv3 credential sets to try (v3 only).
 	V3Credentials []V3Credentials
 
 	// DeviceGroups lists the device group names applied to this device.
+	// After Load it is the effective list, appends included.
 	DeviceGroups []string
 
+	// DeviceGroupsAppend lists groups added to the inherited DeviceGroups
+	// rather than replacing them. Load folds it into DeviceGroups; it is only
+	// set on entries returned by ReadDeviceFile.
+	DeviceGroupsAppend []string
+
 	// MaxConcurrentPolls limits how many concurrent SNMP requests may be
 	// in-flight to this device at any time (default 4).
 	MaxConcurrentPolls int
+
+	// MaxOids is the number of OIDs per Get request and the number of
+	// columns per GetBulk request (default 60).
+	MaxOids int
+
+	// MaxRepetitions is the GetBulk max-repetitions of BulkWalk and GetBulk
+	// polls (default 50). A GetBulk of several columns splits it between
+	// them, so a response carries about as many varbinds as a BulkWalk's.
+	MaxRepetitions int
+
+	// AdaptiveBulk tunes max-repetitions per device: a tooBig response or a
+	// timeout halves it, a successful poll grows it back towards
+	// MaxRepetitions. The ConnectionPool remembers the value between polls.
+	AdaptiveBulk bool
+
+	// Tags are static labels (site, role, tenant, …) attached to every
+	// metric and trap from this device. They override tags inherited from
+	// the device's groups; see LoadedConfig.DeviceTags.
+	Tags map[string]string
+
+	// Schedule, when set, adds cron fire times and allow / block windows on
+	// top of PollInterval. Block windows may also suppress the device's traps.
+	Schedule *schedule.Spec
+
+	// Credentials names the CredentialProfile the device inherits version,
+	// port, timeout, retries and credentials from. Load has already merged it.
+	Credentials string
+
+	// Extends names the device template the device inherits unset fields
+	// from. Load has already merged the whole template chain.
+	Extends string
 }
 
 // V3Credentials holds a single set of SNMPv3 security parameters.
 type V3Credentials struct {
 	// Username is the SNMPv3 security name.
-	Username string `yaml:"username"`
+	Username string `yaml:"username" json:"username,omitempty"`
 
 	// AuthenticationProtocol is one of: noauth, md5, sha, sha224, sha256, sha384, sha512.
-	AuthenticationProtocol string `yaml:"authentication_protocol"`
+	AuthenticationProtocol string `yaml:"authentication_protocol" json:"authentication_protocol,omitempty"`
 
 	// AuthenticationPassphrase is the passphrase for the chosen auth protocol.
-	AuthenticationPassphrase string `yaml:"authentication_passphrase"`
+	// Like PrivacyPassphrase it may be a secret reference in device files.
+	AuthenticationPassphrase string `yaml:"authentication_passphrase" json:"authentication_passphrase,omitempty"`
 
 	// PrivacyProtocol is one of: nopriv, des, aes, aes192, aes256, aes192c, aes256c.
-	PrivacyProtocol string `yaml:"privacy_protocol"`
+	PrivacyProtocol string `yaml:"privacy_protocol" json:"privacy_protocol,omitempty"`
 
 	// PrivacyPassphrase is the passphrase for the chosen privacy protocol.
-	PrivacyPassphrase string `yaml:"privacy_passphrase"`
+	PrivacyPassphrase string `yaml:"privacy_passphrase" json:"privacy_passphrase,omitempty"`
 }
 
 // DeviceGroup lists the object group names applied to devices in this group.
 type DeviceGroup struct {
 	ObjectGroups []string
+
+	// Tags are static labels inherited by every device in the group.
+	Tags map[string]string
 }
 
 // ObjectGroup lists the object definition keys that belong to this group.
 type ObjectGroup struct {
 	Objects []string
+
+	// PollInterval, when > 0, overrides the device poll interval for the
+	// objects in this group, in seconds. An object's own poll_interval takes
+	// precedence over it.
+	PollInterval int
+
+	// Schedule, when set, applies to the objects in this group on top of the
+	// device's schedule: both sets of windows apply, and its cron expression
+	// replaces the device's.
+	Schedule *schedule.Spec
 }
 
 // rawDeviceEntry is the intermediate YAML-decoded form of a single device.
-// It maps 1-to-1 with the device YAML schema. Hard-coded fallbacks are applied
-// for zero-valued fields during resolution.
+// It maps 1-to-1 with the device YAML schema, which device templates share.
+// Zero-valued fields are inherited or filled with hard-coded fallbacks during
+// resolution; ExponentialTimeout and AdaptiveBulk are pointers so an
+// explicit false overrides a template.
 type rawDeviceEntry struct {
-	IP                 string          `yaml:"ip"`
-	Port               int             `yaml:"port"`
-	PollInterval       int             `yaml:"poll_interval"`
-	Timeout            int             `yaml:"timeout"`
-	Retries            int             `yaml:"retries"`
-	ExponentialTimeout bool            `yaml:"exponential_timeout"`
-	Version            string          `yaml:"version"`
-	Communities        []string        `yaml:"communities"`
-	V3Credentials      []V3Credentials `yaml:"v3_credentials"`
-	DeviceGroups       []string        `yaml:"device_groups"`
-	MaxConcurrentPolls int             `yaml:"max_concurrent_polls"`
+	IP                 string            `yaml:"ip,omitempty"`
+	Extends            string            `yaml:"extends,omitempty"`
+	Credentials        string            `yaml:"credentials,omitempty"`
+	Port               int               `yaml:"port,omitempty"`
+	PollInterval       int               `yaml:"poll_interval,omitempty"`
+	Timeout            int               `yaml:"timeout,omitempty"`
+	Retries            int               `yaml:"retries,omitempty"`
+	ExponentialTimeout *bool             `yaml:"exponential_timeout,omitempty"`
+	Version            string            `yaml:"version,omitempty"`
+	Communities        []string          `yaml:"communities,omitempty"`
+	V3Credentials      []V3Credentials   `yaml:"v3_credentials,omitempty"`
+	DeviceGroups       []string          `yaml:"device_groups,omitempty"`
+	DeviceGroupsAppend []string          `yaml:"device_groups_append,omitempty"`
+	MaxConcurrentPolls int               `yaml:"max_concurrent_polls,omitempty"`
+	MaxOids            int               `yaml:"max_oids,omitempty"`
+	MaxRepetitions     int               `yaml:"max_repetitions,omitempty"`
+	AdaptiveBulk       *bool             `yaml:"adaptive_bulk,omitempty"`
+	Tags               map[string]string `yaml:"tags,omitempty"`
+	Schedule           *schedule.Spec    `yaml:"schedule,omitempty"`
+}
+
+// resolveSecrets replaces secret references (see package credentials) in e's
+// communities and v3 passphrases with their values. Errors name the field but
+// never carry a secret.
+func resolveSecrets(e *rawDeviceEntry) error {
+	return resolveSecretFields(e.Communities, e.V3Credentials)
+}
+
+// resolveSecretFields resolves communities and v3 passphrases in place.
+func resolveSecretFields(communities []string, v3 []V3Credentials) error {
+	for i, c := range communities {
+		v, err := credentials.Resolve(c)
+		if err != nil {
+			return fmt.Errorf("communities[%d]: %w", i, err)
+		}
+		communities[i] = v
+	}
+	for i := range v3 {
+		cred := &v3[i]
+		for _, f := range []struct {
+			name  string
+			value *string
+		}{
+			{"authentication_passphrase", &cred.AuthenticationPassphrase},
+			{"privacy_passphrase", &cred.PrivacyPassphrase},
+		} {
+			v, err := credentials.Resolve(*f.value)
+			if err != nil {
+				return fmt.Errorf("v3_credentials[%d].%s: %w", i, f.name, err)
+			}
+			*f.value = v
+		}
+	}
+	return nil
+}
+
+// DeviceTags returns the static tags for dev: the tags of each of its device
+// groups in listed order (a later group overrides an earlier one), overridden
+// by the device's own tags. Unknown groups contribute nothing. It returns nil
+// when no tags apply.
+func (c *LoadedConfig) DeviceTags(dev DeviceConfig) map[string]string {
+	layers := make([]map[string]string, 0, len(dev.DeviceGroups)+1)
+	for _, name := range dev.DeviceGroups {
+		layers = append(layers, c.DeviceGroups[name].Tags)
+	}
+	layers = append(layers, dev.Tags)
+	return metrics.MergeTags(layers...)
 }
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Device files — read / write
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ReadDeviceFile decodes a single device YAML file and returns every entry as
+// written, so a rewritten file stays equivalent to the original: secret
+// references are kept, and fields left unset stay zero so the device goes on
+// inheriting them from its templates, credential profile and the defaults
+// template. A missing file yields an empty map and the os.IsNotExist error.
+func ReadDeviceFile(path string) (map[string]DeviceConfig, error) {
+	result := make(map[string]DeviceConfig)
+	var raw map[string]rawDeviceEntry
+	if err := decodeFile(path, &raw); err != nil {
+		return result, err
+	}
+	for hostname, entry := range raw {
+		result[hostname] = entry.deviceConfig()
+	}
+	return result, nil
+}
+
+// EncodeDevices renders devices in the INPUT_SNMP_DEVICE_DEFINITIONS_DIRECTORY_PATH
+// file format, keyed by hostname in sorted order.
+func EncodeDevices(devices map[string]DeviceConfig) ([]byte, error) {
+	raw := make(map[string]rawDeviceEntry, len(devices))
+	for hostname, d := range devices {
+		raw[hostname] = rawEntry(d)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(raw); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rawEntry converts d back to the device YAML schema. Lists are copied, so
+// resolving secrets in the result leaves d untouched.
+func rawEntry(d DeviceConfig) rawDeviceEntry {
+	e := rawDeviceEntry{
+		IP:                 d.IP,
+		Extends:            d.Extends,
+		Port:               d.Port,
+		PollInterval:       d.PollInterval,
+		Timeout:            d.Timeout,
+		Retries:            d.Retries,
+		Version:            d.Version,
+		Communities:        slices.Clone(d.Communities),
+		V3Credentials:      slices.Clone(d.V3Credentials),
+		DeviceGroups:       d.DeviceGroups,
+		DeviceGroupsAppend: d.DeviceGroupsAppend,
+		MaxConcurrentPolls: d.MaxConcurrentPolls,
+		MaxOids:            d.MaxOids,
+		MaxRepetitions:     d.MaxRepetitions,
+		Tags:               d.Tags,
+		Schedule:           d.Schedule,
+		Credentials:        d.Credentials,
+	}
+	if d.ExponentialTimeout {
+		e.ExponentialTimeout = &d.ExponentialTimeout
+	}
+	if d.AdaptiveBulk {
+		e.AdaptiveBulk = &d.AdaptiveBulk
+	}
+	return e
+}
+
+// WriteDeviceFile writes devices to path atomically: the content goes to a
+// temporary file in the same directory which then replaces path. The file
+// holds communities and v3 passphrases, so it is readable by its owner only.
+func WriteDeviceFile(path string, devices map[string]DeviceConfig) error {
+	data, err := EncodeDevices(devices)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	// A leftover temporary file would keep its old, possibly wider, mode.
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
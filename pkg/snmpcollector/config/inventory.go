@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Inventory sources
+// ─────────────────────────────────────────────────────────────────────────────
+
+// InventorySource supplies devices from an external system of record, such
+// as a CSV export, an HTTP endpoint or NetBox, alongside the device files.
+type InventorySource interface {
+	// Name identifies the source in logs, e.g. "csv:/etc/inventory.csv".
+	Name() string
+
+	// Devices returns the source's devices keyed by hostname, as written:
+	// fields left zero are inherited from the device's templates, credential
+	// profile and the defaults template when LoadedConfig.WithInventory
+	// resolves them, exactly as for a device file entry.
+	Devices(ctx context.Context) (map[string]DeviceConfig, error)
+}
+
+// Inventory fetches devices from a list of sources and remembers the last
+// successful result of each, so a source that is briefly unreachable does not
+// remove its devices. Devices is safe to call concurrently with Refresh;
+// Refresh itself must not run concurrently.
+type Inventory struct {
+	sources []InventorySource
+	logger  *slog.Logger
+	last    []map[string]DeviceConfig // per source, nil until first success
+
+	mu     sync.Mutex
+	merged map[string]DeviceConfig
+}
+
+// NewInventory returns an Inventory over sources. Call Refresh to fetch.
+func NewInventory(sources []InventorySource, logger *slog.Logger) *Inventory {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
+	}
+	return &Inventory{
+		sources: sources,
+		logger:  logger,
+		last:    make([]map[string]DeviceConfig, len(sources)),
+	}
+}
+
+// Refresh fetches every source and returns the merged devices: when two
+// sources define the same hostname the earlier source wins. A failing source
+// is logged and keeps its previous devices.
+func (inv *Inventory) Refresh(ctx context.Context) map[string]DeviceConfig {
+	for i, src := range inv.sources {
+		devices, err := src.Devices(ctx)
+		if err != nil {
+			inv.logger.Warn("config: inventory source failed — keeping its previous devices",
+				"source", src.Name(), "error", err.Error())
+			continue
+		}
+		inv.last[i] = devices
+		inv.logger.Debug("config: inventory source fetched", "source", src.Name(), "devices", len(devices))
+	}
+
+	merged := make(map[string]DeviceConfig)
+	for i, devices := range inv.last {
+		for hostname, d := range devices {
+			if _, ok := merged[hostname]; ok {
+				inv.logger.Warn("config: duplicate inventory hostname — keeping the earlier source's",
+					"source", inv.sources[i].Name(), "hostname", hostname)
+				continue
+			}
+			merged[hostname] = d
+		}
+	}
+
+	inv.mu.Lock()
+	inv.merged = merged
+	inv.mu.Unlock()
+	return maps.Clone(merged)
+}
+
+// Devices returns the merged devices of the last Refresh.
+func (inv *Inventory) Devices() map[string]DeviceConfig {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return maps.Clone(inv.merged)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Merging inventory into a loaded configuration
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ResolveDevice resolves d, a device as written, the way Load resolves a
+// device file entry: secret references, then its template chain, credential
+// profile and the defaults template from c, then the hard-coded fallbacks.
+func (c *LoadedConfig) ResolveDevice(d DeviceConfig) (DeviceConfig, error) {
+	e := rawEntry(d)
+	if err := validSchedule(e.Schedule); err != nil {
+		return DeviceConfig{}, err
+	}
+	if err := resolveSecrets(&e); err != nil {
+		return DeviceConfig{}, err
+	}
+	return resolveDevice(e, c.templates, c.Credentials)
+}
+
+// WithInventory returns a copy of c whose Devices also hold devices, each
+// resolved with ResolveDevice. Devices from c win over inventory devices of
+// the same hostname, and devices that fail to resolve are logged and skipped.
+// c itself is not modified.
+func (c *LoadedConfig) WithInventory(devices map[string]DeviceConfig, logger *slog.Logger) *LoadedConfig {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
+	}
+	out := *c
+	out.Devices = maps.Clone(c.Devices)
+	if out.Devices == nil {
+		out.Devices = make(map[string]DeviceConfig, len(devices))
+	}
+	for hostname, d := range devices {
+		if _, ok := c.Devices[hostname]; ok {
+			logger.Debug("config: inventory device shadowed by a device file", "hostname", hostname)
+			continue
+		}
+		dev, err := c.ResolveDevice(d)
+		if err != nil {
+			logger.Warn("config: skip inventory device", "hostname", hostname, "error", err.Error())
+			continue
+		}
+		out.Devices[hostname] = dev
+	}
+	return &out
+}
+
+// DeviceDiff lists the hostnames that differ between two device maps, each
+// sorted.
+type DeviceDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// Empty reports whether the two maps were equal.
+func (d DeviceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// DiffDevices compares the device maps before and after a refresh.
+func DiffDevices(before, after map[string]DeviceConfig) DeviceDiff {
+	var d DeviceDiff
+	for hostname, dev := range after {
+		old, ok := before[hostname]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, hostname)
+		case !reflect.DeepEqual(old, dev):
+			d.Changed = append(d.Changed, hostname)
+		}
+	}
+	for hostname := range before {
+		if _, ok := after[hostname]; !ok {
+			d.Removed = append(d.Removed, hostname)
+		}
+	}
+	slices.Sort(d.Added)
+	slices.Sort(d.Changed)
+	slices.Sort(d.Removed)
+	return d
+}
@@ -0,0 +1,446 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/credentials"
+)
+
+// maxInventoryBody bounds the response read from an HTTP inventory source.
+const maxInventoryBody = 64 << 20
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CSV
+// ─────────────────────────────────────────────────────────────────────────────
+
+// CSVSource reads devices from a CSV file with a header row. Columns are
+// named after the device YAML keys plus hostname:
+//
+//	hostname,ip,extends,credentials,device_groups,tag.site,tag.role
+//	core-r1,10.0.0.1,core,corp_v3,,hcm-dc1,core
+//	sw-07,10.1.0.7,access,,access_switches;lldp,hcm-dc1,access
+//
+// hostname and ip are required; every other column is optional. List columns
+// (communities, device_groups, device_groups_append) separate items with ";",
+// and each tag.<key> column sets one tag. Empty cells are left unset, so the
+// device inherits them. An unknown column is an error, to catch typos.
+type CSVSource struct {
+	Path string
+}
+
+// Name implements InventorySource.
+func (s CSVSource) Name() string { return "csv:" + s.Path }
+
+// Devices implements InventorySource. The file is re-read on every call.
+func (s CSVSource) Devices(_ context.Context) (map[string]DeviceConfig, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseCSVInventory(f)
+}
+
+func parseCSVInventory(r io.Reader) (map[string]DeviceConfig, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return map[string]DeviceConfig{}, nil
+		}
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	for _, col := range []string{"hostname", "ip"} {
+		if !slices.Contains(header, col) {
+			return nil, fmt.Errorf("csv: missing %q column", col)
+		}
+	}
+
+	result := make(map[string]DeviceConfig)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		var hostname string
+		var d DeviceConfig
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			if header[i] == "hostname" {
+				hostname = cell
+				continue
+			}
+			if err := setCSVField(&d, header[i], cell); err != nil {
+				return nil, fmt.Errorf("csv line %d: %w", line, err)
+			}
+		}
+		switch {
+		case hostname == "":
+			return nil, fmt.Errorf("csv line %d: hostname is required", line)
+		case d.IP == "":
+			return nil, fmt.Errorf("csv line %d: device %q: ip is required", line, hostname)
+		}
+		if _, ok := result[hostname]; ok {
+			return nil, fmt.Errorf("csv line %d: device %q is defined twice", line, hostname)
+		}
+		result[hostname] = d
+	}
+	return result, nil
+}
+
+// setCSVField sets the field of d named by a CSV column.
+func setCSVField(d *DeviceConfig, column, cell string) error {
+	if key, ok := strings.CutPrefix(column, "tag."); ok && key != "" {
+		if d.Tags == nil {
+			d.Tags = make(map[string]string)
+		}
+		d.Tags[key] = cell
+		return nil
+	}
+
+	ints := map[string]*int{
+		"port":                 &d.Port,
+		"poll_interval":        &d.PollInterval,
+		"timeout":              &d.Timeout,
+		"retries":              &d.Retries,
+		"max_concurrent_polls": &d.MaxConcurrentPolls,
+		"max_oids":             &d.MaxOids,
+		"max_repetitions":      &d.MaxRepetitions,
+	}
+	if p, ok := ints[column]; ok {
+		n, err := strconv.Atoi(cell)
+		if err != nil {
+			return fmt.Errorf("%s %q is not an integer", column, cell)
+		}
+		*p = n
+		return nil
+	}
+
+	switch column {
+	case "ip":
+		d.IP = cell
+	case "version":
+		d.Version = cell
+	case "extends":
+		d.Extends = cell
+	case "credentials":
+		d.Credentials = cell
+	case "exponential_timeout":
+		v, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("exponential_timeout %q is not a boolean", cell)
+		}
+		d.ExponentialTimeout = v
+	case "adaptive_bulk":
+		v, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("adaptive_bulk %q is not a boolean", cell)
+		}
+		d.AdaptiveBulk = v
+	case "communities":
+		d.Communities = splitList(cell)
+	case "device_groups":
+		d.DeviceGroups = splitList(cell)
+	case "device_groups_append":
+		d.DeviceGroupsAppend = splitList(cell)
+	default:
+		return fmt.Errorf("unknown column %q", column)
+	}
+	return nil
+}
+
+// splitList splits a ";"-separated cell, dropping empty items.
+func splitList(cell string) []string {
+	var out []string
+	for _, item := range strings.Split(cell, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// HTTP JSON endpoint
+// ─────────────────────────────────────────────────────────────────────────────
+
+// HTTPSource fetches devices from an HTTP endpoint returning JSON in the
+// device file schema, either keyed by hostname or as a list with a hostname
+// field:
+//
+//	{"core-r1": {"ip": "10.0.0.1", "extends": "core", "tags": {"site": "hcm"}}}
+//	[{"hostname": "core-r1", "ip": "10.0.0.1", "extends": "core"}]
+type HTTPSource struct {
+	URL string
+
+	// Token, when set, is sent as "Authorization: Bearer <token>". It may be
+	// a secret reference (see package credentials).
+	Token string
+
+	// Header is sent with every request.
+	Header http.Header
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Name implements InventorySource.
+func (s HTTPSource) Name() string { return "http:" + s.URL }
+
+// Devices implements InventorySource.
+func (s HTTPSource) Devices(ctx context.Context) (map[string]DeviceConfig, error) {
+	header := s.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if s.Token != "" {
+		token, err := credentials.Resolve(s.Token)
+		if err != nil {
+			return nil, fmt.Errorf("http inventory token: %w", err)
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+	body, err := getJSON(ctx, s.Client, s.URL, header)
+	if err != nil {
+		return nil, err
+	}
+	return decodeHTTPInventory(body)
+}
+
+func decodeHTTPInventory(body []byte) (map[string]DeviceConfig, error) {
+	result := make(map[string]DeviceConfig)
+	trimmed := bytes.TrimSpace(body)
+	// JSON is YAML, so the device file decoder and its schema apply as is.
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var list []struct {
+			Hostname       string `yaml:"hostname"`
+			rawDeviceEntry `yaml:",inline"`
+		}
+		if err := yaml.Unmarshal(trimmed, &list); err != nil {
+			return nil, err
+		}
+		for i, item := range list {
+			if item.Hostname == "" {
+				return nil, fmt.Errorf("device %d: hostname is required", i)
+			}
+			result[item.Hostname] = item.deviceConfig()
+		}
+		return result, nil
+	}
+	var raw map[string]rawDeviceEntry
+	if err := yaml.Unmarshal(trimmed, &raw); err != nil {
+		return nil, err
+	}
+	for hostname, entry := range raw {
+		result[hostname] = entry.deviceConfig()
+	}
+	return result, nil
+}
+
+// getJSON GETs target and returns the body of a 200 response.
+func getJSON(ctx context.Context, client *http.Client, target string, header http.Header) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInventoryBody))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return body, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// NetBox
+// ─────────────────────────────────────────────────────────────────────────────
+
+// NetBox custom fields read by NetBoxSource. Each is optional.
+const (
+	NetBoxFieldDeviceGroups = "snmp_device_groups" // list or comma-separated text
+	NetBoxFieldTemplate     = "snmp_template"      // device template name → extends
+	NetBoxFieldCredentials  = "snmp_credentials"   // credential profile name
+)
+
+// NetBoxSource reads devices from the NetBox REST API (/api/dcim/devices/),
+// following pagination. A device is used when it has a name and a primary IP
+// address. Its site, role, tenant, platform and manufacturer slugs become
+// tags of those names, and the custom fields named by the NetBoxField*
+// constants supply device groups, template and credential profile; anything
+// else comes from the device's template and the defaults template.
+type NetBoxSource struct {
+	// URL is the NetBox base URL, e.g. https://netbox.example.com.
+	URL string
+
+	// Token is the API token. It may be a secret reference.
+	Token string
+
+	// Filter is added to the device query, e.g. status=active&site=hcm.
+	Filter url.Values
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Name implements InventorySource.
+func (s NetBoxSource) Name() string { return "netbox:" + s.URL }
+
+type netboxRef struct {
+	Slug string `json:"slug"`
+}
+
+type netboxIP struct {
+	Address string `json:"address"`
+}
+
+type netboxDevice struct {
+	Name       string     `json:"name"`
+	PrimaryIP4 *netboxIP  `json:"primary_ip4"`
+	PrimaryIP  *netboxIP  `json:"primary_ip"`
+	Site       *netboxRef `json:"site"`
+	Role       *netboxRef `json:"role"`
+	DeviceRole *netboxRef `json:"device_role"` // NetBox < 4.0
+	Tenant     *netboxRef `json:"tenant"`
+	Platform   *netboxRef `json:"platform"`
+	DeviceType *struct {
+		Manufacturer *netboxRef `json:"manufacturer"`
+	} `json:"device_type"`
+	CustomFields map[string]any `json:"custom_fields"`
+}
+
+// Devices implements InventorySource.
+func (s NetBoxSource) Devices(ctx context.Context) (map[string]DeviceConfig, error) {
+	header := http.Header{}
+	if s.Token != "" {
+		token, err := credentials.Resolve(s.Token)
+		if err != nil {
+			return nil, fmt.Errorf("netbox token: %w", err)
+		}
+		header.Set("Authorization", "Token "+token)
+	}
+	query := url.Values{"limit": {"1000"}}
+	for key, values := range s.Filter {
+		query[key] = values
+	}
+	next := strings.TrimSuffix(s.URL, "/") + "/api/dcim/devices/?" + query.Encode()
+
+	result := make(map[string]DeviceConfig)
+	for next != "" {
+		body, err := getJSON(ctx, s.Client, next, header)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Next    *string        `json:"next"`
+			Results []netboxDevice `json:"results"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("netbox: %w", err)
+		}
+		for _, nd := range page.Results {
+			if d, ok := nd.deviceConfig(); ok {
+				result[nd.Name] = d
+			}
+		}
+		next = ""
+		if page.Next != nil {
+			next = *page.Next
+		}
+	}
+	return result, nil
+}
+
+// deviceConfig maps a NetBox device; ok is false when it has no name or
+// primary IP address.
+func (nd netboxDevice) deviceConfig() (DeviceConfig, bool) {
+	ip := nd.PrimaryIP4
+	if ip == nil {
+		ip = nd.PrimaryIP
+	}
+	if nd.Name == "" || ip == nil || ip.Address == "" {
+		return DeviceConfig{}, false
+	}
+	addr, _, _ := strings.Cut(ip.Address, "/")
+	d := DeviceConfig{IP: addr}
+
+	role := nd.Role
+	if role == nil {
+		role = nd.DeviceRole
+	}
+	var manufacturer *netboxRef
+	if nd.DeviceType != nil {
+		manufacturer = nd.DeviceType.Manufacturer
+	}
+	for _, t := range []struct {
+		key string
+		ref *netboxRef
+	}{
+		{"site", nd.Site},
+		{"role", role},
+		{"tenant", nd.Tenant},
+		{"platform", nd.Platform},
+		{"manufacturer", manufacturer},
+	} {
+		if t.ref == nil || t.ref.Slug == "" {
+			continue
+		}
+		if d.Tags == nil {
+			d.Tags = make(map[string]string)
+		}
+		d.Tags[t.key] = t.ref.Slug
+	}
+
+	switch groups := nd.CustomFields[NetBoxFieldDeviceGroups].(type) {
+	case []any:
+		for _, g := range groups {
+			if s, ok := g.(string); ok && s != "" {
+				d.DeviceGroups = append(d.DeviceGroups, s)
+			}
+		}
+	case string:
+		d.DeviceGroups = splitList(strings.ReplaceAll(groups, ",", ";"))
+	}
+	d.Extends, _ = nd.CustomFields[NetBoxFieldTemplate].(string)
+	d.Credentials, _ = nd.CustomFields[NetBoxFieldCredentials].(string)
+	return d, true
+}
@@ -8,9 +8,30 @@
 //	INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH → ObjectGroups map
 //	INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH     → ObjectDefs map
 //	PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH   → EnumRegistry
+//
+// Optional trees match devices by their SNMPv2-MIB system group:
+//
+//	INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH         → Vendors
+//	INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH → Profiles
+//
+// and two supply settings devices inherit:
+//
+//	INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH      → Credentials
+//	INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH → device templates
+//
+// Device communities and v3 passphrases may be secret references
+// ("${VAR}", "file:/run/secrets/…", or a scheme registered with package
+// credentials); Load resolves them on every call, so a reload picks up
+// rotated secrets.
+//
+// Validate checks the same trees without loading them and reports every
+// problem with its file and line.
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
@@ -22,6 +43,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
 	"github.com/vpbank/snmp_collector/producer/metrics"
 )
 
@@ -36,6 +58,10 @@ type Paths struct {
 	ObjectGroups string // INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH
 	Objects      string // INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH
 	Enums        string // PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH
+	Vendors      string // INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH
+	Profiles     string // INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH
+	Credentials  string // INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH
+	Templates    string // INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH
 }
 
 // PathsFromEnv reads each path from its environment variable, falling back to
@@ -47,7 +73,49 @@ func PathsFromEnv() Paths {
 		ObjectGroups: envOr("INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/object_groups"),
 		Objects:      envOr("INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/objects"),
 		Enums:        envOr("PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/enums"),
+		Vendors:      envOr("INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/vendors"),
+		Profiles:     envOr("INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/device_profiles"),
+		Credentials:  envOr("INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/credentials"),
+		Templates:    envOr("INPUT_SNMP_DEVICE_TEMPLATE_DEFINITIONS_DIRECTORY_PATH", "/etc/snmp_collector/snmp/device_templates"),
+	}
+}
+
+// Dirs returns the configured directories in load order, skipping empty ones.
+func (p Paths) Dirs() []string {
+	var dirs []string
+	for _, d := range []string{p.Credentials, p.Templates, p.Devices, p.DeviceGroups, p.ObjectGroups, p.Objects, p.Enums, p.Vendors, p.Profiles} {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// Fingerprint summarises the YAML files under every directory of paths by
+// path, size and modification time, so a watcher can detect edits without
+// parsing anything. Missing directories contribute nothing.
+func Fingerprint(paths Paths) (string, error) {
+	h := sha256.New()
+	for _, dir := range paths.Dirs() {
+		files, err := yamlFiles(dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		for _, f := range files {
+			info, err := os.Stat(f)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue // removed mid-scan; the next scan sees it gone
+				}
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00%d\n", f, info.Size(), info.ModTime().UnixNano())
+		}
 	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func envOr(key, def string) string {
@@ -78,6 +146,22 @@ type LoadedConfig struct {
 	// Enums is the populated EnumRegistry ready for the producer.
 	// nil when the enums directory is empty or does not exist.
 	Enums *metrics.EnumRegistry
+
+	// Vendors maps sysObjectID prefixes to vendor / model names.
+	// Empty (never nil) when the vendors directory does not exist.
+	Vendors *VendorMap
+
+	// Profiles selects device groups for devices declaring
+	// `device_groups: [auto]`. Empty (never nil) when the directory does not exist.
+	Profiles *ProfileRules
+
+	// Credentials maps profile name → CredentialProfile. Devices referencing
+	// a profile already have it merged into their DeviceConfig.
+	Credentials map[string]CredentialProfile
+
+	// templates maps device template name → entry, kept so ResolveDevice can
+	// resolve devices from inventory sources as Load resolves files.
+	templates map[string]rawDeviceEntry
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -97,8 +181,19 @@ func Load(paths Paths, logger *slog.Logger) (*LoadedConfig, error) {
 
 	var errs []string
 
+	// 0. Credential profiles and device templates ————————————————————————————
+	creds, err := loadCredentials(paths.Credentials, logger)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	templates, err := loadTemplates(paths.Templates, logger)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
 	// 1. Devices ——————————————————————————————————————————————————————————————
-	devices, err := loadDevices(paths.Devices, logger)
+	devices, err := loadDevices(paths.Devices, templates, creds, logger)
 	if err != nil {
 		errs = append(errs, err.Error())
 	}
@@ -127,6 +222,18 @@ func Load(paths Paths, logger *slog.Logger) (*LoadedConfig, error) {
 		errs = append(errs, err.Error())
 	}
 
+	// 6. Vendor definitions ——————————————————————————————————————————————————
+	vendors, err := loadVendors(paths.Vendors, logger)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	// 7. Device profiles ————————————————————————————————————————————————————
+	profiles, err := loadProfiles(paths.Profiles, logger)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("config: %d error(s):\n  %s", len(errs), strings.Join(errs, "\n  "))
 	}
@@ -137,6 +244,10 @@ func Load(paths Paths, logger *slog.Logger) (*LoadedConfig, error) {
 		ObjectGroups: ogroups,
 		ObjectDefs:   objDefs,
 		Enums:        enumReg,
+		Vendors:      vendors,
+		Profiles:     profiles,
+		Credentials:  creds,
+		templates:    templates,
 	}, nil
 }
 
@@ -144,7 +255,7 @@ func Load(paths Paths, logger *slog.Logger) (*LoadedConfig, error) {
 // Devices
 // ─────────────────────────────────────────────────────────────────────────────
 
-func loadDevices(dir string, logger *slog.Logger) (map[string]DeviceConfig, error) {
+func loadDevices(dir string, templates map[string]rawDeviceEntry, creds map[string]CredentialProfile, logger *slog.Logger) (map[string]DeviceConfig, error) {
 	result := make(map[string]DeviceConfig)
 	files, err := yamlFiles(dir)
 	if err != nil {
@@ -161,67 +272,117 @@ func loadDevices(dir string, logger *slog.Logger) (map[string]DeviceConfig, erro
 			continue
 		}
 		for hostname, entry := range raw {
-			result[hostname] = resolveDevice(entry)
+			if err := validSchedule(entry.Schedule); err != nil {
+				logger.Warn("config: skip device with invalid schedule", "file", path, "hostname", hostname, "error", err.Error())
+				continue
+			}
+			if err := resolveSecrets(&entry); err != nil {
+				logger.Warn("config: skip device with unresolved secret", "file", path, "hostname", hostname, "error", err.Error())
+				continue
+			}
+			dev, err := resolveDevice(entry, templates, creds)
+			if err != nil {
+				logger.Warn("config: skip device", "file", path, "hostname", hostname, "error", err.Error())
+				continue
+			}
+			result[hostname] = dev
 		}
 		logger.Debug("config: loaded device file", "file", path, "count", len(raw))
 	}
 	return result, nil
 }
 
-// resolveDevice applies hard-coded fallbacks for zero-valued fields,
-// producing a fully-resolved DeviceConfig.
-func resolveDevice(e rawDeviceEntry) DeviceConfig {
-	port := e.Port
-	if port == 0 {
-		port = 161
+// resolveDevice merges, in order, the templates e extends, its credential
+// profile and the defaults template, then applies hard-coded fallbacks for
+// the fields still zero, producing a fully-resolved DeviceConfig.
+func resolveDevice(e rawDeviceEntry, templates map[string]rawDeviceEntry, creds map[string]CredentialProfile) (DeviceConfig, error) {
+	extends := e.Extends
+	e, err := applyTemplates(e, templates)
+	if err != nil {
+		return DeviceConfig{}, err
 	}
-
-	interval := e.PollInterval
-	if interval == 0 {
-		interval = 60
+	defaults := templates[DefaultsTemplate]
+	if e.Credentials == "" {
+		e.Credentials = defaults.Credentials
 	}
-
-	timeout := e.Timeout
-	if timeout == 0 {
-		timeout = 3000
+	if e.Credentials != "" {
+		p, ok := creds[e.Credentials]
+		if !ok {
+			return DeviceConfig{}, fmt.Errorf("unknown credential profile %q", e.Credentials)
+		}
+		e = p.apply(e)
 	}
-
-	retries := e.Retries
-	if retries == 0 {
-		retries = 2
+	e = e.inherit(defaults)
+	e.DeviceGroups, e.DeviceGroupsAppend = e.deviceGroups(), nil
+	e.Extends = extends
+	if e.Port == 0 {
+		e.Port = 161
 	}
-
-	version := e.Version
-	if version == "" {
-		version = "2c"
+	if e.PollInterval == 0 {
+		e.PollInterval = 60
 	}
-
-	maxPolls := e.MaxConcurrentPolls
-	if maxPolls == 0 {
-		maxPolls = 4
+	if e.Timeout == 0 {
+		e.Timeout = 3000
+	}
+	if e.Retries == 0 {
+		e.Retries = 2
+	}
+	if e.Version == "" {
+		e.Version = "2c"
+	}
+	if e.MaxConcurrentPolls == 0 {
+		e.MaxConcurrentPolls = 4
+	}
+	if e.MaxOids == 0 {
+		e.MaxOids = 60
 	}
+	if e.MaxRepetitions == 0 {
+		e.MaxRepetitions = 50
+	}
+	return e.deviceConfig(), nil
+}
 
+// deviceConfig copies e into a DeviceConfig as written.
+func (e rawDeviceEntry) deviceConfig() DeviceConfig {
 	return DeviceConfig{
 		IP:                 e.IP,
-		Port:               port,
-		PollInterval:       interval,
-		Timeout:            timeout,
-		Retries:            retries,
-		ExponentialTimeout: e.ExponentialTimeout,
-		Version:            version,
+		Port:               e.Port,
+		PollInterval:       e.PollInterval,
+		Timeout:            e.Timeout,
+		Retries:            e.Retries,
+		ExponentialTimeout: e.ExponentialTimeout != nil && *e.ExponentialTimeout,
+		Version:            e.Version,
 		Communities:        e.Communities,
 		V3Credentials:      e.V3Credentials,
 		DeviceGroups:       e.DeviceGroups,
-		MaxConcurrentPolls: maxPolls,
+		DeviceGroupsAppend: e.DeviceGroupsAppend,
+		MaxConcurrentPolls: e.MaxConcurrentPolls,
+		MaxOids:            e.MaxOids,
+		MaxRepetitions:     e.MaxRepetitions,
+		AdaptiveBulk:       e.AdaptiveBulk != nil && *e.AdaptiveBulk,
+		Tags:               e.Tags,
+		Schedule:           e.Schedule,
+		Credentials:        e.Credentials,
+		Extends:            e.Extends,
 	}
 }
 
+// validSchedule reports whether spec (which may be nil) compiles.
+func validSchedule(spec *schedule.Spec) error {
+	if spec == nil {
+		return nil
+	}
+	_, err := schedule.Compile(*spec)
+	return err
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Device groups
 // ─────────────────────────────────────────────────────────────────────────────
 
 type rawDeviceGroupFile map[string]struct {
-	ObjectGroups []string `yaml:"object_groups"`
+	ObjectGroups []string          `yaml:"object_groups"`
+	Tags         map[string]string `yaml:"tags"`
 }
 
 func loadDeviceGroups(dir string, logger *slog.Logger) (map[string]DeviceGroup, error) {
@@ -241,7 +402,7 @@ func loadDeviceGroups(dir string, logger *slog.Logger) (map[string]DeviceGroup,
 			continue
 		}
 		for name, g := range raw {
-			result[name] = DeviceGroup{ObjectGroups: g.ObjectGroups}
+			result[name] = DeviceGroup{ObjectGroups: g.ObjectGroups, Tags: g.Tags}
 		}
 		logger.Debug("config: loaded device_groups file", "file", path, "count", len(raw))
 	}
@@ -253,7 +414,9 @@ func loadDeviceGroups(dir string, logger *slog.Logger) (map[string]DeviceGroup,
 // ─────────────────────────────────────────────────────────────────────────────
 
 type rawObjectGroupFile map[string]struct {
-	Objects []string `yaml:"objects"`
+	Objects      []string       `yaml:"objects"`
+	PollInterval int            `yaml:"poll_interval"`
+	Schedule     *schedule.Spec `yaml:"schedule"`
 }
 
 func loadObjectGroups(dir string, logger *slog.Logger) (map[string]ObjectGroup, error) {
@@ -273,7 +436,11 @@ func loadObjectGroups(dir string, logger *slog.Logger) (map[string]ObjectGroup,
 			continue
 		}
 		for name, g := range raw {
-			result[name] = ObjectGroup{Objects: g.Objects}
+			if err := validSchedule(g.Schedule); err != nil {
+				logger.Warn("config: skip object_group with invalid schedule", "file", path, "group", name, "error", err.Error())
+				continue
+			}
+			result[name] = ObjectGroup{Objects: g.Objects, PollInterval: g.PollInterval, Schedule: g.Schedule}
 		}
 		logger.Debug("config: loaded object_groups file", "file", path, "count", len(raw))
 	}
@@ -294,6 +461,7 @@ type rawObjectBody struct {
 	Index              []rawIndexBody              `yaml:"index"`
 	DiscoveryAttribute string                      `yaml:"discovery_attribute"`
 	Attributes         map[string]rawAttributeBody `yaml:"attributes"`
+	PollInterval       int                         `yaml:"poll_interval"`
 }
 
 type rawIndexBody struct {
@@ -379,6 +547,7 @@ func convertObjectDef(key string, b rawObjectBody) models.ObjectDefinition {
 		Index:              index,
 		DiscoveryAttribute: b.DiscoveryAttribute,
 		Attributes:         attrs,
+		PollInterval:       b.PollInterval,
 	}
 }
 
@@ -435,7 +604,7 @@ func loadEnums(dir string, objectDefs map[string]models.ObjectDefinition, logger
 				// Integer / bitmap enum: keys are integer values (as strings in yaml.v3).
 				intMap, err := parseIntEnumMap(v)
 				if err != nil {
-					logger.Warn("config: skip unparseable int enum", "oid", oid, "error", err.Error())
+					logger.Warn("config: skip unparseable int enum", "file", path, "oid", oid, "error", err.Error())
 					continue
 				}
 				reg.RegisterIntEnum(normOID, bitmapOIDs[normOID], intMap)
@@ -444,13 +613,14 @@ func loadEnums(dir string, objectDefs map[string]models.ObjectDefinition, logger
 				// yaml.v3 decodes YAML maps with integer keys as map[interface{}]interface{}.
 				intMap, err := parseIntEnumMapGeneric(v)
 				if err != nil {
-					logger.Warn("config: skip unparseable int enum", "oid", oid, "error", err.Error())
+					logger.Warn("config: skip unparseable int enum", "file", path, "oid", oid, "error", err.Error())
 					continue
 				}
 				reg.RegisterIntEnum(normOID, bitmapOIDs[normOID], intMap)
 
 			default:
-				logger.Warn("config: unknown enum value type", "oid", oid, "type", fmt.Sprintf("%T", val))
+				logger.Warn("config: skip enum with unknown value type", "file", path, "oid", oid,
+					"error", fmt.Sprintf("want a label or a map of integer values, not %T", val))
 			}
 		}
 		logger.Debug("config: loaded enum file", "file", path)
@@ -1,8 +1,15 @@
 package config_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
@@ -28,6 +35,7 @@ func TestPathsFromEnv_Defaults(t *testing.T) {
 		"INPUT_SNMP_OBJECT_GROUP_DEFINITIONS_DIRECTORY_PATH",
 		"INPUT_SNMP_OBJECT_DEFINITIONS_DIRECTORY_PATH",
 		"PROCESSOR_SNMP_ENUM_DEFINITIONS_DIRECTORY_PATH",
+		"INPUT_SNMP_CREDENTIAL_DEFINITIONS_DIRECTORY_PATH",
 	} {
 		t.Setenv(v, "")
 	}
@@ -41,6 +49,12 @@ func TestPathsFromEnv_Defaults(t *testing.T) {
 	if p.Enums != "/etc/snmp_collector/snmp/enums" {
 		t.Errorf("Enums = %q", p.Enums)
 	}
+	if p.Credentials != "/etc/snmp_collector/snmp/credentials" {
+		t.Errorf("Credentials = %q", p.Credentials)
+	}
+	if p.Templates != "/etc/snmp_collector/snmp/device_templates" {
+		t.Errorf("Templates = %q", p.Templates)
+	}
 }
 
 func TestPathsFromEnv_Override(t *testing.T) {
@@ -160,6 +174,432 @@ func TestLoad_FallbacksApplied(t *testing.T) {
 	if d.PollInterval != 60 {
 		t.Errorf("poll_interval = %d, want 60 (hard-coded fallback)", d.PollInterval)
 	}
+	if d.MaxOids != 60 || d.MaxRepetitions != 50 || d.AdaptiveBulk {
+		t.Errorf("max_oids = %d, max_repetitions = %d, adaptive_bulk = %v; want 60, 50, false (hard-coded fallbacks)",
+			d.MaxOids, d.MaxRepetitions, d.AdaptiveBulk)
+	}
+}
+
+// ── Secret references ─────────────────────────────────────────────────────────
+
+func TestLoad_SecretReferences(t *testing.T) {
+	t.Setenv("SNMP_TEST_COMMUNITY", "from-env")
+	secrets := tmpDir(t, map[string]string{"auth": "authpass\n", "priv": "privpass"})
+	devDir := tmpDir(t, map[string]string{"devices.yml": `
+r1:
+  ip: 10.0.0.1
+  communities: ["${SNMP_TEST_COMMUNITY}", public]
+r2:
+  ip: 10.0.0.2
+  version: "3"
+  v3_credentials:
+    - username: mon
+      authentication_protocol: sha
+      authentication_passphrase: file:` + filepath.Join(secrets, "auth") + `
+      privacy_protocol: aes
+      privacy_passphrase: file:` + filepath.Join(secrets, "priv") + `
+r3:
+  ip: 10.0.0.3
+  communities: ["${SNMP_TEST_UNSET_VAR}"]
+`})
+	cfg, err := config.Load(config.Paths{Devices: devDir}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Devices["r1"].Communities; len(got) != 2 || got[0] != "from-env" || got[1] != "public" {
+		t.Errorf("r1 communities = %v", got)
+	}
+	cred := cfg.Devices["r2"].V3Credentials[0]
+	if cred.AuthenticationPassphrase != "authpass" || cred.PrivacyPassphrase != "privpass" {
+		t.Errorf("r2 passphrases not resolved: %+v", cred)
+	}
+	if _, ok := cfg.Devices["r3"]; ok {
+		t.Error("device with an unresolvable secret should be skipped")
+	}
+
+	// Device files are rewritten with the references, not their values.
+	raw, err := config.ReadDeviceFile(filepath.Join(devDir, "devices.yml"))
+	if err != nil {
+		t.Fatalf("ReadDeviceFile: %v", err)
+	}
+	if got := raw["r1"].Communities[0]; got != "${SNMP_TEST_COMMUNITY}" {
+		t.Errorf("ReadDeviceFile community = %q, want the reference", got)
+	}
+}
+
+// ── Credential profiles ───────────────────────────────────────────────────────
+
+func TestLoad_CredentialProfiles(t *testing.T) {
+	t.Setenv("SNMP_TEST_PRIV", "privpass")
+	credDir := tmpDir(t, map[string]string{"corp.yml": `
+corp_v3:
+  version: "3"
+  port: 1161
+  timeout: 5000
+  v3_credentials:
+    - username: monitor
+      authentication_protocol: sha256
+      authentication_passphrase: authpass
+      privacy_protocol: aes256
+      privacy_passphrase: ${SNMP_TEST_PRIV}
+`})
+	devDir := tmpDir(t, map[string]string{"devices.yml": `
+core1:
+  ip: 10.0.0.1
+  credentials: corp_v3
+  device_groups: [auto]
+core2:
+  ip: 10.0.0.2
+  credentials: corp_v3
+  timeout: 9000
+  device_groups: [auto]
+lost:
+  ip: 10.0.0.3
+  credentials: nosuch
+  device_groups: [auto]
+`})
+	paths := config.Paths{Devices: devDir, Credentials: credDir}
+	cfg, err := config.Load(paths, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	c1 := cfg.Devices["core1"]
+	if c1.Version != "3" || c1.Port != 1161 || c1.Timeout != 5000 || c1.Retries != 2 {
+		t.Errorf("core1 = version %q port %d timeout %d retries %d; want profile values and the retries fallback",
+			c1.Version, c1.Port, c1.Timeout, c1.Retries)
+	}
+	if len(c1.V3Credentials) != 1 || c1.V3Credentials[0].PrivacyPassphrase != "privpass" {
+		t.Errorf("core1 v3 credentials = %+v", c1.V3Credentials)
+	}
+	if c1.Credentials != "corp_v3" {
+		t.Errorf("core1 Credentials = %q", c1.Credentials)
+	}
+	if got := cfg.Devices["core2"].Timeout; got != 9000 {
+		t.Errorf("core2 timeout = %d, want the device override 9000", got)
+	}
+	if _, ok := cfg.Devices["lost"]; ok {
+		t.Error("device with an unknown credential profile should be skipped")
+	}
+
+	// A rewritten device file keeps inheriting from the profile.
+	raw, err := config.ReadDeviceFile(filepath.Join(devDir, "devices.yml"))
+	if err != nil {
+		t.Fatalf("ReadDeviceFile: %v", err)
+	}
+	data, err := config.EncodeDevices(map[string]config.DeviceConfig{"core1": raw["core1"]})
+	if err != nil {
+		t.Fatalf("EncodeDevices: %v", err)
+	}
+	if out := string(data); !strings.Contains(out, "credentials: corp_v3") || strings.Contains(out, "port:") || strings.Contains(out, "version:") {
+		t.Errorf("encoded entry should reference the profile without pinning its fields:\n%s", out)
+	}
+
+	issues, err := config.Validate(paths)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(issues) != 1 || !strings.Contains(issues[0].String(), `devices.yml:13: error: device "lost": unknown credential profile "nosuch"`) {
+		t.Errorf("Validate issues = %v", issues)
+	}
+}
+
+// ── Device templates ──────────────────────────────────────────────────────────
+
+func TestLoad_DeviceTemplates(t *testing.T) {
+	tplDir := tmpDir(t, map[string]string{"templates.yml": `
+defaults:
+  timeout: 5000
+  communities: [public]
+  device_groups: [generic]
+  tags: {site: hq}
+base:
+  retries: 4
+  exponential_timeout: true
+  max_repetitions: 20
+  adaptive_bulk: true
+edge:
+  extends: base
+  poll_interval: 30
+  device_groups_append: [cisco_c1000]
+  tags: {role: edge}
+loop_a:
+  extends: loop_b
+loop_b:
+  extends: loop_a
+`})
+	devDir := tmpDir(t, map[string]string{"devices.yml": `
+e1:
+  ip: 10.0.0.1
+  extends: edge
+e2:
+  ip: 10.0.0.2
+  extends: edge
+  exponential_timeout: false
+  adaptive_bulk: false
+  device_groups: [cisco_c1000]
+plain:
+  ip: 10.0.0.3
+cyclic:
+  ip: 10.0.0.4
+  extends: loop_a
+orphan:
+  ip: 10.0.0.5
+  extends: nosuch
+`})
+	dgDir := tmpDir(t, map[string]string{"groups.yml": `
+generic: {object_groups: []}
+cisco_c1000: {object_groups: []}
+`})
+	paths := config.Paths{Devices: devDir, DeviceGroups: dgDir, Templates: tplDir}
+	cfg, err := config.Load(paths, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	e1 := cfg.Devices["e1"]
+	if e1.Port != 161 || e1.PollInterval != 30 || e1.Timeout != 5000 || e1.Retries != 4 || !e1.ExponentialTimeout {
+		t.Errorf("e1 = %+v; want inherited poll_interval, timeout, retries and exponential_timeout", e1)
+	}
+	if e1.MaxRepetitions != 20 || !e1.AdaptiveBulk || e1.MaxOids != 60 {
+		t.Errorf("e1 = %+v; want inherited max_repetitions and adaptive_bulk, fallback max_oids", e1)
+	}
+	if !slices.Equal(e1.DeviceGroups, []string{"generic", "cisco_c1000"}) {
+		t.Errorf("e1 device groups = %v, want defaults plus the appended group", e1.DeviceGroups)
+	}
+	if e1.Tags["site"] != "hq" || e1.Tags["role"] != "edge" {
+		t.Errorf("e1 tags = %v", e1.Tags)
+	}
+	if e1.Extends != "edge" {
+		t.Errorf("e1 Extends = %q", e1.Extends)
+	}
+	e2 := cfg.Devices["e2"]
+	if e2.ExponentialTimeout || e2.AdaptiveBulk || !slices.Equal(e2.DeviceGroups, []string{"cisco_c1000"}) {
+		t.Errorf("e2 = %+v; want its own exponential_timeout, adaptive_bulk and device_groups", e2)
+	}
+	plain := cfg.Devices["plain"]
+	if plain.Timeout != 5000 || plain.PollInterval != 60 || len(plain.Communities) != 1 || plain.Communities[0] != "public" {
+		t.Errorf("plain = %+v; want defaults then fallbacks", plain)
+	}
+	for _, host := range []string{"cyclic", "orphan"} {
+		if _, ok := cfg.Devices[host]; ok {
+			t.Errorf("device %q with a broken template chain should be skipped", host)
+		}
+	}
+
+	// A rewritten device file keeps inheriting from its template.
+	raw, err := config.ReadDeviceFile(filepath.Join(devDir, "devices.yml"))
+	if err != nil {
+		t.Fatalf("ReadDeviceFile: %v", err)
+	}
+	data, err := config.EncodeDevices(map[string]config.DeviceConfig{"e1": raw["e1"]})
+	if err != nil {
+		t.Fatalf("EncodeDevices: %v", err)
+	}
+	if out := string(data); !strings.Contains(out, "extends: edge") || strings.Contains(out, "timeout:") {
+		t.Errorf("encoded entry should extend the template without pinning its fields:\n%s", out)
+	}
+
+	issues, err := config.Validate(paths)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%s:%d: %s", filepath.Base(issue.File), issue.Line, issue.Message))
+	}
+	want := []string{
+		`templates.yml:18: device template "loop_a": template chain loop_b → loop_a → loop_b: cycle`,
+		`templates.yml:20: device template "loop_b": template chain loop_a → loop_b → loop_a: cycle`,
+		`devices.yml:15: device "cyclic": template chain loop_a → loop_b → loop_a: cycle`,
+		`devices.yml:18: device "orphan": template chain nosuch: unknown template "nosuch"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Validate issues:\n  %s\nwant:\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+}
+
+// ── Inventory sources ─────────────────────────────────────────────────────────
+
+func TestCSVSource(t *testing.T) {
+	dir := tmpDir(t, map[string]string{
+		"inventory.csv": `hostname,ip,extends,port,device_groups,communities,tag.site
+# exported from the CMDB
+core-r1,10.0.0.1,core,,core_routers;bgp,,hcm
+sw-07,10.1.0.7,,1161,,public;backup,
+`,
+		"typo.csv": "hostname,ip,devce_groups\nr1,10.0.0.1,generic\n",
+	})
+
+	devices, err := config.CSVSource{Path: filepath.Join(dir, "inventory.csv")}.Devices(context.Background())
+	if err != nil {
+		t.Fatalf("Devices: %v", err)
+	}
+	r1 := devices["core-r1"]
+	if r1.IP != "10.0.0.1" || r1.Extends != "core" || r1.Port != 0 || r1.Tags["site"] != "hcm" ||
+		!slices.Equal(r1.DeviceGroups, []string{"core_routers", "bgp"}) {
+		t.Errorf("core-r1 = %+v", r1)
+	}
+	sw := devices["sw-07"]
+	if sw.Port != 1161 || !slices.Equal(sw.Communities, []string{"public", "backup"}) || sw.Tags != nil {
+		t.Errorf("sw-07 = %+v", sw)
+	}
+
+	_, err = config.CSVSource{Path: filepath.Join(dir, "typo.csv")}.Devices(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `line 2: unknown column "devce_groups"`) {
+		t.Errorf("unknown column error = %v", err)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	t.Setenv("SNMP_TEST_INVENTORY_TOKEN", "s3cret")
+	body := `[{"hostname": "r1", "ip": "10.0.0.1", "extends": "edge", "tags": {"site": "hcm"}},
+	          {"hostname": "r2", "ip": "10.0.0.2", "device_groups": ["generic"]}]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	src := config.HTTPSource{URL: srv.URL, Token: "${SNMP_TEST_INVENTORY_TOKEN}"}
+	devices, err := src.Devices(context.Background())
+	if err != nil {
+		t.Fatalf("Devices: %v", err)
+	}
+	if d := devices["r1"]; d.IP != "10.0.0.1" || d.Extends != "edge" || d.Tags["site"] != "hcm" {
+		t.Errorf("r1 = %+v", d)
+	}
+	if d := devices["r2"]; !slices.Equal(d.DeviceGroups, []string{"generic"}) {
+		t.Errorf("r2 = %+v", d)
+	}
+
+	body = `{"r3": {"ip": "10.0.0.3"}}` // keyed by hostname, as in device files
+	if devices, err = src.Devices(context.Background()); err != nil || devices["r3"].IP != "10.0.0.3" {
+		t.Errorf("map form = %v, %v", devices, err)
+	}
+
+	if _, err := (config.HTTPSource{URL: srv.URL}).Devices(context.Background()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("unauthorised fetch error = %v", err)
+	}
+}
+
+func TestNetBoxSource(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/dcim/devices/" || r.Header.Get("Authorization") != "Token nbtoken" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("site") != "hcm" {
+			http.Error(w, "filter not applied", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("offset") == "" {
+			fmt.Fprintf(w, `{"next": %q, "results": [
+  {"name": "core-r1", "primary_ip4": {"address": "10.0.0.1/32"},
+   "site": {"slug": "hcm"}, "role": {"slug": "core"}, "device_type": {"manufacturer": {"slug": "cisco"}},
+   "custom_fields": {"snmp_device_groups": ["core_routers", "bgp"], "snmp_template": "core", "snmp_credentials": "corp_v3"}},
+  {"name": "patch-panel", "primary_ip4": null, "site": {"slug": "hcm"}}
+]}`, srvURL+"/api/dcim/devices/?site=hcm&offset=1000&limit=1000")
+			return
+		}
+		fmt.Fprint(w, `{"next": null, "results": [
+  {"name": "sw-07", "primary_ip": {"address": "10.1.0.7/24"}, "device_role": {"slug": "access"},
+   "custom_fields": {"snmp_device_groups": "access_switches, lldp"}}
+]}`)
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	src := config.NetBoxSource{URL: srv.URL + "/", Token: "nbtoken", Filter: map[string][]string{"site": {"hcm"}}}
+	devices, err := src.Devices(context.Background())
+	if err != nil {
+		t.Fatalf("Devices: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("devices = %v, want core-r1 and sw-07 (patch-panel has no IP)", devices)
+	}
+	r1 := devices["core-r1"]
+	if r1.IP != "10.0.0.1" || r1.Extends != "core" || r1.Credentials != "corp_v3" ||
+		!slices.Equal(r1.DeviceGroups, []string{"core_routers", "bgp"}) ||
+		r1.Tags["site"] != "hcm" || r1.Tags["role"] != "core" || r1.Tags["manufacturer"] != "cisco" {
+		t.Errorf("core-r1 = %+v", r1)
+	}
+	sw := devices["sw-07"]
+	if sw.IP != "10.1.0.7" || sw.Tags["role"] != "access" || !slices.Equal(sw.DeviceGroups, []string{"access_switches", "lldp"}) {
+		t.Errorf("sw-07 = %+v", sw)
+	}
+}
+
+// stubSource is an InventorySource returning fixed devices or an error.
+type stubSource struct {
+	devices map[string]config.DeviceConfig
+	err     error
+}
+
+func (s *stubSource) Name() string { return "stub" }
+
+func (s *stubSource) Devices(context.Context) (map[string]config.DeviceConfig, error) {
+	return s.devices, s.err
+}
+
+func TestInventory_MergeAndDiff(t *testing.T) {
+	tplDir := tmpDir(t, map[string]string{"t.yml": `
+defaults:
+  communities: [public]
+edge:
+  poll_interval: 30
+  device_groups: [generic]
+`})
+	devDir := tmpDir(t, map[string]string{"d.yml": `
+r1:
+  ip: 10.0.0.1
+  device_groups: [generic]
+`})
+	cfg, err := config.Load(config.Paths{Devices: devDir, Templates: tplDir}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	first := &stubSource{devices: map[string]config.DeviceConfig{
+		"r1": {IP: "10.9.9.9"}, // shadowed by the device file
+		"r2": {IP: "10.0.0.2", Extends: "edge"},
+	}}
+	second := &stubSource{devices: map[string]config.DeviceConfig{
+		"r2":  {IP: "10.0.0.99"}, // the earlier source wins
+		"bad": {IP: "10.0.0.3", Extends: "nosuch"},
+	}}
+	inv := config.NewInventory([]config.InventorySource{first, second}, nil)
+	merged := cfg.WithInventory(inv.Refresh(context.Background()), nil)
+
+	if got := merged.Devices["r1"].IP; got != "10.0.0.1" {
+		t.Errorf("r1 IP = %s, want the device file's", got)
+	}
+	r2 := merged.Devices["r2"]
+	if r2.IP != "10.0.0.2" || r2.PollInterval != 30 || r2.Port != 161 || !slices.Equal(r2.Communities, []string{"public"}) {
+		t.Errorf("r2 = %+v; want the first source's entry resolved through its template", r2)
+	}
+	if _, ok := merged.Devices["bad"]; ok {
+		t.Error("inventory device with an unknown template should be skipped")
+	}
+	if len(cfg.Devices) != 1 {
+		t.Errorf("WithInventory modified the file configuration: %v", cfg.Devices)
+	}
+
+	// A failing source keeps its devices; a changed one is diffed.
+	first.err = errors.New("unreachable")
+	second.devices = map[string]config.DeviceConfig{"r3": {IP: "10.0.0.3"}}
+	next := cfg.WithInventory(inv.Refresh(context.Background()), nil)
+	diff := config.DiffDevices(merged.Devices, next.Devices)
+	if !slices.Equal(diff.Added, []string{"r3"}) || len(diff.Changed) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("diff = %+v, want r3 added only", diff)
+	}
+	if !config.DiffDevices(next.Devices, next.Devices).Empty() {
+		t.Error("diff of identical maps should be empty")
+	}
 }
 
 // ── Device groups ─────────────────────────────────────────────────────────────
@@ -223,6 +663,97 @@ func TestLoad_ObjectGroups(t *testing.T) {
 	}
 }
 
+func TestLoad_PollIntervalOverrides(t *testing.T) {
+	ogDir := tmpDir(t, map[string]string{"inventory.yml": `
+inventory:
+  poll_interval: 3600
+  objects:
+    - ENTITY-MIB::entPhysicalEntry
+`})
+	objDir := tmpDir(t, map[string]string{"ifXEntry.yml": `
+IF-MIB::ifXEntry:
+  mib: IF-MIB
+  object: ifXEntry
+  poll_interval: 30
+  attributes:
+    ifHCInOctets:
+      oid: .1.3.6.1.2.1.31.1.1.1.6
+      name: netif.bytes.in
+      syntax: Counter64
+`})
+	cfg, err := config.Load(config.Paths{
+		Devices:      t.TempDir(),
+		DeviceGroups: t.TempDir(), ObjectGroups: ogDir,
+		Objects: objDir, Enums: t.TempDir(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.ObjectGroups["inventory"].PollInterval; got != 3600 {
+		t.Errorf("object group PollInterval = %d, want 3600", got)
+	}
+	if got := cfg.ObjectDefs["IF-MIB::ifXEntry"].PollInterval; got != 30 {
+		t.Errorf("object PollInterval = %d, want 30", got)
+	}
+}
+
+func TestLoad_Schedules(t *testing.T) {
+	devDir := tmpDir(t, map[string]string{"devices.yml": `
+metered1:
+  ip: 10.0.0.1
+  device_groups: [g]
+  schedule:
+    timezone: Asia/Ho_Chi_Minh
+    allow:
+      - days: [mon-fri]
+        start: "08:00"
+        end: "18:00"
+    block:
+      - days: [sun]
+        start: "02:00"
+        end: "04:00"
+        suppress_traps: true
+broken1:
+  ip: 10.0.0.2
+  schedule:
+    timezone: Mars/Olympus_Mons
+`})
+	ogDir := tmpDir(t, map[string]string{"groups.yml": `
+nightly:
+  objects: [ENTITY-MIB::entPhysicalEntry]
+  schedule:
+    cron: "0 3 * * *"
+broken:
+  objects: [IF-MIB::ifEntry]
+  schedule:
+    cron: "61 * * * *"
+`})
+	cfg, err := config.Load(config.Paths{
+		Devices: devDir, DeviceGroups: t.TempDir(), ObjectGroups: ogDir,
+		Objects: t.TempDir(), Enums: t.TempDir(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	dev, ok := cfg.Devices["metered1"]
+	if !ok || dev.Schedule == nil {
+		t.Fatalf("metered1 schedule not loaded: %+v", dev)
+	}
+	if dev.Schedule.Timezone != "Asia/Ho_Chi_Minh" || len(dev.Schedule.Allow) != 1 || !dev.Schedule.Block[0].SuppressTraps {
+		t.Errorf("metered1 schedule = %+v", *dev.Schedule)
+	}
+	if _, ok := cfg.Devices["broken1"]; ok {
+		t.Error("device with an unknown timezone should be skipped")
+	}
+	if og := cfg.ObjectGroups["nightly"]; og.Schedule == nil || og.Schedule.Cron != "0 3 * * *" {
+		t.Errorf("nightly schedule = %+v", og.Schedule)
+	}
+	if _, ok := cfg.ObjectGroups["broken"]; ok {
+		t.Error("object group with an invalid cron should be skipped")
+	}
+}
+
 // ── Object definitions ────────────────────────────────────────────────────────
 
 var ifEntryYAML = `
@@ -419,10 +950,166 @@ func TestLoad_BitmapEnum(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Load: %v", err)
 	}
-	// Bits 0 and 2 set (mask = 5 = 0b101) -> "PDR,CDR"
+	// Bits 0 and 2 set (mask = 5 = 0b101) -> ["PDR", "CDR"]
 	got := cfg.Enums.Resolve("1.3.6.1.2.1.10.166.3.2.10.1.5", int64(5))
-	if got != "PDR,CDR" {
-		t.Errorf("bitmap Resolve(5) = %v, want %q", got, "PDR,CDR")
+	labels, ok := got.([]string)
+	if !ok || len(labels) != 2 || labels[0] != "PDR" || labels[1] != "CDR" {
+		t.Errorf("bitmap Resolve(5) = %#v, want []string{\"PDR\", \"CDR\"}", got)
+	}
+}
+
+// ── Vendor definitions ────────────────────────────────────────────────────────
+
+var vendorYAML = `
+1.3.6.1.4.1.9:
+  vendor: Cisco
+.1.3.6.1.4.1.9.1.1208:
+  model: WS-C2960X-48FPD-L
+1.3.6.1.4.1.2636:
+  vendor: Juniper
+`
+
+func TestLoad_Vendors(t *testing.T) {
+	vendorDir := tmpDir(t, map[string]string{"enterprises.yml": vendorYAML})
+	cfg, err := config.Load(config.Paths{
+		Devices:      t.TempDir(),
+		DeviceGroups: t.TempDir(), ObjectGroups: t.TempDir(),
+		Objects: t.TempDir(), Enums: t.TempDir(), Vendors: vendorDir,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Vendors.Len() != 3 {
+		t.Fatalf("vendor rules = %d, want 3", cfg.Vendors.Len())
+	}
+
+	tests := []struct {
+		sysObjectID, vendor, model string
+	}{
+		{"1.3.6.1.4.1.9.1.1208", "Cisco", "WS-C2960X-48FPD-L"},
+		{".1.3.6.1.4.1.9.1.1208", "Cisco", "WS-C2960X-48FPD-L"},
+		{"1.3.6.1.4.1.9.1.999", "Cisco", ""},
+		{"1.3.6.1.4.1.2636.1.1.1.2.29", "Juniper", ""},
+		{"1.3.6.1.4.1.99", "", ""},
+		// Prefix matching is on arc boundaries: .92 is not under .9.
+		{"1.3.6.1.4.1.92.1", "", ""},
+	}
+	for _, tc := range tests {
+		vendor, model := cfg.Vendors.Match(tc.sysObjectID)
+		if vendor != tc.vendor || model != tc.model {
+			t.Errorf("Match(%q) = (%q, %q), want (%q, %q)", tc.sysObjectID, vendor, model, tc.vendor, tc.model)
+		}
+	}
+}
+
+// ── Device profiles ───────────────────────────────────────────────────────────
+
+var profileYAML = `
+cisco:
+  sys_object_id: [1.3.6.1.4.1.9]
+  device_groups: [cisco_generic]
+cisco_cat_2960:
+  sys_object_id: [.1.3.6.1.4.1.9.1]
+  sys_descr: ['(?i)C2960']
+  device_groups: [cisco_cat_2960, cisco_generic]
+linux:
+  sys_descr: ['^Linux ']
+  device_groups: [linux]
+bad_regex:
+  sys_descr: ['(unclosed']
+  device_groups: [broken]
+no_groups:
+  sys_object_id: [1.3.6.1.4.1.2636]
+no_criteria:
+  device_groups: [generic]
+`
+
+func TestLoad_Profiles(t *testing.T) {
+	profileDir := tmpDir(t, map[string]string{"profiles.yml": profileYAML})
+	cfg, err := config.Load(config.Paths{
+		Devices:      t.TempDir(),
+		DeviceGroups: t.TempDir(), ObjectGroups: t.TempDir(),
+		Objects: t.TempDir(), Enums: t.TempDir(), Profiles: profileDir,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// bad_regex, no_groups and no_criteria are skipped.
+	if cfg.Profiles.Len() != 3 {
+		t.Fatalf("profile rules = %d, want 3", cfg.Profiles.Len())
+	}
+
+	tests := []struct {
+		sysObjectID, sysDescr string
+		want                  []string
+	}{
+		{"1.3.6.1.4.1.9.1.1208", "Cisco IOS Software, C2960X Software", []string{"cisco_generic", "cisco_cat_2960"}},
+		{"1.3.6.1.4.1.9.1.2066", "Cisco IOS XE Software, Catalyst L3 Switch", []string{"cisco_generic"}},
+		{"1.3.6.1.4.1.8072.3.2.10", "Linux host 6.1.0 #1 SMP x86_64", []string{"linux"}},
+		{"1.3.6.1.4.1.92.1", "Something else", nil},
+	}
+	for _, tc := range tests {
+		got := cfg.Profiles.Match(tc.sysObjectID, tc.sysDescr)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.sysObjectID, tc.sysDescr, got, tc.want)
+		}
+	}
+}
+
+func TestDeviceConfig_HasAutoDeviceGroup(t *testing.T) {
+	if !(config.DeviceConfig{DeviceGroups: []string{"generic", config.AutoDeviceGroup}}).HasAutoDeviceGroup() {
+		t.Error("device with auto group: HasAutoDeviceGroup = false, want true")
+	}
+	if (config.DeviceConfig{DeviceGroups: []string{"generic"}}).HasAutoDeviceGroup() {
+		t.Error("device without auto group: HasAutoDeviceGroup = true, want false")
+	}
+}
+
+// ── Device tags ───────────────────────────────────────────────────────────────
+
+func TestLoad_DeviceTags(t *testing.T) {
+	devDir := tmpDir(t, map[string]string{"devices.yml": `
+edge1:
+  ip: 10.0.0.1
+  device_groups: [base, access]
+  tags:
+    role: edge
+    tenant: retail
+`})
+	dgDir := tmpDir(t, map[string]string{"groups.yml": `
+base:
+  object_groups: []
+  tags:
+    site: hcm-dc1
+    role: generic
+access:
+  object_groups: []
+  tags:
+    role: access
+    environment: prod
+`})
+	cfg, err := config.Load(config.Paths{
+		Devices: devDir, DeviceGroups: dgDir, ObjectGroups: t.TempDir(),
+		Objects: t.TempDir(), Enums: t.TempDir(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	got := cfg.DeviceTags(cfg.Devices["edge1"])
+	want := map[string]string{
+		"site":        "hcm-dc1", // base group
+		"environment": "prod",    // access group
+		"role":        "edge",    // device overrides both groups
+		"tenant":      "retail",  // device only
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DeviceTags = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("DeviceTags[%q] = %q, want %q", k, got[k], v)
+		}
 	}
 }
 
@@ -435,12 +1122,41 @@ func TestLoad_MissingDirectoriesAreIgnored(t *testing.T) {
 		ObjectGroups: "/tmp/no-such-og",
 		Objects:      "/tmp/no-such-objects",
 		Enums:        "/tmp/no-such-enums",
+		Vendors:      "/tmp/no-such-vendors",
+		Profiles:     "/tmp/no-such-profiles",
 	}, nil)
 	if err != nil {
 		t.Errorf("missing dirs should not cause error, got: %v", err)
 	}
 }
 
+func TestFingerprint(t *testing.T) {
+	dir := tmpDir(t, map[string]string{"a.yml": "a: {ip: 10.0.0.1}\n", "notes.txt": "x"})
+	paths := config.Paths{Devices: dir, Objects: "/tmp/no-such-objects"}
+
+	fp1, err := config.Fingerprint(paths)
+	if err != nil {
+		t.Fatalf("Fingerprint: %v", err)
+	}
+	if fp2, _ := config.Fingerprint(paths); fp2 != fp1 {
+		t.Error("unchanged tree should give the same fingerprint")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if fp, _ := config.Fingerprint(paths); fp != fp1 {
+		t.Error("non-YAML files should not affect the fingerprint")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("b: {ip: 10.0.0.2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if fp, _ := config.Fingerprint(paths); fp == fp1 {
+		t.Error("new YAML file should change the fingerprint")
+	}
+}
+
 // ── Multiple files ────────────────────────────────────────────────────────────
 
 func TestLoad_MultipleObjectFiles(t *testing.T) {
@@ -494,3 +1210,123 @@ func TestLoad_FullIntegration(t *testing.T) {
 		t.Error("expected enum registry")
 	}
 }
+
+// ── Validation ────────────────────────────────────────────────────────────────
+
+func TestValidate_CleanConfig(t *testing.T) {
+	issues, err := config.Validate(config.Paths{
+		Devices:      tmpDir(t, map[string]string{"router01.yml": deviceYAML}),
+		DeviceGroups: tmpDir(t, map[string]string{"groups.yml": "cisco_c1000:\n  object_groups: [netif]\ngeneric:\n  object_groups: [netif]\n"}),
+		ObjectGroups: tmpDir(t, map[string]string{"netif.yml": objectGroupYAML}),
+		Objects:      tmpDir(t, map[string]string{"ifEntry.yml": ifEntryYAML, "ifXEntry.yml": ifXEntryYAML}),
+		Enums:        tmpDir(t, map[string]string{"ifOperStatus.yml": intEnumYAML}),
+		Vendors:      "/tmp/no-such-vendors",
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestValidate_ReportsIssuesWithLines(t *testing.T) {
+	objDir := tmpDir(t, map[string]string{"obj.yml": `IF-MIB::ifEntry:
+  index:
+    - type: Integer64
+      oid: .1.3.6.1.2.1.2.2.1.1
+  attributes:
+    ifDescr:
+      oid: .1.3.6.1.2.1.2.2.1.2
+      syntax: DisplayStrnig
+    ifSpeed:
+      oid: 1.3.6.1.2.1.2.2.1.x
+      syntax: Gauge32
+      overrides:
+        object: IF-MIB::ifEntry
+        attribute: ifNoSuch
+`})
+	ogDir := tmpDir(t, map[string]string{"og.yml": "netif:\n  objects: [IF-MIB::ifEntry, IF-MIB::ifXEntry]\n"})
+	dgDir := tmpDir(t, map[string]string{"dg.yml": "generic:\n  object_groups: [netif, nosuch]\n"})
+	devDir := tmpDir(t, map[string]string{
+		"a.yml": `r1:
+  ip: 10.0.0.1
+  communities: [public]
+  device_groups: [generic, auto]
+r2:
+  ip: 10.0.0.2
+  version: 3
+  v3_credentials:
+    - username: mon
+      authentication_protocol: sha1
+      authentication_passphrase: secret
+      privacy_protocol: aes256
+  device_groups: [missing]
+`,
+		"b.yml": "r1:\n  ip: 10.0.0.9\n  version: 2\n  device_groups: [generic]\n",
+		"c.yml": "r3: [not, a, device]\n",
+		"d.yml": "r4:\n  ip: 10.0.0.4\n  communities: [\"${SNMP_TEST_UNSET_VAR}\"]\n  device_groups: [generic]\n",
+	})
+	enumDir := tmpDir(t, map[string]string{"e.yml": ".1.3.6.1.2.1.99.1:\n  1: up\n"})
+
+	issues, err := config.Validate(config.Paths{
+		Devices: devDir, DeviceGroups: dgDir, ObjectGroups: ogDir,
+		Objects: objDir, Enums: enumDir,
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	got := make([]string, len(issues))
+	for i, issue := range issues {
+		got[i] = issue.String()
+	}
+	// Sorted by path: the objects, object group, device group, device and
+	// enum directories were created in that order.
+	want := []string{
+		`obj.yml:3: error: object "IF-MIB::ifEntry": index type "Integer64" is not one of`,
+		`obj.yml:8: warning: object "IF-MIB::ifEntry" attribute "ifDescr": unknown syntax "DisplayStrnig"`,
+		`obj.yml:10: error: object "IF-MIB::ifEntry" attribute "ifSpeed": oid "1.3.6.1.2.1.2.2.1.x" is not a numeric OID`,
+		`obj.yml:12: warning: object "IF-MIB::ifEntry" attribute "ifSpeed" overrides unknown attribute "ifNoSuch" of "IF-MIB::ifEntry"`,
+		`og.yml:2: error: object group "netif": unknown object "IF-MIB::ifXEntry"`,
+		`dg.yml:2: error: device group "generic": unknown object group "nosuch"`,
+		`a.yml:9: error: device "r2": unknown authentication_protocol "sha1"`,
+		`a.yml:9: error: device "r2": privacy_protocol "aes256" needs privacy_passphrase`,
+		`a.yml:13: error: device "r2": unknown device group "missing"`,
+		`b.yml:1: error: device "r1" is already defined at`,
+		`b.yml:3: error: device "r1": version "2" is not one of 1, 2c, 3`,
+		`c.yml:1: error: cannot unmarshal !!seq into config.rawDeviceEntry`,
+		`d.yml:2: error: device "r4": communities[0]: credentials: env:SNMP_TEST_UNSET_VAR: environment variable SNMP_TEST_UNSET_VAR: secret not found`,
+		`e.yml:1: warning: enum "1.3.6.1.2.1.99.1": no object attribute has this OID`,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d:\n%s", len(issues), len(want), strings.Join(got, "\n"))
+	}
+	for i, w := range want {
+		if !strings.Contains(got[i], "/"+w) {
+			t.Errorf("issue %d = %q, want it to contain %q", i, got[i], w)
+		}
+		if strings.Contains(w, ": warning: ") != (issues[i].Severity == config.SeverityWarning) {
+			t.Errorf("issue %d severity = %s", i, issues[i].Severity)
+		}
+	}
+}
+
+func TestValidate_ShippedTestdata(t *testing.T) {
+	root := filepath.Join("..", "..", "..", "testdata")
+	issues, err := config.Validate(config.Paths{
+		Devices:      filepath.Join(root, "devices"),
+		DeviceGroups: filepath.Join(root, "device_groups"),
+		ObjectGroups: filepath.Join(root, "object_groups"),
+		Objects:      filepath.Join(root, "objects"),
+		Vendors:      filepath.Join(root, "vendors"),
+		Profiles:     filepath.Join(root, "device_profiles"),
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			t.Errorf("unexpected error: %s", issue)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AutoDeviceGroup is the reserved device group name that asks the collector to
+// pick device groups from the device's sysObjectID / sysDescr fingerprint:
+//
+//	device_groups: [auto]
+//
+// Explicit groups listed alongside it are kept.
+const AutoDeviceGroup = "auto"
+
+// HasAutoDeviceGroup reports whether the device opts in to auto-profiling.
+func (d DeviceConfig) HasAutoDeviceGroup() bool {
+	for _, g := range d.DeviceGroups {
+		if g == AutoDeviceGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// ProfileRules — sysObjectID / sysDescr fingerprint → device groups
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ProfileRule maps a device fingerprint to the device groups it should use.
+// A rule matches when sysObjectID is under any of SysObjectIDs (or the list is
+// empty) and sysDescr matches any of SysDescr (or the list is empty). At least
+// one of the two lists is always non-empty.
+type ProfileRule struct {
+	// Name is the rule's key in the profile YAML.
+	Name string
+
+	// SysObjectIDs are OID prefixes, matched on arc boundaries.
+	SysObjectIDs []string
+
+	// SysDescr are regular expressions matched against sysDescr.
+	SysDescr []*regexp.Regexp
+
+	// DeviceGroups are the groups applied when the rule matches.
+	DeviceGroups []string
+}
+
+// Matches reports whether the rule accepts the given fingerprint.
+func (r ProfileRule) Matches(sysObjectID, sysDescr string) bool {
+	if len(r.SysObjectIDs) > 0 {
+		oid := normaliseOID(strings.TrimSpace(sysObjectID))
+		ok := false
+		for _, prefix := range r.SysObjectIDs {
+			if oid == prefix || strings.HasPrefix(oid, prefix+".") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.SysDescr) > 0 {
+		ok := false
+		for _, re := range r.SysDescr {
+			if re.MatchString(sysDescr) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ProfileRules is the ordered set of auto-profile rules. It is read-only after
+// loading and safe for concurrent use.
+//
+// Loaded from YAML files under INPUT_SNMP_DEVICE_PROFILE_DEFINITIONS_DIRECTORY_PATH:
+//
+//	cisco_cat_2960:
+//	  sys_object_id:
+//	    - 1.3.6.1.4.1.9.1.1208
+//	  sys_descr:
+//	    - '(?i)C2960X'
+//	  device_groups:
+//	    - cisco_cat_2960
+type ProfileRules struct {
+	rules []ProfileRule // sorted by Name
+}
+
+// NewProfileRules builds a ProfileRules from already-compiled rules.
+func NewProfileRules(rules []ProfileRule) *ProfileRules {
+	out := make([]ProfileRule, len(rules))
+	copy(out, rules)
+	for i := range out {
+		oids := make([]string, len(out[i].SysObjectIDs))
+		for j, oid := range out[i].SysObjectIDs {
+			oids[j] = normaliseOID(oid)
+		}
+		out[i].SysObjectIDs = oids
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return &ProfileRules{rules: out}
+}
+
+// Len returns the number of rules.
+func (p *ProfileRules) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.rules)
+}
+
+// Match returns the union of device groups of every rule that accepts the
+// fingerprint, in rule-name order with duplicates removed. It returns nil when
+// no rule matches or p is nil.
+func (p *ProfileRules) Match(sysObjectID, sysDescr string) []string {
+	if p == nil {
+		return nil
+	}
+	var groups []string
+	seen := make(map[string]bool)
+	for _, r := range p.rules {
+		if !r.Matches(sysObjectID, sysDescr) {
+			continue
+		}
+		for _, g := range r.DeviceGroups {
+			if !seen[g] {
+				seen[g] = true
+				groups = append(groups, g)
+			}
+		}
+	}
+	return groups
+}
+
+type rawProfileFile map[string]struct {
+	SysObjectID  []string `yaml:"sys_object_id"`
+	SysDescr     []string `yaml:"sys_descr"`
+	DeviceGroups []string `yaml:"device_groups"`
+}
+
+func loadProfiles(dir string, logger *slog.Logger) (*ProfileRules, error) {
+	var rules []ProfileRule
+	files, err := yamlFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewProfileRules(rules), nil
+		}
+		return NewProfileRules(rules), fmt.Errorf("list device_profiles dir %q: %w", dir, err)
+	}
+
+	for _, path := range files {
+		var raw rawProfileFile
+		if err := decodeFile(path, &raw); err != nil {
+			logger.Warn("config: skip malformed device_profile file", "file", path, "error", err.Error())
+			continue
+		}
+		for name, r := range raw {
+			rule, err := compileProfileRule(name, r.SysObjectID, r.SysDescr, r.DeviceGroups)
+			if err != nil {
+				logger.Warn("config: skip invalid device_profile rule", "file", path, "rule", name, "error", err.Error())
+				continue
+			}
+			rules = append(rules, rule)
+		}
+		logger.Debug("config: loaded device_profiles file", "file", path, "count", len(raw))
+	}
+	return NewProfileRules(rules), nil
+}
+
+// compileProfileRule validates a raw rule and compiles its sysDescr patterns.
+func compileProfileRule(name string, oids, descr, groups []string) (ProfileRule, error) {
+	if len(oids) == 0 && len(descr) == 0 {
+		return ProfileRule{}, fmt.Errorf("rule needs sys_object_id or sys_descr")
+	}
+	if len(groups) == 0 {
+		return ProfileRule{}, fmt.Errorf("rule has no device_groups")
+	}
+	rule := ProfileRule{
+		Name:         name,
+		SysObjectIDs: oids,
+		DeviceGroups: groups,
+	}
+	for _, pattern := range descr {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return ProfileRule{}, fmt.Errorf("sys_descr %q: %w", pattern, err)
+		}
+		rule.SysDescr = append(rule.SysDescr, re)
+	}
+	return rule, nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/vpbank/snmp_collector/producer/metrics"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Device templates
+// ─────────────────────────────────────────────────────────────────────────────
+
+// DefaultsTemplate is the reserved device template every device inherits
+// from last, in place of the hard-coded fallbacks. Fields it leaves unset
+// still fall back to port 161, poll_interval 60, timeout 3000, retries 2,
+// version 2c, max_concurrent_polls 4, max_oids 60 and max_repetitions 50.
+const DefaultsTemplate = "defaults"
+
+// Templates use the device schema. A device or template inherits every field
+// it leaves unset from the template named by `extends:`, which may extend
+// another in turn:
+//
+//	# device_templates/templates.yml
+//	defaults:
+//	  timeout: 5000
+//	  device_groups: [generic]
+//	edge:
+//	  poll_interval: 30
+//	  device_groups_append: [bgp]     # → [generic, bgp]
+//	# devices/edge.yml
+//	edge-r1:
+//	  ip: 10.0.0.1
+//	  extends: edge
+//
+// Precedence, highest first: the device, its templates from nearest to
+// furthest, its credential profile, the defaults template, hard-coded
+// fallbacks. Lists replace the inherited list, except that
+// device_groups_append adds to it; tags merge key by key.
+
+func loadTemplates(dir string, logger *slog.Logger) (map[string]rawDeviceEntry, error) {
+	result := make(map[string]rawDeviceEntry)
+	files, err := yamlFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, fmt.Errorf("list device_templates dir %q: %w", dir, err)
+	}
+
+	for _, path := range files {
+		var raw map[string]rawDeviceEntry
+		if err := decodeFile(path, &raw); err != nil {
+			logger.Warn("config: skip malformed device_template file", "file", path, "error", err.Error())
+			continue
+		}
+		for name, t := range raw {
+			if err := validSchedule(t.Schedule); err != nil {
+				logger.Warn("config: skip device_template with invalid schedule", "file", path, "template", name, "error", err.Error())
+				continue
+			}
+			if err := resolveSecrets(&t); err != nil {
+				logger.Warn("config: skip device_template with unresolved secret", "file", path, "template", name, "error", err.Error())
+				continue
+			}
+			if name == DefaultsTemplate && t.Extends != "" {
+				logger.Warn("config: defaults template cannot extend another; ignoring extends", "file", path, "template", name, "extends", t.Extends)
+				t.Extends = ""
+			}
+			result[name] = t
+		}
+		logger.Debug("config: loaded device_templates file", "file", path, "count", len(raw))
+	}
+	return result, nil
+}
+
+// applyTemplates merges the chain of templates e extends into e. Errors name
+// the chain, e.g. `template chain edge → core → edge: cycle`.
+func applyTemplates(e rawDeviceEntry, templates map[string]rawDeviceEntry) (rawDeviceEntry, error) {
+	var chain []string
+	for name := e.Extends; name != ""; {
+		if slices.Contains(chain, name) {
+			chain = append(chain, name)
+			return e, fmt.Errorf("template chain %s: cycle", strings.Join(chain, " → "))
+		}
+		chain = append(chain, name)
+		t, ok := templates[name]
+		if !ok {
+			return e, fmt.Errorf("template chain %s: unknown template %q", strings.Join(chain, " → "), name)
+		}
+		name = t.Extends
+		e = e.inherit(t)
+	}
+	return e, nil
+}
+
+// inherit returns e with every field it leaves unset taken from parent.
+// Lists and maps are copied so entries never share a template's backing
+// storage.
+func (e rawDeviceEntry) inherit(parent rawDeviceEntry) rawDeviceEntry {
+	if e.IP == "" {
+		e.IP = parent.IP
+	}
+	if e.Credentials == "" {
+		e.Credentials = parent.Credentials
+	}
+	if e.Port == 0 {
+		e.Port = parent.Port
+	}
+	if e.PollInterval == 0 {
+		e.PollInterval = parent.PollInterval
+	}
+	if e.Timeout == 0 {
+		e.Timeout = parent.Timeout
+	}
+	if e.Retries == 0 {
+		e.Retries = parent.Retries
+	}
+	if e.ExponentialTimeout == nil {
+		e.ExponentialTimeout = parent.ExponentialTimeout
+	}
+	if e.Version == "" {
+		e.Version = parent.Version
+	}
+	if len(e.Communities) == 0 {
+		e.Communities = slices.Clone(parent.Communities)
+	}
+	if len(e.V3Credentials) == 0 {
+		e.V3Credentials = slices.Clone(parent.V3Credentials)
+	}
+	if e.MaxConcurrentPolls == 0 {
+		e.MaxConcurrentPolls = parent.MaxConcurrentPolls
+	}
+	if e.MaxOids == 0 {
+		e.MaxOids = parent.MaxOids
+	}
+	if e.MaxRepetitions == 0 {
+		e.MaxRepetitions = parent.MaxRepetitions
+	}
+	if e.AdaptiveBulk == nil {
+		e.AdaptiveBulk = parent.AdaptiveBulk
+	}
+	if e.Schedule == nil {
+		e.Schedule = parent.Schedule
+	}
+	if len(parent.Tags) > 0 {
+		e.Tags = metrics.MergeTags(parent.Tags, e.Tags)
+	} else {
+		e.Tags = maps.Clone(e.Tags)
+	}
+
+	// The parent's appends apply to the parent's base list, before e's.
+	if len(e.DeviceGroups) == 0 {
+		e.DeviceGroups = slices.Clone(parent.DeviceGroups)
+		e.DeviceGroupsAppend = append(slices.Clone(parent.DeviceGroupsAppend), e.DeviceGroupsAppend...)
+	}
+	e.Extends = parent.Extends
+	return e
+}
+
+// deviceGroups returns e's effective device groups: the base list followed
+// by the appended groups, without duplicates.
+func (e rawDeviceEntry) deviceGroups() []string {
+	if len(e.DeviceGroupsAppend) == 0 {
+		return e.DeviceGroups
+	}
+	out := slices.Clone(e.DeviceGroups)
+	for _, g := range e.DeviceGroupsAppend {
+		if !slices.Contains(out, g) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
@@ -0,0 +1,733 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/snmp/decoder"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Issues
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Severity ranks a validation Issue.
+type Severity int
+
+const (
+	// SeverityError marks configuration that Load skips or that cannot poll.
+	SeverityError Severity = iota
+
+	// SeverityWarning marks configuration that loads but is probably wrong.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue is one problem found by Validate.
+type Issue struct {
+	File     string
+	Line     int // 1-based; 0 when the issue concerns the whole file
+	Severity Severity
+	Message  string
+}
+
+// String formats the issue as "file:line: severity: message".
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Severity, i.Message)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Validate
+// ─────────────────────────────────────────────────────────────────────────────
+
+// authProtocols and privProtocols are the SNMPv3 protocol names the poller
+// accepts, compared case-insensitively. Empty means none.
+var (
+	authProtocols = []string{"", "noauth", "md5", "sha", "sha224", "sha256", "sha384", "sha512"}
+	privProtocols = []string{"", "nopriv", "des", "aes", "aes192", "aes256", "aes192c", "aes256c"}
+)
+
+// indexTypes are the supported object index encodings.
+var indexTypes = []string{
+	"Integer", "Integer32", "Unsigned32", "OctetString", "ImplicitOctetString",
+	"ObjectIdentifier", "ImplicitObjectIdentifier", "IpAddress", "MacAddress", "Opaque",
+}
+
+// Entry kinds, one per configuration tree. Each also starts the messages
+// about its entries, e.g. `device "r1": ...`.
+const (
+	kindCredentials  = "credential profile"
+	kindObjects      = "object"
+	kindObjectGroups = "object group"
+	kindDeviceGroups = "device group"
+	kindTemplates    = "device template"
+	kindDevices      = "device"
+	kindEnums        = "enum"
+	kindVendors      = "vendor"
+	kindProfiles     = "device profile"
+)
+
+// Validate reads every configuration tree under paths and reports each
+// problem with its file and line, sorted by file and line.
+//
+// The checks run on what Load resolves: every entry Load warns about and
+// skips is an error, and the loaded devices, templates, groups, objects and
+// profiles are checked for values and references Load accepts but that
+// cannot poll (unknown groups and objects otherwise surface only in
+// ResolveJobs). A pass over the YAML nodes only maps each entry and key to
+// its line and notices names defined more than once.
+//
+// The returned error is non-nil only when a directory cannot be listed;
+// missing directories are skipped as they are by Load.
+func Validate(paths Paths) ([]Issue, error) {
+	v := &validator{entries: make(map[string]map[string][]entry)}
+	trees := []struct{ kind, dir string }{
+		{kindCredentials, paths.Credentials},
+		{kindObjects, paths.Objects},
+		{kindObjectGroups, paths.ObjectGroups},
+		{kindDeviceGroups, paths.DeviceGroups},
+		{kindTemplates, paths.Templates},
+		{kindDevices, paths.Devices},
+		{kindEnums, paths.Enums},
+		{kindVendors, paths.Vendors},
+		{kindProfiles, paths.Profiles},
+	}
+	for _, tree := range trees {
+		if err := v.index(tree.kind, tree.dir); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := Load(paths, slog.New(loadHandler{v}))
+	if err != nil {
+		return nil, err
+	}
+	v.checkCredentials(cfg)
+	v.checkTemplates(cfg)
+	v.checkDevices(cfg)
+	v.checkDeviceGroups(cfg)
+	v.checkObjectGroups(cfg)
+	v.checkObjects(cfg)
+	v.checkEnums(cfg)
+	v.checkVendors(cfg)
+	v.checkProfiles(cfg)
+
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		a, b := v.issues[i], v.issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return v.issues, nil
+}
+
+type location struct {
+	file string
+	line int
+}
+
+func (l location) String() string { return fmt.Sprintf("%s:%d", l.file, l.line) }
+
+// entry is one top-level key of a configuration file and its value.
+type entry struct {
+	file       string
+	key, value *yaml.Node
+}
+
+// line returns the line of key in the entry's mapping, or the line of the
+// value when the entry does not set key itself or key is empty.
+func (e entry) line(key string) int { return keyLine(e.value, key) }
+
+// sets reports whether the entry sets key itself rather than inheriting it.
+func (e entry) sets(key string) bool { return field(e.value, key) != nil }
+
+// itemLine returns the line of value in the first of the sequences keys
+// that lists it, or 0 when the entry does not list it itself.
+func (e entry) itemLine(value string, keys ...string) int {
+	for _, key := range keys {
+		seq := field(e.value, key)
+		if seq == nil || seq.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range seq.Content {
+			if item.Value == value {
+				return item.Line
+			}
+		}
+	}
+	return 0
+}
+
+type validator struct {
+	issues []Issue
+
+	// entries maps kind → name → every definition of the name, in the
+	// order Load reads them; Load keeps the last.
+	entries map[string]map[string][]entry
+}
+
+func (v *validator) errorf(file string, line int, format string, args ...any) {
+	v.issues = append(v.issues, Issue{File: file, Line: line, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(file string, line int, format string, args ...any) {
+	v.issues = append(v.issues, Issue{File: file, Line: line, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// entryName returns the name entries of kind are indexed by: OID keys
+// without a leading dot, as Load stores them, and other keys as written.
+func entryName(kind, name string) string {
+	if kind == kindEnums || kind == kindVendors {
+		return normaliseOID(strings.TrimSpace(name))
+	}
+	return name
+}
+
+// last returns the definition of name that Load keeps.
+func (v *validator) last(kind, name string) (entry, bool) {
+	defs := v.entries[kind][entryName(kind, name)]
+	if len(defs) == 0 {
+		return entry{}, false
+	}
+	return defs[len(defs)-1], true
+}
+
+// find returns the definition of name in file.
+func (v *validator) find(kind, file, name string) (entry, bool) {
+	for _, e := range v.entries[kind][entryName(kind, name)] {
+		if e.file == file {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// index records where each top-level key of the YAML files under dir is
+// defined, and reports names defined again. Files that do not parse are
+// left to Load, which reports them.
+func (v *validator) index(kind, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	files, err := yamlFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("config: list %q: %w", dir, err)
+	}
+	byName := v.entries[kind]
+	if byName == nil {
+		byName = make(map[string][]entry)
+		v.entries[kind] = byName
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+			continue
+		}
+		for _, kv := range pairs(doc.Content[0]) {
+			name := entryName(kind, kv[0].Value)
+			if defs := byName[name]; len(defs) > 0 {
+				prev := location{defs[len(defs)-1].file, defs[len(defs)-1].key.Line}
+				switch kind {
+				case kindDevices:
+					v.errorf(path, kv[0].Line, "device %q is already defined at %s", name, prev)
+				case kindEnums, kindVendors, kindProfiles:
+				default:
+					v.warnf(path, kv[0].Line, "%s %q redefines the one at %s", kind, name, prev)
+				}
+			}
+			byName[name] = append(byName[name], entry{file: path, key: kv[0], value: kv[1]})
+		}
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Load warnings
+// ─────────────────────────────────────────────────────────────────────────────
+
+// loadHandler receives what Load logs while reading the trees and reports
+// each warning as an Issue on the line of the entry it names. A warning
+// about an entry or file Load skips is an error; the rest stay warnings.
+type loadHandler struct{ v *validator }
+
+// entryAttrs maps the log attribute naming an entry to the entry's kind.
+var entryAttrs = []struct{ attr, kind string }{
+	{"hostname", kindDevices},
+	{"template", kindTemplates},
+	{"profile", kindCredentials},
+	{"group", kindObjectGroups},
+	{"rule", kindProfiles},
+	{"oid", kindEnums},
+}
+
+func (h loadHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn
+}
+
+func (h loadHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h loadHandler) WithGroup(string) slog.Handler { return h }
+
+func (h loadHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]string, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	file, msg := attrs["file"], attrs["error"]
+	if msg == io.EOF.Error() {
+		return nil // an empty file
+	}
+	if msg == "" {
+		msg = strings.TrimPrefix(r.Message, "config: ")
+	}
+	severity := SeverityWarning
+	if strings.HasPrefix(r.Message, "config: skip ") {
+		severity = SeverityError
+	}
+
+	for _, a := range entryAttrs {
+		name, ok := attrs[a.attr]
+		if !ok {
+			continue
+		}
+		line := 0
+		if e, ok := h.v.find(a.kind, file, name); ok {
+			line = e.line(warningKey(r.Message, msg))
+		}
+		h.v.issues = append(h.v.issues, Issue{
+			File: file, Line: line, Severity: severity,
+			Message: fmt.Sprintf("%s %q: %s", a.kind, entryName(a.kind, name), msg),
+		})
+		return nil
+	}
+	h.v.yamlError(file, msg)
+	return nil
+}
+
+// warningKey returns the key a Load warning is about, so the issue lands
+// on its line; "" means the entry as a whole.
+func warningKey(message, err string) string {
+	switch {
+	case strings.Contains(message, "schedule"):
+		return "schedule"
+	case strings.Contains(message, "extends"), strings.HasPrefix(err, "template chain"):
+		return "extends"
+	case strings.HasPrefix(err, "unknown credential profile"):
+		return "credentials"
+	}
+	return ""
+}
+
+// yamlErrLine extracts the line number from a yaml.v3 error message.
+var yamlErrLine = regexp.MustCompile(`line (\d+)`)
+
+// yamlError reports a file Load cannot read, parse or decode, moving the
+// line yaml.v3 embeds in the message into the Issue.
+func (v *validator) yamlError(path, msg string) {
+	msg = strings.TrimPrefix(msg, "yaml: ")
+	msg = strings.ReplaceAll(strings.TrimPrefix(msg, "unmarshal errors:\n  "), "\n  ", "; ")
+	line := 0
+	if m := yamlErrLine.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = strings.TrimPrefix(msg, m[0]+": ")
+	}
+	v.errorf(path, line, "%s", msg)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// YAML nodes
+// ─────────────────────────────────────────────────────────────────────────────
+
+// pairs returns the key / value node pairs of a mapping node.
+func pairs(n *yaml.Node) [][2]*yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	out := make([][2]*yaml.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		out = append(out, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+	}
+	return out
+}
+
+// field returns the value node of key in mapping n, or nil.
+func field(n *yaml.Node, key string) *yaml.Node {
+	for _, kv := range pairs(n) {
+		if kv[0].Value == key {
+			return kv[1]
+		}
+	}
+	return nil
+}
+
+// keyLine returns the line of key in mapping n, or n's own line when absent.
+func keyLine(n *yaml.Node, key string) int {
+	for _, kv := range pairs(n) {
+		if kv[0].Value == key {
+			return kv[0].Line
+		}
+	}
+	return n.Line
+}
+
+// itemLine returns the line of the i-th item of sequence key in mapping n.
+func itemLine(n *yaml.Node, key string, i int) int {
+	seq := field(n, key)
+	if seq == nil || seq.Kind != yaml.SequenceNode || i >= len(seq.Content) {
+		return keyLine(n, key)
+	}
+	return seq.Content[i].Line
+}
+
+// validOID reports whether oid is a dotted numeric OID with at least two
+// arcs. A leading dot is allowed.
+func validOID(oid string) bool {
+	arcs := strings.Split(normaliseOID(oid), ".")
+	if len(arcs) < 2 {
+		return false
+	}
+	for _, arc := range arcs {
+		if _, err := strconv.ParseUint(arc, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Credentials, templates and devices
+// ─────────────────────────────────────────────────────────────────────────────
+
+func (v *validator) checkCredentials(cfg *LoadedConfig) {
+	for _, name := range slices.Sorted(maps.Keys(cfg.Credentials)) {
+		e, ok := v.last(kindCredentials, name)
+		if !ok {
+			continue
+		}
+		p := cfg.Credentials[name]
+		subject := fmt.Sprintf("credential profile %q", name)
+		switch p.Version {
+		case "", "1", "2c", "3":
+		default:
+			v.errorf(e.file, e.line("version"), "%s: version %q is not one of 1, 2c, 3", subject, p.Version)
+		}
+		if p.Port < 0 || p.Port > 65535 {
+			v.errorf(e.file, e.line("port"), "%s: port %d out of range", subject, p.Port)
+		}
+		if p.Timeout < 0 || p.Retries < 0 {
+			v.errorf(e.file, e.line(""), "%s: timeout and retries must not be negative", subject)
+		}
+		for i, cred := range p.V3Credentials {
+			v.checkV3(e.file, itemLine(e.value, "v3_credentials", i), subject, cred)
+		}
+	}
+}
+
+func (v *validator) checkTemplates(cfg *LoadedConfig) {
+	for _, name := range slices.Sorted(maps.Keys(cfg.templates)) {
+		e, ok := v.last(kindTemplates, name)
+		if !ok {
+			continue
+		}
+		t := cfg.templates[name]
+		subject := fmt.Sprintf("device template %q", name)
+		v.checkEntry(cfg, e, subject, t.deviceConfig())
+		if _, err := applyTemplates(t, cfg.templates); err != nil {
+			v.errorf(e.file, e.line("extends"), "%s: %v", subject, err)
+		}
+	}
+}
+
+func (v *validator) checkDevices(cfg *LoadedConfig) {
+	for _, host := range slices.Sorted(maps.Keys(cfg.Devices)) {
+		e, ok := v.last(kindDevices, host)
+		if !ok {
+			continue
+		}
+		dev := cfg.Devices[host]
+		subject := fmt.Sprintf("device %q", host)
+		v.checkEntry(cfg, e, subject, dev)
+
+		// The rest applies to the device as resolved, whichever of its
+		// templates or credential profile set the value.
+		if dev.IP == "" {
+			v.errorf(e.file, e.line(""), "%s: ip is required", subject)
+		}
+		switch dev.Version {
+		case "1", "2c":
+			if len(dev.Communities) == 0 {
+				v.warnf(e.file, e.line(""), "%s: no communities; requests use an empty community", subject)
+			}
+		case "3":
+			if len(dev.V3Credentials) == 0 {
+				v.errorf(e.file, e.line(""), "%s: version 3 needs v3_credentials", subject)
+			}
+		}
+		if len(dev.DeviceGroups) == 0 {
+			v.warnf(e.file, e.line(""), "%s has no device groups; nothing is polled", subject)
+		}
+	}
+}
+
+// checkEntry checks the values a device or device template sets itself, so
+// a value it inherits is reported once, where it is written.
+func (v *validator) checkEntry(cfg *LoadedConfig, e entry, subject string, d DeviceConfig) {
+	if e.sets("port") && (d.Port < 0 || d.Port > 65535) {
+		v.errorf(e.file, e.line("port"), "%s: port %d out of range", subject, d.Port)
+	}
+	for _, f := range []struct {
+		key string
+		val int
+	}{
+		{"poll_interval", d.PollInterval},
+		{"timeout", d.Timeout},
+		{"retries", d.Retries},
+		{"max_concurrent_polls", d.MaxConcurrentPolls},
+		{"max_oids", d.MaxOids},
+		{"max_repetitions", d.MaxRepetitions},
+	} {
+		if e.sets(f.key) && f.val < 0 {
+			v.errorf(e.file, e.line(f.key), "%s: %s must not be negative", subject, f.key)
+		}
+	}
+	if e.sets("version") {
+		switch d.Version {
+		case "", "1", "2c", "3":
+		default:
+			v.errorf(e.file, e.line("version"), "%s: version %q is not one of 1, 2c, 3", subject, d.Version)
+		}
+	}
+	if e.sets("v3_credentials") {
+		for i, cred := range d.V3Credentials {
+			v.checkV3(e.file, itemLine(e.value, "v3_credentials", i), subject, cred)
+		}
+	}
+	if _, ok := cfg.Credentials[d.Credentials]; e.sets("credentials") && !ok && d.Credentials != "" {
+		v.errorf(e.file, e.line("credentials"), "%s: unknown credential profile %q", subject, d.Credentials)
+	}
+	for _, g := range slices.Concat(d.DeviceGroups, d.DeviceGroupsAppend) {
+		if _, ok := cfg.DeviceGroups[g]; ok || g == AutoDeviceGroup {
+			continue
+		}
+		if line := e.itemLine(g, "device_groups", "device_groups_append"); line != 0 {
+			v.errorf(e.file, line, "%s: unknown device group %q", subject, g)
+		}
+	}
+}
+
+func (v *validator) checkV3(path string, line int, subject string, c V3Credentials) {
+	auth, priv := strings.ToLower(c.AuthenticationProtocol), strings.ToLower(c.PrivacyProtocol)
+	if c.Username == "" {
+		v.errorf(path, line, "%s: v3 credentials need a username", subject)
+	}
+	if !slices.Contains(authProtocols, auth) {
+		v.errorf(path, line, "%s: unknown authentication_protocol %q", subject, c.AuthenticationProtocol)
+	}
+	if !slices.Contains(privProtocols, priv) {
+		v.errorf(path, line, "%s: unknown privacy_protocol %q", subject, c.PrivacyProtocol)
+	}
+	hasAuth := auth != "" && auth != "noauth"
+	hasPriv := priv != "" && priv != "nopriv"
+	if hasPriv && !hasAuth {
+		v.errorf(path, line, "%s: privacy_protocol %q needs an authentication_protocol", subject, c.PrivacyProtocol)
+	}
+	if hasAuth && c.AuthenticationPassphrase == "" {
+		v.errorf(path, line, "%s: authentication_protocol %q needs authentication_passphrase", subject, c.AuthenticationProtocol)
+	}
+	if hasPriv && c.PrivacyPassphrase == "" {
+		v.errorf(path, line, "%s: privacy_protocol %q needs privacy_passphrase", subject, c.PrivacyProtocol)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Groups and objects
+// ─────────────────────────────────────────────────────────────────────────────
+
+func (v *validator) checkDeviceGroups(cfg *LoadedConfig) {
+	for _, name := range slices.Sorted(maps.Keys(cfg.DeviceGroups)) {
+		e, ok := v.last(kindDeviceGroups, name)
+		if !ok {
+			continue
+		}
+		for i, og := range cfg.DeviceGroups[name].ObjectGroups {
+			if _, ok := cfg.ObjectGroups[og]; !ok {
+				v.errorf(e.file, itemLine(e.value, "object_groups", i), "device group %q: unknown object group %q", name, og)
+			}
+		}
+	}
+}
+
+func (v *validator) checkObjectGroups(cfg *LoadedConfig) {
+	for _, name := range slices.Sorted(maps.Keys(cfg.ObjectGroups)) {
+		e, ok := v.last(kindObjectGroups, name)
+		if !ok {
+			continue
+		}
+		g := cfg.ObjectGroups[name]
+		if g.PollInterval < 0 {
+			v.errorf(e.file, e.line("poll_interval"), "object group %q: poll_interval must not be negative", name)
+		}
+		for i, obj := range g.Objects {
+			if _, ok := cfg.ObjectDefs[obj]; !ok {
+				v.errorf(e.file, itemLine(e.value, "objects", i), "object group %q: unknown object %q", name, obj)
+			}
+		}
+	}
+}
+
+// checkObjects checks each object definition. Augments and overrides only
+// document how objects relate, and nothing polls through them, so an
+// unknown target is a warning.
+func (v *validator) checkObjects(cfg *LoadedConfig) {
+	for _, key := range slices.Sorted(maps.Keys(cfg.ObjectDefs)) {
+		e, ok := v.last(kindObjects, key)
+		if !ok {
+			continue
+		}
+		def := cfg.ObjectDefs[key]
+		if def.PollInterval < 0 {
+			v.errorf(e.file, e.line("poll_interval"), "object %q: poll_interval must not be negative", key)
+		}
+		for i, idx := range def.Index {
+			line := itemLine(e.value, "index", i)
+			if !slices.Contains(indexTypes, idx.Type) {
+				v.errorf(e.file, line, "object %q: index type %q is not one of %s", key, idx.Type, strings.Join(indexTypes, ", "))
+			}
+			if idx.OID != "" && !validOID(idx.OID) {
+				v.errorf(e.file, line, "object %q: index oid %q is not a numeric OID", key, idx.OID)
+			}
+		}
+		if def.Augments != "" {
+			if _, ok := cfg.ObjectDefs[def.Augments]; !ok {
+				v.warnf(e.file, e.line("augments"), "object %q augments unknown object %q", key, def.Augments)
+			}
+		}
+
+		if len(def.Attributes) == 0 {
+			v.errorf(e.file, e.line(""), "object %q has no attributes", key)
+			continue
+		}
+		attrs := field(e.value, "attributes")
+		for _, name := range slices.Sorted(maps.Keys(def.Attributes)) {
+			a, node := def.Attributes[name], field(attrs, name)
+			switch {
+			case a.OID == "":
+				v.errorf(e.file, keyLine(attrs, name), "object %q attribute %q: oid is required", key, name)
+			case !validOID(a.OID):
+				v.errorf(e.file, keyLine(node, "oid"), "object %q attribute %q: oid %q is not a numeric OID", key, name, a.OID)
+			}
+			if a.Syntax != "" && !decoder.KnownSyntax(a.Syntax) {
+				v.warnf(e.file, keyLine(node, "syntax"), "object %q attribute %q: unknown syntax %q", key, name, a.Syntax)
+			}
+			switch a.Rediscover {
+			case "", "OnChange", "OnReset":
+			default:
+				v.errorf(e.file, keyLine(node, "rediscover"), "object %q attribute %q: rediscover %q is not OnChange or OnReset", key, name, a.Rediscover)
+			}
+			if ovr := a.Overrides; ovr != nil {
+				target, ok := cfg.ObjectDefs[ovr.Object]
+				switch {
+				case !ok:
+					v.warnf(e.file, keyLine(node, "overrides"), "object %q attribute %q overrides unknown object %q", key, name, ovr.Object)
+				case !hasAttribute(target, ovr.Attribute):
+					v.warnf(e.file, keyLine(node, "overrides"), "object %q attribute %q overrides unknown attribute %q of %q", key, name, ovr.Attribute, ovr.Object)
+				}
+			}
+		}
+	}
+}
+
+func hasAttribute(def models.ObjectDefinition, name string) bool {
+	_, ok := def.Attributes[name]
+	return ok
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Enums, vendors and profiles
+// ─────────────────────────────────────────────────────────────────────────────
+
+func (v *validator) checkEnums(cfg *LoadedConfig) {
+	if cfg.Enums == nil {
+		return
+	}
+	for _, oid := range slices.Sorted(maps.Keys(cfg.Enums.OIDEnums())) {
+		if e, ok := v.last(kindEnums, oid); ok && !validOID(oid) {
+			v.errorf(e.file, e.key.Line, "enum key %q is not a numeric OID", e.key.Value)
+		}
+	}
+
+	// Integer enums label attribute values, so each needs an attribute.
+	attrOIDs := make(map[string]bool)
+	for _, def := range cfg.ObjectDefs {
+		for _, a := range def.Attributes {
+			attrOIDs[a.OID] = true
+		}
+	}
+	for _, oid := range slices.Sorted(maps.Keys(cfg.Enums.IntEnums())) {
+		e, ok := v.last(kindEnums, oid)
+		switch {
+		case !ok:
+		case !validOID(oid):
+			v.errorf(e.file, e.key.Line, "enum key %q is not a numeric OID", e.key.Value)
+		case !attrOIDs[oid]:
+			v.warnf(e.file, e.key.Line, "enum %q: no object attribute has this OID", oid)
+		}
+	}
+}
+
+func (v *validator) checkVendors(cfg *LoadedConfig) {
+	for _, oid := range slices.Sorted(maps.Keys(cfg.Vendors.rules)) {
+		if e, ok := v.last(kindVendors, oid); ok && !validOID(oid) {
+			v.errorf(e.file, e.key.Line, "vendor key %q is not a numeric OID", e.key.Value)
+		}
+	}
+}
+
+func (v *validator) checkProfiles(cfg *LoadedConfig) {
+	for _, r := range cfg.Profiles.rules {
+		e, ok := v.last(kindProfiles, r.Name)
+		if !ok {
+			continue
+		}
+		for i, oid := range r.SysObjectIDs {
+			if !validOID(oid) {
+				v.errorf(e.file, itemLine(e.value, "sys_object_id", i), "device profile %q: sys_object_id %q is not a numeric OID", r.Name, oid)
+			}
+		}
+		for i, g := range r.DeviceGroups {
+			if _, ok := cfg.DeviceGroups[g]; !ok {
+				v.errorf(e.file, itemLine(e.value, "device_groups", i), "device profile %q: unknown device group %q", r.Name, g)
+			}
+		}
+	}
+}
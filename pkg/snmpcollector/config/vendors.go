@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// VendorMap — sysObjectID → vendor / model
+// ─────────────────────────────────────────────────────────────────────────────
+
+// VendorRule is the vendor and model assigned to a sysObjectID prefix.
+// Either field may be empty; an empty Vendor is inherited from the longest
+// shorter prefix that names one (typically the enterprise OID).
+type VendorRule struct {
+	Vendor string `yaml:"vendor"`
+	Model  string `yaml:"model"`
+}
+
+// VendorMap resolves a device's sysObjectID to a vendor and model using
+// longest-prefix matching on OID arc boundaries. It is read-only after
+// loading and safe for concurrent use.
+//
+// Loaded from YAML files under INPUT_SNMP_VENDOR_DEFINITIONS_DIRECTORY_PATH:
+//
+//	1.3.6.1.4.1.9:
+//	  vendor: Cisco
+//	1.3.6.1.4.1.9.1.1208:
+//	  model: WS-C2960X-48FPD-L
+type VendorMap struct {
+	rules map[string]VendorRule // normalised OID prefix → rule
+}
+
+// NewVendorMap builds a VendorMap from prefix → rule pairs. Prefixes may carry
+// a leading dot.
+func NewVendorMap(rules map[string]VendorRule) *VendorMap {
+	m := &VendorMap{rules: make(map[string]VendorRule, len(rules))}
+	for oid, r := range rules {
+		m.rules[normaliseOID(oid)] = r
+	}
+	return m
+}
+
+// Len returns the number of registered prefixes.
+func (m *VendorMap) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.rules)
+}
+
+// Match returns the vendor and model for sysObjectID. The model comes from the
+// longest matching prefix that defines one, and the vendor likewise. Both are
+// empty when nothing matches or m is nil.
+func (m *VendorMap) Match(sysObjectID string) (vendor, model string) {
+	if m == nil || len(m.rules) == 0 {
+		return "", ""
+	}
+	oid := normaliseOID(strings.TrimSpace(sysObjectID))
+	for oid != "" {
+		if r, ok := m.rules[oid]; ok {
+			if model == "" {
+				model = r.Model
+			}
+			if vendor == "" {
+				vendor = r.Vendor
+			}
+			if vendor != "" && model != "" {
+				return vendor, model
+			}
+		}
+		dot := strings.LastIndex(oid, ".")
+		if dot < 0 {
+			break
+		}
+		oid = oid[:dot]
+	}
+	return vendor, model
+}
+
+func loadVendors(dir string, logger *slog.Logger) (*VendorMap, error) {
+	rules := make(map[string]VendorRule)
+	files, err := yamlFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewVendorMap(rules), nil
+		}
+		return NewVendorMap(rules), fmt.Errorf("list vendors dir %q: %w", dir, err)
+	}
+
+	for _, path := range files {
+		var raw map[string]VendorRule
+		if err := decodeFile(path, &raw); err != nil {
+			logger.Warn("config: skip malformed vendor file", "file", path, "error", err.Error())
+			continue
+		}
+		for oid, r := range raw {
+			rules[oid] = r
+		}
+		logger.Debug("config: loaded vendors file", "file", path, "count", len(raw))
+	}
+	return NewVendorMap(rules), nil
+}
@@ -0,0 +1,147 @@
+// Package credentials resolves secret references in configuration values, so
+// device files need not hold plaintext communities or SNMPv3 passphrases.
+//
+// A value is a reference when it has one of these forms:
+//
+//	${SNMP_COMMUNITY}              environment variable
+//	env:SNMP_COMMUNITY             environment variable
+//	file:/run/secrets/community    file content, trailing newline removed
+//	<scheme>:<ref>                 a Provider registered under scheme
+//
+// Any other value, including one whose prefix is not a registered scheme, is
+// a literal and returned unchanged. External stores such as HashiCorp Vault
+// plug in through Register:
+//
+//	credentials.Register("vault", credentials.ProviderFunc(func(ref string) (string, error) {
+//		return vaultClient.Read(ref) // e.g. "secret/data/snmp#community"
+//	}))
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned (wrapped) when a provider has no secret for a
+// reference.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets for one reference scheme.
+type Provider interface {
+	// Secret returns the secret named by ref, the part of the reference after
+	// "scheme:". Errors must not contain the secret itself.
+	Secret(ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func(ref string) (string, error)
+
+// Secret calls f(ref).
+func (f ProviderFunc) Secret(ref string) (string, error) { return f(ref) }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Resolver
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Resolver maps reference schemes to providers. It is safe for concurrent use.
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver returns a Resolver with the built-in "env" and "file" schemes.
+func NewResolver() *Resolver {
+	return &Resolver{providers: map[string]Provider{
+		"env":  ProviderFunc(envSecret),
+		"file": ProviderFunc(fileSecret),
+	}}
+}
+
+// Register makes p resolve references of the form "scheme:ref", replacing
+// any provider already registered for scheme.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = p
+}
+
+// IsReference reports whether value would be resolved through a provider
+// rather than returned as a literal.
+func (r *Resolver) IsReference(value string) bool {
+	_, _, ok := r.lookup(value)
+	return ok
+}
+
+// Resolve returns the secret value references, or value itself when it is a
+// literal.
+func (r *Resolver) Resolve(value string) (string, error) {
+	scheme, ref, ok := r.lookup(value)
+	if !ok {
+		return value, nil
+	}
+	r.mu.RLock()
+	p := r.providers[scheme]
+	r.mu.RUnlock()
+	secret, err := p.Secret(ref)
+	if err != nil {
+		return "", fmt.Errorf("credentials: %s:%s: %w", scheme, ref, err)
+	}
+	return secret, nil
+}
+
+// lookup splits value into a registered scheme and its reference.
+func (r *Resolver) lookup(value string) (scheme, ref string, ok bool) {
+	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") && len(value) > 3 {
+		return "env", value[2 : len(value)-1], true
+	}
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found || scheme == "" || ref == "" {
+		return "", "", false
+	}
+	r.mu.RLock()
+	_, ok = r.providers[scheme]
+	r.mu.RUnlock()
+	return scheme, ref, ok
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Default resolver
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Default is the Resolver the configuration loader uses.
+var Default = NewResolver()
+
+// Register registers p for scheme on Default.
+func Register(scheme string, p Provider) { Default.Register(scheme, p) }
+
+// Resolve resolves value with Default.
+func Resolve(value string) (string, error) { return Default.Resolve(value) }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Built-in providers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func envSecret(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s: %w", name, ErrNotFound)
+	}
+	return v, nil
+}
+
+// fileSecret reads a secret file such as a Docker or Kubernetes secret mount.
+// One trailing newline is removed.
+func fileSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return "", err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
@@ -0,0 +1,67 @@
+package credentials_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/credentials"
+)
+
+func TestResolve_BuiltIns(t *testing.T) {
+	t.Setenv("SNMP_TEST_COMMUNITY", "s3cret")
+	path := filepath.Join(t.TempDir(), "priv")
+	if err := os.WriteFile(path, []byte("privpass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := credentials.NewResolver()
+	tests := []struct{ in, want string }{
+		{"${SNMP_TEST_COMMUNITY}", "s3cret"},
+		{"env:SNMP_TEST_COMMUNITY", "s3cret"},
+		{"file:" + path, "privpass"},
+		{"public", "public"},                 // literal
+		{"vault:secret/x", "vault:secret/x"}, // unregistered scheme is a literal
+		{"${}", "${}"},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	r := credentials.NewResolver()
+	for _, ref := range []string{"${SNMP_TEST_UNSET_VAR}", "file:" + filepath.Join(t.TempDir(), "missing")} {
+		_, err := r.Resolve(ref)
+		if !errors.Is(err, credentials.ErrNotFound) {
+			t.Errorf("Resolve(%q) error = %v, want ErrNotFound", ref, err)
+		}
+	}
+}
+
+func TestRegister_CustomProvider(t *testing.T) {
+	store := map[string]string{"snmp/core#auth": "authpass"}
+	r := credentials.NewResolver()
+	r.Register("store", credentials.ProviderFunc(func(ref string) (string, error) {
+		if v, ok := store[ref]; ok {
+			return v, nil
+		}
+		return "", credentials.ErrNotFound
+	}))
+
+	if !r.IsReference("store:snmp/core#auth") || r.IsReference("public") {
+		t.Error("IsReference should match registered schemes only")
+	}
+	if got, err := r.Resolve("store:snmp/core#auth"); err != nil || got != "authpass" {
+		t.Errorf("Resolve = %q, %v", got, err)
+	}
+	_, err := r.Resolve("store:snmp/other")
+	if err == nil || !strings.Contains(err.Error(), "store:snmp/other") {
+		t.Errorf("error %v should name the reference", err)
+	}
+}
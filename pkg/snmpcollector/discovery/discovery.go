@@ -0,0 +1,443 @@
+// Package discovery sweeps IP ranges for SNMP agents and turns the responders
+// into device configuration entries.
+//
+// Every address in the configured targets is probed with each candidate
+// credential in turn (credential profiles first, then v2c communities, then
+// SNMPv3 credential sets) until one answers a Get of the SNMPv2-MIB system group. The responder's
+// sysName becomes its hostname and its sysObjectID / sysDescr select device
+// groups through config.ProfileRules.
+//
+// Probes are rate-limited and the number of hosts in flight is bounded, so a
+// sweep of a large range does not flood the network or the local socket
+// table.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/credentials"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Options
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ProbeFunc reads the system group of the device described by dev, using the
+// single credential it carries.
+type ProbeFunc func(ctx context.Context, dev config.DeviceConfig) (poller.SystemInfo, error)
+
+// Options configures a Sweeper.
+type Options struct {
+	// Targets are CIDR prefixes ("10.0.0.0/24") or single addresses.
+	Targets []string
+
+	// Port is the SNMP UDP port probed on every address (default 161).
+	Port int
+
+	// Timeout is the per-probe timeout (default 1 s).
+	Timeout time.Duration
+
+	// Retries is the number of retries per probe (default 0).
+	Retries int
+
+	// CredentialProfiles names the profiles in Credentials to try first, in
+	// order. A device that answers one is written with `credentials: <name>`
+	// and inherits the profile's version and credentials.
+	CredentialProfiles []string
+
+	// Credentials maps profile name → CredentialProfile, secrets resolved
+	// (LoadedConfig.Credentials).
+	Credentials map[string]config.CredentialProfile
+
+	// Communities are the v2c community strings to try after the profiles,
+	// in order. Each may be a secret reference: it is resolved for the probe
+	// and written to the device file as given.
+	Communities []string
+
+	// V3Credentials are the SNMPv3 credential sets to try after the
+	// communities, in order. Passphrases may be secret references, written
+	// to the device file as given.
+	V3Credentials []config.V3Credentials
+
+	// Concurrency bounds how many hosts are probed at once (default 32).
+	Concurrency int
+
+	// Rate is the maximum number of probes sent per second across the whole
+	// sweep (default 50).
+	Rate float64
+
+	// MaxHosts caps the number of addresses a sweep may expand to, guarding
+	// against a mistyped prefix length (default 65536).
+	MaxHosts int
+
+	// Vendors and Profiles resolve vendor / model and device groups from the
+	// responder's fingerprint. Both may be nil.
+	Vendors  *config.VendorMap
+	Profiles *config.ProfileRules
+
+	// Probe overrides the SNMP probe. Tests inject a stub.
+	Probe ProbeFunc
+}
+
+func (o *Options) defaults() {
+	if o.Port <= 0 {
+		o.Port = 161
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = time.Second
+	}
+	if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 32
+	}
+	if o.Rate <= 0 {
+		o.Rate = 50
+	}
+	if o.MaxHosts <= 0 {
+		o.MaxHosts = 65536
+	}
+	if o.Probe == nil {
+		o.Probe = probeSNMP
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Sweeper
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Result is one responding device.
+type Result struct {
+	// Hostname is the device key: the responder's sysName, or its address
+	// when sysName is empty or already taken by another responder.
+	Hostname string
+
+	// Device is the entry to write for the responder: its address, the
+	// credential that answered as configured — a credential profile name,
+	// or the port, version and community / v3 credential set with secret
+	// references unresolved — and the selected device groups.
+	Device config.DeviceConfig
+
+	// Info is the system group read during the probe.
+	Info poller.SystemInfo
+}
+
+// Sweeper probes a set of address ranges for SNMP agents.
+type Sweeper struct {
+	opts   Options
+	addrs  []netip.Addr
+	creds  []candidate
+	logger *slog.Logger
+}
+
+// candidate is one credential to probe hosts with: probe is the session
+// configuration, secrets resolved, and entry the device file entry written
+// for a host that answers. Neither carries an address yet.
+type candidate struct {
+	probe config.DeviceConfig
+	entry config.DeviceConfig
+}
+
+// NewSweeper validates opts and expands its targets. It fails when a target
+// does not parse, no credential is configured, a credential profile is
+// unknown, a secret reference does not resolve, or the targets exceed
+// MaxHosts.
+func NewSweeper(opts Options, logger *slog.Logger) (*Sweeper, error) {
+	opts.defaults()
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
+	}
+	if len(opts.CredentialProfiles) == 0 && len(opts.Communities) == 0 && len(opts.V3Credentials) == 0 {
+		return nil, errors.New("discovery: no credential profiles, communities or v3 credentials configured")
+	}
+	creds, err := candidates(opts)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := ExpandTargets(opts.Targets, opts.MaxHosts)
+	if err != nil {
+		return nil, err
+	}
+	return &Sweeper{opts: opts, addrs: addrs, creds: creds, logger: logger}, nil
+}
+
+// Hosts returns the number of addresses the sweep will probe.
+func (s *Sweeper) Hosts() int { return len(s.addrs) }
+
+// Run probes every address and returns the responders sorted by address.
+// It returns early with the responders found so far when ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) ([]Result, error) {
+	limiter := time.NewTicker(time.Duration(float64(time.Second) / s.opts.Rate))
+	defer limiter.Stop()
+
+	var (
+		mu      sync.Mutex
+		results []Result
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, s.opts.Concurrency)
+	)
+
+	s.logger.Info("discovery: sweep started",
+		"hosts", len(s.addrs),
+		"credentials", len(s.creds),
+	)
+
+loop:
+	for _, addr := range s.addrs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+		wg.Add(1)
+		go func(addr netip.Addr) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r, ok := s.probeHost(ctx, addr, limiter.C)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Device.IP < results[j].Device.IP
+	})
+	assignHostnames(results)
+
+	s.logger.Info("discovery: sweep finished",
+		"hosts", len(s.addrs),
+		"responders", len(results),
+	)
+	return results, ctx.Err()
+}
+
+// probeHost tries each candidate credential against addr until one answers.
+func (s *Sweeper) probeHost(ctx context.Context, addr netip.Addr, tick <-chan time.Time) (Result, bool) {
+	for _, c := range s.creds {
+		select {
+		case <-tick:
+		case <-ctx.Done():
+			return Result{}, false
+		}
+		probe, dev := c.probe, c.entry
+		probe.IP, dev.IP = addr.String(), addr.String()
+		info, err := s.opts.Probe(ctx, probe)
+		if err != nil {
+			s.logger.Debug("discovery: probe failed",
+				"ip", probe.IP,
+				"version", probe.Version,
+				"error", err.Error(),
+			)
+			continue
+		}
+		info.Vendor, info.Model = s.opts.Vendors.Match(info.SysObjectID)
+		dev.DeviceGroups = s.opts.Profiles.Match(info.SysObjectID, info.SysDescr)
+		if len(dev.DeviceGroups) == 0 {
+			// Leave the choice to the runtime auto-profiler so rules added
+			// later still apply.
+			dev.DeviceGroups = []string{config.AutoDeviceGroup}
+		}
+		s.logger.Info("discovery: device responded",
+			"ip", dev.IP,
+			"sys_name", info.SysName,
+			"sys_object_id", info.SysObjectID,
+			"version", probe.Version,
+			"groups", dev.DeviceGroups,
+		)
+		return Result{Device: dev, Info: info}, true
+	}
+	return Result{}, false
+}
+
+// candidates returns one candidate per credential in opts: credential
+// profiles, then v2c communities, then SNMPv3 credential sets. Probes use
+// opts' timeout and retries; entries leave them to the device's templates.
+func candidates(opts Options) ([]candidate, error) {
+	base := config.DeviceConfig{
+		Port:    opts.Port,
+		Timeout: int(opts.Timeout / time.Millisecond),
+		Retries: opts.Retries,
+	}
+	var out []candidate
+	for _, name := range opts.CredentialProfiles {
+		p, ok := opts.Credentials[name]
+		if !ok {
+			return nil, fmt.Errorf("discovery: unknown credential profile %q", name)
+		}
+		c := candidate{probe: base, entry: config.DeviceConfig{Credentials: name}}
+		c.probe.Version = p.Version
+		if c.probe.Version == "" {
+			c.probe.Version = "2c"
+		}
+		if p.Port != 0 {
+			c.probe.Port = p.Port
+		} else {
+			c.entry.Port = opts.Port
+		}
+		c.probe.Communities = p.Communities
+		c.probe.V3Credentials = p.V3Credentials
+		out = append(out, c)
+	}
+	for i, community := range opts.Communities {
+		resolved, err := credentials.Resolve(community)
+		if err != nil {
+			return nil, fmt.Errorf("discovery: communities[%d]: %w", i, err)
+		}
+		c := candidate{probe: base, entry: config.DeviceConfig{Port: opts.Port, Version: "2c"}}
+		c.probe.Version = "2c"
+		c.probe.Communities = []string{resolved}
+		c.entry.Communities = []string{community}
+		out = append(out, c)
+	}
+	for i, cred := range opts.V3Credentials {
+		resolved := cred
+		for _, f := range []struct {
+			name  string
+			value *string
+		}{
+			{"authentication_passphrase", &resolved.AuthenticationPassphrase},
+			{"privacy_passphrase", &resolved.PrivacyPassphrase},
+		} {
+			v, err := credentials.Resolve(*f.value)
+			if err != nil {
+				return nil, fmt.Errorf("discovery: v3_credentials[%d].%s: %w", i, f.name, err)
+			}
+			*f.value = v
+		}
+		c := candidate{probe: base, entry: config.DeviceConfig{Port: opts.Port, Version: "3"}}
+		c.probe.Version = "3"
+		c.probe.V3Credentials = []config.V3Credentials{resolved}
+		c.entry.V3Credentials = []config.V3Credentials{cred}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// assignHostnames names each result after its sysName. Responders without a
+// usable sysName, or whose sysName is already taken by a lower address, are
+// named after their address. results must be sorted by address.
+func assignHostnames(results []Result) {
+	taken := make(map[string]bool, len(results))
+	for i := range results {
+		name := sanitiseHostname(results[i].Info.SysName)
+		if name == "" || taken[name] {
+			name = results[i].Device.IP
+		}
+		taken[name] = true
+		results[i].Hostname = name
+	}
+}
+
+// sanitiseHostname trims sysName and replaces characters that are awkward in
+// a YAML key or a metric label with '-'.
+func sanitiseHostname(s string) string {
+	s = strings.TrimSpace(s)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Target expansion
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ExpandTargets parses CIDR prefixes and single addresses into a deduplicated,
+// sorted address list. For IPv4 prefixes shorter than /31 the network and
+// broadcast addresses are skipped. It fails when the result would exceed max
+// addresses.
+func ExpandTargets(targets []string, max int) ([]netip.Addr, error) {
+	seen := make(map[netip.Addr]bool)
+	var out []netip.Addr
+	add := func(a netip.Addr) error {
+		if seen[a] {
+			return nil
+		}
+		if len(out) >= max {
+			return fmt.Errorf("discovery: targets expand to more than %d hosts", max)
+		}
+		seen[a] = true
+		out = append(out, a)
+		return nil
+	}
+
+	for _, t := range targets {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !strings.Contains(t, "/") {
+			a, err := netip.ParseAddr(t)
+			if err != nil {
+				return nil, fmt.Errorf("discovery: target %q: %w", t, err)
+			}
+			if err := add(a.Unmap()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		prefix, err := netip.ParsePrefix(t)
+		if err != nil {
+			return nil, fmt.Errorf("discovery: target %q: %w", t, err)
+		}
+		prefix = prefix.Masked()
+		skipEnds := prefix.Addr().Is4() && prefix.Bits() < 31
+		for a := prefix.Addr(); prefix.Contains(a); a = a.Next() {
+			if skipEnds && (a == prefix.Addr() || !prefix.Contains(a.Next())) {
+				continue
+			}
+			if err := add(a); err != nil {
+				return nil, err
+			}
+			if !a.Next().IsValid() {
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Less(out[j]) })
+	return out, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Default probe
+// ─────────────────────────────────────────────────────────────────────────────
+
+// probeSNMP is the default ProbeFunc: dial, Get the system group, close.
+func probeSNMP(ctx context.Context, dev config.DeviceConfig) (poller.SystemInfo, error) {
+	conn, err := poller.NewSession(dev)
+	if err != nil {
+		return poller.SystemInfo{}, err
+	}
+	defer conn.Conn.Close()
+	conn.Context = ctx
+	return poller.FetchSystemInfo(conn, nil)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// no-op logger writer
+// ─────────────────────────────────────────────────────────────────────────────
+
+type noopWriter struct{}
+
+func (noopWriter) Write(p []byte) (int, error) { return len(p), nil }
@@ -0,0 +1,411 @@
+package discovery_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/discovery"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Simulated agent
+// ─────────────────────────────────────────────────────────────────────────────
+
+// startAgent runs a minimal SNMPv2c agent on 127.0.0.1 that answers Get
+// requests carrying community from values and ignores everything else.
+func startAgent(t *testing.T, community string, values map[string]interface{}) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		codec := &gosnmp.GoSNMP{Version: gosnmp.Version2c}
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req, err := codec.SnmpDecodePacket(buf[:n])
+			if err != nil || req.Community != community || req.PDUType != gosnmp.GetRequest {
+				continue
+			}
+			resp := *req
+			resp.PDUType = gosnmp.GetResponse
+			resp.Variables = nil
+			for _, v := range req.Variables {
+				val, ok := values[v.Name]
+				if !ok {
+					resp.Variables = append(resp.Variables, gosnmp.SnmpPDU{Name: v.Name, Type: gosnmp.NoSuchObject})
+					continue
+				}
+				typ := gosnmp.OctetString
+				if _, isOID := val.(oid); isOID {
+					typ = gosnmp.ObjectIdentifier
+					val = string(val.(oid))
+				}
+				resp.Variables = append(resp.Variables, gosnmp.SnmpPDU{Name: v.Name, Type: typ, Value: val})
+			}
+			out, err := resp.MarshalMsg()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(out, addr)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+type oid string
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Sweep tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestSweep_SimulatedAgent(t *testing.T) {
+	port := startAgent(t, "secret", map[string]interface{}{
+		poller.OIDSysName:     "lab-router",
+		poller.OIDSysObjectID: oid(".1.3.6.1.4.1.8072.3.2.10"),
+		poller.OIDSysDescr:    "Linux lab-router 6.1.0 x86_64",
+	})
+
+	s, err := discovery.NewSweeper(discovery.Options{
+		// 127.0.0.2 has no listener, so it is probed and never answers.
+		Targets:     []string{"127.0.0.1", "127.0.0.2/32"},
+		Port:        port,
+		Timeout:     200 * time.Millisecond,
+		Communities: []string{"public", "secret"},
+		Rate:        1000,
+		Vendors: config.NewVendorMap(map[string]config.VendorRule{
+			"1.3.6.1.4.1.8072": {Vendor: "Net-SNMP"},
+		}),
+		Profiles: config.NewProfileRules([]config.ProfileRule{
+			{Name: "linux", SysObjectIDs: []string{"1.3.6.1.4.1.8072.3.2.10"}, DeviceGroups: []string{"linux"}},
+		}),
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewSweeper: %v", err)
+	}
+	if s.Hosts() != 2 {
+		t.Fatalf("Hosts() = %d, want 2", s.Hosts())
+	}
+
+	results, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d responders, want 1", len(results))
+	}
+	r := results[0]
+	if r.Hostname != "lab-router" {
+		t.Errorf("Hostname = %q, want lab-router", r.Hostname)
+	}
+	if r.Device.Version != "2c" || len(r.Device.Communities) != 1 || r.Device.Communities[0] != "secret" {
+		t.Errorf("credential = %s %v, want 2c [secret]", r.Device.Version, r.Device.Communities)
+	}
+	if r.Device.Timeout != 0 || r.Device.Retries != 0 {
+		t.Errorf("timeout, retries = %d, %d, want probe settings left out of the entry", r.Device.Timeout, r.Device.Retries)
+	}
+	if len(r.Device.DeviceGroups) != 1 || r.Device.DeviceGroups[0] != "linux" {
+		t.Errorf("DeviceGroups = %v, want [linux]", r.Device.DeviceGroups)
+	}
+	if r.Info.Vendor != "Net-SNMP" {
+		t.Errorf("Vendor = %q, want Net-SNMP", r.Info.Vendor)
+	}
+}
+
+func TestSweep_WritesCredentialsAsConfigured(t *testing.T) {
+	t.Setenv("DISCOVERY_TEST_COMMUNITY", "secret")
+	port := startAgent(t, "secret", map[string]interface{}{
+		poller.OIDSysName: "lab-router",
+	})
+
+	tests := []struct {
+		name  string
+		opts  discovery.Options
+		check func(t *testing.T, d config.DeviceConfig)
+	}{
+		{
+			name: "secret reference",
+			opts: discovery.Options{Communities: []string{"public", "${DISCOVERY_TEST_COMMUNITY}"}},
+			check: func(t *testing.T, d config.DeviceConfig) {
+				if len(d.Communities) != 1 || d.Communities[0] != "${DISCOVERY_TEST_COMMUNITY}" {
+					t.Errorf("Communities = %v, want the reference, not its value", d.Communities)
+				}
+			},
+		},
+		{
+			name: "credential profile",
+			opts: discovery.Options{
+				CredentialProfiles: []string{"wrong", "lab"},
+				Credentials: map[string]config.CredentialProfile{
+					"wrong": {Communities: []string{"public"}},
+					"lab":   {Version: "2c", Port: port, Communities: []string{"secret"}},
+				},
+			},
+			check: func(t *testing.T, d config.DeviceConfig) {
+				if d.Credentials != "lab" || d.Version != "" || d.Port != 0 || len(d.Communities) != 0 {
+					t.Errorf("entry = %+v, want only credentials: lab", d)
+				}
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			opts.Targets = []string{"127.0.0.1"}
+			opts.Port = port
+			opts.Timeout = 200 * time.Millisecond
+			opts.Rate = 1000
+			s, err := discovery.NewSweeper(opts, nil)
+			if err != nil {
+				t.Fatalf("NewSweeper: %v", err)
+			}
+			results, err := s.Run(context.Background())
+			if err != nil || len(results) != 1 {
+				t.Fatalf("Run = %d responders, %v; want 1", len(results), err)
+			}
+			tc.check(t, results[0].Device)
+		})
+	}
+}
+
+func TestNewSweeper_CredentialErrors(t *testing.T) {
+	for name, opts := range map[string]discovery.Options{
+		"unknown profile":    {CredentialProfiles: []string{"nosuch"}},
+		"unresolved secret":  {Communities: []string{"${DISCOVERY_TEST_UNSET}"}},
+		"unresolved v3 pass": {V3Credentials: []config.V3Credentials{{Username: "u", PrivacyPassphrase: "file:/nonexistent/priv"}}},
+	} {
+		opts.Targets = []string{"127.0.0.1"}
+		if _, err := discovery.NewSweeper(opts, nil); err == nil {
+			t.Errorf("%s: NewSweeper succeeded, want error", name)
+		}
+	}
+}
+
+func TestSweep_UnmatchedDeviceGetsAuto(t *testing.T) {
+	probe := func(_ context.Context, dev config.DeviceConfig) (poller.SystemInfo, error) {
+		return poller.SystemInfo{SysObjectID: "1.3.6.1.4.1.99999"}, nil
+	}
+	s, err := discovery.NewSweeper(discovery.Options{
+		Targets:     []string{"192.0.2.10", "192.0.2.11"},
+		Communities: []string{"public"},
+		Rate:        1000,
+		Probe:       probe,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewSweeper: %v", err)
+	}
+	results, _ := s.Run(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("got %d responders, want 2", len(results))
+	}
+	for _, r := range results {
+		// No sysName → named after the address.
+		if r.Hostname != r.Device.IP {
+			t.Errorf("Hostname = %q, want %q", r.Hostname, r.Device.IP)
+		}
+		if len(r.Device.DeviceGroups) != 1 || r.Device.DeviceGroups[0] != config.AutoDeviceGroup {
+			t.Errorf("%s DeviceGroups = %v, want [auto]", r.Hostname, r.Device.DeviceGroups)
+		}
+	}
+}
+
+func TestSweep_RateLimited(t *testing.T) {
+	probe := func(_ context.Context, dev config.DeviceConfig) (poller.SystemInfo, error) {
+		return poller.SystemInfo{}, nil
+	}
+	s, err := discovery.NewSweeper(discovery.Options{
+		Targets:     []string{"192.0.2.0/29"}, // 6 usable hosts
+		Communities: []string{"public"},
+		Rate:        20, // one probe per 50 ms
+		Concurrency: 6,
+		Probe:       probe,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewSweeper: %v", err)
+	}
+	start := time.Now()
+	s.Run(context.Background())
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("6 probes at 20/s took %v, want ≥ 250ms", elapsed)
+	}
+}
+
+func TestNewSweeper_NoCredentials(t *testing.T) {
+	if _, err := discovery.NewSweeper(discovery.Options{Targets: []string{"192.0.2.1"}}, nil); err == nil {
+		t.Error("expected error without communities or v3 credentials")
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// ExpandTargets tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestExpandTargets(t *testing.T) {
+	addrs, err := discovery.ExpandTargets([]string{"10.0.0.0/30", "10.0.0.2", "10.0.1.5/31"}, 100)
+	if err != nil {
+		t.Fatalf("ExpandTargets: %v", err)
+	}
+	var got []string
+	for _, a := range addrs {
+		got = append(got, a.String())
+	}
+	// /30 drops network and broadcast; /31 keeps both; duplicates collapse.
+	want := "10.0.0.1,10.0.0.2,10.0.1.4,10.0.1.5"
+	if strings.Join(got, ",") != want {
+		t.Errorf("ExpandTargets = %v, want %s", got, want)
+	}
+}
+
+func TestExpandTargets_Errors(t *testing.T) {
+	if _, err := discovery.ExpandTargets([]string{"10.0.0.0/16"}, 1024); err == nil {
+		t.Error("expected error when /16 exceeds max hosts")
+	}
+	if _, err := discovery.ExpandTargets([]string{"not-an-ip"}, 1024); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Device file output tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func result(hostname, ip, community string, groups ...string) discovery.Result {
+	return discovery.Result{
+		Hostname: hostname,
+		Device: config.DeviceConfig{
+			IP: ip, Port: 161, Timeout: 1000, Version: "2c",
+			Communities: []string{community}, DeviceGroups: groups,
+		},
+	}
+}
+
+func TestWriteDevices_EmitAndRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "discovered.yml")
+	managed := map[string]config.DeviceConfig{
+		"core1": {IP: "10.0.0.1"},
+	}
+
+	sum, err := discovery.WriteDevices(path, managed, []discovery.Result{
+		result("core1-dup", "10.0.0.1", "public", "linux"), // managed IP → skipped
+		result("edge1", "10.0.0.2", "public", "linux"),
+		result("edge2", "10.0.0.3", "public", "auto"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("WriteDevices: %v", err)
+	}
+	if sum.Added != 2 || sum.Skipped != 1 {
+		t.Errorf("first write summary = %+v, want Added 2, Skipped 1", sum)
+	}
+	if fi, err := os.Stat(path); err != nil {
+		t.Errorf("stat device file: %v", err)
+	} else if fi.Mode().Perm() != 0o600 {
+		t.Errorf("device file mode = %v, want 0600 (it holds credentials)", fi.Mode().Perm())
+	}
+
+	// Operator tunes edge1 by hand between sweeps.
+	devs, err := config.ReadDeviceFile(path)
+	if err != nil {
+		t.Fatalf("ReadDeviceFile: %v", err)
+	}
+	edge1 := devs["edge1"]
+	edge1.PollInterval = 15
+	devs["edge1"] = edge1
+	if err := config.WriteDeviceFile(path, devs); err != nil {
+		t.Fatalf("WriteDeviceFile: %v", err)
+	}
+
+	// Loader view of the directory now includes the discovered file.
+	for h, d := range devs {
+		managed[h] = d
+	}
+	sum, err = discovery.WriteDevices(path, managed, []discovery.Result{
+		result("edge1", "10.0.0.2", "private", "cisco_generic"),
+		result("edge3", "10.0.0.3", "public", "linux"), // edge2 renamed
+	}, nil)
+	if err != nil {
+		t.Fatalf("WriteDevices refresh: %v", err)
+	}
+	if sum.Added != 1 || sum.Updated != 1 || sum.Skipped != 0 {
+		t.Errorf("refresh summary = %+v, want Added 1, Updated 1", sum)
+	}
+
+	devs, err = config.ReadDeviceFile(path)
+	if err != nil {
+		t.Fatalf("ReadDeviceFile: %v", err)
+	}
+	if len(devs) != 2 {
+		t.Fatalf("devices = %d, want 2 (edge1, edge3)", len(devs))
+	}
+	if _, ok := devs["edge2"]; ok {
+		t.Error("renamed device edge2 still present")
+	}
+	got := devs["edge1"]
+	if got.DeviceGroups[0] != "cisco_generic" {
+		t.Errorf("edge1 groups not refreshed: %+v", got)
+	}
+	if got.Communities[0] != "public" {
+		t.Errorf("edge1 community = %q, want the written public kept", got.Communities[0])
+	}
+	if got.PollInterval != 15 {
+		t.Errorf("edge1 PollInterval = %d, want hand-tuned 15 kept", got.PollInterval)
+	}
+}
+
+func TestMergeDevices_KeepsOperatorFields(t *testing.T) {
+	existing := map[string]config.DeviceConfig{
+		"edge1": {
+			IP: "10.0.0.2", Port: 1161, Version: "3", Timeout: 5000,
+			V3Credentials: []config.V3Credentials{{
+				Username: "monitor", AuthenticationProtocol: "sha", AuthenticationPassphrase: "${SNMP_AUTH}",
+			}},
+			PollInterval: 15, MaxConcurrentPolls: 1, MaxOids: 20, MaxRepetitions: 10, AdaptiveBulk: true,
+			Extends: "branch", Credentials: "branch-snmp",
+			Tags:               map[string]string{"site": "hn1", "role": "edge"},
+			DeviceGroupsAppend: []string{"ups"},
+		},
+	}
+
+	merged, sum := discovery.MergeDevices(existing, nil, []discovery.Result{
+		result("edge1", "10.0.0.2", "private", "cisco_generic"),
+	})
+	if sum.Updated != 1 {
+		t.Fatalf("summary = %+v, want Updated 1", sum)
+	}
+	got := merged["edge1"]
+	if got.IP != "10.0.0.2" || got.DeviceGroups[0] != "cisco_generic" {
+		t.Errorf("edge1 not refreshed: %+v", got)
+	}
+	if got.Version != "3" || got.Port != 1161 || got.Timeout != 5000 || len(got.Communities) != 0 {
+		t.Errorf("version, port, timeout, communities = %s, %d, %d, %v, want the written 3, 1161, 5000, none",
+			got.Version, got.Port, got.Timeout, got.Communities)
+	}
+	if len(got.V3Credentials) != 1 || got.V3Credentials[0].AuthenticationPassphrase != "${SNMP_AUTH}" {
+		t.Errorf("V3Credentials = %+v, want the secret reference kept", got.V3Credentials)
+	}
+	if got.Tags["site"] != "hn1" || got.Tags["role"] != "edge" {
+		t.Errorf("Tags = %v, want operator tags kept", got.Tags)
+	}
+	if len(got.DeviceGroupsAppend) != 1 || got.DeviceGroupsAppend[0] != "ups" {
+		t.Errorf("DeviceGroupsAppend = %v, want [ups]", got.DeviceGroupsAppend)
+	}
+	if got.Credentials != "branch-snmp" || got.Extends != "branch" {
+		t.Errorf("Credentials, Extends = %q, %q, want branch-snmp, branch", got.Credentials, got.Extends)
+	}
+	if got.PollInterval != 15 || got.MaxConcurrentPolls != 1 || got.MaxOids != 20 || got.MaxRepetitions != 10 || !got.AdaptiveBulk {
+		t.Errorf("poll settings not kept: %+v", got)
+	}
+}
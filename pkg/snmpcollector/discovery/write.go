@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Device file output
+// ─────────────────────────────────────────────────────────────────────────────
+
+// WriteSummary reports what WriteDevices changed.
+type WriteSummary struct {
+	Added   int // hostnames new to the file
+	Updated int // hostnames already in the file, refreshed
+	Skipped int // responders already managed by another device file
+}
+
+// MergeDevices folds results into the entries of a discovered-devices file.
+//
+//   - A result whose hostname or address belongs to a device in managed (the
+//     devices defined by other files) is skipped: hand-written entries win.
+//   - A result replacing an existing entry keeps everything but the address
+//     and device groups, which are refreshed. In particular its version,
+//     port, timeout, retries, credential profile, communities and v3
+//     credentials stay as written, secret references included, so they do
+//     not turn into plaintext or override the entry's templates.
+//   - An existing entry at the same address under a different hostname (the
+//     device was renamed) is dropped.
+//   - Entries that did not respond this sweep are kept.
+//
+// existing is modified in place and returned.
+func MergeDevices(existing, managed map[string]config.DeviceConfig, results []Result) (map[string]config.DeviceConfig, WriteSummary) {
+	if existing == nil {
+		existing = make(map[string]config.DeviceConfig)
+	}
+	managedIPs := make(map[string]bool, len(managed))
+	for _, d := range managed {
+		managedIPs[d.IP] = true
+	}
+
+	var sum WriteSummary
+	for _, r := range results {
+		if _, ok := managed[r.Hostname]; ok || managedIPs[r.Device.IP] {
+			sum.Skipped++
+			continue
+		}
+		for hostname, d := range existing {
+			if d.IP == r.Device.IP && hostname != r.Hostname {
+				delete(existing, hostname)
+			}
+		}
+
+		dev := r.Device
+		if old, ok := existing[r.Hostname]; ok {
+			dev = old
+			dev.IP = r.Device.IP
+			dev.DeviceGroups = r.Device.DeviceGroups
+			sum.Updated++
+		} else {
+			sum.Added++
+		}
+		existing[r.Hostname] = dev
+	}
+	return existing, sum
+}
+
+// WriteDevices merges results into the device file at path (see
+// MergeDevices) and rewrites it atomically. managed holds every device known
+// from the devices directory; entries that come from path itself are
+// excluded automatically.
+func WriteDevices(path string, managed map[string]config.DeviceConfig, results []Result, logger *slog.Logger) (WriteSummary, error) {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
+	}
+	existing, err := config.ReadDeviceFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return WriteSummary{}, err
+	}
+
+	others := make(map[string]config.DeviceConfig, len(managed))
+	for hostname, d := range managed {
+		if _, ok := existing[hostname]; !ok {
+			others[hostname] = d
+		}
+	}
+
+	merged, sum := MergeDevices(existing, others, results)
+	if err := config.WriteDeviceFile(path, merged); err != nil {
+		return sum, err
+	}
+	logger.Info("discovery: device file written",
+		"file", path,
+		"devices", len(merged),
+		"added", sum.Added,
+		"updated", sum.Updated,
+		"skipped", sum.Skipped,
+	)
+	return sum, nil
+}
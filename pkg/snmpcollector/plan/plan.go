@@ -0,0 +1,367 @@
+// Package plan describes what the collector will poll: for every device, the
+// jobs scheduler.ResolveJobs produces after device → device group → object
+// group → object resolution, the SNMP operation the poller chooses for each,
+// an estimate of the requests they cost per interval and warnings about
+// duplicate or overlapping walks. `snmpcollector plan` prints it.
+package plan
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/scheduler"
+)
+
+// redacted replaces every secret in a Plan.
+const redacted = "<redacted>"
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Plan
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Plan is the resolved poll plan of a configuration.
+type Plan struct {
+	Devices []Device `json:"devices"`
+	Totals  Totals   `json:"totals"`
+}
+
+// Device is the plan of one device.
+type Device struct {
+	Hostname    string      `json:"hostname"`
+	IP          string      `json:"ip"`
+	Port        int         `json:"port"`
+	Version     string      `json:"version"`
+	Timeout     int         `json:"timeout_ms"`
+	Retries     int         `json:"retries"`
+	Credentials Credentials `json:"credentials"`
+	Jobs        []Job       `json:"jobs"`
+
+	// Requests is the estimated number of SNMP requests per poll, summed
+	// over the jobs sharing each interval.
+	Requests []IntervalRequests `json:"requests"`
+
+	// RequestsPerMinute is the estimated request rate of the device.
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Credentials are the credentials a session to the device uses, with every
+// secret redacted. Like poller.NewSession, only the first community or
+// SNMPv3 credential set is used.
+type Credentials struct {
+	// Profile is the credential profile the device inherits from.
+	Profile string `json:"profile,omitempty"`
+
+	Community string `json:"community,omitempty"`
+
+	Username       string `json:"username,omitempty"`
+	AuthProtocol   string `json:"auth_protocol,omitempty"`
+	AuthPassphrase string `json:"auth_passphrase,omitempty"`
+	PrivProtocol   string `json:"priv_protocol,omitempty"`
+	PrivPassphrase string `json:"priv_passphrase,omitempty"`
+}
+
+// Job is one object polled from a device.
+type Job struct {
+	Object    string           `json:"object"`
+	Operation poller.Operation `json:"operation"`
+
+	// Roots are the walk roots of Walk and BulkWalk jobs, or the columns a
+	// GetBulk job fetches in parallel, sorted.
+	Roots []string `json:"roots,omitempty"`
+
+	// OIDs are the OIDs fetched by a Get job, sorted.
+	OIDs []string `json:"oids,omitempty"`
+
+	// OIDCount is the number of OIDs a Get fetches, or the number of columns
+	// a walk or GetBulk returns per table row.
+	OIDCount int `json:"oid_count"`
+
+	Interval  int  `json:"interval_seconds"`
+	Scheduled bool `json:"scheduled,omitempty"` // cron or allow / block windows apply
+
+	// Requests is the estimated number of SNMP requests per poll.
+	Requests int `json:"requests"`
+}
+
+// IntervalRequests is the estimated number of requests per poll of all jobs
+// sharing one interval.
+type IntervalRequests struct {
+	Interval int `json:"interval_seconds"`
+	Requests int `json:"requests"`
+}
+
+// Totals sums the plan over all devices.
+type Totals struct {
+	Devices           int                `json:"devices"`
+	Jobs              int                `json:"jobs"`
+	Requests          []IntervalRequests `json:"requests"`
+	RequestsPerMinute float64            `json:"requests_per_minute"`
+	Warnings          int                `json:"warnings"`
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Options
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Options tunes the request estimates.
+type Options struct {
+	// Rows is the assumed number of rows in every walked table (default 1,
+	// which makes walk estimates a lower bound).
+	Rows int
+
+	// MaxOids is the number of OIDs per Get request for devices that do not
+	// set max_oids (default 60).
+	MaxOids int
+
+	// MaxRepetitions is the number of varbinds per GetBulk response for
+	// devices that do not set max_repetitions (default 50). A GetBulk job
+	// splits it between its columns, as the poller does. Adaptive tuning is
+	// not modelled: estimates assume the configured value.
+	MaxRepetitions int
+}
+
+func (o *Options) defaults() {
+	if o.Rows <= 0 {
+		o.Rows = 1
+	}
+	if o.MaxOids <= 0 {
+		o.MaxOids = 60
+	}
+	if o.MaxRepetitions <= 0 {
+		o.MaxRepetitions = 50
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Build
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Build resolves cfg with scheduler.ResolveJobs and returns its plan. Devices
+// are sorted by hostname and jobs keep their resolution order. Devices that
+// resolve to no jobs are listed with a warning.
+func Build(cfg *config.LoadedConfig, opts Options, logger *slog.Logger) *Plan {
+	opts.defaults()
+	byHost := make(map[string][]poller.PollJob)
+	for _, job := range scheduler.ResolveJobs(cfg, logger) {
+		byHost[job.Hostname] = append(byHost[job.Hostname], job)
+	}
+
+	p := &Plan{Devices: []Device{}}
+	if cfg == nil {
+		return p
+	}
+	hostnames := make([]string, 0, len(cfg.Devices))
+	for h := range cfg.Devices {
+		hostnames = append(hostnames, h)
+	}
+	sort.Strings(hostnames)
+
+	total := make(map[int]int)
+	for _, h := range hostnames {
+		d := buildDevice(h, cfg.Devices[h], byHost[h], opts)
+		for _, r := range d.Requests {
+			total[r.Interval] += r.Requests
+		}
+		p.Totals.Jobs += len(d.Jobs)
+		p.Totals.Warnings += len(d.Warnings)
+		p.Totals.RequestsPerMinute += d.RequestsPerMinute
+		p.Devices = append(p.Devices, d)
+	}
+	p.Totals.Devices = len(p.Devices)
+	p.Totals.Requests = sortedRequests(total)
+	return p
+}
+
+func buildDevice(hostname string, dev config.DeviceConfig, jobs []poller.PollJob, opts Options) Device {
+	d := Device{
+		Hostname:    hostname,
+		IP:          dev.IP,
+		Port:        dev.Port,
+		Version:     dev.Version,
+		Timeout:     dev.Timeout,
+		Retries:     dev.Retries,
+		Credentials: credentials(dev),
+		Jobs:        make([]Job, 0, len(jobs)),
+	}
+	switch {
+	case dev.Version == "3" && d.Credentials.Username == "":
+		d.Warnings = append(d.Warnings, "no SNMPv3 credentials")
+	case dev.Version != "3" && d.Credentials.Community == "":
+		d.Warnings = append(d.Warnings, "no community")
+	}
+
+	perInterval := make(map[int]int)
+	for _, pj := range jobs {
+		j := buildJob(pj, opts)
+		if j.Operation != poller.OpGet && len(j.Roots) == 0 {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("%s has no attribute OIDs to walk", j.Object))
+		}
+		perInterval[j.Interval] += j.Requests
+		if j.Interval > 0 {
+			d.RequestsPerMinute += float64(j.Requests) * 60 / float64(j.Interval)
+		}
+		d.Jobs = append(d.Jobs, j)
+	}
+	d.Requests = sortedRequests(perInterval)
+
+	if len(jobs) == 0 {
+		if slices.Contains(dev.DeviceGroups, config.AutoDeviceGroup) {
+			d.Warnings = append(d.Warnings, "awaiting auto-profile: device groups are chosen after the first probe")
+		} else {
+			d.Warnings = append(d.Warnings, "no objects to poll")
+		}
+	}
+	d.Warnings = append(d.Warnings, overlaps(d.Jobs)...)
+	return d
+}
+
+func buildJob(pj poller.PollJob, opts Options) Job {
+	if n := pj.DeviceConfig.MaxOids; n > 0 {
+		opts.MaxOids = n
+	}
+	if n := pj.DeviceConfig.MaxRepetitions; n > 0 {
+		opts.MaxRepetitions = n
+	}
+	op, oids := poller.OperationFor(pj)
+	j := Job{
+		Object:    pj.ObjectDef.Key,
+		Operation: op,
+		Interval:  int(pj.Interval / time.Second),
+		Scheduled: pj.Schedule != nil,
+	}
+	if op == poller.OpGet {
+		j.OIDs = slices.Sorted(slices.Values(oids))
+		j.OIDCount = len(oids)
+		j.Requests = (len(oids) + opts.MaxOids - 1) / opts.MaxOids
+		return j
+	}
+	j.Roots = slices.Sorted(slices.Values(oids))
+	if op == poller.OpGetBulk {
+		// Columns go out in batches of MaxOids; each request returns
+		// MaxRepetitions / columns rows of a batch, plus one request that
+		// leaves every column.
+		j.OIDCount = len(oids)
+		for n := len(oids); n > 0; n -= opts.MaxOids {
+			batch := min(n, opts.MaxOids)
+			rows := max(1, opts.MaxRepetitions/batch)
+			j.Requests += opts.Rows/rows + 1
+		}
+		return j
+	}
+	// A root that is not itself a column is a table entry, whose walk
+	// returns every column up to the highest configured one at least.
+	// Every walk ends with one request that leaves the subtree.
+	for _, root := range oids {
+		columns := entryColumns(pj, root)
+		j.OIDCount += columns
+		varbinds := columns * opts.Rows
+		if op == poller.OpWalk {
+			j.Requests += varbinds + 1
+		} else {
+			j.Requests += varbinds/opts.MaxRepetitions + 1
+		}
+	}
+	return j
+}
+
+// entryColumns returns the number of columns a walk of root returns per row:
+// 1 for a column, or the highest configured column number for a table entry.
+func entryColumns(pj poller.PollJob, root string) int {
+	highest := 0
+	for _, attr := range pj.ObjectDef.Attributes {
+		if attr.OID == root {
+			return 1
+		}
+		if under(attr.OID, root) {
+			highest = max(highest, lastArc(attr.OID))
+		}
+	}
+	return max(1, highest)
+}
+
+// credentials returns the redacted credentials NewSession would use for dev.
+func credentials(dev config.DeviceConfig) Credentials {
+	c := Credentials{Profile: dev.Credentials}
+	if dev.Version == "3" {
+		if len(dev.V3Credentials) > 0 {
+			v3 := dev.V3Credentials[0]
+			c.Username = v3.Username
+			c.AuthProtocol = v3.AuthenticationProtocol
+			c.PrivProtocol = v3.PrivacyProtocol
+			if v3.AuthenticationPassphrase != "" {
+				c.AuthPassphrase = redacted
+			}
+			if v3.PrivacyPassphrase != "" {
+				c.PrivPassphrase = redacted
+			}
+		}
+		return c
+	}
+	if len(dev.Communities) > 0 && dev.Communities[0] != "" {
+		c.Community = redacted
+	}
+	return c
+}
+
+func sortedRequests(m map[int]int) []IntervalRequests {
+	out := make([]IntervalRequests, 0, len(m))
+	for iv, n := range m {
+		out = append(out, IntervalRequests{Interval: iv, Requests: n})
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Interval < out[k].Interval })
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Warnings
+// ─────────────────────────────────────────────────────────────────────────────
+
+// overlaps warns about walks that share a root, walks inside another walk's
+// subtree and Get OIDs that a walk already returns.
+func overlaps(jobs []Job) []string {
+	var warnings []string
+	for i, a := range jobs {
+		for _, ra := range a.Roots {
+			for k, b := range jobs {
+				if k == i {
+					continue
+				}
+				for _, rb := range b.Roots {
+					switch {
+					case rb == ra && k > i:
+						warnings = append(warnings, fmt.Sprintf("%s and %s both walk %s", a.Object, b.Object, ra))
+					case under(rb, ra):
+						warnings = append(warnings, fmt.Sprintf("%s walks %s inside %s's walk of %s", b.Object, rb, a.Object, ra))
+					}
+				}
+				for _, oid := range b.OIDs {
+					if under(oid, ra) {
+						warnings = append(warnings, fmt.Sprintf("%s gets %s, which %s's walk of %s also returns", b.Object, oid, a.Object, ra))
+					}
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// under reports whether oid lies strictly below root.
+func under(oid, root string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(oid, "."), strings.TrimPrefix(root, ".")+".")
+}
+
+// lastArc returns the last sub-identifier of oid, or 0 when it is not
+// numeric.
+func lastArc(oid string) int {
+	n, _ := strconv.Atoi(oid[strings.LastIndexByte(oid, '.')+1:])
+	return n
+}
@@ -0,0 +1,246 @@
+package plan_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/plan"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// testConfig has a v2c switch polling the system group every 300 s and two
+// overlapping interface objects every 60 s, a v3 router polling the system
+// group, and an auto-profiled device with nothing to poll yet.
+func testConfig() *config.LoadedConfig {
+	return &config.LoadedConfig{
+		Devices: map[string]config.DeviceConfig{
+			"switch1": {
+				IP: "10.0.0.1", Port: 161, PollInterval: 60, Timeout: 1000, Retries: 1,
+				Version: "2c", Communities: []string{"s3cret", "fallback"},
+				DeviceGroups: []string{"access"},
+			},
+			"router1": {
+				IP: "10.0.0.2", Port: 161, PollInterval: 60, Version: "3",
+				V3Credentials: []config.V3Credentials{{
+					Username: "monitor", AuthenticationProtocol: "sha", AuthenticationPassphrase: "authpass",
+					PrivacyProtocol: "aes", PrivacyPassphrase: "privpass",
+				}},
+				Credentials:  "corp",
+				DeviceGroups: []string{"core"},
+			},
+			"new1": {IP: "10.0.0.3", Version: "2c", Communities: []string{"public"}, DeviceGroups: []string{config.AutoDeviceGroup}},
+		},
+		DeviceGroups: map[string]config.DeviceGroup{
+			"access": {ObjectGroups: []string{"system", "netif"}},
+			"core":   {ObjectGroups: []string{"system"}},
+		},
+		ObjectGroups: map[string]config.ObjectGroup{
+			"system": {Objects: []string{"SNMPv2-MIB::system"}, PollInterval: 300},
+			"netif":  {Objects: []string{"IF-MIB::ifEntry", "IF-MIB::ifInOctets"}},
+		},
+		ObjectDefs: map[string]models.ObjectDefinition{
+			"SNMPv2-MIB::system": {
+				Key: "SNMPv2-MIB::system",
+				Attributes: map[string]models.AttributeDefinition{
+					"sysDescr":  {OID: ".1.3.6.1.2.1.1.1"},
+					"sysUpTime": {OID: ".1.3.6.1.2.1.1.3"},
+				},
+			},
+			"IF-MIB::ifEntry": {
+				Key:   "IF-MIB::ifEntry",
+				Index: []models.IndexDefinition{{Type: "Integer", OID: ".1.3.6.1.2.1.2.2.1.1", Name: "netif"}},
+				Attributes: map[string]models.AttributeDefinition{
+					"ifInOctets":   {OID: ".1.3.6.1.2.1.2.2.1.10"},
+					"ifOutOctets":  {OID: ".1.3.6.1.2.1.2.2.1.16"},
+					"ifHCInOctets": {OID: ".1.3.6.1.2.1.31.1.1.1.6"},
+				},
+			},
+			"IF-MIB::ifInOctets": {
+				Key:        "IF-MIB::ifInOctets",
+				Index:      []models.IndexDefinition{{Type: "Integer", OID: ".1.3.6.1.2.1.2.2.1.1", Name: "netif"}},
+				Attributes: map[string]models.AttributeDefinition{"ifInOctets": {OID: ".1.3.6.1.2.1.2.2.1.10"}},
+			},
+		},
+	}
+}
+
+func findDevice(t *testing.T, p *plan.Plan, hostname string) plan.Device {
+	t.Helper()
+	for _, d := range p.Devices {
+		if d.Hostname == hostname {
+			return d
+		}
+	}
+	t.Fatalf("device %q not in plan", hostname)
+	return plan.Device{}
+}
+
+func hasWarning(d plan.Device, substr string) bool {
+	return slices.ContainsFunc(d.Warnings, func(w string) bool { return strings.Contains(w, substr) })
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestBuild_JobsAndEstimates(t *testing.T) {
+	p := plan.Build(testConfig(), plan.Options{Rows: 100}, nil)
+
+	if got := []string{p.Devices[0].Hostname, p.Devices[1].Hostname, p.Devices[2].Hostname}; !slices.Equal(got, []string{"new1", "router1", "switch1"}) {
+		t.Fatalf("devices = %v, want sorted by hostname", got)
+	}
+
+	sw := findDevice(t, p, "switch1")
+	if len(sw.Jobs) != 3 {
+		t.Fatalf("switch1 jobs = %+v, want 3", sw.Jobs)
+	}
+	sys, ifEntry, ifIn := sw.Jobs[0], sw.Jobs[1], sw.Jobs[2]
+	if sys.Operation != poller.OpGet || sys.OIDCount != 2 || sys.Interval != 300 || sys.Requests != 1 {
+		t.Errorf("system job = %+v, want Get of 2 OIDs every 300 s in 1 request", sys)
+	}
+	// 3 columns share 50 repetitions: 16 rows per GetBulk, 100 / 16 + 1.
+	wantCols := []string{".1.3.6.1.2.1.2.2.1.10", ".1.3.6.1.2.1.2.2.1.16", ".1.3.6.1.2.1.31.1.1.1.6"}
+	if ifEntry.Operation != poller.OpGetBulk || !slices.Equal(ifEntry.Roots, wantCols) || ifEntry.Requests != 7 {
+		t.Errorf("ifEntry job = %+v, want GetBulk of %v in 7 requests", ifEntry, wantCols)
+	}
+	// 100 rows at 50 per GetBulk, plus the request that leaves the column.
+	if ifIn.Operation != poller.OpBulkWalk || !slices.Equal(ifIn.Roots, []string{".1.3.6.1.2.1.2.2.1.10"}) || ifIn.Requests != 3 || ifIn.Interval != 60 {
+		t.Errorf("ifInOctets job = %+v", ifIn)
+	}
+
+	want := []plan.IntervalRequests{{Interval: 60, Requests: 10}, {Interval: 300, Requests: 1}}
+	if !slices.Equal(sw.Requests, want) {
+		t.Errorf("switch1 requests = %+v, want %+v", sw.Requests, want)
+	}
+	if sw.RequestsPerMinute != 10.2 {
+		t.Errorf("switch1 requests/min = %v, want 10.2", sw.RequestsPerMinute)
+	}
+	if p.Totals.Devices != 3 || p.Totals.Jobs != 4 {
+		t.Errorf("totals = %+v", p.Totals)
+	}
+}
+
+func TestBuild_Warnings(t *testing.T) {
+	p := plan.Build(testConfig(), plan.Options{}, nil)
+
+	sw := findDevice(t, p, "switch1")
+	if !hasWarning(sw, "IF-MIB::ifEntry and IF-MIB::ifInOctets both walk .1.3.6.1.2.1.2.2.1.10") {
+		t.Errorf("no overlap warning: %v", sw.Warnings)
+	}
+	if len(findDevice(t, p, "router1").Warnings) != 0 {
+		t.Errorf("router1 warnings = %v, want none", findDevice(t, p, "router1").Warnings)
+	}
+	if !hasWarning(findDevice(t, p, "new1"), "awaiting auto-profile") {
+		t.Errorf("new1 warnings = %v", findDevice(t, p, "new1").Warnings)
+	}
+}
+
+func TestBuild_EntryWalk(t *testing.T) {
+	cfg := testConfig()
+	cfg.ObjectDefs["IF-MIB::ifEntry"] = models.ObjectDefinition{
+		Key:   "IF-MIB::ifEntry",
+		Index: []models.IndexDefinition{{Type: "Integer", OID: ".1.3.6.1.2.1.2.2.1.1", Name: "netif"}},
+		Attributes: map[string]models.AttributeDefinition{
+			"ifDescr": {OID: ".1.3.6.1.2.1.2.2.1.2"},
+			"ifType":  {OID: ".1.3.6.1.2.1.2.2.1.3"},
+			"ifMtu":   {OID: ".1.3.6.1.2.1.2.2.1.4"},
+		},
+	}
+
+	sw := findDevice(t, plan.Build(cfg, plan.Options{Rows: 100}, nil), "switch1")
+	// The walk returns columns 1–4: 400 varbinds at 50 per GetBulk, plus the last.
+	ifEntry := sw.Jobs[1]
+	if ifEntry.Operation != poller.OpBulkWalk || !slices.Equal(ifEntry.Roots, []string{".1.3.6.1.2.1.2.2.1"}) ||
+		ifEntry.OIDCount != 4 || ifEntry.Requests != 9 {
+		t.Errorf("ifEntry job = %+v, want BulkWalk of .1.3.6.1.2.1.2.2.1 in 9 requests", ifEntry)
+	}
+	if !hasWarning(sw, "IF-MIB::ifInOctets walks .1.3.6.1.2.1.2.2.1.10 inside IF-MIB::ifEntry's walk of .1.3.6.1.2.1.2.2.1") {
+		t.Errorf("no overlap warning: %v", sw.Warnings)
+	}
+}
+
+func TestBuild_DeviceBulkSizes(t *testing.T) {
+	cfg := testConfig()
+	sw := cfg.Devices["switch1"]
+	sw.MaxRepetitions = 10
+	cfg.Devices["switch1"] = sw
+
+	// 100 rows at 10 per GetBulk, plus the request that leaves the column.
+	ifIn := findDevice(t, plan.Build(cfg, plan.Options{Rows: 100}, nil), "switch1").Jobs[2]
+	if ifIn.Requests != 11 {
+		t.Errorf("ifInOctets job = %+v, want 11 requests at max_repetitions 10", ifIn)
+	}
+}
+
+func TestBuild_DuplicateWalks(t *testing.T) {
+	cfg := testConfig()
+	dup := cfg.ObjectDefs["IF-MIB::ifInOctets"]
+	dup.Key = "CUSTOM::ifInOctets"
+	cfg.ObjectDefs[dup.Key] = dup
+	cfg.ObjectGroups["netif"] = config.ObjectGroup{Objects: []string{"IF-MIB::ifInOctets", dup.Key}}
+
+	sw := findDevice(t, plan.Build(cfg, plan.Options{}, nil), "switch1")
+	if !hasWarning(sw, "IF-MIB::ifInOctets and CUSTOM::ifInOctets both walk .1.3.6.1.2.1.2.2.1.10") {
+		t.Errorf("no duplicate walk warning: %v", sw.Warnings)
+	}
+}
+
+func TestBuild_RedactsCredentials(t *testing.T) {
+	p := plan.Build(testConfig(), plan.Options{}, nil)
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "fallback", "authpass", "privpass"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("output contains secret %q:\n%s", secret, buf.String())
+		}
+	}
+
+	r := findDevice(t, p, "router1").Credentials
+	want := plan.Credentials{
+		Profile: "corp", Username: "monitor",
+		AuthProtocol: "sha", AuthPassphrase: "<redacted>",
+		PrivProtocol: "aes", PrivPassphrase: "<redacted>",
+	}
+	if r != want {
+		t.Errorf("router1 credentials = %+v, want %+v", r, want)
+	}
+	if got := findDevice(t, p, "switch1").Credentials.String(); got != "community=<redacted>" {
+		t.Errorf("switch1 credentials = %q", got)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := plan.Build(testConfig(), plan.Options{}, nil).WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"switch1  10.0.0.1:161  v2c  timeout=1000ms retries=1  community=<redacted>",
+		"SNMPv2-MIB::system",
+		"GetBulk",
+		".1.3.6.1.2.1.2.2.1.10,.1.3.6.1.2.1.2.2.1.16,… (3)",
+		"requests per poll: 2 every 60s, 1 every 300s (2.2/min)",
+		"warning: awaiting auto-profile",
+		"3 device(s), 4 job(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package plan
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText writes p in a human-readable form: one block per device with a
+// table of its jobs, its request estimates and warnings, then the totals.
+func (p *Plan) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range p.Devices {
+		fmt.Fprintf(bw, "%s  %s:%d  v%s  timeout=%dms retries=%d  %s\n",
+			d.Hostname, d.IP, d.Port, d.Version, d.Timeout, d.Retries, d.Credentials)
+		if len(d.Jobs) > 0 {
+			tw := tabwriter.NewWriter(bw, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "  OBJECT\tOPERATION\tROOT / OIDS\tOIDS\tINTERVAL\tREQUESTS\t")
+			for _, j := range d.Jobs {
+				target := j.OIDs
+				if len(j.Roots) > 0 {
+					target = j.Roots
+				}
+				interval := fmt.Sprintf("%ds", j.Interval)
+				if j.Scheduled {
+					interval += " (scheduled)"
+				}
+				fmt.Fprintf(tw, "  %s\t%s\t%s\t%d\t%s\t%d\t\n", j.Object, j.Operation, formatOIDs(target), j.OIDCount, interval, j.Requests)
+			}
+			tw.Flush()
+			fmt.Fprintf(bw, "  requests per poll: %s (%.1f/min)\n", formatRequests(d.Requests), d.RequestsPerMinute)
+		}
+		for _, warn := range d.Warnings {
+			fmt.Fprintf(bw, "  warning: %s\n", warn)
+		}
+		fmt.Fprintln(bw)
+	}
+	t := p.Totals
+	fmt.Fprintf(bw, "%d device(s), %d job(s), %d warning(s); requests per poll: %s (%.1f/min)\n",
+		t.Devices, t.Jobs, t.Warnings, formatRequests(t.Requests), t.RequestsPerMinute)
+	return bw.Flush()
+}
+
+// String returns the credentials on one line, e.g.
+// "community=<redacted> profile=corp".
+func (c Credentials) String() string {
+	var parts []string
+	if c.Community != "" {
+		parts = append(parts, "community="+c.Community)
+	}
+	if c.Username != "" {
+		parts = append(parts, "user="+c.Username)
+		if c.AuthProtocol != "" {
+			parts = append(parts, "auth="+c.AuthProtocol)
+		}
+		if c.PrivProtocol != "" {
+			parts = append(parts, "priv="+c.PrivProtocol)
+		}
+		if c.AuthPassphrase != "" || c.PrivPassphrase != "" {
+			parts = append(parts, "passphrases="+redacted)
+		}
+	}
+	if c.Profile != "" {
+		parts = append(parts, "profile="+c.Profile)
+	}
+	if len(parts) == 0 {
+		return "no credentials"
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatRequests(rs []IntervalRequests) string {
+	if len(rs) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = fmt.Sprintf("%d every %ds", r.Requests, r.Interval)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatOIDs joins oids with commas, eliding all but the first two.
+func formatOIDs(oids []string) string {
+	if len(oids) > 2 {
+		return fmt.Sprintf("%s,… (%d)", strings.Join(oids[:2], ","), len(oids))
+	}
+	return strings.Join(oids, ",")
+}
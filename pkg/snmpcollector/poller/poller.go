@@ -2,14 +2,17 @@ package poller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
 	"github.com/vpbank/snmp_collector/snmp/decoder"
 )
 
@@ -30,6 +33,28 @@ type PollJob struct {
 
 	// ObjectDef is the definition of the SNMP object to poll.
 	ObjectDef models.ObjectDefinition
+
+	// Interval is the effective poll interval for this object on this device:
+	// the object's poll_interval, else its object group's, else the device's.
+	Interval time.Duration
+
+	// Schedule gates when the job may fire (cron, allow / block windows). nil
+	// means poll every Interval.
+	Schedule *schedule.Schedule
+
+	// OIDs, when set, are fetched with Get exactly as given instead of the
+	// attribute OIDs of ObjectDef, which must still map each of them to an
+	// attribute for the decoder. Used by on-demand polls of raw OIDs.
+	OIDs []string
+
+	// Resumed is set on the first dispatch after the job's schedule paused
+	// it; the producer re-seeds counter deltas instead of spanning the pause.
+	Resumed bool
+
+	// Done, if set, is called once by the worker after the job has been
+	// polled and its result handed on (or discarded). The scheduler uses it
+	// to track which objects are still in flight.
+	Done func()
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -45,26 +70,45 @@ type Poller interface {
 // SNMPPoller — production implementation
 // ─────────────────────────────────────────────────────────────────────────────
 
+// PollerOptions configures optional SNMPPoller behaviour. The zero value
+// polls objects only.
+type PollerOptions struct {
+	// SystemInfo, when non-nil, enables per-device polling of the SNMPv2-MIB
+	// system group. The cached values (vendor, model, sysDescr, sysLocation,
+	// sysContact) are attached to the Device of every RawPollResult.
+	SystemInfo *SystemInfoCache
+
+	// RateLimiter, when non-nil, delays every SNMP request until the device
+	// and global request rates allow it. Waiting honours the poll's context.
+	RateLimiter *RateLimiter
+}
+
 // SNMPPoller is the production Poller backed by a ConnectionPool.
 type SNMPPoller struct {
 	pool   *ConnectionPool
+	opts   PollerOptions
 	logger *slog.Logger
 }
 
 // NewSNMPPoller creates a new poller that obtains sessions from pool.
-func NewSNMPPoller(pool *ConnectionPool, logger *slog.Logger) *SNMPPoller {
+func NewSNMPPoller(pool *ConnectionPool, opts PollerOptions, logger *slog.Logger) *SNMPPoller {
 	if logger == nil {
 		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
 	}
-	return &SNMPPoller{pool: pool, logger: logger}
+	return &SNMPPoller{pool: pool, opts: opts, logger: logger}
 }
 
 // Poll executes the SNMP operation described by job and returns a RawPollResult.
 //
-// Operation selection:
+// Operation selection (see OperationFor):
+//   - job.OIDs set             → Get exactly those OIDs
 //   - Scalar object (no Index) → Get all attribute OIDs appended with ".0"
-//   - Table object + SNMPv1    → Walk the lowest-common-prefix OID
-//   - Table object + v2c / v3  → BulkWalk the lowest-common-prefix OID
+//   - Table object             → walk the table entry, walk each column, or
+//     GetBulk all columns in parallel (BulkWalk / GetBulk need v2c / v3)
+//
+// With PollerOptions.RateLimiter every request of the poll, the system group
+// Get included, waits for a token first; a done ctx ends the wait and fails
+// the poll.
 func (p *SNMPPoller) Poll(ctx context.Context, job PollJob) (decoder.RawPollResult, error) {
 	var result decoder.RawPollResult
 
@@ -75,16 +119,27 @@ func (p *SNMPPoller) Poll(ctx context.Context, job PollJob) (decoder.RawPollResu
 
 	result.Device = job.Device
 	result.ObjectDef = job.ObjectDef
+	result.Resumed = job.Resumed
+	s := session{conn: conn, wait: func() error { return p.opts.RateLimiter.Wait(ctx, job.Hostname) }}
+	if p.opts.SystemInfo != nil {
+		p.refreshSystemInfo(s, job.Hostname)
+		if info, ok := p.opts.SystemInfo.Get(job.Hostname); ok {
+			info.Apply(&result.Device)
+		}
+	}
 
 	var pdus []gosnmp.SnmpPDU
 	result.PollStartedAt = time.Now()
 
-	if isScalar(job.ObjectDef) {
-		pdus, err = p.doGet(conn, job.ObjectDef)
-	} else if job.DeviceConfig.Version == "1" {
-		pdus, err = p.doWalk(conn, job.ObjectDef)
-	} else {
-		pdus, err = p.doBulkWalk(conn, job.ObjectDef)
+	switch op, oids := OperationFor(job); {
+	case op == OpGet:
+		pdus, err = getOIDs(s, oids)
+	case len(oids) == 0:
+		err = fmt.Errorf("no attribute OIDs in object %s", job.ObjectDef.Key)
+	case op == OpWalk:
+		pdus, _, err = walkRoots(s, op, oids)
+	default:
+		pdus, err = p.bulk(s, job, op, oids)
 	}
 	result.CollectedAt = time.Now()
 	result.Varbinds = pdus
@@ -107,29 +162,205 @@ func (p *SNMPPoller) Poll(ctx context.Context, job PollJob) (decoder.RawPollResu
 	return result, nil
 }
 
+// bulk runs a BulkWalk or GetBulk poll. A tooBig response makes the poll
+// fall back to smaller requests (see getBulkColumns). With AdaptiveBulk the
+// outcome also tunes the device's max-repetitions in the pool for later
+// polls: a tooBig response or a timeout halves it, and a successful poll
+// grows it back towards DeviceConfig.MaxRepetitions.
+func (p *SNMPPoller) bulk(s session, job PollJob, op Operation, oids []string) ([]gosnmp.SnmpPDU, error) {
+	var pdus []gosnmp.SnmpPDU
+	var shrunk bool
+	var err error
+	if op == OpGetBulk {
+		pdus, shrunk, err = getBulkColumns(s, oids)
+	} else {
+		pdus, shrunk, err = walkRoots(s, op, oids)
+	}
+	if !job.DeviceConfig.AdaptiveBulk {
+		return pdus, err
+	}
+	failed := shrunk || errors.Is(err, errTooBig) || isTimeout(err)
+	if err != nil && !failed {
+		return pdus, err
+	}
+
+	limit := uint32(job.DeviceConfig.MaxRepetitions)
+	if limit == 0 {
+		limit = defaultMaxRepetitions
+	}
+	used := s.conn.MaxRepetitions
+	next := p.pool.TuneRepetitions(job.Hostname, used, limit, failed)
+	if next < used {
+		p.logger.Debug("max-repetitions reduced",
+			"device", job.Hostname,
+			"from", used,
+			"to", next,
+			"too_big", shrunk,
+		)
+	}
+	return pdus, err
+}
+
+// Walk walks each of roots on the job's device through the same session
+// stack as Poll — connection pool, rate limiter, tooBig fallback and
+// adaptive max-repetitions — and returns the varbinds read before any error.
+// Roots are walked with BulkWalk, or with Walk on SNMPv1 or when bulk is
+// false. job.ObjectDef and job.OIDs are ignored. Used by on-demand walks of
+// raw OIDs.
+func (p *SNMPPoller) Walk(ctx context.Context, job PollJob, roots []string, bulk bool) ([]gosnmp.SnmpPDU, error) {
+	conn, err := p.pool.Get(ctx, job.Hostname, job.DeviceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("pool get %s: %w", job.Hostname, err)
+	}
+	s := session{conn: conn, wait: func() error { return p.opts.RateLimiter.Wait(ctx, job.Hostname) }}
+
+	var pdus []gosnmp.SnmpPDU
+	if !bulk || job.DeviceConfig.Version == "1" {
+		pdus, _, err = walkRoots(s, OpWalk, roots)
+	} else {
+		pdus, err = p.bulk(s, job, OpBulkWalk, roots)
+	}
+	if err != nil {
+		p.pool.Discard(job.Hostname, conn)
+		return pdus, fmt.Errorf("snmp %s walk: %w", job.Hostname, err)
+	}
+	p.pool.Put(job.Hostname, conn)
+	return pdus, nil
+}
+
+// FetchSystemInfo reads the system group of hostname through the same
+// session stack as Poll — connection pool and rate limiter — and stamps the
+// vendor and model from the SystemInfoCache's vendor map when there is one.
+// The request gives up between retries once ctx is done. The result is not
+// stored in the cache. It has the shape of a scheduler.ProbeFunc and is used
+// by auto-profiling.
+func (p *SNMPPoller) FetchSystemInfo(ctx context.Context, hostname string, cfg config.DeviceConfig) (SystemInfo, error) {
+	conn, err := p.pool.Get(ctx, hostname, cfg)
+	if err != nil {
+		return SystemInfo{}, fmt.Errorf("pool get %s: %w", hostname, err)
+	}
+	if err := p.opts.RateLimiter.Wait(ctx, hostname); err != nil {
+		p.pool.Put(hostname, conn)
+		return SystemInfo{}, err
+	}
+	var vendors *config.VendorMap
+	if p.opts.SystemInfo != nil {
+		vendors = p.opts.SystemInfo.Vendors()
+	}
+
+	prev := conn.Context
+	conn.Context = ctx
+	info, err := FetchSystemInfo(conn, vendors)
+	conn.Context = prev
+	if err != nil {
+		p.pool.Discard(hostname, conn)
+		return info, fmt.Errorf("snmp %s system group: %w", hostname, err)
+	}
+	p.pool.Put(hostname, conn)
+	return info, nil
+}
+
+// refreshSystemInfo fetches the system group for hostname when the cache says
+// it is due. Failures are logged and retried on a later poll, with backoff
+// until the first success and after the refresh interval from then on (see
+// SystemInfoCache.Claim); they never fail the object poll itself.
+func (p *SNMPPoller) refreshSystemInfo(s session, hostname string) {
+	cache := p.opts.SystemInfo
+	if !cache.Claim(hostname, time.Now()) {
+		return
+	}
+	err := s.wait()
+	var info SystemInfo
+	if err == nil {
+		info, err = FetchSystemInfo(s.conn, cache.Vendors())
+	}
+	if err != nil {
+		p.logger.Warn("system info fetch failed",
+			"device", hostname,
+			"error", err.Error(),
+		)
+		return
+	}
+	cache.Store(hostname, info)
+	p.logger.Debug("system info refreshed",
+		"device", hostname,
+		"sys_object_id", info.SysObjectID,
+		"vendor", info.Vendor,
+		"model", info.Model,
+	)
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // SNMP operation helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-// doGet performs an SNMP Get for scalar objects. Each attribute OID gets ".0"
-// appended (scalar instance).
-func (p *SNMPPoller) doGet(conn *gosnmp.GoSNMP, objDef models.ObjectDefinition) ([]gosnmp.SnmpPDU, error) {
-	oids := make([]string, 0, len(objDef.Attributes))
-	for _, attr := range objDef.Attributes {
-		oid := attr.OID
-		if !strings.HasSuffix(oid, ".0") {
-			oid += ".0"
+// session is a pooled gosnmp session whose requests first wait for the
+// poller's rate limits. Requests the agent left unanswered fail with a
+// timeoutError.
+type session struct {
+	conn *gosnmp.GoSNMP
+	wait func() error
+}
+
+func (s session) get(oids []string) (*gosnmp.SnmpPacket, error) {
+	return s.do(func() (*gosnmp.SnmpPacket, error) { return s.conn.Get(oids) })
+}
+
+func (s session) getNext(oids []string) (*gosnmp.SnmpPacket, error) {
+	return s.do(func() (*gosnmp.SnmpPacket, error) { return s.conn.GetNext(oids) })
+}
+
+func (s session) getBulk(oids []string, maxRepetitions uint32) (*gosnmp.SnmpPacket, error) {
+	return s.do(func() (*gosnmp.SnmpPacket, error) { return s.conn.GetBulk(oids, 0, maxRepetitions) })
+}
+
+// do sends one request once the rate limits allow it. A failed request is
+// wrapped in a timeoutError when it failed with a net.Error timeout, or —
+// since gosnmp reports running out of retries as plain text — when it ran
+// for the whole retry budget, which only happens if every try went
+// unanswered. An error that arrives earlier, on the last try included, is
+// returned as is.
+func (s session) do(request func() (*gosnmp.SnmpPacket, error)) (*gosnmp.SnmpPacket, error) {
+	if err := s.wait(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	pkt, err := request()
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return pkt, err
+	}
+	var netErr net.Error
+	if (errors.As(err, &netErr) && netErr.Timeout()) || (s.conn.Timeout > 0 && time.Since(start) >= retryBudget(s.conn)) {
+		err = timeoutError{err}
+	}
+	return pkt, err
+}
+
+// retryBudget returns how long gosnmp waits for a request before giving up:
+// the timeout of each of the Retries+1 tries, doubling per retry with
+// ExponentialTimeout.
+func retryBudget(conn *gosnmp.GoSNMP) time.Duration {
+	var total time.Duration
+	timeout := conn.Timeout
+	for try := 0; try <= conn.Retries; try++ {
+		if try > 0 && conn.ExponentialTimeout {
+			timeout *= 2
 		}
-		oids = append(oids, oid)
+		total += timeout
 	}
+	return total
+}
+
+// getOIDs performs SNMP Gets for oids, batched to the session's MaxOids.
+func getOIDs(s session, oids []string) ([]gosnmp.SnmpPDU, error) {
 	if len(oids) == 0 {
 		return nil, nil
 	}
 
 	// gosnmp.Get has a MaxOids limit; split into batches if necessary.
-	maxOids := int(conn.MaxOids)
+	maxOids := int(s.conn.MaxOids)
 	if maxOids <= 0 {
-		maxOids = 60
+		maxOids = defaultMaxOids
 	}
 
 	var all []gosnmp.SnmpPDU
@@ -138,7 +369,7 @@ func (p *SNMPPoller) doGet(conn *gosnmp.GoSNMP, objDef models.ObjectDefinition)
 		if end > len(oids) {
 			end = len(oids)
 		}
-		pkt, err := conn.Get(oids[i:end])
+		pkt, err := s.get(oids[i:end])
 		if err != nil {
 			return all, err
 		}
@@ -147,25 +378,6 @@ func (p *SNMPPoller) doGet(conn *gosnmp.GoSNMP, objDef models.ObjectDefinition)
 	return all, nil
 }
 
-// doWalk performs an SNMPv1 Walk (GetNext-based) using the lowest common
-// OID prefix from the object's attributes.
-func (p *SNMPPoller) doWalk(conn *gosnmp.GoSNMP, objDef models.ObjectDefinition) ([]gosnmp.SnmpPDU, error) {
-	root := LowestCommonOID(objDef)
-	if root == "" {
-		return nil, fmt.Errorf("no attribute OIDs in object %s", objDef.Key)
-	}
-	return conn.WalkAll(root)
-}
-
-// doBulkWalk performs a v2c/v3 BulkWalk using the lowest common OID prefix.
-func (p *SNMPPoller) doBulkWalk(conn *gosnmp.GoSNMP, objDef models.ObjectDefinition) ([]gosnmp.SnmpPDU, error) {
-	root := LowestCommonOID(objDef)
-	if root == "" {
-		return nil, fmt.Errorf("no attribute OIDs in object %s", objDef.Key)
-	}
-	return conn.BulkWalkAll(root)
-}
-
 // ─────────────────────────────────────────────────────────────────────────────
 // OID analysis
 // ─────────────────────────────────────────────────────────────────────────────
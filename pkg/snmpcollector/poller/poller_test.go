@@ -2,7 +2,11 @@ package poller_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -13,6 +17,7 @@ import (
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
 	"github.com/vpbank/snmp_collector/snmp/decoder"
+	"github.com/vpbank/snmp_collector/utils/snmptest"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -168,6 +173,52 @@ func TestLowestCommonOID(t *testing.T) {
 	}
 }
 
+func TestOperationFor(t *testing.T) {
+	table, scalar := tableObjDef(), scalarObjDef()
+	columns := []string{".1.3.6.1.2.1.2.2.1.10", ".1.3.6.1.2.1.2.2.1.16"}
+
+	single := tableObjDef()
+	single.Attributes = map[string]models.AttributeDefinition{"ifInOctets": table.Attributes["ifInOctets"]}
+
+	// Columns 2, 3 and 5 cover more than half of ifEntry up to column 5.
+	dense := tableObjDef()
+	dense.Attributes = map[string]models.AttributeDefinition{
+		"ifDescr": {OID: ".1.3.6.1.2.1.2.2.1.2"},
+		"ifType":  {OID: ".1.3.6.1.2.1.2.2.1.3"},
+		"ifSpeed": {OID: ".1.3.6.1.2.1.2.2.1.5"},
+	}
+
+	// Columns of ifEntry and ifXEntry are never walked from .1.3.6.1.2.1.
+	twoTables := tableObjDef()
+	twoTables.Attributes = map[string]models.AttributeDefinition{
+		"ifDescr": {OID: ".1.3.6.1.2.1.2.2.1.2"},
+		"ifName":  {OID: ".1.3.6.1.2.1.31.1.1.1.1"},
+	}
+
+	tests := []struct {
+		name    string
+		job     poller.PollJob
+		wantOp  poller.Operation
+		wantOID []string
+	}{
+		{"scalar", poller.PollJob{ObjectDef: scalar, DeviceConfig: config.DeviceConfig{Version: "2c"}}, poller.OpGet, []string{".1.3.6.1.2.1.1.1.0"}},
+		{"columns v1", poller.PollJob{ObjectDef: table, DeviceConfig: config.DeviceConfig{Version: "1"}}, poller.OpWalk, columns},
+		{"columns v3", poller.PollJob{ObjectDef: table, DeviceConfig: config.DeviceConfig{Version: "3"}}, poller.OpGetBulk, columns},
+		{"single column", poller.PollJob{ObjectDef: single, DeviceConfig: config.DeviceConfig{Version: "2c"}}, poller.OpBulkWalk, columns[:1]},
+		{"dense entry", poller.PollJob{ObjectDef: dense, DeviceConfig: config.DeviceConfig{Version: "2c"}}, poller.OpBulkWalk, []string{".1.3.6.1.2.1.2.2.1"}},
+		{"two tables", poller.PollJob{ObjectDef: twoTables, DeviceConfig: config.DeviceConfig{Version: "2c"}}, poller.OpGetBulk, []string{".1.3.6.1.2.1.2.2.1.2", ".1.3.6.1.2.1.31.1.1.1.1"}},
+		{"raw oids", poller.PollJob{ObjectDef: table, OIDs: []string{".1.3.6.1.2.1.2.2.1.10.3"}}, poller.OpGet, []string{".1.3.6.1.2.1.2.2.1.10.3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, oids := poller.OperationFor(tt.job)
+			if op != tt.wantOp || !slices.Equal(oids, tt.wantOID) {
+				t.Errorf("OperationFor() = %s %v, want %s %v", op, oids, tt.wantOp, tt.wantOID)
+			}
+		})
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // TestSNMPv3MsgFlags (via exported helper — we'll test via session factory)
 // ─────────────────────────────────────────────────────────────────────────────
@@ -322,6 +373,101 @@ func TestConnectionPool_IdleTimeout(t *testing.T) {
 	p.Discard("sw1", c2)
 }
 
+func TestConnectionPool_Evict(t *testing.T) {
+	p := poller.NewConnectionPool(poller.PoolOptions{
+		MaxIdlePerDevice: 2,
+		Dial:             fakeDialer(),
+	}, nil)
+	defer p.Close()
+
+	ctx := context.Background()
+	cfg := testDeviceCfg()
+
+	idle, _ := p.Get(ctx, "sw1", cfg)
+	busy, _ := p.Get(ctx, "sw1", cfg)
+	p.Put("sw1", idle)
+
+	p.Evict("sw1")
+
+	// The idle session is gone and the checked-out one is not pooled on Put.
+	p.Put("sw1", busy)
+	got, _ := p.Get(ctx, "sw1", cfg)
+	if got == idle || got == busy {
+		t.Error("expected a freshly dialed session after Evict")
+	}
+	p.Put("sw1", got)
+
+	// Sessions dialed after the eviction are reused as usual.
+	again, _ := p.Get(ctx, "sw1", cfg)
+	if again != got {
+		t.Error("expected the post-eviction session to be reused")
+	}
+	p.Put("sw1", again)
+	p.Evict("unknown") // no-op
+}
+
+func TestConnectionPool_TuneRepetitions(t *testing.T) {
+	p := poller.NewConnectionPool(poller.PoolOptions{Dial: fakeDialer()}, nil)
+	defer p.Close()
+
+	ctx := context.Background()
+	cfg := testDeviceCfg()
+	cfg.AdaptiveBulk = true
+	conn, _ := p.Get(ctx, "sw1", cfg)
+
+	for _, step := range []struct {
+		reps   uint32
+		failed bool
+		want   uint32
+	}{
+		{40, true, 20},  // halved on failure
+		{20, false, 25}, // grown by a quarter on success
+		{48, false, 50}, // capped at the limit
+		{1, true, 1},    // never below 1
+		{1, false, 2},
+	} {
+		if got := p.TuneRepetitions("sw1", step.reps, 50, step.failed); got != step.want {
+			t.Errorf("TuneRepetitions(%d, failed=%v) = %d, want %d", step.reps, step.failed, got, step.want)
+		}
+	}
+	p.Put("sw1", conn)
+
+	// Sessions handed out carry the tuned value.
+	conn, _ = p.Get(ctx, "sw1", cfg)
+	if conn.MaxRepetitions != 2 || p.Repetitions("sw1") != 2 {
+		t.Errorf("session max-repetitions = %d, pool = %d; want 2", conn.MaxRepetitions, p.Repetitions("sw1"))
+	}
+	p.Put("sw1", conn)
+
+	p.Evict("sw1")
+	if got := p.Repetitions("sw1"); got != 0 {
+		t.Errorf("Repetitions after Evict = %d, want 0", got)
+	}
+}
+
+func TestSessionChanged(t *testing.T) {
+	base := testDeviceCfg()
+	same := testDeviceCfg()
+	same.PollInterval, same.Tags = 300, map[string]string{"site": "x"}
+	if poller.SessionChanged(base, same) {
+		t.Error("poll interval and tags do not affect the session")
+	}
+
+	community := testDeviceCfg()
+	community.Communities = []string{"private"}
+	v3 := testDeviceCfg()
+	v3.V3Credentials = []config.V3Credentials{{Username: "u"}}
+	port := testDeviceCfg()
+	port.Port = 161
+	reps := testDeviceCfg()
+	reps.MaxRepetitions = 10
+	for name, cfg := range map[string]config.DeviceConfig{"community": community, "v3": v3, "port": port, "max_repetitions": reps} {
+		if !poller.SessionChanged(base, cfg) {
+			t.Errorf("%s change not detected", name)
+		}
+	}
+}
+
 func TestConnectionPool_Close(t *testing.T) {
 	p := poller.NewConnectionPool(poller.PoolOptions{
 		Dial: fakeDialer(),
@@ -378,8 +524,8 @@ func TestSNMPPoller_ScalarUsesGet(t *testing.T) {
 	// the PollJob's ObjectDef. This is more practical than mocking gosnmp
 	// at the network level.
 	//
-	// The actual SNMP-level logic is tested via integration tests with a
-	// test SNMP agent (out of scope here). For unit tests we verify:
+	// The SNMP-level logic is exercised end to end against the snmptest
+	// simulator (TestSNMPPoller_Simulator_*). Here we verify:
 	// 1. isScalar detection works correctly
 	// 2. Operation routing logic works
 
@@ -394,6 +540,226 @@ func TestSNMPPoller_ScalarUsesGet(t *testing.T) {
 	}
 }
 
+// ─────────────────────────────────────────────────────────────────────────────
+// RateLimiter tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestRateLimiter_PerDeviceAndGlobal(t *testing.T) {
+	ctx := context.Background()
+
+	// A device gets one second's worth of requests at once, then 20 / s.
+	r := poller.NewRateLimiter(20, 0)
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		if err := r.Wait(ctx, "sw1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("25 requests at 20/s took %v, want ≥ 200ms", d)
+	}
+	// Another device has its own bucket.
+	start = time.Now()
+	if err := r.Wait(ctx, "sw2"); err != nil || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("first request to sw2 waited %v (err %v)", time.Since(start), err)
+	}
+	if st := r.Stats(); st.Requests != 26 || st.DeviceWaits != 5 || st.GlobalWaits != 0 || st.WaitTime <= 0 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	// The global bucket is shared by all devices.
+	g := poller.NewRateLimiter(0, 20)
+	start = time.Now()
+	for i := 0; i < 25; i++ {
+		if err := g.Wait(ctx, fmt.Sprintf("sw%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("25 requests at 20/s globally took %v, want ≥ 200ms", d)
+	}
+	if st := g.Stats(); st.Global != 20 || st.PerDevice != 0 || st.GlobalWaits != 5 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	// A request waiting for its device does not hold a global token.
+	both := poller.NewRateLimiter(1, 2)
+	if err := both.Wait(ctx, "sw1"); err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithCancel(ctx)
+	waited := make(chan error, 1)
+	go func() { waited <- both.Wait(waitCtx, "sw1") }() // a second away
+	time.Sleep(20 * time.Millisecond)
+	start = time.Now()
+	if err := both.Wait(ctx, "sw2"); err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("sw2 behind a waiting sw1 request waited %v (err %v), want the last global token at once", time.Since(start), err)
+	}
+	cancel()
+	if err := <-waited; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled wait err = %v, want context.Canceled", err)
+	}
+
+	// A nil limiter lets everything through.
+	var none *poller.RateLimiter
+	if err := none.Wait(ctx, "sw1"); err != nil || none.Stats() != (poller.RateLimitStats{}) {
+		t.Errorf("nil limiter: err %v, stats %+v", err, none.Stats())
+	}
+}
+
+func TestSNMPPoller_Simulator_RateLimitHonoursContext(t *testing.T) {
+	sim := startSwitch(t, snmptest.Options{})
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	limiter := poller.NewRateLimiter(1, 0)
+	p := poller.NewSNMPPoller(pool, poller.PollerOptions{RateLimiter: limiter}, nil)
+	job := poller.PollJob{Hostname: "sw1", Device: testDevice(), DeviceConfig: simulatorCfg(sim, "2c"), ObjectDef: scalarObjDef()}
+
+	if _, err := p.Poll(context.Background(), job); err != nil {
+		t.Fatalf("first poll: %v", err)
+	}
+
+	// The second request needs a token a second away; the poll gives up
+	// when its context does.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := p.Poll(ctx, job)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("rate-limited poll err = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("rate-limited poll returned after %v, want at the context deadline", d)
+	}
+	if got := limiter.Stats().Requests; got != 1 {
+		t.Errorf("requests let through = %d, want 1", got)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// System info tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func systemGroupPDUs() []gosnmp.SnmpPDU {
+	return []gosnmp.SnmpPDU{
+		{Name: poller.OIDSysDescr, Type: gosnmp.OctetString, Value: []byte("Cisco IOS Software, C2960X")},
+		{Name: poller.OIDSysObjectID, Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9.1.1208"},
+		{Name: poller.OIDSysContact, Type: gosnmp.OctetString, Value: []byte("noc@example.com")},
+		{Name: poller.OIDSysName, Type: gosnmp.OctetString, Value: []byte("sw01")},
+		{Name: poller.OIDSysLocation, Type: gosnmp.NoSuchObject, Value: nil},
+	}
+}
+
+func TestParseSystemInfo(t *testing.T) {
+	vendors := config.NewVendorMap(map[string]config.VendorRule{
+		"1.3.6.1.4.1.9":        {Vendor: "Cisco"},
+		"1.3.6.1.4.1.9.1.1208": {Model: "WS-C2960X-48FPD-L"},
+	})
+	info := poller.ParseSystemInfo(systemGroupPDUs(), vendors)
+
+	if info.SysObjectID != "1.3.6.1.4.1.9.1.1208" {
+		t.Errorf("SysObjectID = %q", info.SysObjectID)
+	}
+	if info.SysDescr != "Cisco IOS Software, C2960X" {
+		t.Errorf("SysDescr = %q", info.SysDescr)
+	}
+	if info.SysName != "sw01" || info.SysContact != "noc@example.com" {
+		t.Errorf("SysName/SysContact = %q/%q", info.SysName, info.SysContact)
+	}
+	if info.SysLocation != "" {
+		t.Errorf("SysLocation = %q, want empty for NoSuchObject", info.SysLocation)
+	}
+	if info.Vendor != "Cisco" || info.Model != "WS-C2960X-48FPD-L" {
+		t.Errorf("Vendor/Model = %q/%q", info.Vendor, info.Model)
+	}
+
+	dev := testDevice()
+	info.Apply(&dev)
+	if dev.Vendor != "Cisco" || dev.Model != "WS-C2960X-48FPD-L" || dev.SysDescr != info.SysDescr {
+		t.Errorf("Apply: device = %+v", dev)
+	}
+	if dev.Hostname != "switch1" {
+		t.Errorf("Apply changed Hostname to %q", dev.Hostname)
+	}
+}
+
+func TestSystemInfoCache_ClaimOncePerInterval(t *testing.T) {
+	c := poller.NewSystemInfoCache(time.Minute, nil)
+	now := time.Now()
+
+	if !c.Claim("sw01", now) {
+		t.Fatal("first Claim should return true")
+	}
+	if c.Claim("sw01", now.Add(time.Second)) {
+		t.Error("second Claim within interval should return false")
+	}
+	if _, ok := c.Get("sw01"); ok {
+		t.Error("Get before Store should report not ok")
+	}
+
+	c.Store("sw01", poller.SystemInfo{SysName: "sw01", FetchedAt: now})
+	if info, ok := c.Get("sw01"); !ok || info.SysName != "sw01" {
+		t.Errorf("Get after Store = %+v, %v", info, ok)
+	}
+	if !c.Claim("sw01", now.Add(time.Minute)) {
+		t.Error("Claim after interval should return true")
+	}
+
+	c.Remove("sw01")
+	if _, ok := c.Get("sw01"); ok {
+		t.Error("Get after Remove should report not ok")
+	}
+}
+
+func TestSystemInfoCache_RetriesFirstFetch(t *testing.T) {
+	c := poller.NewSystemInfoCache(time.Hour, nil)
+	now := time.Now()
+
+	// First fetch fails: retried after 30s, then 1m, then 2m.
+	if !c.Claim("sw01", now) {
+		t.Fatal("first Claim should return true")
+	}
+	for _, step := range []struct {
+		at   time.Duration
+		want bool
+	}{
+		{29 * time.Second, false},
+		{30 * time.Second, true},
+		{80 * time.Second, false},
+		{90 * time.Second, true},
+		{200 * time.Second, false},
+		{210 * time.Second, true},
+	} {
+		if got := c.Claim("sw01", now.Add(step.at)); got != step.want {
+			t.Errorf("Claim at +%v = %v, want %v", step.at, got, step.want)
+		}
+	}
+
+	// After a success the refresh interval applies.
+	c.Store("sw01", poller.SystemInfo{SysName: "sw01"})
+	if c.Claim("sw01", now.Add(30*time.Minute)) {
+		t.Error("Claim within the refresh interval after a success should return false")
+	}
+	if !c.Claim("sw01", now.Add(210*time.Second+time.Hour)) {
+		t.Error("Claim after the refresh interval should return true")
+	}
+}
+
+func TestSystemInfoCache_SetVendorsRederives(t *testing.T) {
+	c := poller.NewSystemInfoCache(time.Hour, nil)
+	c.Store("sw01", poller.ParseSystemInfo(systemGroupPDUs(), nil))
+	if info, _ := c.Get("sw01"); info.Vendor != "" {
+		t.Fatalf("Vendor = %q before vendors loaded", info.Vendor)
+	}
+
+	c.SetVendors(config.NewVendorMap(map[string]config.VendorRule{
+		"1.3.6.1.4.1.9": {Vendor: "Cisco"},
+	}))
+	if info, _ := c.Get("sw01"); info.Vendor != "Cisco" {
+		t.Errorf("Vendor after SetVendors = %q, want Cisco", info.Vendor)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // WorkerPool tests
 // ─────────────────────────────────────────────────────────────────────────────
@@ -565,6 +931,72 @@ func TestWorkerPool_PollError_NoVarbinds(t *testing.T) {
 	wp.Stop()
 }
 
+func TestWorkerPool_SubmitContext_Deadline(t *testing.T) {
+	started := make(chan struct{}, 10)
+	mp := &mockPoller{
+		pollFn: func(ctx context.Context, job poller.PollJob) (decoder.RawPollResult, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return decoder.RawPollResult{}, ctx.Err()
+		},
+	}
+	out := make(chan decoder.RawPollResult, 10)
+	wp := poller.NewWorkerPool(1, mp, out, nil) // 1 worker, channel cap = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	wp.Start(ctx)
+
+	// Occupy the worker, then fill the channel.
+	job := poller.PollJob{Hostname: "sw1", ObjectDef: tableObjDef()}
+	wp.Submit(job)
+	<-started
+	for wp.TrySubmit(job) {
+	}
+
+	subCtx, subCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer subCancel()
+	start := time.Now()
+	if wp.SubmitContext(subCtx, job) {
+		t.Error("SubmitContext accepted a job into a full queue")
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("SubmitContext returned after %v, want it to wait for the deadline", elapsed)
+	}
+
+	cancel()
+	wp.Stop()
+}
+
+func TestWorkerPool_CallsDone(t *testing.T) {
+	mp := &mockPoller{
+		pollFn: func(ctx context.Context, job poller.PollJob) (decoder.RawPollResult, error) {
+			if job.Hostname == "down" {
+				return decoder.RawPollResult{}, fmt.Errorf("device unreachable")
+			}
+			return decoder.RawPollResult{Varbinds: []gosnmp.SnmpPDU{{Name: ".1.3.6.1.2.1.1.3.0"}}}, nil
+		},
+	}
+	out := make(chan decoder.RawPollResult, 10)
+	wp := poller.NewWorkerPool(2, mp, out, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wp.Start(ctx)
+
+	var done sync.WaitGroup
+	done.Add(2)
+	wp.Submit(poller.PollJob{Hostname: "up", ObjectDef: tableObjDef(), Done: done.Done})
+	wp.Submit(poller.PollJob{Hostname: "down", ObjectDef: tableObjDef(), Done: done.Done})
+
+	finished := make(chan struct{})
+	go func() { done.Wait(); close(finished) }()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Done not called for both the successful and the failed job")
+	}
+	cancel()
+	wp.Stop()
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // PollJob construction tests
 // ─────────────────────────────────────────────────────────────────────────────
@@ -586,3 +1018,261 @@ func TestPollJob_Fields(t *testing.T) {
 		t.Errorf("Version = %q, want %q", job.DeviceConfig.Version, "2c")
 	}
 }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// End-to-end against the snmptest simulator
+// ─────────────────────────────────────────────────────────────────────────────
+
+// simulatorCfg returns a DeviceConfig pointing at sim.
+func simulatorCfg(sim *snmptest.Simulator, version string) config.DeviceConfig {
+	cfg := testDeviceCfg()
+	cfg.Port = sim.Port()
+	cfg.Version = version
+	return cfg
+}
+
+// startSwitch starts a simulator serving the snmptest switch fixture.
+func startSwitch(t *testing.T, opts snmptest.Options) *snmptest.Simulator {
+	t.Helper()
+	sim := snmptest.NewSimulator(snmptest.NewSwitch(), opts, nil)
+	if err := sim.Start(); err != nil {
+		t.Fatalf("simulator: %v", err)
+	}
+	t.Cleanup(sim.Stop)
+	return sim
+}
+
+func TestSNMPPoller_Simulator_Versions(t *testing.T) {
+	user := snmptest.User{
+		Name:         "collector",
+		AuthProtocol: gosnmp.SHA256, AuthPassphrase: "authpass123",
+		PrivProtocol: gosnmp.AES, PrivPassphrase: "privpass123",
+	}
+	sim := startSwitch(t, snmptest.Options{Users: []snmptest.User{user}})
+
+	v3 := simulatorCfg(sim, "3")
+	v3.V3Credentials = []config.V3Credentials{{
+		Username:                 "collector",
+		AuthenticationProtocol:   "sha256",
+		AuthenticationPassphrase: "authpass123",
+		PrivacyProtocol:          "aes",
+		PrivacyPassphrase:        "privpass123",
+	}}
+	cfgs := map[string]config.DeviceConfig{
+		"1":  simulatorCfg(sim, "1"),
+		"2c": simulatorCfg(sim, "2c"),
+		"3":  v3,
+	}
+
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	p := poller.NewSNMPPoller(pool, poller.PollerOptions{SystemInfo: poller.NewSystemInfoCache(time.Hour, nil)}, nil)
+
+	for version, cfg := range cfgs {
+		job := poller.PollJob{Hostname: "sw-v" + version, Device: testDevice(), DeviceConfig: cfg}
+
+		job.ObjectDef = scalarObjDef()
+		res, err := p.Poll(context.Background(), job)
+		if err != nil {
+			t.Fatalf("v%s scalar poll: %v", version, err)
+		}
+		if len(res.Varbinds) != 1 || res.Varbinds[0].Name != snmptest.OIDSysDescr {
+			t.Errorf("v%s scalar varbinds = %+v", version, res.Varbinds)
+		}
+		if !strings.HasPrefix(res.Device.SysDescr, "Cisco IOS") {
+			t.Errorf("v%s system info not applied: %+v", version, res.Device)
+		}
+
+		job.ObjectDef = tableObjDef()
+		res, err = p.Poll(context.Background(), job)
+		if err != nil {
+			t.Fatalf("v%s table poll: %v", version, err)
+		}
+		want := len(sim.Device().Walk(snmptest.OIDIfInOctets)) + len(sim.Device().Walk(snmptest.OIDIfOutOctets))
+		if got := len(res.Varbinds); got != want {
+			t.Errorf("v%s table varbinds = %d, want %d", version, got, want)
+		}
+	}
+}
+
+func TestSNMPPoller_Simulator_CountersAndFaults(t *testing.T) {
+	sim := startSwitch(t, snmptest.Options{})
+	sim.Device().AutoIncrement(snmptest.OIDIfInOctets, 100)
+
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	p := poller.NewSNMPPoller(pool, poller.PollerOptions{}, nil)
+
+	cfg := simulatorCfg(sim, "2c")
+	cfg.Timeout = 200
+	job := poller.PollJob{
+		Hostname: "sw1", Device: testDevice(), DeviceConfig: cfg,
+		ObjectDef: tableObjDef(),
+		OIDs:      []string{snmptest.OIDIfInOctets + ".1"},
+	}
+	var seen []uint
+	for i := 0; i < 2; i++ {
+		res, err := p.Poll(context.Background(), job)
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		seen = append(seen, res.Varbinds[0].Value.(uint))
+	}
+	if seen[1]-seen[0] != 100 {
+		t.Errorf("ifInOctets.1 over two polls = %v, want a step of 100", seen)
+	}
+
+	sim.SetLoss(1)
+	if _, err := p.Poll(context.Background(), job); err == nil {
+		t.Error("poll of an unresponsive agent succeeded")
+	}
+	sim.SetLoss(0)
+	if _, err := p.Poll(context.Background(), job); err != nil {
+		t.Errorf("poll after recovery: %v", err)
+	}
+}
+
+func TestSNMPPoller_Simulator_GetBulkColumns(t *testing.T) {
+	objDef := tableObjDef()
+	objDef.Attributes = map[string]models.AttributeDefinition{
+		"ifDescr":      {OID: snmptest.OIDIfDescr},
+		"ifInOctets":   {OID: snmptest.OIDIfInOctets},
+		"ifHCInOctets": {OID: snmptest.OIDIfHCInOctets},
+	}
+
+	// A small message size truncates GetBulk responses mid-row.
+	for _, size := range []int{0, 300} {
+		sim := startSwitch(t, snmptest.Options{MaxMessageSize: size})
+		var want []string
+		for _, col := range []string{snmptest.OIDIfDescr, snmptest.OIDIfInOctets, snmptest.OIDIfHCInOctets} {
+			for _, pdu := range sim.Device().Walk(col) {
+				want = append(want, pdu.Name)
+			}
+		}
+
+		pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+		p := poller.NewSNMPPoller(pool, poller.PollerOptions{}, nil)
+		res, err := p.Poll(context.Background(), poller.PollJob{
+			Hostname: "sw1", Device: testDevice(), DeviceConfig: simulatorCfg(sim, "2c"), ObjectDef: objDef,
+		})
+		pool.Close()
+		if err != nil {
+			t.Fatalf("size %d: poll: %v", size, err)
+		}
+		got := make([]string, len(res.Varbinds))
+		for i, vb := range res.Varbinds {
+			got[i] = vb.Name
+		}
+		slices.Sort(got)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("size %d: varbinds = %v, want %v", size, got, want)
+		}
+	}
+}
+
+func TestSNMPPoller_Simulator_TooBigFallback(t *testing.T) {
+	objDef := tableObjDef()
+	objDef.Attributes = map[string]models.AttributeDefinition{
+		"ifDescr":      {OID: snmptest.OIDIfDescr},
+		"ifInOctets":   {OID: snmptest.OIDIfInOctets},
+		"ifHCInOctets": {OID: snmptest.OIDIfHCInOctets},
+	}
+	sim := startSwitch(t, snmptest.Options{MaxMessageSize: 300, BulkTooBig: true})
+	var want int
+	for _, col := range []string{snmptest.OIDIfDescr, snmptest.OIDIfInOctets, snmptest.OIDIfHCInOctets} {
+		want += len(sim.Device().Walk(col))
+	}
+
+	for _, adaptive := range []bool{false, true} {
+		pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+		p := poller.NewSNMPPoller(pool, poller.PollerOptions{}, nil)
+		cfg := simulatorCfg(sim, "2c")
+		cfg.AdaptiveBulk = adaptive
+		res, err := p.Poll(context.Background(), poller.PollJob{
+			Hostname: "sw1", Device: testDevice(), DeviceConfig: cfg, ObjectDef: objDef,
+		})
+		reps := pool.Repetitions("sw1")
+		pool.Close()
+		if err != nil {
+			t.Fatalf("adaptive %v: poll with tooBig responses: %v", adaptive, err)
+		}
+		if len(res.Varbinds) != want {
+			t.Errorf("adaptive %v: varbinds = %d, want %d", adaptive, len(res.Varbinds), want)
+		}
+		if adaptive && (reps == 0 || reps >= 50) {
+			t.Errorf("max-repetitions after tooBig = %d, want reduced below 50", reps)
+		}
+	}
+}
+
+func TestSNMPPoller_Simulator_AdaptiveBulk(t *testing.T) {
+	sim := startSwitch(t, snmptest.Options{})
+
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	p := poller.NewSNMPPoller(pool, poller.PollerOptions{}, nil)
+
+	cfg := simulatorCfg(sim, "2c")
+	cfg.Timeout = 200
+	cfg.MaxRepetitions = 40
+	cfg.AdaptiveBulk = true
+	job := poller.PollJob{Hostname: "sw1", Device: testDevice(), DeviceConfig: cfg, ObjectDef: tableObjDef()}
+
+	sim.SetLoss(1)
+	if _, err := p.Poll(context.Background(), job); err == nil {
+		t.Fatal("poll of an unresponsive agent succeeded")
+	}
+	if got := pool.Repetitions("sw1"); got != 20 {
+		t.Errorf("max-repetitions after a timeout = %d, want 20", got)
+	}
+
+	sim.SetLoss(0)
+	if _, err := p.Poll(context.Background(), job); err != nil {
+		t.Fatalf("poll after recovery: %v", err)
+	}
+	if got := pool.Repetitions("sw1"); got != 25 {
+		t.Errorf("max-repetitions after a successful poll = %d, want 25", got)
+	}
+}
+
+func TestSNMPPoller_LateErrorIsNotTimeout(t *testing.T) {
+	// The agent ignores the first try and answers the retry with garbage, so
+	// the request fails after one per-try timeout with a decode error.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 65535)
+		for n := 0; ; n++ {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				conn.WriteTo([]byte("not snmp"), addr)
+			}
+		}
+	}()
+
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	p := poller.NewSNMPPoller(pool, poller.PollerOptions{}, nil)
+	cfg := testDeviceCfg()
+	cfg.Port = conn.LocalAddr().(*net.UDPAddr).Port
+	cfg.Timeout = 200
+	cfg.Retries = 1
+	cfg.AdaptiveBulk = true
+	_, err = p.Poll(context.Background(), poller.PollJob{
+		Hostname: "sw1", Device: testDevice(), DeviceConfig: cfg, ObjectDef: tableObjDef(),
+	})
+	if err == nil {
+		t.Fatal("poll answered with garbage succeeded")
+	}
+	// A timeout would have halved max-repetitions; a decode error leaves it.
+	if got := pool.Repetitions("sw1"); got != 0 {
+		t.Errorf("max-repetitions = %d after %v, want untouched (not a timeout)", got, err)
+	}
+}
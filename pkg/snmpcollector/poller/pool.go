@@ -54,9 +54,19 @@ type devicePool struct {
 	mu   sync.Mutex
 	idle []poolEntry // LIFO stack
 
+	// gen is bumped by Evict. inUse records the generation each checked-out
+	// session was obtained under, so sessions dialed with replaced settings
+	// are closed on Put instead of returning to idle.
+	gen   uint64
+	inUse map[*gosnmp.GoSNMP]uint64
+
 	// sem limits concurrent in-flight connections for this device.
 	// Its capacity equals DeviceConfig.MaxConcurrentPolls.
 	sem chan struct{}
+
+	// reps is the max-repetitions adaptive tuning settled on (see
+	// TuneRepetitions); 0 until a bulk poll outcome is recorded.
+	reps uint32
 }
 
 // ConnectionPool manages gosnmp sessions keyed by device hostname.
@@ -109,16 +119,25 @@ func (p *ConnectionPool) Get(ctx context.Context, hostname string, cfg config.De
 
 	// Try to reuse an idle connection.
 	if conn := p.popIdle(dp); conn != nil {
+		p.applyRepetitions(dp, cfg, conn)
 		return conn, nil
 	}
 
-	// Dial a new session.
+	// Dial a new session. The generation is read first so that an Evict
+	// racing with the dial marks this session stale.
+	dp.mu.Lock()
+	gen := dp.gen
+	dp.mu.Unlock()
 	conn, err := p.opts.Dial(cfg)
 	if err != nil {
 		// Release semaphore slot on failure.
 		<-dp.sem
 		return nil, err
 	}
+	dp.mu.Lock()
+	dp.inUse[conn] = gen
+	dp.mu.Unlock()
+	p.applyRepetitions(dp, cfg, conn)
 	return conn, nil
 }
 
@@ -138,7 +157,9 @@ func (p *ConnectionPool) Put(hostname string, conn *gosnmp.GoSNMP) {
 	dp.mu.Lock()
 	defer dp.mu.Unlock()
 
-	if len(dp.idle) >= p.opts.MaxIdlePerDevice {
+	gen, tracked := dp.inUse[conn]
+	delete(dp.inUse, conn)
+	if !tracked || gen != dp.gen || len(dp.idle) >= p.opts.MaxIdlePerDevice {
 		if conn.Conn != nil {
 			_ = conn.Conn.Close()
 		}
@@ -156,10 +177,70 @@ func (p *ConnectionPool) Discard(hostname string, conn *gosnmp.GoSNMP) {
 	}
 	dp := p.getPool(hostname)
 	if dp != nil {
+		dp.mu.Lock()
+		delete(dp.inUse, conn)
+		dp.mu.Unlock()
 		<-dp.sem
 	}
 }
 
+// Evict closes the idle sessions of hostname and marks its checked-out
+// sessions stale, so they are closed when returned. Call it when the device's
+// address or credentials change or the device is removed; the next Get dials
+// afresh. The device's concurrency limit is kept; its tuned max-repetitions
+// is forgotten.
+func (p *ConnectionPool) Evict(hostname string) {
+	dp := p.getPool(hostname)
+	if dp == nil {
+		return
+	}
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	dp.gen++
+	dp.reps = 0
+	for _, e := range dp.idle {
+		if e.conn.Conn != nil {
+			_ = e.conn.Conn.Close()
+		}
+	}
+	dp.idle = dp.idle[:0]
+}
+
+// Repetitions returns the max-repetitions adaptive tuning settled on for
+// hostname, or 0 when no bulk poll outcome was recorded yet.
+func (p *ConnectionPool) Repetitions(hostname string) uint32 {
+	dp := p.getPool(hostname)
+	if dp == nil {
+		return 0
+	}
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	return dp.reps
+}
+
+// TuneRepetitions records the outcome of a bulk poll of hostname that used
+// reps max-repetitions and returns the value the device's sessions use from
+// now on: half of reps after a failure (a tooBig response or a timeout),
+// otherwise reps grown by a quarter, both at least 1 and at most limit.
+// Sessions handed out by Get for devices with AdaptiveBulk carry the value.
+func (p *ConnectionPool) TuneRepetitions(hostname string, reps, limit uint32, failed bool) uint32 {
+	dp := p.getPool(hostname)
+	if dp == nil {
+		return reps
+	}
+	if failed {
+		reps /= 2
+	} else {
+		reps += max(1, reps/4)
+	}
+	reps = min(max(reps, 1), max(limit, 1))
+
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	dp.reps = reps
+	return reps
+}
+
 // Close drains all idle connections and prevents new Get calls.
 func (p *ConnectionPool) Close() error {
 	select {
@@ -208,8 +289,9 @@ func (p *ConnectionPool) getOrCreatePool(hostname string, maxConcurrent int) *de
 		return dp
 	}
 	dp = &devicePool{
-		idle: make([]poolEntry, 0, p.opts.MaxIdlePerDevice),
-		sem:  make(chan struct{}, maxConcurrent),
+		idle:  make([]poolEntry, 0, p.opts.MaxIdlePerDevice),
+		inUse: make(map[*gosnmp.GoSNMP]uint64),
+		sem:   make(chan struct{}, maxConcurrent),
 	}
 	p.pools[hostname] = dp
 	return dp
@@ -238,11 +320,25 @@ func (p *ConnectionPool) popIdle(dp *devicePool) *gosnmp.GoSNMP {
 			}
 			continue
 		}
+		dp.inUse[entry.conn] = dp.gen
 		return entry.conn
 	}
 	return nil
 }
 
+// applyRepetitions sets the tuned max-repetitions on conn when the device
+// uses adaptive bulk sizing and a value was recorded.
+func (p *ConnectionPool) applyRepetitions(dp *devicePool, cfg config.DeviceConfig, conn *gosnmp.GoSNMP) {
+	if !cfg.AdaptiveBulk {
+		return
+	}
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	if dp.reps > 0 {
+		conn.MaxRepetitions = dp.reps
+	}
+}
+
 // noopWriter discards log output.
 type noopWriter struct{}
 
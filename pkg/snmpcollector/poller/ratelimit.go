@@ -0,0 +1,197 @@
+package poller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// RateLimiter — token buckets for SNMP requests
+// ─────────────────────────────────────────────────────────────────────────────
+
+// RateLimiter limits the SNMP requests (PDUs, not jobs) SNMPPoller sends: at
+// most PerDevice requests per second to any one device and Global requests
+// per second in total. Each limit is a token bucket holding one second's
+// worth of requests, so a device may burst up to its limit after a quiet
+// spell. A zero limit is unlimited. It is safe for concurrent use.
+type RateLimiter struct {
+	perDevice float64
+	global    *bucket // nil when unlimited
+
+	mu      sync.Mutex
+	devices map[string]*bucket // hostname → bucket; empty when unlimited
+
+	requests    atomic.Uint64
+	deviceWaits atomic.Uint64
+	globalWaits atomic.Uint64
+	waitNanos   atomic.Int64
+}
+
+// RateLimitStats is a snapshot of a RateLimiter for metrics.
+type RateLimitStats struct {
+	// PerDevice and Global are the configured limits in requests per second
+	// (0 = unlimited).
+	PerDevice float64
+	Global    float64
+
+	// Requests is the number of requests let through.
+	Requests uint64
+
+	// DeviceWaits and GlobalWaits count the requests delayed by the
+	// per-device and the global limit. A request delayed by both counts
+	// towards both.
+	DeviceWaits uint64
+	GlobalWaits uint64
+
+	// WaitTime is the total time requests spent waiting for a token.
+	WaitTime time.Duration
+}
+
+// NewRateLimiter returns a limiter of perDevice requests per second to each
+// device and global requests per second in total (0 = unlimited).
+func NewRateLimiter(perDevice, global float64) *RateLimiter {
+	r := &RateLimiter{
+		perDevice: max(perDevice, 0),
+		devices:   make(map[string]*bucket),
+	}
+	if global > 0 {
+		r.global = newBucket(global)
+	}
+	return r
+}
+
+// Wait blocks until a request to hostname is allowed under both limits, or
+// until ctx is done, in which case the tokens it reserved are returned and
+// ctx.Err() is returned. The global token is taken only once the per-device
+// wait is over, so requests queued behind a slow device do not spend the
+// global budget. A nil RateLimiter allows every request at once.
+func (r *RateLimiter) Wait(ctx context.Context, hostname string) error {
+	if r == nil {
+		return nil
+	}
+	var dev *bucket
+	if r.perDevice > 0 {
+		dev = r.device(hostname)
+		if err := r.take(ctx, dev, &r.deviceWaits); err != nil {
+			return err
+		}
+	}
+	if r.global != nil {
+		if err := r.take(ctx, r.global, &r.globalWaits); err != nil {
+			if dev != nil {
+				dev.cancel()
+			}
+			return err
+		}
+	}
+	r.requests.Add(1)
+	return nil
+}
+
+// Forget drops the per-device bucket of hostname, e.g. when the device is
+// removed from the configuration.
+func (r *RateLimiter) Forget(hostname string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	delete(r.devices, hostname)
+	r.mu.Unlock()
+}
+
+// Stats returns the limits and counters of r. A nil RateLimiter reports
+// zero values.
+func (r *RateLimiter) Stats() RateLimitStats {
+	if r == nil {
+		return RateLimitStats{}
+	}
+	s := RateLimitStats{
+		PerDevice:   r.perDevice,
+		Requests:    r.requests.Load(),
+		DeviceWaits: r.deviceWaits.Load(),
+		GlobalWaits: r.globalWaits.Load(),
+		WaitTime:    time.Duration(r.waitNanos.Load()),
+	}
+	if r.global != nil {
+		s.Global = r.global.rate
+	}
+	return s
+}
+
+// take reserves a token from b and sleeps until it may be used, counting the
+// delay in waits. If ctx is done first, the token is returned.
+func (r *RateLimiter) take(ctx context.Context, b *bucket, waits *atomic.Uint64) error {
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+	waits.Add(1)
+	r.waitNanos.Add(int64(delay))
+	return nil
+}
+
+func (r *RateLimiter) device(hostname string) *bucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.devices[hostname]
+	if !ok {
+		b = newBucket(r.perDevice)
+		r.devices[hostname] = b
+	}
+	return b
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Token bucket
+// ─────────────────────────────────────────────────────────────────────────────
+
+// bucket is a token bucket refilled at rate tokens per second up to burst.
+// Tokens may go negative: each reservation takes one token at once and
+// waits until the balance it left behind has been refilled, so waiters are
+// served in order.
+type bucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64) *bucket {
+	burst := max(rate, 1)
+	return &bucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve takes a token at now and returns how long the caller must wait
+// before using it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token that was not used.
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	b.tokens = min(b.burst, b.tokens+1)
+	b.mu.Unlock()
+}
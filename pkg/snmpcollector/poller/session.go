@@ -6,6 +6,7 @@ package poller
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +18,13 @@ import (
 // Session factory — DeviceConfig → *gosnmp.GoSNMP
 // ─────────────────────────────────────────────────────────────────────────────
 
+// Session defaults for a DeviceConfig that leaves max_oids or max_repetitions
+// unset; config.Load fills in the same values.
+const (
+	defaultMaxOids        = 60
+	defaultMaxRepetitions = 50
+)
+
 // NewSession creates and connects a gosnmp session for the given device
 // configuration. The caller is responsible for calling Close when the session
 // is no longer needed.
@@ -27,7 +35,14 @@ func NewSession(cfg config.DeviceConfig) (*gosnmp.GoSNMP, error) {
 		Timeout:            time.Duration(cfg.Timeout) * time.Millisecond,
 		Retries:            cfg.Retries,
 		ExponentialTimeout: cfg.ExponentialTimeout,
-		MaxOids:            60,
+		MaxOids:            cfg.MaxOids,
+		MaxRepetitions:     uint32(cfg.MaxRepetitions),
+	}
+	if g.MaxOids <= 0 {
+		g.MaxOids = defaultMaxOids
+	}
+	if g.MaxRepetitions == 0 {
+		g.MaxRepetitions = defaultMaxRepetitions
 	}
 
 	switch cfg.Version {
@@ -65,6 +80,21 @@ func NewSession(cfg config.DeviceConfig) (*gosnmp.GoSNMP, error) {
 	return g, nil
 }
 
+// SessionChanged reports whether a session built from prev would differ from
+// one built from cur: address, version, timeouts, bulk sizes or credentials.
+// Callers use it on reload to decide which pooled sessions to Evict.
+func SessionChanged(prev, cur config.DeviceConfig) bool {
+	if prev.IP != cur.IP || prev.Port != cur.Port || prev.Version != cur.Version ||
+		prev.Timeout != cur.Timeout || prev.Retries != cur.Retries ||
+		prev.ExponentialTimeout != cur.ExponentialTimeout ||
+		prev.MaxOids != cur.MaxOids || prev.MaxRepetitions != cur.MaxRepetitions ||
+		prev.AdaptiveBulk != cur.AdaptiveBulk {
+		return true
+	}
+	return !slices.Equal(prev.Communities, cur.Communities) ||
+		!slices.Equal(prev.V3Credentials, cur.V3Credentials)
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // SNMPv3 helpers
 // ─────────────────────────────────────────────────────────────────────────────
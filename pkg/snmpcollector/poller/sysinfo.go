@@ -0,0 +1,239 @@
+package poller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// SNMPv2-MIB system group
+// ─────────────────────────────────────────────────────────────────────────────
+
+// System group scalar OIDs (SNMPv2-MIB::system), fetched once per device on
+// first contact and again every refresh interval.
+const (
+	OIDSysDescr    = ".1.3.6.1.2.1.1.1.0"
+	OIDSysObjectID = ".1.3.6.1.2.1.1.2.0"
+	OIDSysContact  = ".1.3.6.1.2.1.1.4.0"
+	OIDSysName     = ".1.3.6.1.2.1.1.5.0"
+	OIDSysLocation = ".1.3.6.1.2.1.1.6.0"
+)
+
+// systemOIDs is the request list for FetchSystemInfo.
+var systemOIDs = []string{OIDSysDescr, OIDSysObjectID, OIDSysContact, OIDSysName, OIDSysLocation}
+
+// SystemInfo is the cached SNMPv2-MIB system group of a single device, plus
+// the vendor and model derived from its sysObjectID.
+type SystemInfo struct {
+	SysDescr    string
+	SysObjectID string
+	SysContact  string
+	SysName     string
+	SysLocation string
+	Vendor      string
+	Model       string
+
+	// FetchedAt is when the values were read from the device.
+	FetchedAt time.Time
+}
+
+// Apply copies the inventory fields onto dev. Fields that are empty in info
+// leave the existing value on dev untouched.
+func (info SystemInfo) Apply(dev *models.Device) {
+	if info.Vendor != "" {
+		dev.Vendor = info.Vendor
+	}
+	if info.Model != "" {
+		dev.Model = info.Model
+	}
+	if info.SysDescr != "" {
+		dev.SysDescr = info.SysDescr
+	}
+	if info.SysLocation != "" {
+		dev.SysLocation = info.SysLocation
+	}
+	if info.SysContact != "" {
+		dev.SysContact = info.SysContact
+	}
+}
+
+// ParseSystemInfo builds a SystemInfo from the varbinds of a system group Get
+// and resolves vendor / model through vendors (which may be nil). Varbinds
+// with error types and OIDs outside the system group are ignored.
+func ParseSystemInfo(pdus []gosnmp.SnmpPDU, vendors *config.VendorMap) SystemInfo {
+	var info SystemInfo
+	for _, pdu := range pdus {
+		if pdu.Type == gosnmp.NoSuchObject || pdu.Type == gosnmp.NoSuchInstance ||
+			pdu.Type == gosnmp.EndOfMibView || pdu.Type == gosnmp.Null {
+			continue
+		}
+		name := "." + strings.TrimPrefix(pdu.Name, ".")
+		switch name {
+		case OIDSysDescr:
+			info.SysDescr = pduString(pdu.Value)
+		case OIDSysObjectID:
+			info.SysObjectID = strings.TrimPrefix(pduString(pdu.Value), ".")
+		case OIDSysContact:
+			info.SysContact = pduString(pdu.Value)
+		case OIDSysName:
+			info.SysName = pduString(pdu.Value)
+		case OIDSysLocation:
+			info.SysLocation = pduString(pdu.Value)
+		}
+	}
+	info.Vendor, info.Model = vendors.Match(info.SysObjectID)
+	return info
+}
+
+// pduString renders a DisplayString or OID varbind value as a trimmed string.
+func pduString(v interface{}) string {
+	switch x := v.(type) {
+	case []byte:
+		return strings.TrimSpace(string(x))
+	case string:
+		return strings.TrimSpace(x)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// FetchSystemInfo performs a single Get of the system group on conn and stamps
+// the result with the current time.
+func FetchSystemInfo(conn *gosnmp.GoSNMP, vendors *config.VendorMap) (SystemInfo, error) {
+	pkt, err := conn.Get(systemOIDs)
+	if err != nil {
+		return SystemInfo{}, err
+	}
+	info := ParseSystemInfo(pkt.Variables, vendors)
+	info.FetchedAt = time.Now()
+	return info, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// SystemInfoCache
+// ─────────────────────────────────────────────────────────────────────────────
+
+// sysInfoRetry is the delay before the first retry of a device whose system
+// group has never been fetched. It doubles with each failure, up to the
+// refresh interval.
+const sysInfoRetry = 30 * time.Second
+
+// sysInfoEntry is the cache slot for one device.
+type sysInfoEntry struct {
+	info        SystemInfo
+	valid       bool      // info has been fetched successfully at least once
+	attemptedAt time.Time // last time a refresh was claimed
+	attempts    int       // refreshes claimed since the last successful fetch
+}
+
+// SystemInfoCache holds per-device SystemInfo and decides when it must be
+// refreshed. At most one refresh per device is claimed per interval, so the
+// burst of jobs a device receives each cycle triggers a single extra Get;
+// until the first successful fetch, retries come sooner, with backoff.
+// It is safe for concurrent use.
+type SystemInfoCache struct {
+	refresh time.Duration
+
+	mu      sync.RWMutex
+	vendors *config.VendorMap
+	entries map[string]*sysInfoEntry // hostname → entry
+}
+
+// NewSystemInfoCache creates a cache that refreshes each device's system group
+// every refresh interval (default 1 h when ≤ 0). vendors may be nil.
+func NewSystemInfoCache(refresh time.Duration, vendors *config.VendorMap) *SystemInfoCache {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	return &SystemInfoCache{
+		refresh: refresh,
+		vendors: vendors,
+		entries: make(map[string]*sysInfoEntry),
+	}
+}
+
+// Get returns the cached SystemInfo for hostname. ok is false until the first
+// successful fetch.
+func (c *SystemInfoCache) Get(hostname string) (SystemInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[hostname]
+	if !ok || !e.valid {
+		return SystemInfo{}, false
+	}
+	return e.info, true
+}
+
+// Claim reports whether the caller should refresh hostname now. Once the
+// system group has been fetched, it returns true at most once per refresh
+// interval. Before that, a claim that was not followed by Store (a failed
+// fetch) is retried after 30s, doubling with each failure up to the refresh
+// interval.
+func (c *SystemInfoCache) Claim(hostname string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[hostname]
+	if !ok {
+		e = &sysInfoEntry{}
+		c.entries[hostname] = e
+	}
+	wait := c.refresh
+	if !e.valid && e.attempts > 0 {
+		wait = min(c.refresh, sysInfoRetry<<min(e.attempts-1, 16))
+	}
+	if !e.attemptedAt.IsZero() && now.Sub(e.attemptedAt) < wait {
+		return false
+	}
+	e.attemptedAt = now
+	e.attempts++
+	return true
+}
+
+// Store records a freshly fetched SystemInfo for hostname.
+func (c *SystemInfoCache) Store(hostname string, info SystemInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[hostname]
+	if !ok {
+		e = &sysInfoEntry{attemptedAt: info.FetchedAt}
+		c.entries[hostname] = e
+	}
+	e.info = info
+	e.valid = true
+	e.attempts = 0
+}
+
+// Remove drops hostname from the cache. Call this when a device is removed
+// from the inventory.
+func (c *SystemInfoCache) Remove(hostname string) {
+	c.mu.Lock()
+	delete(c.entries, hostname)
+	c.mu.Unlock()
+}
+
+// Vendors returns the VendorMap used for new fetches.
+func (c *SystemInfoCache) Vendors() *config.VendorMap {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vendors
+}
+
+// SetVendors swaps the VendorMap and re-derives vendor / model for every
+// cached device from its stored sysObjectID, so a reload takes effect without
+// waiting for the next refresh.
+func (c *SystemInfoCache) SetVendors(vendors *config.VendorMap) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vendors = vendors
+	for _, e := range c.entries {
+		if e.valid {
+			e.info.Vendor, e.info.Model = vendors.Match(e.info.SysObjectID)
+		}
+	}
+}
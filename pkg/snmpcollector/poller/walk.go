@@ -0,0 +1,323 @@
+package poller
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/models"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Walk planning — which requests fetch an object
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Operation is the SNMP operation Poll uses for a job.
+type Operation string
+
+// Operations chosen by OperationFor.
+const (
+	// OpGet fetches each OID with Get requests, batched to MaxOids.
+	OpGet Operation = "Get"
+
+	// OpWalk walks each root with GetNext (SNMPv1).
+	OpWalk Operation = "Walk"
+
+	// OpBulkWalk walks each root with GetBulk, one root at a time.
+	OpBulkWalk Operation = "BulkWalk"
+
+	// OpGetBulk walks several columns at once: every GetBulk request carries
+	// all unfinished columns as repeaters and returns a batch of rows.
+	OpGetBulk Operation = "GetBulk"
+)
+
+// minRootCoverage is the share of a table entry's columns an object must
+// poll before the whole entry is walked rather than its columns one by one.
+// The entry is assumed to have as many columns as its highest configured
+// column number.
+const minRootCoverage = 0.5
+
+// OperationFor returns the operation Poll uses for job and the OIDs it
+// requests: the OIDs fetched by a Get, the roots of a walk or the columns of
+// a GetBulk. The OIDs are empty when the object has no attribute OIDs.
+//
+// Table objects are planned from their attribute set:
+//
+//   - Columns of one table entry that cover at least half of it → walk the
+//     entry (poller.LowestCommonOID), one request stream for all columns.
+//   - A single column → walk that column.
+//   - Anything else — a few columns of a wide entry, or columns of several
+//     tables — → GetBulk the columns in parallel on v2c / v3, or walk each
+//     column on SNMPv1.
+//
+// A walk root is therefore never above a table entry, however far apart the
+// attributes are: a root such as ".1.3.6.1.4.1.9" is never walked.
+func OperationFor(job PollJob) (Operation, []string) {
+	switch {
+	case len(job.OIDs) > 0:
+		return OpGet, job.OIDs
+	case isScalar(job.ObjectDef):
+		return OpGet, scalarOIDs(job.ObjectDef)
+	}
+
+	walk := OpBulkWalk
+	if job.DeviceConfig.Version == "1" {
+		walk = OpWalk
+	}
+	columns := columnOIDs(job.ObjectDef)
+	switch {
+	case len(columns) <= 1:
+		return walk, columns
+	case coversEntry(columns):
+		return walk, []string{LowestCommonOID(job.ObjectDef)}
+	case walk == OpWalk:
+		return OpWalk, columns
+	default:
+		return OpGetBulk, columns
+	}
+}
+
+// scalarOIDs returns the attribute OIDs of a scalar object, each with ".0"
+// appended (scalar instance).
+func scalarOIDs(objDef models.ObjectDefinition) []string {
+	oids := make([]string, 0, len(objDef.Attributes))
+	for _, attr := range objDef.Attributes {
+		oid := attr.OID
+		if !strings.HasSuffix(oid, ".0") {
+			oid += ".0"
+		}
+		oids = append(oids, oid)
+	}
+	return oids
+}
+
+// columnOIDs returns the distinct attribute OIDs of a table object, sorted.
+func columnOIDs(objDef models.ObjectDefinition) []string {
+	var oids []string
+	for _, attr := range objDef.Attributes {
+		if attr.OID != "" && !slices.Contains(oids, attr.OID) {
+			oids = append(oids, attr.OID)
+		}
+	}
+	slices.Sort(oids)
+	return oids
+}
+
+// coversEntry reports whether columns all belong to one table entry and
+// cover at least minRootCoverage of it.
+func coversEntry(columns []string) bool {
+	entry, highest := splitColumn(columns[0])
+	for _, c := range columns[1:] {
+		e, n := splitColumn(c)
+		if e != entry {
+			return false
+		}
+		highest = max(highest, n)
+	}
+	return highest > 0 && float64(len(columns)) >= minRootCoverage*float64(highest)
+}
+
+// splitColumn splits a column OID into its table entry and column number.
+// The number is 0 when the last arc is not numeric.
+func splitColumn(oid string) (string, int) {
+	i := strings.LastIndexByte(oid, '.')
+	if i < 0 {
+		return "", 0
+	}
+	n, _ := strconv.Atoi(oid[i+1:])
+	return strings.TrimPrefix(oid[:i], "."), n
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Walk execution
+// ─────────────────────────────────────────────────────────────────────────────
+
+// errTooBig reports a tooBig error-status in response to a GetBulk of a
+// single row of a single column, which no smaller request can avoid.
+var errTooBig = errors.New("response tooBig")
+
+// walkRoots walks each root in turn with op. Walks run on session requests
+// rather than gosnmp's WalkAll / BulkWalkAll, so every PDU passes the rate
+// limits; BulkWalk is getBulkColumns with a single column. shrunk reports
+// that a tooBig response made a BulkWalk fall back to smaller requests.
+func walkRoots(s session, op Operation, roots []string) (pdus []gosnmp.SnmpPDU, shrunk bool, err error) {
+	for _, root := range roots {
+		var got []gosnmp.SnmpPDU
+		var small bool
+		if op == OpWalk {
+			got, err = walkNext(s, root)
+		} else {
+			got, small, err = getBulkColumns(s, []string{root})
+		}
+		pdus = append(pdus, got...)
+		shrunk = shrunk || small
+		if err != nil {
+			return pdus, shrunk, err
+		}
+	}
+	return pdus, shrunk, nil
+}
+
+// getBulkColumns fetches every row of columns with GetBulk requests that
+// carry all unfinished columns (at most MaxOids) as repeaters. Each request
+// asks for max-repetitions / columns rows, so a response holds about as many
+// varbinds as one BulkWalk response. A column is finished once the agent
+// returns an OID outside it or the end of the MIB view.
+//
+// On a tooBig error-status the request is repeated with half the rows, then
+// with half the columns, and the rest of the walk keeps the smaller size;
+// shrunk reports that this happened. Only a single row of a single column
+// that is still tooBig fails the walk, with errTooBig.
+func getBulkColumns(s session, columns []string) (pdus []gosnmp.SnmpPDU, shrunk bool, err error) {
+	maxOids := s.conn.MaxOids
+	if maxOids <= 0 {
+		maxOids = defaultMaxOids
+	}
+	maxReps := int(s.conn.MaxRepetitions)
+	if maxReps <= 0 {
+		maxReps = defaultMaxRepetitions
+	}
+
+	// cursors[i] is the last OID returned for columns[i]; "" once finished.
+	cursors := slices.Clone(columns)
+	for {
+		var active []int
+		for i, c := range cursors {
+			if c != "" && len(active) < maxOids {
+				active = append(active, i)
+			}
+		}
+		if len(active) == 0 {
+			return pdus, shrunk, nil
+		}
+
+		oids := make([]string, len(active))
+		for k, i := range active {
+			oids[k] = cursors[i]
+		}
+		reps := max(1, maxReps/len(active))
+		pkt, err := s.getBulk(oids, uint32(reps))
+		if err != nil {
+			return pdus, shrunk, err
+		}
+		switch pkt.Error {
+		case gosnmp.NoError:
+		case gosnmp.TooBig:
+			switch {
+			case reps > 1:
+				maxReps = reps / 2 * len(active)
+			case len(active) > 1:
+				maxOids = len(active) / 2
+				maxReps = maxOids
+			default:
+				return pdus, shrunk, fmt.Errorf("getbulk %s: %w", oids[0], errTooBig)
+			}
+			shrunk = true
+			continue
+		default:
+			return pdus, shrunk, fmt.Errorf("getbulk %s: %s", oids[0], pkt.Error)
+		}
+
+		// Varbinds come row by row: one per active column per repetition.
+		progress := false
+		for k, i := range active {
+			for r := 0; ; r++ {
+				idx := r*len(active) + k
+				if idx >= len(pkt.Variables) {
+					break
+				}
+				pdu := pkt.Variables[idx]
+				if pdu.Type == gosnmp.EndOfMibView || !underOID(pdu.Name, columns[i]) {
+					cursors[i] = ""
+					break
+				}
+				if compareOIDs(pdu.Name, cursors[i]) <= 0 {
+					return pdus, shrunk, fmt.Errorf("getbulk %s: OID %s not increasing", columns[i], pdu.Name)
+				}
+				pdus = append(pdus, pdu)
+				cursors[i] = pdu.Name
+				progress = true
+			}
+		}
+		// A truncated response may leave later columns without a varbind;
+		// they are requested again, but only while some column advances.
+		if !progress && slices.ContainsFunc(active, func(i int) bool { return cursors[i] != "" }) {
+			return pdus, shrunk, fmt.Errorf("getbulk %s: no varbinds returned", oids[0])
+		}
+	}
+}
+
+// walkNext walks root with one GetNext request per varbind (SNMPv1). The
+// walk ends on an OID outside root, endOfMibView or the noSuchName error
+// SNMPv1 agents return past the last OID.
+func walkNext(s session, root string) ([]gosnmp.SnmpPDU, error) {
+	var all []gosnmp.SnmpPDU
+	cursor := root
+	for {
+		pkt, err := s.getNext([]string{cursor})
+		if err != nil {
+			return all, err
+		}
+		switch pkt.Error {
+		case gosnmp.NoError:
+		case gosnmp.NoSuchName:
+			return all, nil
+		default:
+			return all, fmt.Errorf("getnext %s: %s", cursor, pkt.Error)
+		}
+		if len(pkt.Variables) == 0 {
+			return all, nil
+		}
+		pdu := pkt.Variables[0]
+		if pdu.Type == gosnmp.EndOfMibView || !underOID(pdu.Name, root) {
+			return all, nil
+		}
+		if compareOIDs(pdu.Name, cursor) <= 0 {
+			return all, fmt.Errorf("getnext %s: OID %s not increasing", root, pdu.Name)
+		}
+		all = append(all, pdu)
+		cursor = pdu.Name
+	}
+}
+
+// timeoutError wraps the error of a request the agent did not answer (see
+// session.do).
+type timeoutError struct{ err error }
+
+func (e timeoutError) Error() string { return e.err.Error() }
+func (e timeoutError) Unwrap() error { return e.err }
+
+// isTimeout reports whether err comes from a request the agent did not
+// answer. A poll context running out (e.g. while waiting for the rate
+// limits) is not one.
+func isTimeout(err error) bool {
+	var te timeoutError
+	return errors.As(err, &te)
+}
+
+// compareOIDs orders dotted OIDs arc by arc, so .1.10 sorts after .1.9.
+// Leading dots are ignored.
+func compareOIDs(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "."), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "."), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.ParseUint(as[i], 10, 64)
+		y, _ := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// underOID reports whether oid lies strictly below root. Leading dots are
+// ignored.
+func underOID(oid, root string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(oid, "."), strings.TrimPrefix(root, ".")+".")
+}
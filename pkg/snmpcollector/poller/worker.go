@@ -67,6 +67,17 @@ func (w *WorkerPool) TrySubmit(job PollJob) bool {
 	}
 }
 
+// SubmitContext enqueues a poll job, blocking until there is room in the job
+// channel or ctx is done. Returns false if ctx ended first.
+func (w *WorkerPool) SubmitContext(ctx context.Context, job PollJob) bool {
+	select {
+	case w.jobs <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Stop closes the job channel and waits for all workers to drain.
 func (w *WorkerPool) Stop() {
 	close(w.jobs)
@@ -82,24 +93,7 @@ func (w *WorkerPool) worker(ctx context.Context) {
 			if !ok {
 				return
 			}
-			result, err := w.poller.Poll(ctx, job)
-			if err != nil {
-				w.logger.Warn("poll failed",
-					"device", job.Hostname,
-					"object", job.ObjectDef.Key,
-					"error", err.Error(),
-				)
-				// Still emit the partial result (may have empty Varbinds but
-				// carries timestamps for monitoring/metrics).
-				// If the result has no varbinds at all we skip to avoid
-				// flooding the decoder with empty messages.
-				if len(result.Varbinds) == 0 {
-					continue
-				}
-			}
-			select {
-			case w.output <- result:
-			case <-ctx.Done():
+			if !w.run(ctx, job) {
 				return
 			}
 		case <-ctx.Done():
@@ -107,3 +101,32 @@ func (w *WorkerPool) worker(ctx context.Context) {
 		}
 	}
 }
+
+// run polls one job and forwards its result. It returns false if ctx was
+// cancelled while waiting on the output channel. job.Done is always called.
+func (w *WorkerPool) run(ctx context.Context, job PollJob) bool {
+	if job.Done != nil {
+		defer job.Done()
+	}
+	result, err := w.poller.Poll(ctx, job)
+	if err != nil {
+		w.logger.Warn("poll failed",
+			"device", job.Hostname,
+			"object", job.ObjectDef.Key,
+			"error", err.Error(),
+		)
+		// Still emit the partial result (may have empty Varbinds but
+		// carries timestamps for monitoring/metrics).
+		// If the result has no varbinds at all we skip to avoid
+		// flooding the decoder with empty messages.
+		if len(result.Varbinds) == 0 {
+			return true
+		}
+	}
+	select {
+	case w.output <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,297 @@
+// Package schedule evaluates the `schedule:` block of device and object group
+// configs: cron expressions that set when polls fire, and allow / block time
+// windows that gate them.
+//
+// A Spec is the YAML form. Compile turns it into a Schedule, and Combine
+// layers a device's Schedule with an object group's: windows from every
+// layer apply, while the most specific cron expression wins.
+//
+// Times are evaluated in the Spec's timezone (UTC when unset). The IANA
+// time zone database is embedded so named zones resolve on minimal hosts.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // named timezones without a system zoneinfo
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// YAML form
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Spec is the `schedule:` block accepted on devices and object groups.
+//
+//	schedule:
+//	  timezone: Europe/Berlin
+//	  cron: "*/5 * * * *"          # fire times; replaces poll_interval
+//	  allow:                        # poll only inside one of these
+//	    - days: [mon-fri]
+//	      start: "07:00"
+//	      end: "19:00"
+//	  block:                        # never poll inside these
+//	    - days: [sun]
+//	      start: "02:00"
+//	      end: "04:00"
+//	      suppress_traps: true
+type Spec struct {
+	// Timezone is an IANA zone name, e.g. "Asia/Ho_Chi_Minh". Default: UTC.
+	Timezone string `yaml:"timezone,omitempty"`
+
+	// Cron, when set, decides when polls fire instead of poll_interval.
+	Cron string `yaml:"cron,omitempty"`
+
+	// Allow restricts polling to times inside at least one window. Empty
+	// allows all times.
+	Allow []WindowSpec `yaml:"allow,omitempty"`
+
+	// Block suspends polling inside any of its windows, e.g. maintenance.
+	Block []WindowSpec `yaml:"block,omitempty"`
+}
+
+// WindowSpec is a daily time window on selected weekdays.
+type WindowSpec struct {
+	// Days are weekday names ("mon") or ranges ("mon-fri"). Empty means
+	// every day. A window that crosses midnight belongs to the day it starts.
+	Days []string `yaml:"days,omitempty"`
+
+	// Start and End are "HH:MM" local times. End may be "24:00"; an End at
+	// or before Start crosses midnight.
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+
+	// SuppressTraps drops traps from the device while this block window is
+	// active. Ignored on allow windows.
+	SuppressTraps bool `yaml:"suppress_traps,omitempty"`
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Schedule
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Schedule is a compiled Spec, or the combination of several. A nil
+// *Schedule is valid: it never gates polls and has no cron expression.
+type Schedule struct {
+	key   string
+	cron  *Cron
+	allow [][]window // every layer must contain t
+	block []window
+}
+
+// Compile validates spec and compiles it. It returns nil for a spec with no
+// cron expression and no windows.
+func Compile(spec Spec) (*Schedule, error) {
+	loc := time.UTC
+	if spec.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(spec.Timezone); err != nil {
+			return nil, fmt.Errorf("schedule: timezone %q: %w", spec.Timezone, err)
+		}
+	}
+	if spec.Cron == "" && len(spec.Allow) == 0 && len(spec.Block) == 0 {
+		return nil, nil
+	}
+
+	s := &Schedule{key: fmt.Sprintf("%+v", spec)}
+	if spec.Cron != "" {
+		c, err := ParseCron(spec.Cron, loc)
+		if err != nil {
+			return nil, err
+		}
+		s.cron = c
+	}
+	if len(spec.Allow) > 0 {
+		layer := make([]window, 0, len(spec.Allow))
+		for _, ws := range spec.Allow {
+			w, err := compileWindow(ws, loc)
+			if err != nil {
+				return nil, err
+			}
+			layer = append(layer, w)
+		}
+		s.allow = [][]window{layer}
+	}
+	for _, ws := range spec.Block {
+		w, err := compileWindow(ws, loc)
+		if err != nil {
+			return nil, err
+		}
+		s.block = append(s.block, w)
+	}
+	return s, nil
+}
+
+// Combine layers schedules from least to most specific (device, then object
+// group). Every layer's allow and block windows apply; the last layer with a
+// cron expression supplies it. nil layers are ignored, and Combine returns
+// nil when all are.
+func Combine(layers ...*Schedule) *Schedule {
+	var out *Schedule
+	var keys []string
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		if out == nil {
+			out = &Schedule{}
+		}
+		keys = append(keys, l.key)
+		if l.cron != nil {
+			out.cron = l.cron
+		}
+		out.allow = append(out.allow, l.allow...)
+		out.block = append(out.block, l.block...)
+	}
+	if out != nil {
+		out.key = strings.Join(keys, " + ")
+	}
+	return out
+}
+
+// Key identifies the schedule's configuration; equal specs give equal keys.
+// It is empty for a nil Schedule.
+func (s *Schedule) Key() string {
+	if s == nil {
+		return ""
+	}
+	return s.key
+}
+
+// HasCron reports whether polls fire on a cron expression.
+func (s *Schedule) HasCron() bool { return s != nil && s.cron != nil }
+
+// Next returns the next cron fire time after t. It returns false when there is
+// no cron expression or it never matches.
+func (s *Schedule) Next(t time.Time) (time.Time, bool) {
+	if !s.HasCron() {
+		return time.Time{}, false
+	}
+	return s.cron.Next(t)
+}
+
+// Active reports whether polling is allowed at t: inside an allow window of
+// every layer that has them, and outside every block window.
+func (s *Schedule) Active(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	for _, layer := range s.allow {
+		if !anyContains(layer, t) {
+			return false
+		}
+	}
+	return !anyContains(s.block, t)
+}
+
+// SuppressTraps reports whether t falls in a block window with
+// suppress_traps set.
+func (s *Schedule) SuppressTraps(t time.Time) bool {
+	if s == nil {
+		return false
+	}
+	for _, w := range s.block {
+		if w.suppressTraps && w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Windows
+// ─────────────────────────────────────────────────────────────────────────────
+
+type window struct {
+	loc           *time.Location
+	days          uint8 // bit per time.Weekday
+	start, end    int   // minutes since midnight
+	suppressTraps bool
+}
+
+func compileWindow(ws WindowSpec, loc *time.Location) (window, error) {
+	w := window{loc: loc, suppressTraps: ws.SuppressTraps}
+	var err error
+	if w.start, err = parseClock(ws.Start); err != nil || w.start == 24*60 {
+		return window{}, fmt.Errorf("schedule: window start %q: want HH:MM", ws.Start)
+	}
+	if w.end, err = parseClock(ws.End); err != nil {
+		return window{}, fmt.Errorf("schedule: window end %q: want HH:MM", ws.End)
+	}
+	if len(ws.Days) == 0 {
+		w.days = 0x7f
+	}
+	for _, d := range ws.Days {
+		bits, err := parseDays(d)
+		if err != nil {
+			return window{}, fmt.Errorf("schedule: window day %q: %w", d, err)
+		}
+		w.days |= bits
+	}
+	return w, nil
+}
+
+// parseDays parses a weekday ("mon", 0–7) or a range of them ("mon-fri").
+// Ranges may wrap the week ("sat-sun").
+func parseDays(s string) (uint8, error) {
+	a, b, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	from, err := cronValue(a, dayNames)
+	if err != nil || from < 0 || from > 7 {
+		return 0, fmt.Errorf("bad weekday %q", a)
+	}
+	to := from
+	if isRange {
+		if to, err = cronValue(b, dayNames); err != nil || to < 0 || to > 7 {
+			return 0, fmt.Errorf("bad weekday %q", b)
+		}
+	}
+	from, to = from%7, to%7
+	var bits uint8
+	for d := from; ; d = (d + 1) % 7 {
+		bits |= 1 << uint(d)
+		if d == to {
+			break
+		}
+	}
+	return bits, nil
+}
+
+// contains reports whether t falls in the window. A window whose end is at or
+// before its start runs past midnight into the next day.
+func (w window) contains(t time.Time) bool {
+	lt := t.In(w.loc)
+	m := lt.Hour()*60 + lt.Minute()
+	today := w.days&(1<<uint(lt.Weekday())) != 0
+	if w.start < w.end {
+		return today && m >= w.start && m < w.end
+	}
+	if m >= w.start {
+		return today
+	}
+	yesterday := w.days&(1<<uint((lt.Weekday()+6)%7)) != 0
+	return m < w.end && yesterday
+}
+
+func anyContains(ws []window, t time.Time) bool {
+	for _, w := range ws {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" (00:00–24:00) into minutes since midnight.
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("missing ':'")
+	}
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh > 24 || (hh == 24 && mm != 0) {
+		return 0, fmt.Errorf("bad clock")
+	}
+	return hh*60 + mm, nil
+}
@@ -0,0 +1,192 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
+)
+
+func at(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return ts
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Cron tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expr, from, want string
+	}{
+		{"*/15 * * * *", "2026-03-02T10:07:30Z", "2026-03-02T10:15:00Z"},
+		{"0 3 * * *", "2026-03-02T03:00:00Z", "2026-03-03T03:00:00Z"},
+		{"30 8 * * mon-fri", "2026-03-06T09:00:00Z", "2026-03-09T08:30:00Z"}, // Fri → Mon
+		{"0 0 1 jan,jul *", "2026-03-02T00:00:00Z", "2026-07-01T00:00:00Z"},
+		{"0 12 13 * fri", "2026-03-02T00:00:00Z", "2026-03-06T12:00:00Z"}, // dom OR dow
+		{"5/20 * * * *", "2026-03-02T10:30:00Z", "2026-03-02T10:45:00Z"},
+		{"@daily", "2026-03-02T10:00:00Z", "2026-03-03T00:00:00Z"},
+		{"0 0 * * 7", "2026-03-02T00:00:00Z", "2026-03-08T00:00:00Z"}, // 7 = Sunday
+	}
+	for _, tt := range tests {
+		c, err := schedule.ParseCron(tt.expr, nil)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		got, ok := c.Next(at(t, tt.from))
+		if !ok || !got.Equal(at(t, tt.want)) {
+			t.Errorf("%q Next(%s) = %v %v, want %s", tt.expr, tt.from, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCronNext_Timezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh") // UTC+7
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	c, err := schedule.ParseCron("0 2 * * *", loc)
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	got, _ := c.Next(at(t, "2026-03-02T12:00:00Z"))
+	if want := at(t, "2026-03-02T19:00:00Z"); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v (02:00 local)", got.UTC(), want)
+	}
+}
+
+func TestCronNext_NeverMatches(t *testing.T) {
+	c, err := schedule.ParseCron("0 0 30 feb *", nil)
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	if _, ok := c.Next(at(t, "2026-01-01T00:00:00Z")); ok {
+		t.Error("30 February matched")
+	}
+}
+
+func TestParseCron_Errors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := schedule.ParseCron(expr, nil); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Window tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestActive_AllowAndBlock(t *testing.T) {
+	s, err := schedule.Compile(schedule.Spec{
+		Allow: []schedule.WindowSpec{{Days: []string{"mon-fri"}, Start: "08:00", End: "18:00"}},
+		Block: []schedule.WindowSpec{{Days: []string{"wed"}, Start: "12:00", End: "13:00"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	tests := []struct {
+		ts   string
+		want bool
+	}{
+		{"2026-03-02T08:00:00Z", true},  // Mon, window start inclusive
+		{"2026-03-02T18:00:00Z", false}, // Mon, window end exclusive
+		{"2026-03-04T12:30:00Z", false}, // Wed, blocked
+		{"2026-03-04T13:00:00Z", true},  // Wed, block over
+		{"2026-03-07T10:00:00Z", false}, // Sat
+	}
+	for _, tt := range tests {
+		if got := s.Active(at(t, tt.ts)); got != tt.want {
+			t.Errorf("Active(%s) = %v, want %v", tt.ts, got, tt.want)
+		}
+	}
+}
+
+func TestActive_OvernightWindowInTimezone(t *testing.T) {
+	// Saturday 22:00 → Sunday 06:00 in UTC+7.
+	s, err := schedule.Compile(schedule.Spec{
+		Timezone: "Asia/Ho_Chi_Minh",
+		Block:    []schedule.WindowSpec{{Days: []string{"sat"}, Start: "22:00", End: "06:00", SuppressTraps: true}},
+	})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	tests := []struct {
+		ts       string
+		inWindow bool
+	}{
+		{"2026-03-07T14:59:00Z", false}, // Sat 21:59 local
+		{"2026-03-07T15:00:00Z", true},  // Sat 22:00 local
+		{"2026-03-07T22:30:00Z", true},  // Sun 05:30 local
+		{"2026-03-07T23:00:00Z", false}, // Sun 06:00 local
+		{"2026-03-08T16:00:00Z", false}, // Sun 23:00 local — Sunday not listed
+	}
+	for _, tt := range tests {
+		ts := at(t, tt.ts)
+		if got := s.Active(ts); got == tt.inWindow {
+			t.Errorf("Active(%s) = %v, want %v", tt.ts, got, !tt.inWindow)
+		}
+		if got := s.SuppressTraps(ts); got != tt.inWindow {
+			t.Errorf("SuppressTraps(%s) = %v, want %v", tt.ts, got, tt.inWindow)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	specs := []schedule.Spec{
+		{Timezone: "Nowhere/Nope"},
+		{Cron: "* * *"},
+		{Allow: []schedule.WindowSpec{{Start: "8am", End: "18:00"}}},
+		{Block: []schedule.WindowSpec{{Start: "24:00", End: "01:00"}}},
+		{Block: []schedule.WindowSpec{{Days: []string{"funday"}, Start: "00:00", End: "01:00"}}},
+	}
+	for _, spec := range specs {
+		if _, err := schedule.Compile(spec); err == nil {
+			t.Errorf("Compile(%+v) succeeded, want error", spec)
+		}
+	}
+	if s, err := schedule.Compile(schedule.Spec{Timezone: "UTC"}); s != nil || err != nil {
+		t.Errorf("empty spec = %v, %v; want nil, nil", s, err)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Combine tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestCombine(t *testing.T) {
+	device, _ := schedule.Compile(schedule.Spec{
+		Cron:  "*/5 * * * *",
+		Allow: []schedule.WindowSpec{{Start: "06:00", End: "22:00"}},
+	})
+	group, _ := schedule.Compile(schedule.Spec{
+		Cron:  "0 * * * *",
+		Allow: []schedule.WindowSpec{{Start: "00:00", End: "12:00"}},
+	})
+
+	s := schedule.Combine(device, nil, group)
+	next, ok := s.Next(at(t, "2026-03-02T10:07:00Z"))
+	if !ok || !next.Equal(at(t, "2026-03-02T11:00:00Z")) {
+		t.Errorf("Next = %v, want the group cron (11:00)", next)
+	}
+	// Both allow layers apply: 06:00–12:00.
+	if s.Active(at(t, "2026-03-02T05:00:00Z")) || !s.Active(at(t, "2026-03-02T07:00:00Z")) || s.Active(at(t, "2026-03-02T13:00:00Z")) {
+		t.Error("combined allow windows should intersect to 06:00–12:00")
+	}
+	if s.Key() == device.Key() || s.Key() == "" {
+		t.Errorf("combined Key %q should differ from the device key", s.Key())
+	}
+
+	if schedule.Combine(nil, nil) != nil {
+		t.Error("Combine of nil layers should be nil")
+	}
+	var none *schedule.Schedule
+	if !none.Active(time.Now()) || none.HasCron() || none.SuppressTraps(time.Now()) || none.Key() != "" {
+		t.Error("nil Schedule should always be active with no cron")
+	}
+}
@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// AutoProfiler — device_groups: [auto]
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ProbeFunc reads the SNMPv2-MIB system group of a single device.
+type ProbeFunc func(ctx context.Context, hostname string, cfg config.DeviceConfig) (poller.SystemInfo, error)
+
+// AutoProfileOptions configures an AutoProfiler.
+type AutoProfileOptions struct {
+	// Concurrency bounds how many devices are probed at once (default 16).
+	Concurrency int
+
+	// Probe fetches sysObjectID / sysDescr for a device. Defaults to
+	// Poller.FetchSystemInfo. Tests inject a stub.
+	Probe ProbeFunc
+
+	// Poller, when Probe is nil, probes through its connection pool and
+	// rate limiter, so probes count against the same per-device concurrency
+	// and request rates as polls.
+	Poller *poller.SNMPPoller
+
+	// SystemInfo, when non-nil, receives every successful probe so the poller
+	// does not fetch the system group again on its first poll.
+	SystemInfo *poller.SystemInfoCache
+}
+
+func (o *AutoProfileOptions) defaults() {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 16
+	}
+	if o.Probe == nil && o.Poller != nil {
+		o.Probe = o.Poller.FetchSystemInfo
+	}
+	if o.Probe == nil {
+		o.Probe = noProbe
+	}
+}
+
+// AutoProfiler selects device groups for devices that declare
+// `device_groups: [auto]` by matching their sysObjectID and sysDescr against
+// config.ProfileRules. The selected groups are substituted into a copy of the
+// config by Expand, so ResolveJobs handles the rest as usual.
+//
+// It is safe for concurrent use.
+type AutoProfiler struct {
+	opts   AutoProfileOptions
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	groups map[string][]string // hostname → matched device groups
+}
+
+// NewAutoProfiler creates an AutoProfiler with no discovered devices.
+func NewAutoProfiler(opts AutoProfileOptions, logger *slog.Logger) *AutoProfiler {
+	opts.defaults()
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
+	}
+	return &AutoProfiler{
+		opts:   opts,
+		logger: logger,
+		groups: make(map[string][]string),
+	}
+}
+
+// Discover probes every auto device in cfg and re-evaluates its profile
+// match. It returns true when any device's selected groups changed, i.e. when
+// the scheduler needs a Reload with the new Expand result.
+//
+// A failed probe keeps the device's previous groups so a transient timeout
+// does not stop polling. Devices no longer in cfg (or no longer auto) are
+// forgotten.
+func (p *AutoProfiler) Discover(ctx context.Context, cfg *config.LoadedConfig) bool {
+	if cfg == nil {
+		return false
+	}
+
+	var hostnames []string
+	for hostname, dev := range cfg.Devices {
+		if dev.HasAutoDeviceGroup() {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	sort.Strings(hostnames)
+
+	type outcome struct {
+		hostname string
+		groups   []string
+		ok       bool
+	}
+	results := make(chan outcome, len(hostnames))
+	sem := make(chan struct{}, p.opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, hostname := range hostnames {
+		wg.Add(1)
+		go func(hostname string, dev config.DeviceConfig) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results <- outcome{hostname: hostname}
+				return
+			}
+			defer func() { <-sem }()
+
+			info, err := p.opts.Probe(ctx, hostname, dev)
+			if err != nil {
+				p.logger.Warn("scheduler: auto-profile probe failed",
+					"hostname", hostname,
+					"error", err.Error(),
+				)
+				results <- outcome{hostname: hostname}
+				return
+			}
+			if p.opts.SystemInfo != nil {
+				info.Vendor, info.Model = cfg.Vendors.Match(info.SysObjectID)
+				p.opts.SystemInfo.Store(hostname, info)
+			}
+			groups := cfg.Profiles.Match(info.SysObjectID, info.SysDescr)
+			if len(groups) == 0 {
+				p.logger.Warn("scheduler: no device profile matched",
+					"hostname", hostname,
+					"sys_object_id", info.SysObjectID,
+				)
+			}
+			results <- outcome{hostname: hostname, groups: groups, ok: true}
+		}(hostname, cfg.Devices[hostname])
+	}
+	wg.Wait()
+	close(results)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	active := make(map[string]bool, len(hostnames))
+	for r := range results {
+		active[r.hostname] = true
+		if !r.ok {
+			continue
+		}
+		if !equalStrings(p.groups[r.hostname], r.groups) {
+			p.logger.Info("scheduler: auto-profile selected device groups",
+				"hostname", r.hostname,
+				"groups", r.groups,
+			)
+			p.groups[r.hostname] = r.groups
+			changed = true
+		}
+	}
+	for hostname := range p.groups {
+		if !active[hostname] {
+			delete(p.groups, hostname)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Groups returns the device groups currently selected for hostname.
+func (p *AutoProfiler) Groups(hostname string) ([]string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	g, ok := p.groups[hostname]
+	return g, ok
+}
+
+// Expand returns a shallow copy of cfg in which every auto device has the
+// `auto` entry replaced by its selected groups. Explicit groups listed next to
+// `auto` are kept. Devices not yet discovered keep only their explicit groups.
+// cfg itself is not modified.
+func (p *AutoProfiler) Expand(cfg *config.LoadedConfig) *config.LoadedConfig {
+	if cfg == nil {
+		return nil
+	}
+	out := *cfg
+	out.Devices = make(map[string]config.DeviceConfig, len(cfg.Devices))
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for hostname, dev := range cfg.Devices {
+		if dev.HasAutoDeviceGroup() {
+			var groups []string
+			for _, g := range dev.DeviceGroups {
+				if g == config.AutoDeviceGroup {
+					groups = append(groups, p.groups[hostname]...)
+				} else {
+					groups = append(groups, g)
+				}
+			}
+			dev.DeviceGroups = groups
+		}
+		out.Devices[hostname] = dev
+	}
+	return &out
+}
+
+// errNoProbe fails every probe of an AutoProfiler given neither a Probe nor
+// a Poller.
+var errNoProbe = errors.New("scheduler: auto-profiler has no poller")
+
+func noProbe(context.Context, string, config.DeviceConfig) (poller.SystemInfo, error) {
+	return poller.SystemInfo{}, errNoProbe
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -7,15 +7,26 @@ package scheduler
 import (
 	"log/slog"
 	"sort"
+	"time"
 
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
 )
 
 // ResolveJobs walks the config hierarchy for every device and returns a flat
 // list of PollJob values. Objects that appear via multiple groups are
 // deduplicated per device.
+//
+// Each job's Interval is the most specific poll_interval that applies: the
+// object definition's, else the object group's, else the device's (60 s when
+// unset). An object reached through several object groups is polled at the
+// shortest of their intervals.
+//
+// Each job's Schedule combines the device's schedule with that of the first
+// object group (in device-group order) reaching the object that has one.
+// Devices and object groups whose schedule does not compile are skipped.
 func ResolveJobs(cfg *config.LoadedConfig, logger *slog.Logger) []poller.PollJob {
 	if cfg == nil {
 		return nil
@@ -31,17 +42,55 @@ func ResolveJobs(cfg *config.LoadedConfig, logger *slog.Logger) []poller.PollJob
 	}
 	sort.Strings(hostnames)
 
+	groupSchedules := make(map[string]*schedule.Schedule)
+	groupSchedule := func(name string, og config.ObjectGroup) (*schedule.Schedule, bool) {
+		if og.Schedule == nil {
+			return nil, true
+		}
+		if s, ok := groupSchedules[name]; ok {
+			return s, true
+		}
+		s, err := schedule.Compile(*og.Schedule)
+		if err != nil {
+			logger.Warn("scheduler: invalid object group schedule", "objectGroup", name, "error", err.Error())
+			return nil, false
+		}
+		groupSchedules[name] = s
+		return s, true
+	}
+
 	var jobs []poller.PollJob
 	for _, hostname := range hostnames {
 		devCfg := cfg.Devices[hostname]
+		var devSchedule *schedule.Schedule
+		if devCfg.Schedule != nil {
+			s, err := schedule.Compile(*devCfg.Schedule)
+			if err != nil {
+				logger.Warn("scheduler: invalid device schedule", "hostname", hostname, "error", err.Error())
+				continue
+			}
+			devSchedule = s
+		}
 		dev := models.Device{
 			Hostname:    hostname,
 			IPAddress:   devCfg.IP,
 			SNMPVersion: devCfg.Version,
+			Tags:        cfg.DeviceTags(devCfg),
 		}
 
-		seen := make(map[string]bool)
+		deviceInterval := seconds(devCfg.PollInterval, 60*time.Second)
+
+		// Object keys in first-seen order, with the shortest group interval
+		// and the first group schedule.
+		var order []string
+		groupInterval := make(map[string]time.Duration)
+		objSchedule := make(map[string]*schedule.Schedule)
 		for _, dgName := range devCfg.DeviceGroups {
+			if dgName == config.AutoDeviceGroup {
+				// Not expanded by an AutoProfiler (yet) — nothing to poll.
+				logger.Debug("scheduler: device awaiting auto-profile", "hostname", hostname)
+				continue
+			}
 			dg, ok := cfg.DeviceGroups[dgName]
 			if !ok {
 				logger.Warn("scheduler: unknown device group", "hostname", hostname, "group", dgName)
@@ -53,35 +102,65 @@ func ResolveJobs(cfg *config.LoadedConfig, logger *slog.Logger) []poller.PollJob
 					logger.Warn("scheduler: unknown object group", "hostname", hostname, "objectGroup", ogName)
 					continue
 				}
+				ogSchedule, ok := groupSchedule(ogName, og)
+				if !ok {
+					continue
+				}
+				ogInterval := seconds(og.PollInterval, deviceInterval)
 				for _, objKey := range og.Objects {
-					if seen[objKey] {
-						continue
+					if _, set := objSchedule[objKey]; !set && ogSchedule != nil {
+						objSchedule[objKey] = ogSchedule
 					}
-					seen[objKey] = true
-
-					objDef, ok := cfg.ObjectDefs[objKey]
-					if !ok {
-						logger.Warn("scheduler: unknown object definition", "hostname", hostname, "object", objKey)
-						continue
+					prev, seen := groupInterval[objKey]
+					if !seen {
+						order = append(order, objKey)
+					}
+					if !seen || ogInterval < prev {
+						groupInterval[objKey] = ogInterval
 					}
-					jobs = append(jobs, poller.PollJob{
-						Hostname:     hostname,
-						Device:       dev,
-						DeviceConfig: devCfg,
-						ObjectDef:    objDef,
-					})
 				}
 			}
 		}
+
+		for _, objKey := range order {
+			objDef, ok := cfg.ObjectDefs[objKey]
+			if !ok {
+				logger.Warn("scheduler: unknown object definition", "hostname", hostname, "object", objKey)
+				continue
+			}
+			jobs = append(jobs, poller.PollJob{
+				Hostname:     hostname,
+				Device:       dev,
+				DeviceConfig: devCfg,
+				ObjectDef:    objDef,
+				Interval:     seconds(objDef.PollInterval, groupInterval[objKey]),
+				Schedule:     schedule.Combine(devSchedule, objSchedule[objKey]),
+			})
+		}
 	}
 	return jobs
 }
 
-// jobsByHostname groups a flat job slice by hostname.
-func jobsByHostname(jobs []poller.PollJob) map[string][]poller.PollJob {
-	m := make(map[string][]poller.PollJob)
+// seconds converts a poll_interval in seconds to a Duration, returning
+// fallback when it is unset.
+func seconds(sec int, fallback time.Duration) time.Duration {
+	if sec <= 0 {
+		return fallback
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// jobsByBucket groups a flat job slice by (hostname, interval, schedule). Jobs
+// with no Interval fall back to the device's poll_interval.
+func jobsByBucket(jobs []poller.PollJob) map[bucketKey][]poller.PollJob {
+	m := make(map[bucketKey][]poller.PollJob)
 	for _, j := range jobs {
-		m[j.Hostname] = append(m[j.Hostname], j)
+		interval := j.Interval
+		if interval <= 0 {
+			interval = seconds(j.DeviceConfig.PollInterval, 60*time.Second)
+		}
+		k := bucketKey{j.Hostname, interval, j.Schedule.Key()}
+		m[k] = append(m[k], j)
 	}
 	return m
 }
@@ -2,13 +2,16 @@ package scheduler
 
 import (
 	"context"
+	"hash/fnv"
 	"log/slog"
+	"math/rand/v2"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -20,42 +23,162 @@ import (
 type JobSubmitter interface {
 	Submit(poller.PollJob)
 	TrySubmit(poller.PollJob) bool
+	SubmitContext(context.Context, poller.PollJob) bool
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Scheduler
 // ─────────────────────────────────────────────────────────────────────────────
 
-// entry tracks the next-fire time for a single device and its pre-resolved jobs.
+// OverflowPolicy decides what happens to a job when the worker pool's queue
+// is full at fire time.
+type OverflowPolicy string
+
+const (
+	// OverflowDrop skips the job for this cycle (default).
+	OverflowDrop OverflowPolicy = "drop"
+
+	// OverflowBlock waits for queue space, up to Options.BlockTimeout per
+	// scheduler tick, then skips whatever did not fit.
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowDefer holds the job and retries it on later scheduler ticks
+	// until the entry's next cycle; if it is still queued then, the held job
+	// is skipped in favour of the new cycle.
+	OverflowDefer OverflowPolicy = "defer"
+)
+
+// Skip reasons reported in SkipEvent.Reason.
+const (
+	SkipQueueFull = "queue_full" // the worker pool had no room for the job
+	SkipInFlight  = "in_flight"  // the previous cycle for the object had not finished
+	SkipMissed    = "missed"     // the scheduler fell behind and passed over the cycle
+)
+
+// SkipEvent reports one skipped poll cycle for a device/object.
+type SkipEvent struct {
+	// Job is the job that was not dispatched.
+	Job poller.PollJob
+
+	// Reason is SkipQueueFull or SkipInFlight.
+	Reason string
+
+	// Count is the total number of cycles skipped so far for this
+	// device/object, across all reasons. One SkipMissed event may account
+	// for several cycles.
+	Count uint64
+}
+
+// Options tunes how the scheduler spreads polls across each interval and how
+// it behaves when the worker pool falls behind.
+type Options struct {
+	// Spread gives every device a deterministic phase offset within its
+	// interval (FNV-1a hash of the hostname modulo the interval, aligned to
+	// the wall clock), so devices sharing an interval fire evenly across it
+	// instead of all at once. New devices wait for their phase rather than
+	// firing immediately. The phase is stable across restarts.
+	Spread bool
+
+	// Jitter adds a random delay in [0, Jitter) to every fire. It is capped
+	// at the device's interval and never accumulates: each cycle is jittered
+	// from the unjittered schedule. Zero disables jitter.
+	Jitter time.Duration
+
+	// Overflow is the policy applied when the job queue is full. Default:
+	// OverflowDrop.
+	Overflow OverflowPolicy
+
+	// BlockTimeout bounds how long the jobs of one scheduler tick may wait
+	// for queue space under OverflowBlock, all entries firing in the tick
+	// together. The wait happens without the scheduler lock, so Reload and
+	// Entries are not held up. Default: 1s.
+	BlockTimeout time.Duration
+
+	// OnSkip, if set, is called for every skipped cycle. It runs on the
+	// scheduling goroutine and must not block.
+	OnSkip func(SkipEvent)
+
+	// Clock returns the time at which schedule allow / block windows are
+	// evaluated. Default: time.Now.
+	Clock func() time.Time
+}
+
+func (o *Options) defaults() {
+	if o.Jitter < 0 {
+		o.Jitter = 0
+	}
+	switch o.Overflow {
+	case OverflowBlock, OverflowDefer:
+	default:
+		o.Overflow = OverflowDrop
+	}
+	if o.BlockTimeout <= 0 {
+		o.BlockTimeout = time.Second
+	}
+	if o.Clock == nil {
+		o.Clock = time.Now
+	}
+}
+
+// deferRetryInterval is how often deferred jobs are offered to the pool again.
+const deferRetryInterval = 250 * time.Millisecond
+
+// entry tracks the next-fire time for one (device, interval, schedule) bucket
+// and its pre-resolved jobs.
 type entry struct {
 	hostname string
 	interval time.Duration
-	nextRun  time.Time
+	schedule *schedule.Schedule
+	due      time.Time // unjittered schedule
+	nextRun  time.Time // due + jitter
 	jobs     []poller.PollJob
+
+	paused bool            // last fire fell outside the schedule's windows
+	rebase map[string]bool // object keys to dispatch with Resumed set
+
+	pending []poller.PollJob // OverflowDefer: jobs waiting for queue space
+	retryAt time.Time        // next attempt to submit pending
 }
 
 // Scheduler dispatches PollJob values into a JobSubmitter at each device's
 // configured PollInterval.
 type Scheduler struct {
 	pool   JobSubmitter
+	opts   Options
 	logger *slog.Logger
 
 	mu      sync.Mutex
 	entries []entry
 
+	// flightMu guards inFlight and skipped. Workers clear inFlight through
+	// PollJob.Done, so it is separate from mu.
+	flightMu sync.Mutex
+	inFlight map[objectKey]bool
+	skipped  map[objectKey]uint64
+
 	done chan struct{}
 }
 
+// objectKey identifies one object polled on one device.
+type objectKey struct {
+	hostname string
+	object   string
+}
+
 // New creates a Scheduler. The scheduler does NOT start automatically — call
 // Start to begin dispatching.
-func New(cfg *config.LoadedConfig, pool JobSubmitter, logger *slog.Logger) *Scheduler {
+func New(cfg *config.LoadedConfig, pool JobSubmitter, opts Options, logger *slog.Logger) *Scheduler {
 	if logger == nil {
 		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
 	}
+	opts.defaults()
 	s := &Scheduler{
-		pool:   pool,
-		logger: logger,
-		done:   make(chan struct{}),
+		pool:     pool,
+		opts:     opts,
+		logger:   logger,
+		inFlight: make(map[objectKey]bool),
+		skipped:  make(map[objectKey]uint64),
+		done:     make(chan struct{}),
 	}
 	s.entries = s.buildEntries(cfg)
 	return s
@@ -83,6 +206,11 @@ func (s *Scheduler) Start(ctx context.Context) {
 			return s.entries[i].nextRun.Before(s.entries[j].nextRun)
 		})
 		next := s.entries[0].nextRun
+		for i := range s.entries {
+			if len(s.entries[i].pending) > 0 && s.entries[i].retryAt.Before(next) {
+				next = s.entries[i].retryAt
+			}
+		}
 		s.mu.Unlock()
 
 		delay := time.Until(next)
@@ -99,15 +227,23 @@ func (s *Scheduler) Start(ctx context.Context) {
 		}
 
 		now := time.Now()
+		var blocked []blockedJob
 		s.mu.Lock()
 		for i := range s.entries {
-			if s.entries[i].nextRun.After(now) {
-				break
+			e := &s.entries[i]
+			switch {
+			case !e.nextRun.After(now):
+				blocked = append(blocked, s.fireEntry(e, now)...)
+				s.advance(e, now)
+			case len(e.pending) > 0 && !e.retryAt.After(now):
+				s.retryPending(e, now)
 			}
-			s.fireEntry(&s.entries[i])
-			s.entries[i].nextRun = now.Add(s.entries[i].interval)
 		}
 		s.mu.Unlock()
+
+		if len(blocked) > 0 {
+			s.submitBlocked(ctx, blocked)
+		}
 	}
 }
 
@@ -117,15 +253,59 @@ func (s *Scheduler) Stop() {
 	<-s.done
 }
 
-// Reload atomically replaces the running config. New devices are polled
-// immediately; removed devices stop; changed intervals take effect on the
-// next cycle.
+// Reload atomically replaces the running config. (device, interval) buckets
+// that still exist keep their next fire time; new buckets — new devices, or
+// objects moved to a different interval — are scheduled as on start
+// (immediately, or at their phase with Spread); removed buckets stop. Jobs
+// held under OverflowDefer are dropped; polls already in flight still block
+// their object until they finish. Skip counts of removed devices and objects
+// are dropped.
 func (s *Scheduler) Reload(cfg *config.LoadedConfig) {
 	newEntries := s.buildEntries(cfg)
+
 	s.mu.Lock()
+	prev := make(map[bucketKey]entry, len(s.entries))
+	for _, e := range s.entries {
+		prev[e.key()] = e
+		// Deferred jobs carry the old config; let the next cycle replace them.
+		for _, job := range e.pending {
+			s.release(job)
+		}
+	}
+	for i := range newEntries {
+		if old, ok := prev[newEntries[i].key()]; ok {
+			newEntries[i].due = old.due
+			newEntries[i].nextRun = old.nextRun
+			newEntries[i].paused = old.paused
+			newEntries[i].rebase = old.rebase
+		}
+	}
 	s.entries = newEntries
 	s.mu.Unlock()
-	s.logger.Info("scheduler: config reloaded", "devices", len(newEntries))
+
+	// Forget the skip counts of removed devices and objects. inFlight needs
+	// no pruning: workers clear it through Done.
+	live := make(map[objectKey]bool)
+	for _, e := range newEntries {
+		for _, job := range e.jobs {
+			live[objectKey{job.Hostname, job.ObjectDef.Key}] = true
+		}
+	}
+	s.flightMu.Lock()
+	for key := range s.skipped {
+		if !live[key] {
+			delete(s.skipped, key)
+		}
+	}
+	s.flightMu.Unlock()
+	s.logger.Info("scheduler: config reloaded", "entries", len(newEntries))
+}
+
+// Skipped returns the number of cycles skipped so far for object on hostname.
+func (s *Scheduler) Skipped(hostname, object string) uint64 {
+	s.flightMu.Lock()
+	defer s.flightMu.Unlock()
+	return s.skipped[objectKey{hostname, object}]
 }
 
 // Entries returns the number of active entries (for monitoring / tests).
@@ -139,45 +319,314 @@ func (s *Scheduler) Entries() int {
 // Internal helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-// buildEntries resolves the config hierarchy and creates one entry per device.
+// bucketKey identifies a scheduler entry.
+type bucketKey struct {
+	hostname string
+	interval time.Duration
+	schedule string // schedule.Schedule.Key()
+}
+
+func (e *entry) key() bucketKey { return bucketKey{e.hostname, e.interval, e.schedule.Key()} }
+
+// buildEntries resolves the config hierarchy and creates one entry per
+// (device, interval, schedule) bucket, scheduled as new.
 func (s *Scheduler) buildEntries(cfg *config.LoadedConfig) []entry {
 	allJobs := ResolveJobs(cfg, s.logger)
-	byHost := jobsByHostname(allJobs)
+	buckets := jobsByBucket(allJobs)
 
 	now := time.Now()
-	entries := make([]entry, 0, len(byHost))
-	for hostname, jobs := range byHost {
-		if len(jobs) == 0 {
+	entries := make([]entry, 0, len(buckets))
+	for key, jobs := range buckets {
+		sched := jobs[0].Schedule
+		due, ok := s.firstRun(key.hostname, key.interval, sched, now)
+		if !ok {
+			s.logger.Warn("scheduler: cron schedule never fires, not scheduling",
+				"hostname", key.hostname,
+				"schedule", key.schedule,
+			)
 			continue
 		}
-		interval := time.Duration(jobs[0].DeviceConfig.PollInterval) * time.Second
-		if interval <= 0 {
-			interval = 60 * time.Second
-		}
 		entries = append(entries, entry{
-			hostname: hostname,
-			interval: interval,
-			nextRun:  now, // Poll immediately on start / reload.
+			hostname: key.hostname,
+			interval: key.interval,
+			schedule: sched,
+			due:      due,
+			nextRun:  due.Add(s.jitter(key.interval)),
 			jobs:     jobs,
 		})
 	}
 	return entries
 }
 
-// fireEntry dispatches all jobs for one entry using TrySubmit (non-blocking).
-func (s *Scheduler) fireEntry(e *entry) {
+// firstRun returns when a new entry first fires: the next cron match for a
+// cron schedule, else now, or with Spread the next wall-clock instant at the
+// device's phase offset. It returns false if the cron expression never fires.
+func (s *Scheduler) firstRun(hostname string, interval time.Duration, sched *schedule.Schedule, now time.Time) (time.Time, bool) {
+	if sched.HasCron() {
+		return sched.Next(now)
+	}
+	if !s.opts.Spread {
+		return now, true
+	}
+	t := now.Truncate(interval).Add(PhaseOffset(hostname, interval))
+	if t.Before(now) {
+		t = t.Add(interval)
+	}
+	return t, true
+}
+
+// maxMissedCron caps how many passed-over cron matches advance counts.
+const maxMissedCron = 1000
+
+// advance moves e to its next cycle after a fire at now. Cycles missed while
+// the scheduler was blocked are skipped rather than fired back to back, and
+// counted as SkipMissed for every object of e unless e is paused.
+func (s *Scheduler) advance(e *entry, now time.Time) {
+	var missed int64
+	if e.schedule.HasCron() {
+		for t := e.due; missed < maxMissedCron; missed++ {
+			next, ok := e.schedule.Next(t)
+			if !ok || next.After(now) {
+				break
+			}
+			t = next
+		}
+		next, ok := e.schedule.Next(now)
+		if !ok {
+			next = now.AddDate(100, 0, 0) // unreachable now; Reload may fix it
+		}
+		e.due = next
+	} else {
+		e.due = e.due.Add(e.interval)
+		if !e.due.After(now) {
+			missed = int64(now.Sub(e.due)/e.interval + 1)
+			e.due = e.due.Add(time.Duration(missed) * e.interval)
+		}
+	}
+	e.nextRun = e.due.Add(s.jitter(e.interval))
+
+	if missed > 0 && !e.paused {
+		for _, job := range e.jobs {
+			s.skip(job, SkipMissed, uint64(missed))
+		}
+	}
+}
+
+// jitter returns a random delay in [0, min(Jitter, interval)).
+func (s *Scheduler) jitter(interval time.Duration) time.Duration {
+	j := s.opts.Jitter
+	if j > interval {
+		j = interval
+	}
+	if j <= 0 {
+		return 0
+	}
+	return rand.N(j)
+}
+
+// PhaseOffset returns hostname's deterministic offset within interval: the
+// FNV-1a hash of the hostname modulo the interval.
+func PhaseOffset(hostname string, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(hostname))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// blockedJob is a job fireEntry could not queue under OverflowBlock, still
+// marked in flight, waiting for submitBlocked.
+type blockedJob struct {
+	key bucketKey
+	job poller.PollJob
+}
+
+// fireEntry dispatches all jobs for one entry without blocking. Objects whose
+// previous cycle is still in flight are skipped; jobs that do not fit in the
+// queue are handled per Options.Overflow. Under OverflowBlock they are
+// returned for submitBlocked, which waits once s.mu is released.
+func (s *Scheduler) fireEntry(e *entry, now time.Time) []blockedJob {
+	if !s.checkWindow(e) {
+		return nil
+	}
+
+	// Deferred jobs still waiting from the last cycle are superseded.
+	for _, job := range e.pending {
+		s.release(job)
+		s.skip(job, SkipQueueFull, 1)
+	}
+	e.pending = nil
+
+	fired := 0
+	var blocked []blockedJob
 	for _, job := range e.jobs {
-		if !s.pool.TrySubmit(job) {
-			s.logger.Warn("scheduler: job queue full, dropping job",
-				"hostname", e.hostname,
-				"object", job.ObjectDef.Key,
-			)
+		job.Resumed = e.rebase[job.ObjectDef.Key]
+		job, ok := s.acquire(job)
+		if !ok {
+			s.skip(job, SkipInFlight, 1)
+			continue
 		}
+		if s.pool.TrySubmit(job) {
+			delete(e.rebase, job.ObjectDef.Key)
+			fired++
+			continue
+		}
+		switch s.opts.Overflow {
+		case OverflowDefer:
+			e.pending = append(e.pending, job)
+		case OverflowBlock:
+			blocked = append(blocked, blockedJob{e.key(), job})
+		default:
+			s.release(job)
+			s.skip(job, SkipQueueFull, 1)
+		}
+	}
+	if len(e.pending) > 0 {
+		e.retryAt = now.Add(deferRetryInterval)
 	}
 	s.logger.Debug("scheduler: fired jobs",
 		"hostname", e.hostname,
-		"count", len(e.jobs),
+		"interval", e.interval,
+		"count", fired,
+		"deferred", len(e.pending),
+		"blocked", len(blocked),
 	)
+	return blocked
+}
+
+// submitBlocked waits for queue space for the jobs of one tick that did not
+// fit under OverflowBlock, sharing a single BlockTimeout deadline. Jobs still
+// waiting at the deadline are skipped. It must be called without s.mu.
+func (s *Scheduler) submitBlocked(ctx context.Context, blocked []blockedJob) {
+	blockCtx, cancel := context.WithTimeout(ctx, s.opts.BlockTimeout)
+	defer cancel()
+
+	var sent []blockedJob
+	for _, b := range blocked {
+		if s.pool.SubmitContext(blockCtx, b.job) {
+			sent = append(sent, b)
+			continue
+		}
+		s.release(b.job)
+		if ctx.Err() != nil {
+			// Shutting down: not a skipped cycle.
+			continue
+		}
+		s.skip(b.job, SkipQueueFull, 1)
+	}
+	if len(sent) == 0 {
+		return
+	}
+
+	// The entries may have been replaced by a Reload meanwhile; clear the
+	// resume marks of whichever entries now hold the sent jobs' buckets.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		e := &s.entries[i]
+		for _, b := range sent {
+			if b.key == e.key() {
+				delete(e.rebase, b.job.ObjectDef.Key)
+			}
+		}
+	}
+}
+
+// checkWindow reports whether e may fire at now under its schedule's allow /
+// block windows, recording pause and resume transitions. While paused, jobs
+// are not dispatched and cycles are not counted as skipped; every object's
+// first dispatch after the pause carries PollJob.Resumed.
+func (s *Scheduler) checkWindow(e *entry) bool {
+	if e.schedule.Active(s.opts.Clock()) {
+		if e.paused {
+			e.paused = false
+			s.logger.Info("scheduler: schedule window open, resuming polls",
+				"hostname", e.hostname,
+				"interval", e.interval,
+			)
+		}
+		return true
+	}
+	if !e.paused {
+		e.paused = true
+		e.rebase = make(map[string]bool, len(e.jobs))
+		for _, job := range e.jobs {
+			e.rebase[job.ObjectDef.Key] = true
+		}
+		for _, job := range e.pending {
+			s.release(job)
+		}
+		e.pending = nil
+		s.logger.Info("scheduler: outside schedule window, pausing polls",
+			"hostname", e.hostname,
+			"interval", e.interval,
+		)
+	}
+	return false
+}
+
+// retryPending offers e's deferred jobs to the pool again without blocking.
+func (s *Scheduler) retryPending(e *entry, now time.Time) {
+	if !s.checkWindow(e) {
+		return
+	}
+	remaining := e.pending[:0]
+	for _, job := range e.pending {
+		if s.pool.TrySubmit(job) {
+			delete(e.rebase, job.ObjectDef.Key)
+		} else {
+			remaining = append(remaining, job)
+		}
+	}
+	e.pending = remaining
+	e.retryAt = now.Add(deferRetryInterval)
+}
+
+// acquire marks job's device/object as in flight and wires job.Done to clear
+// it. It returns false if the object is already in flight.
+func (s *Scheduler) acquire(job poller.PollJob) (poller.PollJob, bool) {
+	key := objectKey{job.Hostname, job.ObjectDef.Key}
+	s.flightMu.Lock()
+	defer s.flightMu.Unlock()
+	if s.inFlight[key] {
+		return job, false
+	}
+	s.inFlight[key] = true
+	job.Done = func() {
+		s.flightMu.Lock()
+		delete(s.inFlight, key)
+		s.flightMu.Unlock()
+	}
+	return job, true
+}
+
+// release clears the in-flight mark of a job that was never dispatched.
+func (s *Scheduler) release(job poller.PollJob) {
+	if job.Done != nil {
+		job.Done()
+	}
+}
+
+// skip records n skipped cycles and reports them through the log and OnSkip.
+func (s *Scheduler) skip(job poller.PollJob, reason string, n uint64) {
+	key := objectKey{job.Hostname, job.ObjectDef.Key}
+	s.flightMu.Lock()
+	s.skipped[key] += n
+	count := s.skipped[key]
+	s.flightMu.Unlock()
+
+	s.logger.Warn("scheduler: poll cycle skipped",
+		"hostname", job.Hostname,
+		"object", job.ObjectDef.Key,
+		"reason", reason,
+		"cycles", n,
+		"skipped_total", count,
+	)
+	if s.opts.OnSkip != nil {
+		job.Done = nil
+		s.opts.OnSkip(SkipEvent{Job: job, Reason: reason, Count: count})
+	}
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
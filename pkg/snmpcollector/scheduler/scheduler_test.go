@@ -2,6 +2,9 @@ package scheduler_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -10,7 +13,9 @@ import (
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/config"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/poller"
+	"github.com/vpbank/snmp_collector/pkg/snmpcollector/schedule"
 	"github.com/vpbank/snmp_collector/pkg/snmpcollector/scheduler"
+	"github.com/vpbank/snmp_collector/utils/snmptest"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -20,7 +25,8 @@ import (
 type mockSubmitter struct {
 	mu       sync.Mutex
 	jobs     []poller.PollJob
-	capacity int // 0 = unlimited
+	capacity int  // 0 = unlimited
+	hold     bool // keep accepted jobs in flight instead of completing them
 }
 
 func newMockSubmitter(capacity int) *mockSubmitter {
@@ -30,7 +36,7 @@ func newMockSubmitter(capacity int) *mockSubmitter {
 func (m *mockSubmitter) Submit(job poller.PollJob) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.jobs = append(m.jobs, job)
+	m.accept(job)
 }
 
 func (m *mockSubmitter) TrySubmit(job poller.PollJob) bool {
@@ -39,10 +45,39 @@ func (m *mockSubmitter) TrySubmit(job poller.PollJob) bool {
 	if m.capacity > 0 && len(m.jobs) >= m.capacity {
 		return false
 	}
-	m.jobs = append(m.jobs, job)
+	m.accept(job)
 	return true
 }
 
+func (m *mockSubmitter) SubmitContext(ctx context.Context, job poller.PollJob) bool {
+	for !m.TrySubmit(job) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return true
+}
+
+// accept records job and, unless hold is set, completes it at once as a
+// worker would. m.mu must be held.
+func (m *mockSubmitter) accept(job poller.PollJob) {
+	m.jobs = append(m.jobs, job)
+	if !m.hold && job.Done != nil {
+		job.Done()
+	}
+}
+
+// complete finishes every held job.
+func (m *mockSubmitter) complete() {
+	for _, job := range m.getJobs() {
+		if job.Done != nil {
+			job.Done()
+		}
+	}
+}
+
 func (m *mockSubmitter) count() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -207,6 +242,128 @@ func TestResolveJobs_MissingObjectDef(t *testing.T) {
 	}
 }
 
+func TestResolveJobs_DeviceTags(t *testing.T) {
+	cfg := basicConfig()
+	cfg.DeviceGroups["group_a"] = config.DeviceGroup{
+		ObjectGroups: []string{"og_netif"},
+		Tags:         map[string]string{"site": "dc1", "role": "access"},
+	}
+	dev := cfg.Devices["switch1"]
+	dev.Tags = map[string]string{"role": "core"}
+	cfg.Devices["switch1"] = dev
+
+	jobs := scheduler.ResolveJobs(cfg, nil)
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(jobs))
+	}
+	tags := jobs[0].Device.Tags
+	if tags["site"] != "dc1" || tags["role"] != "core" {
+		t.Errorf("Device.Tags = %v, want site=dc1 role=core", tags)
+	}
+}
+
+func TestResolveJobs_SkipsUnexpandedAuto(t *testing.T) {
+	cfg := basicConfig()
+	dev := cfg.Devices["switch1"]
+	dev.DeviceGroups = []string{config.AutoDeviceGroup}
+	cfg.Devices["switch1"] = dev
+
+	jobs := scheduler.ResolveJobs(cfg, nil)
+	if len(jobs) != 0 {
+		t.Errorf("got %d jobs, want 0 for an undiscovered auto device", len(jobs))
+	}
+}
+
+// intervalConfig is basicConfig (device interval 1 s) with two more objects:
+// sysEntry in a 2 s object group and entPhysicalEntry whose own 3 s
+// poll_interval overrides its 2 s group.
+func intervalConfig() *config.LoadedConfig {
+	cfg := basicConfig()
+	cfg.DeviceGroups["group_a"] = config.DeviceGroup{ObjectGroups: []string{"og_netif", "og_slow"}}
+	cfg.ObjectGroups["og_slow"] = config.ObjectGroup{
+		Objects:      []string{"SNMPv2-MIB::system", "ENTITY-MIB::entPhysicalEntry"},
+		PollInterval: 2,
+	}
+	cfg.ObjectDefs["SNMPv2-MIB::system"] = models.ObjectDefinition{Key: "SNMPv2-MIB::system"}
+	cfg.ObjectDefs["ENTITY-MIB::entPhysicalEntry"] = models.ObjectDefinition{
+		Key:          "ENTITY-MIB::entPhysicalEntry",
+		PollInterval: 3,
+	}
+	return cfg
+}
+
+func TestResolveJobs_PollIntervalPrecedence(t *testing.T) {
+	jobs := scheduler.ResolveJobs(intervalConfig(), nil)
+	want := map[string]time.Duration{
+		"IF-MIB::ifEntry":              1 * time.Second, // device
+		"SNMPv2-MIB::system":           2 * time.Second, // object group
+		"ENTITY-MIB::entPhysicalEntry": 3 * time.Second, // object definition
+	}
+	if len(jobs) != len(want) {
+		t.Fatalf("got %d jobs, want %d", len(jobs), len(want))
+	}
+	for _, j := range jobs {
+		if j.Interval != want[j.ObjectDef.Key] {
+			t.Errorf("%s Interval = %v, want %v", j.ObjectDef.Key, j.Interval, want[j.ObjectDef.Key])
+		}
+	}
+}
+
+func TestResolveJobs_SharedObjectUsesShortestGroupInterval(t *testing.T) {
+	cfg := intervalConfig()
+	// ifEntry is also listed in the 2 s group; the device's 1 s still wins.
+	og := cfg.ObjectGroups["og_slow"]
+	og.Objects = append(og.Objects, "IF-MIB::ifEntry")
+	cfg.ObjectGroups["og_slow"] = og
+
+	for _, j := range scheduler.ResolveJobs(cfg, nil) {
+		if j.ObjectDef.Key == "IF-MIB::ifEntry" && j.Interval != time.Second {
+			t.Errorf("ifEntry Interval = %v, want 1s", j.Interval)
+		}
+	}
+}
+
+func TestResolveJobs_Schedules(t *testing.T) {
+	cfg := basicConfig()
+	dev := cfg.Devices["switch1"]
+	dev.Schedule = &schedule.Spec{Block: []schedule.WindowSpec{{Start: "02:00", End: "03:00"}}}
+	cfg.Devices["switch1"] = dev
+	cfg.ObjectDefs["ENTITY-MIB::entPhysicalEntry"] = models.ObjectDefinition{Key: "ENTITY-MIB::entPhysicalEntry"}
+	cfg.ObjectGroups["og_inventory"] = config.ObjectGroup{
+		Objects:  []string{"ENTITY-MIB::entPhysicalEntry"},
+		Schedule: &schedule.Spec{Cron: "0 4 * * *"},
+	}
+	cfg.DeviceGroups["group_a"] = config.DeviceGroup{ObjectGroups: []string{"og_netif", "og_inventory"}}
+
+	jobs := scheduler.ResolveJobs(cfg, nil)
+	if len(jobs) != 2 {
+		t.Fatalf("got %d jobs, want 2", len(jobs))
+	}
+	night := time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC)
+	for _, j := range jobs {
+		if j.Schedule.Active(night) {
+			t.Errorf("%s: device block window not applied", j.ObjectDef.Key)
+		}
+		hasCron := j.ObjectDef.Key == "ENTITY-MIB::entPhysicalEntry"
+		if j.Schedule.HasCron() != hasCron {
+			t.Errorf("%s: HasCron = %v, want %v", j.ObjectDef.Key, j.Schedule.HasCron(), hasCron)
+		}
+	}
+
+	// Same interval, different schedules → separate entries.
+	if got := scheduler.New(cfg, newMockSubmitter(0), scheduler.Options{}, nil).Entries(); got != 2 {
+		t.Errorf("Entries() = %d, want 2", got)
+	}
+
+	cfg.ObjectGroups["og_inventory"] = config.ObjectGroup{
+		Objects:  []string{"ENTITY-MIB::entPhysicalEntry"},
+		Schedule: &schedule.Spec{Cron: "not cron"},
+	}
+	if jobs := scheduler.ResolveJobs(cfg, nil); len(jobs) != 1 {
+		t.Errorf("got %d jobs with an invalid group schedule, want 1", len(jobs))
+	}
+}
+
 func TestResolveJobs_NilConfig(t *testing.T) {
 	jobs := scheduler.ResolveJobs(nil, nil)
 	if jobs != nil {
@@ -258,7 +415,7 @@ func TestSchedulerFiresOnInterval(t *testing.T) {
 	}
 
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go s.Start(ctx)
@@ -281,7 +438,7 @@ func TestSchedulerMultipleIntervals(t *testing.T) {
 	// switch1: PollInterval=1s, router1: PollInterval=2s
 
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go s.Start(ctx)
@@ -319,7 +476,7 @@ func TestSchedulerMultipleIntervals(t *testing.T) {
 func TestSchedulerStop(t *testing.T) {
 	cfg := basicConfig()
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go s.Start(ctx)
@@ -347,7 +504,7 @@ func TestSchedulerNoop(t *testing.T) {
 		Devices: map[string]config.DeviceConfig{},
 	}
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	if s.Entries() != 0 {
 		t.Errorf("expected 0 entries, got %d", s.Entries())
@@ -368,7 +525,7 @@ func TestSchedulerNoop(t *testing.T) {
 func TestSchedulerReload(t *testing.T) {
 	cfg := basicConfig()
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	if s.Entries() != 1 {
 		t.Fatalf("expected 1 entry after init, got %d", s.Entries())
@@ -422,7 +579,7 @@ func TestSchedulerReload(t *testing.T) {
 func TestSchedulerReload_RemoveDevice(t *testing.T) {
 	cfg := multiDeviceConfig()
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	if s.Entries() != 2 {
 		t.Fatalf("expected 2 entries, got %d", s.Entries())
@@ -436,11 +593,167 @@ func TestSchedulerReload_RemoveDevice(t *testing.T) {
 	}
 }
 
+func TestSchedulerReload_ForgetsRemovedSkipCounts(t *testing.T) {
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"}) // queue already full
+	s := scheduler.New(multiDeviceConfig(), sub, scheduler.Options{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		s.Stop()
+	}()
+	go s.Start(ctx)
+	time.Sleep(200 * time.Millisecond) // both devices fire once and are skipped
+	if s.Skipped("router1", "IF-MIB::ifEntry") == 0 {
+		t.Fatal("router1 cycle not counted as skipped")
+	}
+
+	s.Reload(basicConfig())
+	if got := s.Skipped("router1", "IF-MIB::ifEntry"); got != 0 {
+		t.Errorf("removed router1 Skipped() = %d, want 0", got)
+	}
+	if got := s.Skipped("switch1", "IF-MIB::ifEntry"); got == 0 {
+		t.Error("switch1 skip count lost on reload")
+	}
+}
+
+func TestSchedulerReload_PreservesNextRun(t *testing.T) {
+	cfg := basicConfig()
+	dev := cfg.Devices["switch1"]
+	dev.PollInterval = 2
+	cfg.Devices["switch1"] = dev
+
+	sub := newMockSubmitter(0)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+
+	time.Sleep(200 * time.Millisecond)
+	if sub.count() != 1 {
+		t.Fatalf("expected 1 dispatch before reload, got %d", sub.count())
+	}
+
+	// Reloading an unchanged device must not fire it again before its
+	// interval elapses.
+	s.Reload(cfg)
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	if sub.count() != 1 {
+		t.Errorf("expected no extra dispatch after reload, got %d total", sub.count())
+	}
+}
+
+// spreadConfig returns n devices named dev00…, all with a 2 s interval.
+func spreadConfig(n int) *config.LoadedConfig {
+	cfg := basicConfig()
+	base := cfg.Devices["switch1"]
+	base.PollInterval = 2
+	cfg.Devices = make(map[string]config.DeviceConfig, n)
+	for i := 0; i < n; i++ {
+		cfg.Devices[fmt.Sprintf("dev%02d", i)] = base
+	}
+	return cfg
+}
+
+func TestSchedulerSpread(t *testing.T) {
+	const n = 20
+	sub := newMockSubmitter(0)
+	s := scheduler.New(spreadConfig(n), sub, scheduler.Options{Spread: true}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+
+	// Without spreading every device would fire at once.
+	time.Sleep(300 * time.Millisecond)
+	early := sub.count()
+
+	time.Sleep(2000 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	if early >= n {
+		t.Errorf("all %d devices fired within 300ms; expected them spread across 2s", early)
+	}
+	seen := make(map[string]bool)
+	for _, j := range sub.getJobs() {
+		seen[j.Hostname] = true
+	}
+	if len(seen) != n {
+		t.Errorf("after one interval %d/%d devices fired, want all", len(seen), n)
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	cfg := basicConfig() // 1 s interval
+	sub := newMockSubmitter(0)
+	s := scheduler.New(cfg, sub, scheduler.Options{Jitter: 300 * time.Millisecond}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(2500 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	// Jitter delays fires but never accumulates: 2–3 fires in 2.5s.
+	if c := sub.count(); c < 2 || c > 3 {
+		t.Errorf("expected 2–3 dispatches in 2.5s with 300ms jitter, got %d", c)
+	}
+}
+
+func TestPhaseOffset(t *testing.T) {
+	interval := 60 * time.Second
+	if a, b := scheduler.PhaseOffset("core1", interval), scheduler.PhaseOffset("core1", interval); a != b {
+		t.Errorf("PhaseOffset not deterministic: %v != %v", a, b)
+	}
+
+	quarters := make(map[time.Duration]bool)
+	for i := 0; i < 40; i++ {
+		off := scheduler.PhaseOffset(fmt.Sprintf("dev%02d", i), interval)
+		if off < 0 || off >= interval {
+			t.Fatalf("PhaseOffset = %v, want within [0, %v)", off, interval)
+		}
+		quarters[off/(interval/4)] = true
+	}
+	if len(quarters) != 4 {
+		t.Errorf("40 hostnames cover %d of 4 interval quarters, want all", len(quarters))
+	}
+	if scheduler.PhaseOffset("core1", 0) != 0 {
+		t.Error("PhaseOffset with zero interval should be 0")
+	}
+}
+
+func TestSchedulerIntervalBuckets(t *testing.T) {
+	sub := newMockSubmitter(0)
+	s := scheduler.New(intervalConfig(), sub, scheduler.Options{}, nil)
+	if s.Entries() != 3 {
+		t.Fatalf("expected 3 (device, interval) entries, got %d", s.Entries())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(2500 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	counts := make(map[string]int)
+	for _, j := range sub.getJobs() {
+		counts[j.ObjectDef.Key]++
+	}
+	// 2.5 s: 1 s bucket fires at 0,1,2; 2 s at 0,2; 3 s at 0.
+	if counts["IF-MIB::ifEntry"] != 3 || counts["SNMPv2-MIB::system"] != 2 || counts["ENTITY-MIB::entPhysicalEntry"] != 1 {
+		t.Errorf("dispatch counts = %v, want ifEntry 3, system 2, entPhysicalEntry 1", counts)
+	}
+}
+
 func TestTrySubmitBackpressure(t *testing.T) {
 	cfg := basicConfig()
 	// Capacity of 0 — rejects all after first.
 	sub := newMockSubmitter(1)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go s.Start(ctx)
@@ -456,10 +769,283 @@ func TestTrySubmitBackpressure(t *testing.T) {
 	}
 }
 
+// skipRecorder collects SkipEvents delivered through Options.OnSkip.
+type skipRecorder struct {
+	mu     sync.Mutex
+	events []scheduler.SkipEvent
+}
+
+func (r *skipRecorder) record(ev scheduler.SkipEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func (r *skipRecorder) get() []scheduler.SkipEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]scheduler.SkipEvent(nil), r.events...)
+}
+
+func TestOverflowDrop_CountsSkips(t *testing.T) {
+	cfg := basicConfig()
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"}) // queue already full
+	rec := &skipRecorder{}
+	s := scheduler.New(cfg, sub, scheduler.Options{OnSkip: rec.record}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(1500 * time.Millisecond) // fires at ~0s and ~1s
+	cancel()
+	s.Stop()
+
+	events := rec.get()
+	if len(events) != 2 {
+		t.Fatalf("got %d skip events, want 2", len(events))
+	}
+	for i, ev := range events {
+		if ev.Reason != scheduler.SkipQueueFull || ev.Job.Hostname != "switch1" || ev.Count != uint64(i+1) {
+			t.Errorf("event %d = %s/%s count %d, want switch1/queue_full count %d",
+				i, ev.Job.Hostname, ev.Reason, ev.Count, i+1)
+		}
+	}
+	if got := s.Skipped("switch1", "IF-MIB::ifEntry"); got != 2 {
+		t.Errorf("Skipped() = %d, want 2", got)
+	}
+}
+
+func TestOverflowBlock_WaitsForRoom(t *testing.T) {
+	cfg := basicConfig()
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"})
+	rec := &skipRecorder{}
+	s := scheduler.New(cfg, sub, scheduler.Options{
+		Overflow:     scheduler.OverflowBlock,
+		BlockTimeout: 500 * time.Millisecond,
+		OnSkip:       rec.record,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+	sub.reset() // a worker picks up the filler; the blocked fire gets through
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	if sub.count() != 1 || sub.getJobs()[0].Hostname != "switch1" {
+		t.Errorf("jobs = %v, want the switch1 job", sub.getJobs())
+	}
+	if n := len(rec.get()); n != 0 {
+		t.Errorf("got %d skip events, want 0", n)
+	}
+}
+
+func TestOverflowBlock_TimesOut(t *testing.T) {
+	cfg := basicConfig()
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"})
+	rec := &skipRecorder{}
+	s := scheduler.New(cfg, sub, scheduler.Options{
+		Overflow:     scheduler.OverflowBlock,
+		BlockTimeout: 100 * time.Millisecond,
+		OnSkip:       rec.record,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	events := rec.get()
+	if len(events) != 1 || events[0].Reason != scheduler.SkipQueueFull {
+		t.Errorf("skip events = %+v, want one queue_full", events)
+	}
+}
+
+func TestOverflowBlock_DoesNotHoldLock(t *testing.T) {
+	cfg := basicConfig()
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"})
+	s := scheduler.New(cfg, sub, scheduler.Options{
+		Overflow:     scheduler.OverflowBlock,
+		BlockTimeout: 2 * time.Second,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		s.Stop()
+	}()
+	go s.Start(ctx)
+	time.Sleep(100 * time.Millisecond) // the fire is now waiting for room
+
+	done := make(chan struct{})
+	go func() {
+		s.Reload(basicConfig())
+		s.Entries()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Reload / Entries blocked behind a fire waiting for queue space")
+	}
+}
+
+func TestSchedulerCountsMissedCycles(t *testing.T) {
+	cfg := basicConfig() // 1s interval
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"})
+	rec := &skipRecorder{}
+	// The first tick blocks for 2.5s, so the 1s cycle fires late and the
+	// 2s cycle is passed over.
+	s := scheduler.New(cfg, sub, scheduler.Options{
+		Overflow:     scheduler.OverflowBlock,
+		BlockTimeout: 2500 * time.Millisecond,
+		OnSkip:       rec.record,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(2700 * time.Millisecond)
+	cancel()
+	s.Stop()
+
+	var missed uint64
+	for _, ev := range rec.get() {
+		if ev.Reason == scheduler.SkipMissed {
+			missed++
+		}
+	}
+	if missed != 1 {
+		t.Errorf("missed-cycle events = %d, want 1 (events %+v)", missed, rec.get())
+	}
+	if got := s.Skipped("switch1", "IF-MIB::ifEntry"); got < 2 {
+		t.Errorf("Skipped() = %d, want the timed-out and the missed cycle counted", got)
+	}
+}
+
+func TestOverflowDefer_RetriesUntilNextCycle(t *testing.T) {
+	cfg := basicConfig()
+	sub := newMockSubmitter(1)
+	sub.Submit(poller.PollJob{Hostname: "filler"})
+	rec := &skipRecorder{}
+	s := scheduler.New(cfg, sub, scheduler.Options{
+		Overflow: scheduler.OverflowDefer,
+		OnSkip:   rec.record,
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+	sub.reset()
+	time.Sleep(500 * time.Millisecond) // deferred job retried within this window
+	if sub.count() != 1 || sub.getJobs()[0].Hostname != "switch1" {
+		t.Errorf("jobs = %v, want the deferred switch1 job", sub.getJobs())
+	}
+	if n := len(rec.get()); n != 0 {
+		t.Errorf("got %d skip events before the next cycle, want 0", n)
+	}
+
+	// Queue stays full through the next cycle: that job is deferred, then
+	// superseded by the cycle after it.
+	time.Sleep(1500 * time.Millisecond)
+	cancel()
+	s.Stop()
+	events := rec.get()
+	if len(events) == 0 || events[0].Reason != scheduler.SkipQueueFull {
+		t.Errorf("skip events = %+v, want queue_full once the deferred job is superseded", events)
+	}
+}
+
+func TestInFlightSuppression(t *testing.T) {
+	cfg := basicConfig()
+	sub := newMockSubmitter(0)
+	sub.hold = true
+	rec := &skipRecorder{}
+	s := scheduler.New(cfg, sub, scheduler.Options{OnSkip: rec.record}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(1500 * time.Millisecond) // fires at ~0s (dispatched) and ~1s (in flight)
+	if sub.count() != 1 {
+		t.Errorf("dispatched %d jobs while the first was in flight, want 1", sub.count())
+	}
+	events := rec.get()
+	if len(events) != 1 || events[0].Reason != scheduler.SkipInFlight {
+		t.Errorf("skip events = %+v, want one in_flight", events)
+	}
+
+	sub.complete() // the slow poll finishes
+	time.Sleep(1000 * time.Millisecond)
+	cancel()
+	s.Stop()
+	if sub.count() != 2 {
+		t.Errorf("dispatched %d jobs, want 2 after the first completed", sub.count())
+	}
+}
+
+func TestSchedulerWindowPauseResume(t *testing.T) {
+	cfg := basicConfig()
+	dev := cfg.Devices["switch1"]
+	dev.Schedule = &schedule.Spec{Allow: []schedule.WindowSpec{{Start: "10:00", End: "11:00"}}}
+	cfg.Devices["switch1"] = dev
+
+	var clock atomic.Int64
+	setClock := func(hh, mm int) {
+		clock.Store(time.Date(2026, 3, 2, hh, mm, 0, 0, time.UTC).UnixNano())
+	}
+	setClock(9, 30)
+	sub := newMockSubmitter(0)
+	s := scheduler.New(cfg, sub, scheduler.Options{
+		Clock: func() time.Time { return time.Unix(0, clock.Load()) },
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	time.Sleep(1500 * time.Millisecond) // two fires, both outside the window
+	if sub.count() != 0 {
+		t.Fatalf("dispatched %d jobs outside the allow window, want 0", sub.count())
+	}
+
+	setClock(10, 30)
+	time.Sleep(2000 * time.Millisecond) // two fires inside the window
+	cancel()
+	s.Stop()
+
+	jobs := sub.getJobs()
+	if len(jobs) < 2 {
+		t.Fatalf("dispatched %d jobs after the window opened, want ≥ 2", len(jobs))
+	}
+	if !jobs[0].Resumed {
+		t.Error("first job after the pause should carry Resumed")
+	}
+	if jobs[1].Resumed {
+		t.Error("second job after the pause should not carry Resumed")
+	}
+	if s.Skipped("switch1", "IF-MIB::ifEntry") != 0 {
+		t.Error("paused cycles should not count as skipped")
+	}
+}
+
+func TestSchedulerCronNeverFires(t *testing.T) {
+	cfg := basicConfig()
+	dev := cfg.Devices["switch1"]
+	dev.Schedule = &schedule.Spec{Cron: "0 0 30 feb *"}
+	cfg.Devices["switch1"] = dev
+
+	if got := scheduler.New(cfg, newMockSubmitter(0), scheduler.Options{}, nil).Entries(); got != 0 {
+		t.Errorf("Entries() = %d, want 0 for a cron that never fires", got)
+	}
+}
+
 func TestSchedulerEntries(t *testing.T) {
 	cfg := multiDeviceConfig()
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	if got := s.Entries(); got != 2 {
 		t.Errorf("Entries() = %d, want 2", got)
@@ -473,7 +1059,7 @@ func TestSchedulerEntries(t *testing.T) {
 func TestSchedulerConcurrentReload(t *testing.T) {
 	cfg := basicConfig()
 	sub := newMockSubmitter(0)
-	s := scheduler.New(cfg, sub, nil)
+	s := scheduler.New(cfg, sub, scheduler.Options{}, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go s.Start(ctx)
@@ -502,3 +1088,188 @@ func TestSchedulerConcurrentReload(t *testing.T) {
 		t.Errorf("concurrent Reload caused %d panics", panicCount.Load())
 	}
 }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// AutoProfiler tests
+// ─────────────────────────────────────────────────────────────────────────────
+
+// autoConfig is basicConfig with switch1 set to auto and a profile rule that
+// maps the Cisco enterprise prefix to group_a.
+func autoConfig() *config.LoadedConfig {
+	cfg := basicConfig()
+	dev := cfg.Devices["switch1"]
+	dev.DeviceGroups = []string{config.AutoDeviceGroup}
+	cfg.Devices["switch1"] = dev
+	cfg.Profiles = config.NewProfileRules([]config.ProfileRule{
+		{Name: "cisco", SysObjectIDs: []string{"1.3.6.1.4.1.9"}, DeviceGroups: []string{"group_a"}},
+	})
+	return cfg
+}
+
+// stubProbe answers every probe with the sysObjectID currently stored in oid,
+// or fails when it is empty.
+type stubProbe struct {
+	mu  sync.Mutex
+	oid string
+}
+
+func (s *stubProbe) set(oid string) {
+	s.mu.Lock()
+	s.oid = oid
+	s.mu.Unlock()
+}
+
+func (s *stubProbe) probe(_ context.Context, _ string, _ config.DeviceConfig) (poller.SystemInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oid == "" {
+		return poller.SystemInfo{}, errors.New("timeout")
+	}
+	return poller.SystemInfo{SysObjectID: s.oid, SysDescr: "Cisco IOS"}, nil
+}
+
+func TestAutoProfiler_DiscoverAndExpand(t *testing.T) {
+	cfg := autoConfig()
+	stub := &stubProbe{oid: "1.3.6.1.4.1.9.1.1208"}
+	cache := poller.NewSystemInfoCache(time.Hour, config.NewVendorMap(map[string]config.VendorRule{
+		"1.3.6.1.4.1.9": {Vendor: "Cisco"},
+	}))
+	cfg.Vendors = cache.Vendors()
+	p := scheduler.NewAutoProfiler(scheduler.AutoProfileOptions{Probe: stub.probe, SystemInfo: cache}, nil)
+
+	if !p.Discover(context.Background(), cfg) {
+		t.Fatal("first Discover reported no change")
+	}
+	if groups, ok := p.Groups("switch1"); !ok || len(groups) != 1 || groups[0] != "group_a" {
+		t.Fatalf("Groups(switch1) = %v, %v; want [group_a], true", groups, ok)
+	}
+
+	expanded := p.Expand(cfg)
+	if got := expanded.Devices["switch1"].DeviceGroups; len(got) != 1 || got[0] != "group_a" {
+		t.Errorf("expanded DeviceGroups = %v, want [group_a]", got)
+	}
+	if got := cfg.Devices["switch1"].DeviceGroups; got[0] != config.AutoDeviceGroup {
+		t.Errorf("Expand modified the input config: DeviceGroups = %v", got)
+	}
+	if jobs := scheduler.ResolveJobs(expanded, nil); len(jobs) != 1 {
+		t.Errorf("ResolveJobs(expanded) = %d jobs, want 1", len(jobs))
+	}
+
+	info, ok := cache.Get("switch1")
+	if !ok || info.Vendor != "Cisco" {
+		t.Errorf("SystemInfo cache = %+v, %v; want vendor Cisco", info, ok)
+	}
+
+	if p.Discover(context.Background(), cfg) {
+		t.Error("second Discover with an unchanged device reported a change")
+	}
+}
+
+func TestAutoProfiler_FailedProbeKeepsGroups(t *testing.T) {
+	cfg := autoConfig()
+	stub := &stubProbe{oid: "1.3.6.1.4.1.9.1.1208"}
+	p := scheduler.NewAutoProfiler(scheduler.AutoProfileOptions{Probe: stub.probe}, nil)
+	p.Discover(context.Background(), cfg)
+
+	stub.set("")
+	if p.Discover(context.Background(), cfg) {
+		t.Error("Discover after a failed probe reported a change")
+	}
+	if groups, _ := p.Groups("switch1"); len(groups) != 1 || groups[0] != "group_a" {
+		t.Errorf("Groups(switch1) after failed probe = %v, want [group_a]", groups)
+	}
+}
+
+func TestAutoProfiler_DetectsChange(t *testing.T) {
+	cfg := autoConfig()
+	stub := &stubProbe{oid: "1.3.6.1.4.1.9.1.1208"}
+	p := scheduler.NewAutoProfiler(scheduler.AutoProfileOptions{Probe: stub.probe}, nil)
+	p.Discover(context.Background(), cfg)
+
+	// The device was replaced by one that matches no rule.
+	stub.set("1.3.6.1.4.1.2636.1.1.1.2.29")
+	if !p.Discover(context.Background(), cfg) {
+		t.Fatal("Discover after a fingerprint change reported no change")
+	}
+	if got := p.Expand(cfg).Devices["switch1"].DeviceGroups; len(got) != 0 {
+		t.Errorf("expanded DeviceGroups = %v, want none", got)
+	}
+
+	// Dropping the device from the inventory forgets it.
+	delete(cfg.Devices, "switch1")
+	if !p.Discover(context.Background(), cfg) {
+		t.Error("Discover after removing the device reported no change")
+	}
+	if _, ok := p.Groups("switch1"); ok {
+		t.Error("removed device still has groups")
+	}
+}
+
+func TestAutoProfiler_PollerProbeHonoursContext(t *testing.T) {
+	// A socket that never answers: only the context ends the probe.
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer silent.Close()
+	cfg := autoConfig()
+	dev := cfg.Devices["switch1"]
+	dev.IP, dev.Port, dev.Timeout, dev.Retries = "127.0.0.1", silent.LocalAddr().(*net.UDPAddr).Port, 2000, 3
+	cfg.Devices["switch1"] = dev
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	p := scheduler.NewAutoProfiler(scheduler.AutoProfileOptions{
+		Poller: poller.NewSNMPPoller(pool, poller.PollerOptions{}, nil),
+	}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if p.Discover(ctx, cfg) {
+		t.Error("Discover of an unreachable device reported a change")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Discover returned after %v, want soon after the context deadline", d)
+	}
+}
+
+func TestAutoProfiler_ProbesThroughPoller(t *testing.T) {
+	sim := snmptest.NewSimulator(snmptest.NewSwitch(), snmptest.Options{}, nil)
+	if err := sim.Start(); err != nil {
+		t.Fatalf("simulator: %v", err)
+	}
+	t.Cleanup(sim.Stop)
+	cfg := autoConfig()
+	dev := cfg.Devices["switch1"]
+	dev.IP, dev.Port, dev.MaxConcurrentPolls = "127.0.0.1", sim.Port(), 1
+	cfg.Devices["switch1"] = dev
+
+	pool := poller.NewConnectionPool(poller.PoolOptions{}, nil)
+	defer pool.Close()
+	limiter := poller.NewRateLimiter(1000, 0)
+	p := scheduler.NewAutoProfiler(scheduler.AutoProfileOptions{
+		Poller: poller.NewSNMPPoller(pool, poller.PollerOptions{RateLimiter: limiter}, nil),
+	}, nil)
+
+	// A poll holding the device's only session makes the probe wait.
+	conn, err := pool.Get(context.Background(), "switch1", dev)
+	if err != nil {
+		t.Fatalf("pool get: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if p.Discover(ctx, cfg) {
+		t.Error("Discover while the device's session is busy reported a change")
+	}
+	pool.Put("switch1", conn)
+
+	if !p.Discover(context.Background(), cfg) {
+		t.Fatal("Discover reported no change")
+	}
+	if groups, _ := p.Groups("switch1"); len(groups) != 1 || groups[0] != "group_a" {
+		t.Errorf("Groups(switch1) = %v, want [group_a]", groups)
+	}
+	if got := limiter.Stats().Requests; got != 1 {
+		t.Errorf("rate limiter requests = %d, want 1", got)
+	}
+}
@@ -10,6 +10,7 @@ import (
 "github.com/gosnmp/gosnmp"
 "github.com/vpbank/snmp_collector/models"
 "github.com/vpbank/snmp_collector/pkg/snmpcollector/trapreceiver"
+"github.com/vpbank/snmp_collector/utils/snmptest"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -329,3 +330,36 @@ case <-time.After(3 * time.Second):
 t.Fatal("timed out waiting for trap on output channel")
 }
 }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Informs from the snmptest simulator
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestSimulator_InformAcknowledged(t *testing.T) {
+	port := freePort(t)
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	r, cancel := startReceiver(t, trapreceiver.Config{ListenAddr: addr, Community: "public"})
+	defer cancel()
+	defer r.Stop()
+
+	sim := snmptest.NewSimulator(snmptest.NewSwitch(), snmptest.Options{}, nil)
+	if err := sim.Start(); err != nil {
+		t.Fatalf("simulator: %v", err)
+	}
+	defer sim.Stop()
+
+	// SendInform returns only once the receiver has acknowledged.
+	ifIndex := gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2}
+	if err := sim.SendInform(addr, ".1.3.6.1.6.3.1.1.5.3", ifIndex); err != nil {
+		t.Fatalf("SendInform: %v", err)
+	}
+
+	select {
+	case got := <-r.Output():
+		if got.TrapInfo.TrapOID != ".1.3.6.1.6.3.1.1.5.3" || got.Device.IPAddress != "127.0.0.1" {
+			t.Errorf("trap = %+v", got.TrapInfo)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the inform on the output channel")
+	}
+}
@@ -58,57 +58,95 @@ func (r *EnumRegistry) RegisterOIDEnum(oid, label string) {
 	r.mu.Unlock()
 }
 
+// IntEnums returns a copy of the integer and bitmap enumerations, keyed by OID
+// without a leading dot. The value maps are shared and must not be modified.
+func (r *EnumRegistry) IntEnums() map[string]map[int64]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]map[int64]string, len(r.ints))
+	for oid, e := range r.ints {
+		out[oid] = e.Values
+	}
+	return out
+}
+
+// OIDEnums returns a copy of the OID enumerations, keyed by OID without a
+// leading dot.
+func (r *EnumRegistry) OIDEnums() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]string, len(r.oids))
+	for oid, label := range r.oids {
+		out[oid] = label
+	}
+	return out
+}
+
 // Resolve attempts to translate a raw SNMP value to a text label using the
 // enum table registered for the given OID. The oid argument is the attribute
 // OID (no leading dot).
 //
 // Resolution rules:
 //   - For integer enums: value must be int64 or convertible numeric type.
-//   - For bitmap enums: returns a comma-joined list of set bit labels.
+//   - For bitmap enums: returns a []string of set bit labels in bit order,
+//     empty (not the raw mask) when no labelled bit is set, so the value
+//     keeps one type from poll to poll.
 //   - For OID enums: value must be a string OID; looked up as a key.
 //
 // If no enum is registered or no match is found, rawValue is returned unchanged.
 // This is the safe-fallback contract so missing enum files never crash the pipeline.
 func (r *EnumRegistry) Resolve(oid string, rawValue interface{}) interface{} {
+	if resolved, ok := r.Lookup(oid, rawValue); ok {
+		return resolved
+	}
+	return rawValue
+}
+
+// Lookup is Resolve without the passthrough: ok is false when no enum is
+// registered for oid or the value has no matching label. The resolved value is
+// a string for integer and OID enums, and a []string for bitmap enums; a
+// bitmap always matches, with an empty list when no labelled bit is set.
+func (r *EnumRegistry) Lookup(oid string, rawValue interface{}) (interface{}, bool) {
 	oid = normaliseRegistryOID(oid)
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	// OID enumeration (OID → label lookup).
 	if label, ok := r.oids[oid]; ok {
-		return label
+		return label, true
 	}
 	if strVal, ok := rawValue.(string); ok {
 		if label, ok := r.oids[normaliseRegistryOID(strVal)]; ok {
-			return label
+			return label, true
 		}
 	}
 
 	// Integer or bitmap enumeration.
 	intEnum, ok := r.ints[oid]
 	if !ok {
-		return rawValue
+		return nil, false
 	}
 
 	intVal, err := toInt64ForEnum(rawValue)
 	if err != nil {
-		return rawValue
+		return nil, false
 	}
 
 	if intEnum.IsBitmap {
-		return resolveBitmap(intEnum.Values, intVal)
+		return resolveBitmap(intEnum.Values, intVal), true
 	}
 
 	if label, ok := intEnum.Values[intVal]; ok {
-		return label
+		return label, true
 	}
-	return rawValue
+	return nil, false
 }
 
-// resolveBitmap returns a comma-separated list of active bit labels.
-// If no bits are set or no labels are registered, returns the raw integer string.
-func resolveBitmap(values map[int64]string, mask int64) interface{} {
-	var active []string
+// resolveBitmap returns the labels of every set bit, ordered by bit position.
+// The result is empty, not nil, if no bits are set or none of the set bits has
+// a label.
+func resolveBitmap(values map[int64]string, mask int64) []string {
+	active := []string{}
 	// Iterate bit positions 0..63 in order.
 	for bit := int64(0); bit < 64; bit++ {
 		if mask&(1<<bit) != 0 {
@@ -117,10 +155,7 @@ func resolveBitmap(values map[int64]string, mask int64) interface{} {
 			}
 		}
 	}
-	if len(active) == 0 {
-		return mask
-	}
-	return strings.Join(active, ",")
+	return active
 }
 
 // toInt64ForEnum attempts a best-effort conversion to int64 for enum lookup.
@@ -100,6 +100,15 @@ func (s *CounterState) Delta(key CounterKey, current uint64, now time.Time, wrap
 	}
 }
 
+// Seed records current as the baseline for key without computing a delta, as
+// on a first observation. Use it when the previous sample is not comparable,
+// e.g. after polling was paused.
+func (s *CounterState) Seed(key CounterKey, current uint64, now time.Time) {
+	s.mu.Lock()
+	s.entries[key] = counterEntry{Value: current, SeenAt: now}
+	s.mu.Unlock()
+}
+
 // Remove deletes all stored state for the given key. Call this when a device is
 // removed from the inventory to avoid stale state accumulating indefinitely.
 func (s *CounterState) Remove(key CounterKey) {
@@ -108,6 +117,23 @@ func (s *CounterState) Remove(key CounterKey) {
 	s.mu.Unlock()
 }
 
+// RemoveFunc deletes every entry whose key drop reports true and returns how
+// many were removed. Use it after a config reload to forget counters of
+// devices or objects that are no longer polled.
+func (s *CounterState) RemoveFunc(drop func(CounterKey) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for k := range s.entries {
+		if drop(k) {
+			delete(s.entries, k)
+			removed++
+		}
+	}
+	return removed
+}
+
 // Purge removes all counter entries whose last observation is older than maxAge.
 // Call this on a slow timer (e.g. every 10× poll interval) to reclaim memory for
 // devices that have gone away or had their object definitions changed.
@@ -24,7 +24,9 @@ type BuildOptions struct {
 	PollStatus string
 
 	// Enums, when non-nil, resolves EnumInteger / EnumBitmap / EnumObjectIdentifier
-	// values to their text labels. When nil, raw numeric values are left intact.
+	// values to their text labels, and fills Metric.Label for the KeepID / KeepOID
+	// variants and Metric.RawValue with the mask behind a bitmap's labels. When
+	// nil, raw numeric values are left intact.
 	Enums *EnumRegistry
 
 	// Counters, when non-nil, replaces raw Counter32/Counter64 values with the
@@ -88,23 +90,37 @@ func Build(decoded decoder.DecodedPollResult, opts BuildOptions) models.SNMPMetr
 	metrics := make([]models.Metric, 0, len(decoded.Varbinds))
 
 	for instance, byName := range resolved {
-		// Merge this instance's tags with device-level tags from poll tags.
-		// Device-level tags live in models.Device.Tags; per-instance tags come
-		// from tagsByInstance. Both are propagated: instance tags shadow device tags.
+		// Device-level tags (device groups, then device) live in
+		// models.Device.Tags; per-instance tags come from tagsByInstance. Both
+		// are merged into every metric: instance tags shadow device tags.
 		instanceTags := tagsByInstance[instance] // may be nil
 
 		for _, vb := range byName {
 			value := vb.Value
+			var label string
+			var raw interface{}
 
 			// Enum resolution.
 			// Strip the instance suffix from the full varbind OID so that the
 			// lookup key matches the base attribute OID stored in the registry.
+			// KeepID / KeepOID syntaxes leave the raw value in place and only
+			// attach the label; bitmaps keep their mask next to the labels.
 			if opts.Enums != nil && isEnumSyntax(vb.Syntax) {
 				baseOID := vb.OID
 				if vb.Instance != "" {
 					baseOID = strings.TrimSuffix(vb.OID, "."+vb.Instance)
 				}
-				value = opts.Enums.Resolve(baseOID, value)
+				if keepsRawEnumValue(vb.Syntax) {
+					if resolved, ok := opts.Enums.Lookup(baseOID, value); ok {
+						label = tagValue(resolved)
+					}
+				} else {
+					resolved := opts.Enums.Resolve(baseOID, value)
+					if _, ok := resolved.([]string); ok {
+						raw = value
+					}
+					value = resolved
+				}
 			}
 
 			// Counter delta.
@@ -115,8 +131,12 @@ func Build(decoded decoder.DecodedPollResult, opts BuildOptions) models.SNMPMetr
 						Attribute: vb.AttributeName,
 						Instance:  instance,
 					}
-					dr := opts.Counters.Delta(key, raw, now, WrapForSyntax(vb.Syntax))
-					if dr.Valid {
+					if decoded.Resumed {
+						// First poll after a schedule pause: the previous
+						// sample predates the pause, so start afresh.
+						opts.Counters.Seed(key, raw, now)
+						value = uint64(0)
+					} else if dr := opts.Counters.Delta(key, raw, now, WrapForSyntax(vb.Syntax)); dr.Valid {
 						value = dr.Delta
 					} else {
 						value = uint64(0) // first observation: emit 0 so the metric exists
@@ -124,20 +144,16 @@ func Build(decoded decoder.DecodedPollResult, opts BuildOptions) models.SNMPMetr
 				}
 			}
 
-			// Build tag map for this metric: copy instance tags (may be nil).
-			var tags map[string]string
-			if len(instanceTags) > 0 {
-				tags = make(map[string]string, len(instanceTags))
-				for k, v := range instanceTags {
-					tags[k] = v
-				}
-			}
+			// Build tag map for this metric (nil when there are no tags).
+			tags := MergeTags(decoded.Device.Tags, instanceTags)
 
 			metrics = append(metrics, models.Metric{
 				OID:      vb.OID,
 				Name:     vb.AttributeName,
 				Instance: instance,
 				Value:    value,
+				Label:    label,
+				RawValue: raw,
 				Type:     vb.SNMPType,
 				Syntax:   vb.Syntax,
 				Tags:     tags,
@@ -194,6 +210,12 @@ func isEnumSyntax(syntax string) bool {
 	}
 }
 
+// keepsRawEnumValue returns true for the enum syntaxes whose raw numeric or
+// OID value is preserved in Metric.Value, with the label carried alongside.
+func keepsRawEnumValue(syntax string) bool {
+	return syntax == "EnumIntegerKeepID" || syntax == "EnumObjectIdentifierKeepOID"
+}
+
 // toUint64Safe converts a value to uint64 without panicking.
 func toUint64Safe(v interface{}) (uint64, bool) {
 	switch x := v.(type) {
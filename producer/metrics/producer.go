@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"log/slog"
+	"sync/atomic"
 
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/snmp/decoder"
@@ -35,6 +36,7 @@ type Config struct {
 
 	// Enums is the pre-loaded enum registry. Required when EnumEnabled=true.
 	// If nil and EnumEnabled=true, enum resolution is silently skipped.
+	// SetEnums replaces it at runtime.
 	Enums *EnumRegistry
 
 	// CounterDeltaEnabled controls whether Counter32/Counter64 values are
@@ -50,10 +52,11 @@ type Config struct {
 
 // MetricsProducer is the production Producer implementation.
 // It is stateless w.r.t. the pipeline messages; mutable state is confined
-// to CounterState (protected by its own mutex) and EnumRegistry (read-only
-// after construction).
+// to CounterState (protected by its own mutex) and the EnumRegistry pointer,
+// which SetEnums swaps atomically. A registry is read-only once set.
 type MetricsProducer struct {
 	cfg      Config
+	enums    atomic.Pointer[EnumRegistry]
 	counters *CounterState
 	logger   *slog.Logger
 }
@@ -70,11 +73,29 @@ func New(cfg Config, logger *slog.Logger) *MetricsProducer {
 		cs = NewCounterState()
 	}
 
-	return &MetricsProducer{
+	p := &MetricsProducer{
 		cfg:      cfg,
 		counters: cs,
 		logger:   logger,
 	}
+	p.enums.Store(cfg.Enums)
+	return p
+}
+
+// SetEnums replaces the enum registry used by subsequent Produce calls, e.g.
+// after a config reload. Calls already in progress finish with the old one.
+func (p *MetricsProducer) SetEnums(enums *EnumRegistry) {
+	p.enums.Store(enums)
+}
+
+// RemoveCounters deletes the counter delta baselines whose key drop reports
+// true and returns how many were removed. It is a no-op when counter deltas
+// are disabled.
+func (p *MetricsProducer) RemoveCounters(drop func(CounterKey) bool) int {
+	if p.counters == nil {
+		return 0
+	}
+	return p.counters.RemoveFunc(drop)
 }
 
 // Produce implements Producer.
@@ -85,7 +106,7 @@ func New(cfg Config, logger *slog.Logger) *MetricsProducer {
 func (p *MetricsProducer) Produce(decoded decoder.DecodedPollResult) (models.SNMPMetric, error) {
 	var enums *EnumRegistry
 	if p.cfg.EnumEnabled {
-		enums = p.cfg.Enums
+		enums = p.enums.Load()
 	}
 
 	opts := BuildOptions{
@@ -94,15 +94,17 @@ func TestEnumRegistry_BitmapEnum(t *testing.T) {
 
 	// Bits 0 and 2 set → mask = 0b101 = 5
 	got := r.Resolve("1.3.6.1.2.1.10.166.3.2.10.1.5", int64(5))
-	want := "PDR,CDR"
-	if got != want {
-		t.Errorf("bitmap Resolve(5) = %v, want %q", got, want)
+	labels, ok := got.([]string)
+	if !ok || len(labels) != 2 || labels[0] != "PDR" || labels[1] != "CDR" {
+		t.Errorf("bitmap Resolve(5) = %#v, want []string{\"PDR\", \"CDR\"}", got)
 	}
 
-	// No bits set → raw value returned
-	got = r.Resolve("1.3.6.1.2.1.10.166.3.2.10.1.5", int64(0))
-	if got != int64(0) {
-		t.Errorf("bitmap Resolve(0) = %v, want raw int64(0)", got)
+	// No labelled bit set → empty list, never the raw value
+	for _, mask := range []int64{0, 8} {
+		got = r.Resolve("1.3.6.1.2.1.10.166.3.2.10.1.5", mask)
+		if labels, ok := got.([]string); !ok || labels == nil || len(labels) != 0 {
+			t.Errorf("bitmap Resolve(%d) = %#v, want []string{}", mask, got)
+		}
 	}
 }
 
@@ -123,6 +125,21 @@ func TestEnumRegistry_OIDEnum(t *testing.T) {
 	}
 }
 
+func TestEnumRegistry_Lookup(t *testing.T) {
+	r := metrics.NewEnumRegistry()
+	r.RegisterIntEnum("1.3.6.1.2.1.2.2.1.8", false, map[int64]string{1: "up"})
+
+	if got, ok := r.Lookup("1.3.6.1.2.1.2.2.1.8", int64(1)); !ok || got != "up" {
+		t.Errorf("Lookup(1) = %v, %v; want \"up\", true", got, ok)
+	}
+	if got, ok := r.Lookup("1.3.6.1.2.1.2.2.1.8", int64(99)); ok {
+		t.Errorf("Lookup(99) = %v, true; want no match", got)
+	}
+	if _, ok := r.Lookup("1.2.3.4", int64(1)); ok {
+		t.Error("Lookup on unregistered OID reported a match")
+	}
+}
+
 func TestEnumRegistry_NoMatchPassthrough(t *testing.T) {
 	r := metrics.NewEnumRegistry()
 	raw := int64(42)
@@ -132,6 +149,25 @@ func TestEnumRegistry_NoMatchPassthrough(t *testing.T) {
 	}
 }
 
+func TestEnumRegistry_Snapshots(t *testing.T) {
+	r := metrics.NewEnumRegistry()
+	r.RegisterIntEnum(".1.3.6.1.2.1.2.2.1.8", false, map[int64]string{1: "up"})
+	r.RegisterOIDEnum(".1.3.6.1.4.1.9.1.1208", "cat2960")
+
+	ints := r.IntEnums()
+	if got := ints["1.3.6.1.2.1.2.2.1.8"][1]; got != "up" {
+		t.Errorf("IntEnums()[ifOperStatus][1] = %q, want up", got)
+	}
+	oids := r.OIDEnums()
+	if got := oids["1.3.6.1.4.1.9.1.1208"]; got != "cat2960" {
+		t.Errorf("OIDEnums()[...1208] = %q, want cat2960", got)
+	}
+	delete(oids, "1.3.6.1.4.1.9.1.1208")
+	if _, ok := r.Lookup("1.3.6.1.4.1.9.1.1208", "1.3.6.1.4.1.9.1.1208"); !ok {
+		t.Error("deleting from the OIDEnums copy changed the registry")
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // CounterState tests
 // ─────────────────────────────────────────────────────────────────────────────
@@ -200,6 +236,26 @@ func TestCounterState_Purge(t *testing.T) {
 	}
 }
 
+func TestCounterState_RemoveFunc(t *testing.T) {
+	cs := metrics.NewCounterState()
+	now := time.Now()
+	for _, dev := range []string{"a", "b", "c"} {
+		cs.Delta(metrics.CounterKey{Device: dev, Attribute: "x", Instance: "1"}, 100, now, ^uint64(0))
+	}
+
+	removed := cs.RemoveFunc(func(k metrics.CounterKey) bool { return k.Device != "b" })
+	if removed != 2 {
+		t.Errorf("removed %d entries, want 2", removed)
+	}
+	// "b" kept its baseline: the next sample yields a delta.
+	if dr := cs.Delta(metrics.CounterKey{Device: "b", Attribute: "x", Instance: "1"}, 150, now.Add(time.Second), ^uint64(0)); !dr.Valid || dr.Delta != 50 {
+		t.Errorf("kept key delta = %+v, want 50", dr)
+	}
+	if dr := cs.Delta(metrics.CounterKey{Device: "a", Attribute: "x", Instance: "1"}, 150, now.Add(time.Second), ^uint64(0)); dr.Valid {
+		t.Error("removed key should start over")
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Build (poll assembly) tests
 // ─────────────────────────────────────────────────────────────────────────────
@@ -227,6 +283,44 @@ func TestBuild_TagsAttachedToMetrics(t *testing.T) {
 	}
 }
 
+func TestBuild_DeviceTagsMergedIntoMetrics(t *testing.T) {
+	decoded := ifEntryDecoded(time.Now())
+	decoded.Device.Tags = map[string]string{"site": "hcm-dc1", "netif.descr": "from-device"}
+	result := metrics.Build(decoded, metrics.BuildOptions{PollStatus: "success"})
+
+	m, ok := findMetric(result.Metrics, "netif.bytes.in", "1")
+	if !ok {
+		t.Fatal("metric netif.bytes.in instance=1 not found")
+	}
+	if m.Tags["site"] != "hcm-dc1" {
+		t.Errorf("tag site = %q, want device tag %q", m.Tags["site"], "hcm-dc1")
+	}
+	// Instance tags shadow device tags.
+	if m.Tags["netif.descr"] != "GigabitEthernet0/0/1" {
+		t.Errorf("tag netif.descr = %q, want instance value", m.Tags["netif.descr"])
+	}
+	if result.Device.Tags["site"] != "hcm-dc1" {
+		t.Errorf("record Device.Tags = %v, want site tag", result.Device.Tags)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	group := map[string]string{"site": "dc1", "role": "access"}
+	device := map[string]string{"role": "core"}
+	instance := map[string]string{"ifName": "Gi0/1"}
+
+	got := metrics.MergeTags(group, device, instance)
+	if len(got) != 3 || got["site"] != "dc1" || got["role"] != "core" || got["ifName"] != "Gi0/1" {
+		t.Errorf("MergeTags = %v", got)
+	}
+	if group["role"] != "access" {
+		t.Error("MergeTags modified an input layer")
+	}
+	if metrics.MergeTags(nil, map[string]string{}) != nil {
+		t.Error("MergeTags of empty layers should be nil")
+	}
+}
+
 func TestBuild_OverrideResolution_PreferencesHigherSyntax(t *testing.T) {
 	// Supply both Counter32 and Counter64 for the same attribute name + instance.
 	// Counter64 must win.
@@ -273,6 +367,74 @@ func TestBuild_EnumResolution(t *testing.T) {
 	}
 }
 
+func TestBuild_EnumKeepIDPreservesRawValue(t *testing.T) {
+	reg := metrics.NewEnumRegistry()
+	reg.RegisterIntEnum("1.3.6.1.2.1.2.2.1.8", false, map[int64]string{1: "up", 2: "down"})
+	reg.RegisterOIDEnum("1.3.6.1.4.1.9.1.1208", "cat2960x")
+
+	decoded := decoder.DecodedPollResult{
+		Device:      testDevice,
+		CollectedAt: time.Now(),
+		Varbinds: []decoder.DecodedVarbind{
+			{OID: "1.3.6.1.2.1.2.2.1.8.1", AttributeName: "netif.state.oper", Instance: "1", Value: int64(2), SNMPType: "Integer", Syntax: "EnumIntegerKeepID"},
+			{OID: "1.3.6.1.2.1.1.2.0", AttributeName: "sys.object_id", Instance: "0", Value: "1.3.6.1.4.1.9.1.1208", SNMPType: "ObjectIdentifier", Syntax: "EnumObjectIdentifierKeepOID"},
+		},
+	}
+	result := metrics.Build(decoded, metrics.BuildOptions{PollStatus: "success", Enums: reg})
+
+	m, ok := findMetric(result.Metrics, "netif.state.oper", "1")
+	if !ok {
+		t.Fatal("metric netif.state.oper instance=1 not found")
+	}
+	if m.Value != int64(2) || m.Label != "down" {
+		t.Errorf("KeepID metric = (%v, %q), want (2, \"down\")", m.Value, m.Label)
+	}
+
+	m, ok = findMetric(result.Metrics, "sys.object_id", "0")
+	if !ok {
+		t.Fatal("metric sys.object_id not found")
+	}
+	if m.Value != "1.3.6.1.4.1.9.1.1208" || m.Label != "cat2960x" {
+		t.Errorf("KeepOID metric = (%v, %q), want raw OID and \"cat2960x\"", m.Value, m.Label)
+	}
+}
+
+func TestBuild_EnumBitmapEmitsList(t *testing.T) {
+	reg := metrics.NewEnumRegistry()
+	reg.RegisterIntEnum("1.3.6.1.2.1.10.166.3.2.10.1.5", true, map[int64]string{0: "PDR", 1: "PBS", 2: "CDR"})
+
+	decoded := decoder.DecodedPollResult{
+		Device:      testDevice,
+		CollectedAt: time.Now(),
+		Varbinds: []decoder.DecodedVarbind{
+			{OID: "1.3.6.1.2.1.10.166.3.2.10.1.5.7", AttributeName: "mpls.te.flags", Instance: "7", Value: int64(3), SNMPType: "Integer", Syntax: "EnumBitmap"},
+			{OID: "1.3.6.1.2.1.10.166.3.2.10.1.5.8", AttributeName: "mpls.te.flags", Instance: "8", Value: int64(0), SNMPType: "Integer", Syntax: "EnumBitmap"},
+		},
+	}
+	result := metrics.Build(decoded, metrics.BuildOptions{PollStatus: "success", Enums: reg})
+
+	m, ok := findMetric(result.Metrics, "mpls.te.flags", "7")
+	if !ok {
+		t.Fatal("metric mpls.te.flags not found")
+	}
+	labels, ok := m.Value.([]string)
+	if !ok || len(labels) != 2 || labels[0] != "PDR" || labels[1] != "PBS" {
+		t.Errorf("bitmap value = %#v, want []string{\"PDR\", \"PBS\"}", m.Value)
+	}
+	if m.Label != "" || m.RawValue != int64(3) {
+		t.Errorf("bitmap Label, RawValue = %q, %#v, want empty and the mask int64(3)", m.Label, m.RawValue)
+	}
+
+	// No bits set: still a list, so the value's type does not change.
+	m, ok = findMetric(result.Metrics, "mpls.te.flags", "8")
+	if !ok {
+		t.Fatal("metric mpls.te.flags instance 8 not found")
+	}
+	if labels, ok := m.Value.([]string); !ok || len(labels) != 0 || m.RawValue != int64(0) {
+		t.Errorf("empty bitmap = %#v (raw %#v), want []string{} and int64(0)", m.Value, m.RawValue)
+	}
+}
+
 func TestBuild_CounterDelta_FirstPollZero(t *testing.T) {
 	cs := metrics.NewCounterState()
 	decoded := ifEntryDecoded(time.Now())
@@ -324,6 +486,29 @@ func TestBuild_CounterDelta_SecondPollDelta(t *testing.T) {
 	}
 }
 
+func TestBuild_CounterDelta_ResumedReseeds(t *testing.T) {
+	cs := metrics.NewCounterState()
+	t0 := time.Now()
+	metrics.Build(ifEntryDecoded(t0), metrics.BuildOptions{PollStatus: "success", Counters: cs})
+
+	// Polling paused for 8 hours; the counter moved a lot meanwhile.
+	resumed := ifEntryDecoded(t0.Add(8 * time.Hour))
+	resumed.Resumed = true
+	resumed.Varbinds[3].Value = uint64(1234567890 + 50_000_000)
+	result := metrics.Build(resumed, metrics.BuildOptions{PollStatus: "success", Counters: cs})
+	if m, _ := findMetric(result.Metrics, "netif.bytes.in", "1"); m.Value != uint64(0) {
+		t.Errorf("resumed counter value = %v, want 0 (re-seeded, no spike)", m.Value)
+	}
+
+	// The next regular poll deltas against the re-seeded value.
+	next := ifEntryDecoded(t0.Add(8*time.Hour + time.Minute))
+	next.Varbinds[3].Value = uint64(1234567890 + 50_000_000 + 6000)
+	result = metrics.Build(next, metrics.BuildOptions{PollStatus: "success", Counters: cs})
+	if m, _ := findMetric(result.Metrics, "netif.bytes.in", "1"); m.Value != uint64(6000) {
+		t.Errorf("counter delta after resume = %v, want 6000", m.Value)
+	}
+}
+
 func TestBuild_Metadata(t *testing.T) {
 	decoded := ifEntryDecoded(time.Now())
 	result := metrics.Build(decoded, metrics.BuildOptions{
@@ -390,3 +575,21 @@ func TestMetricsProducer_Produce(t *testing.T) {
 		t.Errorf("enum value = %v, want %q", m.Value, "up")
 	}
 }
+
+func TestMetricsProducer_SetEnums(t *testing.T) {
+	p := metrics.New(metrics.Config{EnumEnabled: true}, nil)
+
+	decoded := ifEntryDecoded(time.Now())
+	result, _ := p.Produce(decoded)
+	if m, _ := findMetric(result.Metrics, "netif.state.oper", "1"); m.Value == "up" {
+		t.Fatal("no registry yet, value should stay numeric")
+	}
+
+	reg := metrics.NewEnumRegistry()
+	reg.RegisterIntEnum("1.3.6.1.2.1.2.2.1.8", false, map[int64]string{1: "up", 2: "down"})
+	p.SetEnums(reg)
+	result, _ = p.Produce(decoded)
+	if m, _ := findMetric(result.Metrics, "netif.state.oper", "1"); m.Value != "up" {
+		t.Errorf("after SetEnums value = %v, want %q", m.Value, "up")
+	}
+}
@@ -0,0 +1,22 @@
+package metrics
+
+// MergeTags flattens tag layers into a single map. Layers are applied in
+// order, so a key in a later layer overrides the same key in an earlier one.
+// The collector's precedence is device group → device → instance. It returns
+// nil when every layer is empty; the inputs are never modified.
+func MergeTags(layers ...map[string]string) map[string]string {
+	n := 0
+	for _, l := range layers {
+		n += len(l)
+	}
+	if n == 0 {
+		return nil
+	}
+	out := make(map[string]string, n)
+	for _, l := range layers {
+		for k, v := range l {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,451 @@
+// Package capture records decoder.RawPollResult values to a file and reads
+// them back, so a decoder or producer problem seen in production can be
+// replayed offline without the device.
+//
+// A capture is a stream of JSON lines, gzip-compressed when the file name ends
+// in ".gz". Object definitions are written once, before the first poll that
+// uses them (and again if they change after a reload); polls refer to them by
+// key and carry each varbind as an [oid, type, value] triple:
+//
+//	{"def":{"Key":"IF-MIB::ifEntry","MIB":"IF-MIB",…}}
+//	{"poll":{"device":{"hostname":"sw01",…},"object":"IF-MIB::ifEntry",
+//	  "started":"2026-10-18T10:00:00.001Z","collected":"2026-10-18T10:00:00.042Z",
+//	  "varbinds":[[".1.3.6.1.2.1.2.2.1.10.1","Counter32",1000],
+//	              [".1.3.6.1.2.1.2.2.1.2.1","OctetString","R2kwLzE="]]}}
+//
+// Values keep the Go type gosnmp decodes for their ASN.1 type: integers are
+// JSON numbers, OctetString / Opaque / BitString values base64 strings, OIDs
+// and IP addresses plain strings and NoSuchObject / EndOfMibView null.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/snmp/decoder"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// File records
+// ─────────────────────────────────────────────────────────────────────────────
+
+// line is one JSON line of a capture: exactly one field is set.
+type line struct {
+	Def  *models.ObjectDefinition `json:"def,omitempty"`
+	Poll *poll                    `json:"poll,omitempty"`
+}
+
+// poll is a RawPollResult with its object definition replaced by its key.
+type poll struct {
+	Device    models.Device `json:"device"`
+	Object    string        `json:"object"`
+	Started   time.Time     `json:"started"`
+	Collected time.Time     `json:"collected"`
+	Resumed   bool          `json:"resumed,omitempty"`
+	Varbinds  []varbind     `json:"varbinds"`
+}
+
+// varbind is a gosnmp.SnmpPDU encoded as an [oid, type, value] array.
+type varbind gosnmp.SnmpPDU
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Writer
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Writer appends poll results to a capture. It is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	buf    *bufio.Writer
+	gz     *gzip.Writer // nil when not compressing
+	closer io.Closer    // the file opened by Create; nil for NewWriter
+	defs   map[string]models.ObjectDefinition
+}
+
+// Create creates (or truncates) the capture file at path. A ".gz" suffix
+// selects gzip compression.
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("capture: %w", err)
+	}
+	w := NewWriter(f, strings.HasSuffix(path, ".gz"))
+	w.closer = f
+	return w, nil
+}
+
+// NewWriter returns a Writer that writes a capture to out, gzip-compressed
+// when compress is set. Close flushes it but does not close out.
+func NewWriter(out io.Writer, compress bool) *Writer {
+	w := &Writer{defs: make(map[string]models.ObjectDefinition)}
+	if compress {
+		w.gz = gzip.NewWriter(out)
+		out = w.gz
+	}
+	w.buf = bufio.NewWriter(out)
+	return w
+}
+
+// Write appends r, preceded by its object definition when that was not yet
+// written or has changed since.
+func (w *Writer) Write(r decoder.RawPollResult) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if prev, ok := w.defs[r.ObjectDef.Key]; !ok || !reflect.DeepEqual(prev, r.ObjectDef) {
+		def := r.ObjectDef
+		if err := w.writeLine(line{Def: &def}); err != nil {
+			return err
+		}
+		w.defs[def.Key] = def
+	}
+
+	p := poll{
+		Device:    r.Device,
+		Object:    r.ObjectDef.Key,
+		Started:   r.PollStartedAt,
+		Collected: r.CollectedAt,
+		Resumed:   r.Resumed,
+		Varbinds:  make([]varbind, len(r.Varbinds)),
+	}
+	for i, pdu := range r.Varbinds {
+		p.Varbinds[i] = varbind(pdu)
+	}
+	return w.writeLine(line{Poll: &p})
+}
+
+func (w *Writer) writeLine(l line) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("capture: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.buf.Write(data); err != nil {
+		return fmt.Errorf("capture: %w", err)
+	}
+	return nil
+}
+
+// Flush writes buffered records through to the underlying writer. With
+// compression the data is flushed to a gzip block boundary, so a reader sees
+// every record written so far.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *Writer) flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("capture: %w", err)
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return fmt.Errorf("capture: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close flushes the capture and closes the file opened by Create.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.flush()
+	if w.gz != nil {
+		if cerr := w.gz.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("capture: %w", cerr)
+		}
+	}
+	if w.closer != nil {
+		if cerr := w.closer.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("capture: %w", cerr)
+		}
+	}
+	return err
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Reader
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Reader reads poll results back from a capture in the order they were
+// written.
+type Reader struct {
+	in     *bufio.Reader
+	closer io.Closer // the file opened by Open; nil for NewReader
+	defs   map[string]models.ObjectDefinition
+	line   int
+}
+
+// Open opens the capture file at path. Compression is detected from the
+// content, not the name.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("capture: %w", err)
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader returns a Reader for the capture in in, which may be
+// gzip-compressed.
+func NewReader(in io.Reader) (*Reader, error) {
+	br := bufio.NewReader(in)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("capture: %w", err)
+		}
+		br = bufio.NewReader(gz)
+	}
+	return &Reader{in: br, defs: make(map[string]models.ObjectDefinition)}, nil
+}
+
+// Next returns the next poll result. It returns io.EOF after the last one.
+func (r *Reader) Next() (decoder.RawPollResult, error) {
+	for {
+		data, err := r.in.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) == 0 {
+			if err == nil {
+				r.line++
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return decoder.RawPollResult{}, io.EOF
+			}
+			return decoder.RawPollResult{}, fmt.Errorf("capture: line %d: %w", r.line+1, err)
+		}
+		r.line++
+
+		var l line
+		if err := json.Unmarshal(data, &l); err != nil {
+			return decoder.RawPollResult{}, fmt.Errorf("capture: line %d: %w", r.line, err)
+		}
+		switch {
+		case l.Def != nil:
+			r.defs[l.Def.Key] = *l.Def
+		case l.Poll != nil:
+			def, ok := r.defs[l.Poll.Object]
+			if !ok {
+				return decoder.RawPollResult{}, fmt.Errorf("capture: line %d: object %q used before its definition", r.line, l.Poll.Object)
+			}
+			res := decoder.RawPollResult{
+				Device:        l.Poll.Device,
+				ObjectDef:     def,
+				Varbinds:      make([]gosnmp.SnmpPDU, len(l.Poll.Varbinds)),
+				CollectedAt:   l.Poll.Collected,
+				PollStartedAt: l.Poll.Started,
+				Resumed:       l.Poll.Resumed,
+			}
+			for i, vb := range l.Poll.Varbinds {
+				res.Varbinds[i] = gosnmp.SnmpPDU(vb)
+			}
+			return res, nil
+		default:
+			return decoder.RawPollResult{}, fmt.Errorf("capture: line %d: neither def nor poll", r.line)
+		}
+	}
+}
+
+// Close closes the file opened by Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Varbind encoding
+// ─────────────────────────────────────────────────────────────────────────────
+
+// typesByName maps decoder.PDUTypeString names back to their ASN.1 tags.
+var typesByName = func() map[string]gosnmp.Asn1BER {
+	m := make(map[string]gosnmp.Asn1BER)
+	for t := 0; t <= 0xff; t++ {
+		name := decoder.PDUTypeString(gosnmp.Asn1BER(t))
+		if !strings.HasPrefix(name, "Unknown(") {
+			m[name] = gosnmp.Asn1BER(t)
+		}
+	}
+	return m
+}()
+
+// MarshalJSON encodes the varbind as [oid, type, value].
+func (v varbind) MarshalJSON() ([]byte, error) {
+	value, err := encodeValue(v.Type, v.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", v.Name, err)
+	}
+	return json.Marshal([]any{v.Name, decoder.PDUTypeString(v.Type), value})
+}
+
+// UnmarshalJSON decodes an [oid, type, value] array.
+func (v *varbind) UnmarshalJSON(data []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("varbind: want [oid, type, value], got %d elements", len(parts))
+	}
+	var oid, typeName string
+	if err := json.Unmarshal(parts[0], &oid); err != nil {
+		return fmt.Errorf("varbind oid: %w", err)
+	}
+	if err := json.Unmarshal(parts[1], &typeName); err != nil {
+		return fmt.Errorf("varbind %s type: %w", oid, err)
+	}
+	typ, ok := typesByName[typeName]
+	if !ok {
+		var n uint8
+		if _, err := fmt.Sscanf(typeName, "Unknown(0x%02X)", &n); err != nil {
+			return fmt.Errorf("varbind %s: unknown type %q", oid, typeName)
+		}
+		typ = gosnmp.Asn1BER(n)
+	}
+	value, err := decodeValue(typ, parts[2])
+	if err != nil {
+		return fmt.Errorf("varbind %s: %w", oid, err)
+	}
+	*v = varbind{Name: oid, Type: typ, Value: value}
+	return nil
+}
+
+// encodeValue returns the JSON form of a varbind value of type t.
+func encodeValue(t gosnmp.Asn1BER, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch t {
+	case gosnmp.Integer:
+		if n, ok := toInt64(value); ok {
+			return n, nil
+		}
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Uinteger32, gosnmp.Counter64:
+		if n, ok := toUint64(value); ok {
+			return n, nil
+		}
+	case gosnmp.OpaqueFloat, gosnmp.OpaqueDouble:
+		switch x := value.(type) {
+		case float32:
+			return float64(x), nil
+		case float64:
+			return x, nil
+		}
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		switch x := value.(type) {
+		case string:
+			return x, nil
+		case []byte:
+			return string(x), nil
+		}
+	default:
+		switch x := value.(type) {
+		case []byte:
+			return x, nil
+		case string:
+			return []byte(x), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported %s value of type %T", decoder.PDUTypeString(t), value)
+}
+
+// decodeValue restores the Go value gosnmp produces for type t from raw.
+func decodeValue(t gosnmp.Asn1BER, raw json.RawMessage) (any, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	switch t {
+	case gosnmp.Integer:
+		n, err := strconv.ParseInt(string(raw), 10, 64)
+		return int(n), err
+	case gosnmp.Counter32, gosnmp.Gauge32:
+		n, err := strconv.ParseUint(string(raw), 10, 32)
+		return uint(n), err
+	case gosnmp.TimeTicks, gosnmp.Uinteger32:
+		n, err := strconv.ParseUint(string(raw), 10, 32)
+		return uint32(n), err
+	case gosnmp.Counter64:
+		return strconv.ParseUint(string(raw), 10, 64)
+	case gosnmp.OpaqueFloat:
+		f, err := strconv.ParseFloat(string(raw), 32)
+		return float32(f), err
+	case gosnmp.OpaqueDouble:
+		return strconv.ParseFloat(string(raw), 64)
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	default:
+		var b []byte
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	}
+}
+
+// toInt64 converts a Go integer of any width to int64.
+func toInt64(value any) (int64, bool) {
+	switch x := value.(type) {
+	case int:
+		return int64(x), true
+	case int8:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case uint:
+		return int64(x), true
+	case uint8:
+		return int64(x), true
+	case uint16:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case uint64:
+		return int64(x), true
+	}
+	return 0, false
+}
+
+// toUint64 converts a Go integer of any width to uint64.
+func toUint64(value any) (uint64, bool) {
+	switch x := value.(type) {
+	case uint:
+		return uint64(x), true
+	case uint8:
+		return uint64(x), true
+	case uint16:
+		return uint64(x), true
+	case uint32:
+		return uint64(x), true
+	case uint64:
+		return x, true
+	}
+	if n, ok := toInt64(value); ok {
+		return uint64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,226 @@
+package capture_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/models"
+	"github.com/vpbank/snmp_collector/snmp/capture"
+	"github.com/vpbank/snmp_collector/snmp/decoder"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func ifEntry() models.ObjectDefinition {
+	return models.ObjectDefinition{
+		Key:    "IF-MIB::ifEntry",
+		MIB:    "IF-MIB",
+		Object: "ifEntry",
+		Index:  []models.IndexDefinition{{Type: "Integer", OID: ".1.3.6.1.2.1.2.2.1.1", Name: "netif"}},
+		Attributes: map[string]models.AttributeDefinition{
+			"ifInOctets": {OID: ".1.3.6.1.2.1.2.2.1.10", Name: "netif.bytes.in", Syntax: "Counter32"},
+			"ifDescr":    {OID: ".1.3.6.1.2.1.2.2.1.2", Name: "netif.descr", Syntax: "DisplayString", IsTag: true},
+		},
+	}
+}
+
+// allTypes returns one varbind of every type gosnmp decodes, each carrying the
+// Go type gosnmp would give it.
+func allTypes() []gosnmp.SnmpPDU {
+	return []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.7.0", Type: gosnmp.Integer, Value: -42},
+		{Name: ".1.3.6.1.2.1.1.1.0", Type: gosnmp.OctetString, Value: []byte("Cisco IOS\x00\xff")},
+		{Name: ".1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9.1.1208"},
+		{Name: ".1.3.6.1.2.1.4.20.1.1.10.0.0.1", Type: gosnmp.IPAddress, Value: "10.0.0.1"},
+		{Name: ".1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: uint(4294967295)},
+		{Name: ".1.3.6.1.2.1.2.2.1.5.1", Type: gosnmp.Gauge32, Value: uint(1000000000)},
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(8640000)},
+		{Name: ".1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(18446744073709551615)},
+		{Name: ".1.3.6.1.4.1.2021.4.5.0", Type: gosnmp.Uinteger32, Value: uint32(7)},
+		{Name: ".1.3.6.1.4.1.2021.10.1.6.1", Type: gosnmp.Opaque, Value: []byte{0x9f, 0x78}},
+		{Name: ".1.3.6.1.4.1.2021.10.1.6.2", Type: gosnmp.OpaqueFloat, Value: float32(0.25)},
+		{Name: ".1.3.6.1.4.1.2021.10.1.6.3", Type: gosnmp.OpaqueDouble, Value: 1.5},
+		{Name: ".1.3.6.1.2.1.2.2.1.99.1", Type: gosnmp.NoSuchObject, Value: nil},
+		{Name: ".1.3.6.1.2.1.2.2.1.99.2", Type: gosnmp.EndOfMibView, Value: nil},
+	}
+}
+
+func readAll(t *testing.T, r *capture.Reader) []decoder.RawPollResult {
+	t.Helper()
+	var out []decoder.RawPollResult
+	for {
+		res, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		out = append(out, res)
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Round trip
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestCapture_RoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(map[bool]string{false: "plain", true: "gzip"}[compress], func(t *testing.T) {
+			started := time.Date(2026, 10, 18, 10, 0, 0, 1_000_000, time.UTC)
+			in := []decoder.RawPollResult{
+				{
+					Device:        models.Device{Hostname: "sw01", IPAddress: "10.0.0.1", SNMPVersion: "2c", Tags: map[string]string{"site": "hcm"}},
+					ObjectDef:     ifEntry(),
+					Varbinds:      allTypes(),
+					PollStartedAt: started,
+					CollectedAt:   started.Add(41 * time.Millisecond),
+				},
+				{
+					Device:        models.Device{Hostname: "sw02", IPAddress: "10.0.0.2", SNMPVersion: "3"},
+					ObjectDef:     ifEntry(),
+					Varbinds:      []gosnmp.SnmpPDU{},
+					PollStartedAt: started.Add(time.Minute),
+					CollectedAt:   started.Add(time.Minute + time.Second),
+					Resumed:       true,
+				},
+			}
+
+			var buf bytes.Buffer
+			w := capture.NewWriter(&buf, compress)
+			for _, r := range in {
+				if err := w.Write(r); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if compress == strings.Contains(buf.String(), "IF-MIB::ifEntry") {
+				t.Errorf("compress=%v but plain text present=%v", compress, !compress)
+			}
+
+			r, err := capture.NewReader(&buf)
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+			got := readAll(t, r)
+			if !reflect.DeepEqual(got, in) {
+				t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, in)
+			}
+		})
+	}
+}
+
+func TestCapture_DefinitionWrittenOncePerChange(t *testing.T) {
+	var buf bytes.Buffer
+	w := capture.NewWriter(&buf, false)
+	def := ifEntry()
+	res := decoder.RawPollResult{ObjectDef: def, Varbinds: []gosnmp.SnmpPDU{{Name: ".1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: uint(1)}}}
+	_ = w.Write(res)
+	_ = w.Write(res)
+
+	changed := ifEntry()
+	changed.PollInterval = 30
+	res.ObjectDef = changed
+	_ = w.Write(res)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if n := strings.Count(buf.String(), `{"def":`); n != 2 {
+		t.Errorf("def lines = %d, want 2 (first use + change)\n%s", n, buf.String())
+	}
+	if n := strings.Count(buf.String(), `{"poll":`); n != 3 {
+		t.Errorf("poll lines = %d, want 3", n)
+	}
+
+	r, _ := capture.NewReader(&buf)
+	got := readAll(t, r)
+	if len(got) != 3 || got[0].ObjectDef.PollInterval != 0 || got[2].ObjectDef.PollInterval != 30 {
+		t.Errorf("replayed definitions do not follow the change: %+v", got)
+	}
+}
+
+func TestCapture_FileAndGzipByName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"polls.jsonl", "polls.jsonl.gz"} {
+		path := filepath.Join(dir, name)
+		w, err := capture.Create(path)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		res := decoder.RawPollResult{
+			Device:    models.Device{Hostname: "sw01"},
+			ObjectDef: ifEntry(),
+			Varbinds:  allTypes()[:3],
+		}
+		if err := w.Write(res); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		r, err := capture.Open(path)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		got := readAll(t, r)
+		_ = r.Close()
+		if len(got) != 1 || got[0].Device.Hostname != "sw01" || len(got[0].Varbinds) != 3 {
+			t.Errorf("%s: got %+v", name, got)
+		}
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Errors
+// ─────────────────────────────────────────────────────────────────────────────
+
+func TestCapture_WriteRejectsUnsupportedValue(t *testing.T) {
+	w := capture.NewWriter(io.Discard, false)
+	err := w.Write(decoder.RawPollResult{
+		ObjectDef: ifEntry(),
+		Varbinds:  []gosnmp.SnmpPDU{{Name: ".1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: "1000"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), ".1.3.6.1.2.1.2.2.1.10.1") {
+		t.Errorf("err = %v, want an error naming the OID", err)
+	}
+}
+
+func TestCapture_ReadErrors(t *testing.T) {
+	cases := []struct {
+		name, data, want string
+	}{
+		{"poll before def", `{"poll":{"object":"IF-MIB::ifEntry","varbinds":[]}}`, `line 1: object "IF-MIB::ifEntry" used before its definition`},
+		{"bad type", `{"def":{"Key":"x"}}` + "\n\n" + `{"poll":{"object":"x","varbinds":[[".1.3","Bogus",1]]}}`, `line 3`},
+		{"bad arity", `{"def":{"Key":"x"}}` + "\n" + `{"poll":{"object":"x","varbinds":[[".1.3","Integer"]]}}`, "want [oid, type, value]"},
+		{"not json", `hello`, "line 1"},
+		{"empty record", `{}`, "neither def nor poll"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := capture.NewReader(strings.NewReader(tc.data))
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+			_, err = r.Next()
+			for err == nil {
+				_, err = r.Next()
+			}
+			if errors.Is(err, io.EOF) || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
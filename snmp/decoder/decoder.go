@@ -1,11 +1,14 @@
 package decoder
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/mibs/registry"
 	"github.com/vpbank/snmp_collector/models"
 )
 
@@ -33,6 +36,10 @@ type RawPollResult struct {
 	// PollStartedAt is the wall-clock time at which the SNMP request was sent.
 	// Together with CollectedAt it yields the round-trip poll duration.
 	PollStartedAt time.Time
+
+	// Resumed marks the first poll after a schedule pause. Counter deltas are
+	// re-seeded instead of spanning the pause.
+	Resumed bool
 }
 
 // DecodedPollResult is the message placed on the decoded-data channel by the
@@ -57,6 +64,9 @@ type DecodedPollResult struct {
 
 	// PollDurationMs is the round-trip poll duration in milliseconds.
 	PollDurationMs int64
+
+	// Resumed is forwarded unchanged from RawPollResult.
+	Resumed bool
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -75,9 +85,11 @@ type Decoder interface {
 // ─────────────────────────────────────────────────────────────────────────────
 
 // SNMPDecoder is the production Decoder implementation. It is stateless once
-// constructed and safe for concurrent calls to Decode.
+// constructed and safe for concurrent calls to Decode. The optional OID name
+// registry, swapped atomically by SetRegistry, only affects logging.
 type SNMPDecoder struct {
-	logger *slog.Logger
+	logger   *slog.Logger
+	registry atomic.Pointer[registry.Registry]
 }
 
 // NewSNMPDecoder constructs an SNMPDecoder. Pass a structured logger configured
@@ -95,6 +107,12 @@ func NewSNMPDecoder(logger *slog.Logger) *SNMPDecoder {
 	return &SNMPDecoder{logger: logger}
 }
 
+// SetRegistry sets the registry used to name OIDs in log messages, e.g. after
+// a config reload. With no registry (the default) OIDs are logged as numbers.
+func (d *SNMPDecoder) SetRegistry(reg *registry.Registry) {
+	d.registry.Store(reg)
+}
+
 // Decode implements Decoder.
 //
 // For each gosnmp PDU in raw.Varbinds it:
@@ -110,6 +128,7 @@ func (d *SNMPDecoder) Decode(raw RawPollResult) (DecodedPollResult, error) {
 		ObjectDefKey:   raw.ObjectDef.Key,
 		CollectedAt:    raw.CollectedAt,
 		PollDurationMs: raw.CollectedAt.Sub(raw.PollStartedAt).Milliseconds(),
+		Resumed:        raw.Resumed,
 	}
 
 	if len(raw.Varbinds) == 0 {
@@ -150,7 +169,10 @@ func (d *SNMPDecoder) Decode(raw RawPollResult) (DecodedPollResult, error) {
 			"device", raw.Device.Hostname,
 			"object", raw.ObjectDef.Key,
 			"pdu_count", len(raw.Varbinds),
+			"first_oid", d.registry.Load().Name(raw.Varbinds[0].Name),
 		)
+	} else if len(decoded) < len(raw.Varbinds) && d.logger.Enabled(context.Background(), slog.LevelDebug) {
+		d.logUnmatched(raw, parser)
 	}
 
 	d.logger.Debug("decode: completed",
@@ -165,6 +187,27 @@ func (d *SNMPDecoder) Decode(raw RawPollResult) (DecodedPollResult, error) {
 	return result, nil
 }
 
+// logUnmatched logs, at debug level, each varbind of raw that matched no
+// attribute — usually a bulk walk running past the end of the object — by
+// name when the registry knows it.
+func (d *SNMPDecoder) logUnmatched(raw RawPollResult, parser *VarbindParser) {
+	reg := d.registry.Load()
+	for _, pdu := range raw.Varbinds {
+		if IsErrorType(pdu.Type) {
+			continue
+		}
+		if _, _, found := parser.matchAttribute(normaliseOID(pdu.Name)); found {
+			continue
+		}
+		d.logger.Debug("decode: varbind outside object",
+			"device", raw.Device.Hostname,
+			"object", raw.ObjectDef.Key,
+			"oid", pdu.Name,
+			"name", reg.Name(pdu.Name),
+		)
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // noopWriter — discard all log output when no logger is provided
 // ─────────────────────────────────────────────────────────────────────────────
@@ -1,12 +1,15 @@
 package decoder_test
 
 import (
+	"bytes"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
+	"github.com/vpbank/snmp_collector/mibs/registry"
 	"github.com/vpbank/snmp_collector/models"
 	"github.com/vpbank/snmp_collector/snmp/decoder"
 )
@@ -236,6 +239,34 @@ func TestSNMPDecoder_Decode_EmptyVarbinds_NoError(t *testing.T) {
 	}
 }
 
+func TestSNMPDecoder_Decode_LogsUnmatchedByName(t *testing.T) {
+	var buf bytes.Buffer
+	dec := decoder.NewSNMPDecoder(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	dec.SetRegistry(registry.Builtin())
+
+	raw := decoder.RawPollResult{
+		Device:    models.Device{Hostname: "sw01"},
+		ObjectDef: ifEntryDef,
+		Varbinds: append(testPDUs[:1:1],
+			gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.31.1.1.1.1.3", Type: gosnmp.OctetString, Value: []byte("Gi0/3")}),
+	}
+	if _, err := dec.Decode(raw); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !strings.Contains(buf.String(), "decode: varbind outside object") || !strings.Contains(buf.String(), "name=IF-MIB::ifName.3") {
+		t.Errorf("log does not name the unmatched varbind:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	raw.Varbinds = raw.Varbinds[1:]
+	if _, err := dec.Decode(raw); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !strings.Contains(buf.String(), "first_oid=IF-MIB::ifName.3") {
+		t.Errorf("no-match warning does not name the OID:\n%s", buf.String())
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // ConvertValue / type conversion tests
 // ─────────────────────────────────────────────────────────────────────────────
@@ -293,3 +324,16 @@ func TestConvertValue_ErrorType_ReturnsError(t *testing.T) {
 		t.Fatal("expected error for NoSuchObject type, got nil")
 	}
 }
+
+func TestKnownSyntax(t *testing.T) {
+	for _, s := range []string{"Counter64", "BandwidthMBits", "EnumBitmap", "UnsignedAsID", "SignalDBm"} {
+		if !decoder.KnownSyntax(s) {
+			t.Errorf("KnownSyntax(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"", "INTEGER", "Counter46"} {
+		if decoder.KnownSyntax(s) {
+			t.Errorf("KnownSyntax(%q) = true, want false", s)
+		}
+	}
+}
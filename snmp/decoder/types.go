@@ -230,6 +230,54 @@ func ConvertValue(rawType gosnmp.Asn1BER, rawValue interface{}, syntax string) (
 	}
 }
 
+// convertedSyntaxes are the syntaxes ConvertValue has a dedicated case for.
+var convertedSyntaxes = map[string]bool{
+	"Integer": true, "Integer32": true, "InterfaceIndex": true, "InterfaceIndexOrZero": true,
+	"TruthValue": true, "RowStatus": true, "TimeStamp": true, "TimeInterval": true,
+	"EnumInteger": true, "EnumIntegerKeepID": true, "EnumBitmap": true,
+	"Unsigned32": true, "Gauge32": true, "Counter32": true, "Counter64": true, "TimeTicks": true, "Opaque": true,
+	"DisplayString": true, "OctetString": true, "DateAndTime": true,
+	"PhysAddress": true, "MacAddress": true,
+	"ObjectIdentifier": true, "EnumObjectIdentifier": true, "EnumObjectIdentifierKeepOID": true,
+	"IpAddress": true, "IpAddressNoSuffix": true,
+	"BandwidthBits": true, "BandwidthKBits": true, "BandwidthMBits": true, "BandwidthGBits": true,
+	"BytesB": true, "BytesKB": true, "BytesMB": true, "BytesGB": true, "BytesTB": true,
+	"BytesKiB": true, "BytesMiB": true, "BytesGiB": true,
+	"TemperatureC": true, "TemperatureDeciC": true, "TemperatureCentiC": true,
+	"PowerWatt": true, "PowerMilliWatt": true, "PowerKiloWatt": true,
+	"CurrentAmp": true, "CurrentMilliAmp": true, "CurrentMicroAmp": true,
+	"VoltageVolt": true, "VoltageMilliVolt": true, "VoltageMicroVolt": true,
+	"FreqHz": true, "FreqKHz": true, "FreqMHz": true, "FreqGHz": true,
+	"TicksSec": true, "TicksMilliSec": true, "TicksMicroSec": true,
+	"Percent1": true, "Percent100": true, "PercentDeci100": true,
+}
+
+// fallbackSyntaxes are textual conventions used by the object library that
+// ConvertValue has no case for yet; their values convert by PDU type.
+var fallbackSyntaxes = map[string]bool{
+	"UnsignedAsID": true, "IntegerAsID": true, "IpAddressAsID": true, "MacAddressNoSuffix": true,
+	"IANAifType": true, "InetVersion": true, "InetAddressType": true, "IanaSafi": true, "IanaL4Proto": true,
+	"TDomain": true, "TAddress": true, "RowPointer": true, "VariablePointer": true, "AutonomousType": true,
+	"CounterBasedGauge64": true, "Float32": true, "Decibel": true,
+	"RateSec": true, "RateCentiSec": true, "EpochSec": true, "EpochMilliSec": true,
+	"TicksMin": true, "TicksDeciSec": true, "TicksCentiSec": true,
+	"OpTicksCentiSec": true, "OpTicksMilliSec": true, "OpTicksMicroSec": true,
+	"OpTicksTenMicroSec": true, "OpTicksNanoSec": true,
+	"SignalDBm": true, "SignalDeciDBm": true, "SignalMilliDBm": true,
+	"CurrentDeciAmp": true, "CurrentCentiAmp": true, "VoltageDeciVolt": true,
+	"PowerCentiWatt": true, "PowerMicroWatt": true, "TemperatureMilliC": true,
+	"FreqDeciHz": true, "FreqCentiHz": true, "PercentCenti1": true, "PercentCenti100": true,
+	"BandwidthBytes": true, "BandwidthKBytes": true,
+	"LengthMeter": true, "LengthDeciMeter": true, "LengthKiloMeter": true,
+}
+
+// KnownSyntax reports whether syntax is one ConvertValue converts or one the
+// object library relies on the PDU-type fallback for. Any other value still
+// decodes, but is most likely a typo in an object definition.
+func KnownSyntax(syntax string) bool {
+	return convertedSyntaxes[syntax] || fallbackSyntaxes[syntax]
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Low-level conversion helpers
 // ─────────────────────────────────────────────────────────────────────────────
@@ -0,0 +1,68 @@
+package snmptest
+
+import "strings"
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Fixtures
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Well-known OIDs of the SwitchRec fixture.
+const (
+	OIDSysDescr     = ".1.3.6.1.2.1.1.1.0"
+	OIDSysObjectID  = ".1.3.6.1.2.1.1.2.0"
+	OIDSysUpTime    = ".1.3.6.1.2.1.1.3.0"
+	OIDSysName      = ".1.3.6.1.2.1.1.5.0"
+	OIDIfDescr      = ".1.3.6.1.2.1.2.2.1.2"
+	OIDIfOperStatus = ".1.3.6.1.2.1.2.2.1.8"
+	OIDIfInOctets   = ".1.3.6.1.2.1.2.2.1.10"
+	OIDIfOutOctets  = ".1.3.6.1.2.1.2.2.1.16"
+	OIDIfName       = ".1.3.6.1.2.1.31.1.1.1.1"
+	OIDIfHCInOctets = ".1.3.6.1.2.1.31.1.1.1.6"
+)
+
+// SwitchRec is a snmprec image of a small Cisco switch: the system group,
+// ifNumber, and ifTable and ifXTable rows for two interfaces (Gi0/1 up,
+// Gi0/2 down).
+const SwitchRec = `# snmptest switch fixture
+1.3.6.1.2.1.1.1.0|4|Cisco IOS Software, C2960X Software (C2960X-UNIVERSALK9-M), Version 15.2(7)E2
+1.3.6.1.2.1.1.2.0|6|1.3.6.1.4.1.9.1.1208
+1.3.6.1.2.1.1.3.0|67|8640000
+1.3.6.1.2.1.1.4.0|4|noc@example.com
+1.3.6.1.2.1.1.5.0|4|sw01
+1.3.6.1.2.1.1.6.0|4|DC1 rack 4
+1.3.6.1.2.1.1.7.0|2|6
+1.3.6.1.2.1.2.1.0|2|2
+1.3.6.1.2.1.2.2.1.1.1|2|1
+1.3.6.1.2.1.2.2.1.1.2|2|2
+1.3.6.1.2.1.2.2.1.2.1|4|GigabitEthernet0/1
+1.3.6.1.2.1.2.2.1.2.2|4|GigabitEthernet0/2
+1.3.6.1.2.1.2.2.1.3.1|2|6
+1.3.6.1.2.1.2.2.1.3.2|2|6
+1.3.6.1.2.1.2.2.1.5.1|66|1000000000
+1.3.6.1.2.1.2.2.1.5.2|66|1000000000
+1.3.6.1.2.1.2.2.1.6.1|4x|00000c9f0001
+1.3.6.1.2.1.2.2.1.6.2|4x|00000c9f0002
+1.3.6.1.2.1.2.2.1.7.1|2|1
+1.3.6.1.2.1.2.2.1.7.2|2|1
+1.3.6.1.2.1.2.2.1.8.1|2|1
+1.3.6.1.2.1.2.2.1.8.2|2|2
+1.3.6.1.2.1.2.2.1.10.1|65|1000
+1.3.6.1.2.1.2.2.1.10.2|65|0
+1.3.6.1.2.1.2.2.1.16.1|65|2000
+1.3.6.1.2.1.2.2.1.16.2|65|0
+1.3.6.1.2.1.31.1.1.1.1.1|4|Gi0/1
+1.3.6.1.2.1.31.1.1.1.1.2|4|Gi0/2
+1.3.6.1.2.1.31.1.1.1.6.1|70|1000
+1.3.6.1.2.1.31.1.1.1.6.2|70|0
+1.3.6.1.2.1.31.1.1.1.18.1|4|uplink core1
+1.3.6.1.2.1.31.1.1.1.18.2|4|
+`
+
+// NewSwitch returns a Device loaded from SwitchRec.
+func NewSwitch() *Device {
+	d, err := ParseDevice(strings.NewReader(SwitchRec))
+	if err != nil {
+		panic("snmptest: SwitchRec: " + err.Error())
+	}
+	return d
+}
@@ -0,0 +1,531 @@
+package snmptest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Device — the MIB view served by a Simulator
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Device is an ordered set of OID values plus scripted changes to them. It is
+// safe for concurrent use, so tests may change values while a Simulator
+// serves the device.
+//
+// Values use the Go types gosnmp marshals: int for Integer, uint32 for
+// Counter32, Gauge32 and TimeTicks, uint64 for Counter64, []byte (or string)
+// for OctetString and Opaque, and dotted strings for ObjectIdentifier and
+// IPAddress. Other integer types are converted by Set.
+type Device struct {
+	mu     sync.Mutex
+	keys   []oidKey                  // sorted by arcs
+	values map[string]gosnmp.SnmpPDU // ".1.3.6…" → value
+	steps  map[string]uint64         // OID prefix → increment per read
+}
+
+// oidKey is a stored OID with its parsed arcs, for ordering.
+type oidKey struct {
+	oid  string
+	arcs []uint64
+}
+
+// NewDevice returns an empty Device.
+func NewDevice() *Device {
+	return &Device{
+		values: make(map[string]gosnmp.SnmpPDU),
+		steps:  make(map[string]uint64),
+	}
+}
+
+// Set stores value under oid, replacing any previous value. oid may omit the
+// leading dot. Set panics on an OID that is not dotted decimal.
+func (d *Device) Set(oid string, typ gosnmp.Asn1BER, value interface{}) {
+	oid, arcs, err := parseOID(oid)
+	if err != nil {
+		panic("snmptest: " + err.Error())
+	}
+	pdu := gosnmp.SnmpPDU{Name: oid, Type: typ, Value: normaliseValue(typ, value)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.values[oid]; !ok {
+		i, _ := slices.BinarySearchFunc(d.keys, arcs, compareKey)
+		d.keys = slices.Insert(d.keys, i, oidKey{oid: oid, arcs: arcs})
+	}
+	d.values[oid] = pdu
+}
+
+// Delete removes oid. Deleting an unknown OID is a no-op.
+func (d *Device) Delete(oid string) {
+	oid, arcs, err := parseOID(oid)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.values[oid]; !ok {
+		return
+	}
+	delete(d.values, oid)
+	if i, ok := slices.BinarySearchFunc(d.keys, arcs, compareKey); ok {
+		d.keys = slices.Delete(d.keys, i, i+1)
+	}
+}
+
+// Get returns the value stored under oid. Reading through Get does not run
+// scripted increments.
+func (d *Device) Get(oid string) (gosnmp.SnmpPDU, bool) {
+	oid, _, err := parseOID(oid)
+	if err != nil {
+		return gosnmp.SnmpPDU{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pdu, ok := d.values[oid]
+	return pdu, ok
+}
+
+// Next returns the first value after oid in lexicographic OID order, as
+// GetNext does. Reading through Next does not run scripted increments.
+func (d *Device) Next(oid string) (gosnmp.SnmpPDU, bool) {
+	_, arcs, err := parseOID(oid)
+	if err != nil {
+		return gosnmp.SnmpPDU{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.nextLocked(arcs)
+}
+
+// Len returns the number of stored OIDs.
+func (d *Device) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.keys)
+}
+
+// Walk returns every value under prefix in OID order; an empty prefix
+// returns the whole device.
+func (d *Device) Walk(prefix string) []gosnmp.SnmpPDU {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []gosnmp.SnmpPDU
+	for _, k := range d.keys {
+		if prefix == "" || underPrefix(k.oid, prefix) {
+			out = append(out, d.values[k.oid])
+		}
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Scripted changes
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Increment adds delta to the numeric value under oid once, wrapping at the
+// width of its type, so a test can jump a Counter32 to just below 2^32.
+func (d *Device) Increment(oid string, delta uint64) {
+	oid, _, err := parseOID(oid)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if pdu, ok := d.values[oid]; ok {
+		d.values[oid] = addValue(pdu, delta)
+	}
+}
+
+// AutoIncrement scripts every numeric value at or under prefix to grow by
+// step each time the Simulator serves it: the first read returns the stored
+// value, the next one value+step, and so on. A step of 0 removes the script.
+//
+// Pointing it at a table column (e.g. ifHCInOctets) makes consecutive polls
+// see moving counters, which is what rate and delta computations need.
+func (d *Device) AutoIncrement(prefix string, step uint64) {
+	prefix, _, err := parseOID(prefix)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if step == 0 {
+		delete(d.steps, prefix)
+		return
+	}
+	d.steps[prefix] = step
+}
+
+// serve returns the value under oid (or after it, when next is set) and runs
+// the scripted increment for it. It is what the Simulator reads through.
+func (d *Device) serve(oid string, next bool) (gosnmp.SnmpPDU, bool) {
+	oid, arcs, err := parseOID(oid)
+	if err != nil {
+		return gosnmp.SnmpPDU{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var pdu gosnmp.SnmpPDU
+	var ok bool
+	if next {
+		pdu, ok = d.nextLocked(arcs)
+	} else {
+		pdu, ok = d.values[oid]
+	}
+	if !ok {
+		return pdu, false
+	}
+	for prefix, step := range d.steps {
+		if pdu.Name == prefix || underPrefix(pdu.Name, prefix) {
+			d.values[pdu.Name] = addValue(pdu, step)
+			break
+		}
+	}
+	return pdu, true
+}
+
+func (d *Device) nextLocked(arcs []uint64) (gosnmp.SnmpPDU, bool) {
+	i, ok := slices.BinarySearchFunc(d.keys, arcs, compareKey)
+	if ok {
+		i++
+	}
+	if i >= len(d.keys) {
+		return gosnmp.SnmpPDU{}, false
+	}
+	return d.values[d.keys[i].oid], true
+}
+
+// addValue returns pdu with delta added to its numeric value, wrapping at the
+// width of the SNMP type. Non-numeric values are returned unchanged.
+func addValue(pdu gosnmp.SnmpPDU, delta uint64) gosnmp.SnmpPDU {
+	switch v := pdu.Value.(type) {
+	case uint32:
+		pdu.Value = v + uint32(delta)
+	case uint64:
+		pdu.Value = v + delta
+	case int:
+		pdu.Value = v + int(delta)
+	}
+	return pdu
+}
+
+// normaliseValue converts numeric values to the Go type gosnmp marshals for
+// typ, so scripted increments and comparisons see one representation.
+func normaliseValue(typ gosnmp.Asn1BER, value interface{}) interface{} {
+	n, ok := toUint64(value)
+	if !ok {
+		if s, isStr := value.(string); isStr && (typ == gosnmp.OctetString || typ == gosnmp.Opaque) {
+			return []byte(s)
+		}
+		return value
+	}
+	switch typ {
+	case gosnmp.Integer:
+		return int(n)
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Uinteger32:
+		return uint32(n)
+	case gosnmp.Counter64:
+		return n
+	}
+	return value
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch x := v.(type) {
+	case int:
+		return uint64(x), true
+	case int32:
+		return uint64(x), true
+	case int64:
+		return uint64(x), true
+	case uint:
+		return uint64(x), true
+	case uint32:
+		return uint64(x), true
+	case uint64:
+		return x, true
+	}
+	return 0, false
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Data files — snmprec and snmpwalk formats
+// ─────────────────────────────────────────────────────────────────────────────
+
+// LoadDevice reads a data file with ParseDevice.
+func LoadDevice(path string) (*Device, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("snmptest: %w", err)
+	}
+	defer f.Close()
+	d, err := ParseDevice(f)
+	if err != nil {
+		return nil, fmt.Errorf("snmptest: %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// ParseDevice reads device data in either of two line formats, which may be
+// mixed:
+//
+//   - snmprec, as used by snmpsim: "1.3.6.1.2.1.1.5.0|4|sw01", where the middle
+//     field is the BER tag (2 Integer, 4 OctetString, 5 Null, 6 OID,
+//     64 IpAddress, 65 Counter32, 66 Gauge32, 67 TimeTicks, 68 Opaque,
+//     70 Counter64) and a trailing "x" marks a hex-encoded value. Variation
+//     modules ("4:numeric") are ignored and the value is served as is.
+//   - net-snmp "snmpwalk -On" output: ".1.3.6.1.2.1.1.5.0 = STRING: sw01".
+//     Lines that do not start a new varbind continue the previous string.
+//
+// Blank lines and lines starting with '#' are skipped.
+func ParseDevice(r io.Reader) (*Device, error) {
+	d := NewDevice()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	var last string // OID of the previous walk string, for continuation lines
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		var (
+			oid string
+			typ gosnmp.Asn1BER
+			val interface{}
+			err error
+		)
+		switch {
+		case isRecLine(trimmed):
+			oid, typ, val, err = parseRecLine(trimmed)
+		case strings.Contains(trimmed, " = ") && startsWithOID(trimmed):
+			oid, typ, val, err = parseWalkLine(trimmed)
+			if err == errSkip {
+				last = ""
+				continue
+			}
+		case last != "":
+			pdu, _ := d.Get(last)
+			b, _ := pdu.Value.([]byte)
+			b = append(append(b, '\n'), strings.TrimSuffix(line, `"`)...)
+			d.Set(last, gosnmp.OctetString, b)
+			if strings.HasSuffix(trimmed, `"`) {
+				last = ""
+			}
+			continue
+		default:
+			err = fmt.Errorf("unrecognised line %q", trimmed)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if _, _, perr := parseOID(oid); perr != nil {
+			return nil, fmt.Errorf("line %d: %w", n, perr)
+		}
+		d.Set(oid, typ, val)
+		last = ""
+		if typ == gosnmp.OctetString && strings.Contains(trimmed, `= STRING: "`) && !strings.HasSuffix(trimmed, `"`) {
+			last = oid
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// errSkip marks snmpwalk lines that carry no value ("No Such Object …").
+var errSkip = errors.New("skip")
+
+func isRecLine(s string) bool {
+	i := strings.IndexByte(s, '|')
+	return i > 0 && startsWithOID(s) && !strings.Contains(s[:i], " ")
+}
+
+func startsWithOID(s string) bool {
+	s = strings.TrimPrefix(s, ".")
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+func parseRecLine(s string) (string, gosnmp.Asn1BER, interface{}, error) {
+	parts := strings.SplitN(s, "|", 3)
+	if len(parts) != 3 {
+		return "", 0, nil, fmt.Errorf("snmprec line %q: want oid|tag|value", s)
+	}
+	oid, tag, raw := parts[0], parts[1], parts[2]
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		tag = tag[:i]
+	}
+	isHex := strings.HasSuffix(tag, "x")
+	tag = strings.TrimSuffix(tag, "x")
+	n, err := strconv.Atoi(tag)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("snmprec tag %q: %w", parts[1], err)
+	}
+	typ := gosnmp.Asn1BER(n)
+	if isHex {
+		b, err := hex.DecodeString(raw)
+		if err != nil {
+			return "", 0, nil, fmt.Errorf("snmprec hex value %q: %w", raw, err)
+		}
+		if typ == gosnmp.IPAddress && len(b) == 4 {
+			return oid, typ, fmt.Sprintf("%d.%d.%d.%d", b[0], b[1], b[2], b[3]), nil
+		}
+		return oid, typ, b, nil
+	}
+	val, err := parseTypedValue(typ, raw)
+	return oid, typ, val, err
+}
+
+// parseTypedValue converts the text of a snmprec value to the Go type of typ.
+func parseTypedValue(typ gosnmp.Asn1BER, raw string) (interface{}, error) {
+	switch typ {
+	case gosnmp.Integer:
+		return strconv.Atoi(raw)
+	case gosnmp.OctetString, gosnmp.Opaque:
+		return []byte(raw), nil
+	case gosnmp.Null:
+		return nil, nil
+	case gosnmp.ObjectIdentifier:
+		oid, _, err := parseOID(raw)
+		return oid, err
+	case gosnmp.IPAddress:
+		return raw, nil
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Uinteger32:
+		n, err := strconv.ParseUint(raw, 10, 32)
+		return uint32(n), err
+	case gosnmp.Counter64:
+		return strconv.ParseUint(raw, 10, 64)
+	}
+	return nil, fmt.Errorf("unsupported type tag %d", typ)
+}
+
+// parseWalkLine parses one "OID = TYPE: value" line of snmpwalk -On output.
+func parseWalkLine(s string) (string, gosnmp.Asn1BER, interface{}, error) {
+	oid, rest, _ := strings.Cut(s, " = ")
+	rest = strings.TrimSpace(rest)
+	if rest == `""` {
+		return oid, gosnmp.OctetString, []byte{}, nil
+	}
+	kind, raw, ok := strings.Cut(rest, ":")
+	if !ok || strings.HasPrefix(rest, "No Such") || strings.HasPrefix(rest, "No more") {
+		return "", 0, nil, errSkip
+	}
+	raw = strings.TrimSpace(raw)
+	switch kind {
+	case "STRING":
+		return oid, gosnmp.OctetString, []byte(unquote(raw)), nil
+	case "Hex-STRING", "BITS":
+		b, err := parseHexBytes(raw)
+		return oid, gosnmp.OctetString, b, err
+	case "INTEGER":
+		n, err := strconv.Atoi(walkNumber(raw))
+		return oid, gosnmp.Integer, n, err
+	case "Counter32", "Gauge32", "Unsigned32", "UInteger32":
+		n, err := strconv.ParseUint(walkNumber(raw), 10, 32)
+		typ := gosnmp.Gauge32
+		if kind == "Counter32" {
+			typ = gosnmp.Counter32
+		}
+		return oid, typ, uint32(n), err
+	case "Counter64":
+		n, err := strconv.ParseUint(walkNumber(raw), 10, 64)
+		return oid, gosnmp.Counter64, n, err
+	case "Timeticks":
+		n, err := strconv.ParseUint(walkNumber(raw), 10, 32)
+		return oid, gosnmp.TimeTicks, uint32(n), err
+	case "OID":
+		v, _, err := parseOID(raw)
+		return oid, gosnmp.ObjectIdentifier, v, err
+	case "IpAddress":
+		return oid, gosnmp.IPAddress, raw, nil
+	case "Opaque":
+		b, err := parseHexBytes(raw)
+		return oid, gosnmp.Opaque, b, err
+	}
+	return "", 0, nil, fmt.Errorf("unsupported snmpwalk type %q", kind)
+}
+
+// walkNumber extracts the number from snmpwalk renderings such as "5",
+// "up(1)", "(12345) 0:02:03.45" and "100 milliseconds".
+func walkNumber(s string) string {
+	if i := strings.IndexByte(s, '('); i >= 0 {
+		if j := strings.IndexByte(s[i:], ')'); j > 0 {
+			return s[i+1 : i+j]
+		}
+	}
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
+	}
+	return s
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	} else {
+		s = strings.TrimPrefix(s, `"`)
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s)
+}
+
+// parseHexBytes decodes "00 1A 2B" (and BITS renderings "80 00 foo(0)").
+func parseHexBytes(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, f := range strings.Fields(s) {
+		if len(f) != 2 {
+			break
+		}
+		b, err := hex.DecodeString(f)
+		if err != nil {
+			return nil, fmt.Errorf("hex byte %q: %w", f, err)
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// OID helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// parseOID returns oid with a leading dot and its arcs.
+func parseOID(oid string) (string, []uint64, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(oid), ".")
+	if s == "" {
+		return "", nil, fmt.Errorf("empty OID")
+	}
+	parts := strings.Split(s, ".")
+	arcs := make([]uint64, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("OID %q is not dotted decimal", oid)
+		}
+		arcs[i] = n
+	}
+	return "." + s, arcs, nil
+}
+
+func compareKey(k oidKey, arcs []uint64) int {
+	return slices.Compare(k.arcs, arcs)
+}
+
+// underPrefix reports whether oid lies strictly below prefix.
+func underPrefix(oid, prefix string) bool {
+	prefix = "." + strings.Trim(prefix, ".")
+	return strings.HasPrefix(oid, prefix+".")
+}
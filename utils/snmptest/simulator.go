@@ -0,0 +1,776 @@
+// Package snmptest provides an in-process SNMP agent for tests and local
+// development. A Simulator serves a Device — OID values loaded from a snmprec
+// or snmpwalk file, or set by the test — over UDP to real gosnmp clients:
+// SNMPv1, v2c and v3 (USM) Get, GetNext and GetBulk, with injectable faults
+// (delays, packet loss, error statuses, message size limits) and the ability
+// to send traps and informs.
+//
+// It depends only on gosnmp and the standard library, so any package of the
+// collector can drive its real sessions against it.
+package snmptest
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Options
+// ─────────────────────────────────────────────────────────────────────────────
+
+// DefaultEngineID is the snmpEngineID of a Simulator unless Options.EngineID
+// is set: enterprise 8072 (net-snmp) with a text "snmptest" suffix.
+const DefaultEngineID = "\x80\x00\x1f\x88\x04snmptest"
+
+// Options configures a Simulator.
+type Options struct {
+	// Addr is the UDP address to listen on (default "127.0.0.1:0", a random
+	// loopback port — read it back with Address or Port).
+	Addr string
+
+	// Communities are accepted for SNMPv1 and v2c (default ["public"]).
+	// Requests with any other community are dropped, as real agents do.
+	Communities []string
+
+	// Users are the SNMPv3 USM users. SNMPv3 requests are answered only when
+	// at least one user is configured.
+	Users []User
+
+	// EngineID is the authoritative snmpEngineID (default DefaultEngineID).
+	EngineID string
+
+	// MaxMessageSize caps the encoded size of a response (default 65507).
+	// Larger Get and GetNext responses become tooBig errors; GetBulk
+	// responses are truncated instead, as RFC 3416 prescribes.
+	MaxMessageSize int
+
+	// BulkTooBig answers oversized GetBulk responses with tooBig as well,
+	// as some agents do, instead of truncating them.
+	BulkTooBig bool
+}
+
+// User is an SNMPv3 USM user. The security level follows from the
+// protocols: AuthProtocol alone is authNoPriv, both are authPriv.
+type User struct {
+	Name           string
+	AuthProtocol   gosnmp.SnmpV3AuthProtocol
+	AuthPassphrase string
+	PrivProtocol   gosnmp.SnmpV3PrivProtocol
+	PrivPassphrase string
+}
+
+func (u User) msgFlags() gosnmp.SnmpV3MsgFlags {
+	switch {
+	case u.AuthProtocol > gosnmp.NoAuth && u.PrivProtocol > gosnmp.NoPriv:
+		return gosnmp.AuthPriv
+	case u.AuthProtocol > gosnmp.NoAuth:
+		return gosnmp.AuthNoPriv
+	default:
+		return gosnmp.NoAuthNoPriv
+	}
+}
+
+func (u User) securityParameters(engineID string) *gosnmp.UsmSecurityParameters {
+	return &gosnmp.UsmSecurityParameters{
+		UserName:                 u.Name,
+		AuthenticationProtocol:   u.AuthProtocol,
+		AuthenticationPassphrase: u.AuthPassphrase,
+		PrivacyProtocol:          u.PrivProtocol,
+		PrivacyPassphrase:        u.PrivPassphrase,
+		AuthoritativeEngineID:    engineID,
+		AuthoritativeEngineBoots: 1,
+	}
+}
+
+func (o *Options) withDefaults() Options {
+	out := *o
+	if out.Addr == "" {
+		out.Addr = "127.0.0.1:0"
+	}
+	if len(out.Communities) == 0 {
+		out.Communities = []string{"public"}
+	}
+	if out.EngineID == "" {
+		out.EngineID = DefaultEngineID
+	}
+	if out.MaxMessageSize <= 0 {
+		out.MaxMessageSize = 65507
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Simulator
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Simulator is an SNMP agent serving a Device over UDP.
+type Simulator struct {
+	dev    *Device
+	opts   Options
+	logger *slog.Logger
+
+	conn    net.PacketConn
+	started time.Time
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	v3    *gosnmp.GoSNMP     // USM decoder; nil without users
+	users map[string]usmUser // by name
+
+	requests       atomic.Int64
+	unknownEngines atomic.Uint32
+
+	mu       sync.Mutex
+	delay    time.Duration
+	loss     float64
+	dropNext int
+	errs     map[string]gosnmp.SNMPError // OID prefix → forced status
+}
+
+// NewSimulator returns a Simulator serving dev. Call Start to listen.
+func NewSimulator(dev *Device, opts Options, logger *slog.Logger) *Simulator {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(noopWriter{}, nil))
+	}
+	if dev == nil {
+		dev = NewDevice()
+	}
+	return &Simulator{
+		dev:     dev,
+		opts:    opts.withDefaults(),
+		logger:  logger,
+		done:    make(chan struct{}),
+		errs:    make(map[string]gosnmp.SNMPError),
+		started: time.Now(),
+	}
+}
+
+// Start binds the UDP socket and serves requests until Stop.
+func (s *Simulator) Start() error {
+	if len(s.opts.Users) > 0 {
+		if err := s.initUSM(); err != nil {
+			return err
+		}
+	}
+	conn, err := net.ListenPacket("udp", s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("snmptest: listen %s: %w", s.opts.Addr, err)
+	}
+	s.conn = conn
+	s.started = time.Now()
+	s.wg.Add(1)
+	go s.serve()
+	s.logger.Debug("snmptest: listening", "addr", s.Address())
+	return nil
+}
+
+// Stop closes the socket and waits for in-flight requests, including
+// delayed ones, to finish. It is safe to call Stop more than once.
+func (s *Simulator) Stop() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	close(s.done)
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.wg.Wait()
+}
+
+// Address returns the "host:port" the Simulator listens on.
+func (s *Simulator) Address() string {
+	if s.conn == nil {
+		return s.opts.Addr
+	}
+	return s.conn.LocalAddr().String()
+}
+
+// Port returns the UDP port the Simulator listens on.
+func (s *Simulator) Port() int {
+	if s.conn == nil {
+		return 0
+	}
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// Device returns the served Device.
+func (s *Simulator) Device() *Device { return s.dev }
+
+// EngineID returns the authoritative snmpEngineID.
+func (s *Simulator) EngineID() string { return s.opts.EngineID }
+
+// Requests returns the number of datagrams received, including dropped ones,
+// so tests can count retries.
+func (s *Simulator) Requests() int { return int(s.requests.Load()) }
+
+// uptime returns the time since NewSimulator or Start in TimeTicks (hundredths of a second).
+func (s *Simulator) uptime() uint32 {
+	return uint32(time.Since(s.started) / (10 * time.Millisecond))
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Fault injection
+// ─────────────────────────────────────────────────────────────────────────────
+
+// SetDelay delays every response by d. A delay longer than the client timeout
+// turns into a timeout; zero removes it.
+func (s *Simulator) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	s.delay = d
+	s.mu.Unlock()
+}
+
+// SetLoss drops the given fraction (0–1) of requests at random. 1 makes the
+// agent unresponsive.
+func (s *Simulator) SetLoss(rate float64) {
+	s.mu.Lock()
+	s.loss = rate
+	s.mu.Unlock()
+}
+
+// DropNext drops the next n requests, for deterministic retry tests.
+func (s *Simulator) DropNext(n int) {
+	s.mu.Lock()
+	s.dropNext = n
+	s.mu.Unlock()
+}
+
+// SetError answers every request naming an OID at or under prefix with the
+// given error status, e.g. gosnmp.TooBig, gosnmp.NoSuchName or
+// gosnmp.GenErr, and that varbind's error index. gosnmp.NoError removes it.
+func (s *Simulator) SetError(prefix string, status gosnmp.SNMPError) {
+	prefix, _, err := parseOID(prefix)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == gosnmp.NoError {
+		delete(s.errs, prefix)
+		return
+	}
+	s.errs[prefix] = status
+}
+
+// fault decides whether to drop the request and how long to delay it.
+func (s *Simulator) fault() (drop bool, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dropNext > 0 {
+		s.dropNext--
+		return true, 0
+	}
+	if s.loss > 0 && rand.Float64() < s.loss {
+		return true, 0
+	}
+	return false, s.delay
+}
+
+// forcedError returns the first forced status matching a varbind name.
+func (s *Simulator) forcedError(vars []gosnmp.SnmpPDU) (gosnmp.SNMPError, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errs) == 0 {
+		return gosnmp.NoError, 0
+	}
+	for i, v := range vars {
+		name := "." + strings.TrimPrefix(v.Name, ".")
+		for prefix, status := range s.errs {
+			if name == prefix || underPrefix(name, prefix) {
+				return status, i + 1
+			}
+		}
+	}
+	return gosnmp.NoError, 0
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Request handling
+// ─────────────────────────────────────────────────────────────────────────────
+
+func (s *Simulator) serve() {
+	defer s.wg.Done()
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			s.logger.Debug("snmptest: read failed", "error", err.Error())
+			continue
+		}
+		s.requests.Add(1)
+		msg := append([]byte(nil), buf[:n]...)
+		drop, delay := s.fault()
+		if drop {
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-s.done:
+					return
+				}
+			}
+			s.handle(msg, addr)
+		}()
+	}
+}
+
+// handle decodes one request and writes the response, if any.
+func (s *Simulator) handle(msg []byte, addr net.Addr) {
+	version, ok := peekVersion(msg)
+	if !ok {
+		s.logger.Debug("snmptest: undecodable request", "from", addr.String())
+		return
+	}
+
+	var req, resp *gosnmp.SnmpPacket
+	switch version {
+	case gosnmp.Version1, gosnmp.Version2c:
+		var err error
+		req, err = (&gosnmp.GoSNMP{Version: version}).SnmpDecodePacket(msg)
+		if err != nil {
+			s.logger.Debug("snmptest: undecodable request", "from", addr.String(), "error", err.Error())
+			return
+		}
+		if !containsString(s.opts.Communities, req.Community) {
+			s.logger.Debug("snmptest: unknown community", "from", addr.String())
+			return
+		}
+		resp = s.respond(req)
+	case gosnmp.Version3:
+		req, resp = s.handleV3(msg, addr)
+	}
+	if resp == nil {
+		return
+	}
+
+	out, err := resp.MarshalMsg()
+	if err == nil && len(out) > s.opts.MaxMessageSize {
+		out, err = s.shrink(resp, req.PDUType == gosnmp.GetBulkRequest)
+	}
+	if err != nil {
+		s.logger.Warn("snmptest: response encoding failed", "error", err.Error())
+		return
+	}
+	if _, err := s.conn.WriteTo(out, addr); err != nil {
+		s.logger.Debug("snmptest: write failed", "to", addr.String(), "error", err.Error())
+	}
+}
+
+// respond builds the response to a decoded request by reusing it, so the
+// version, community, request ID and v3 header carry over. It returns nil
+// for PDUs an agent does not answer.
+func (s *Simulator) respond(req *gosnmp.SnmpPacket) *gosnmp.SnmpPacket {
+	resp := *req
+	resp.PDUType = gosnmp.GetResponse
+	resp.Error = gosnmp.NoError
+	resp.ErrorIndex = 0
+	resp.NonRepeaters = 0
+	resp.MaxRepetitions = 0
+
+	if status, index := s.forcedError(req.Variables); status != gosnmp.NoError {
+		return withError(&resp, req, status, index)
+	}
+
+	v1 := req.Version == gosnmp.Version1
+	switch req.PDUType {
+	case gosnmp.GetRequest, gosnmp.GetNextRequest:
+		next := req.PDUType == gosnmp.GetNextRequest
+		resp.Variables = make([]gosnmp.SnmpPDU, 0, len(req.Variables))
+		for i, v := range req.Variables {
+			pdu, ok := s.dev.serve(v.Name, next)
+			if !ok && v1 {
+				return withError(&resp, req, gosnmp.NoSuchName, i+1)
+			}
+			if !ok {
+				pdu = gosnmp.SnmpPDU{Name: v.Name, Type: gosnmp.NoSuchObject}
+				if next {
+					pdu.Type = gosnmp.EndOfMibView
+				}
+			}
+			resp.Variables = append(resp.Variables, pdu)
+		}
+	case gosnmp.GetBulkRequest:
+		if v1 {
+			return nil
+		}
+		resp.Variables = s.bulk(req)
+	case gosnmp.SetRequest:
+		if v1 {
+			return withError(&resp, req, gosnmp.NoSuchName, 1)
+		}
+		return withError(&resp, req, gosnmp.NotWritable, 1)
+	default:
+		return nil
+	}
+	return &resp
+}
+
+// bulk answers a GetBulk: one GetNext for each non-repeater, then up to
+// max-repetitions rounds of GetNext over the remaining varbinds, stopping
+// early once every repeater has reached the end of the MIB view.
+func (s *Simulator) bulk(req *gosnmp.SnmpPacket) []gosnmp.SnmpPDU {
+	nonRep := min(int(req.NonRepeaters), len(req.Variables))
+	var out []gosnmp.SnmpPDU
+	for _, v := range req.Variables[:nonRep] {
+		pdu, ok := s.dev.serve(v.Name, true)
+		if !ok {
+			pdu = gosnmp.SnmpPDU{Name: v.Name, Type: gosnmp.EndOfMibView}
+		}
+		out = append(out, pdu)
+	}
+
+	cursors := make([]string, 0, len(req.Variables)-nonRep)
+	for _, v := range req.Variables[nonRep:] {
+		cursors = append(cursors, v.Name)
+	}
+	for r := 0; r < int(req.MaxRepetitions) && len(cursors) > 0; r++ {
+		ended := 0
+		for i, c := range cursors {
+			pdu, ok := s.dev.serve(c, true)
+			if !ok {
+				pdu = gosnmp.SnmpPDU{Name: c, Type: gosnmp.EndOfMibView}
+				ended++
+			}
+			cursors[i] = pdu.Name
+			out = append(out, pdu)
+		}
+		if ended == len(cursors) {
+			break
+		}
+	}
+	return out
+}
+
+// shrink handles a response over MaxMessageSize: GetBulk drops trailing
+// varbinds until it fits (unless BulkTooBig), everything else becomes an
+// empty tooBig response.
+func (s *Simulator) shrink(resp *gosnmp.SnmpPacket, bulk bool) ([]byte, error) {
+	if bulk && !s.opts.BulkTooBig && resp.Error == gosnmp.NoError {
+		for len(resp.Variables) > 1 {
+			resp.Variables = resp.Variables[:len(resp.Variables)-1]
+			out, err := resp.MarshalMsg()
+			if err != nil || len(out) <= s.opts.MaxMessageSize {
+				return out, err
+			}
+		}
+	}
+	resp.Error = gosnmp.TooBig
+	resp.ErrorIndex = 0
+	resp.Variables = nil
+	return resp.MarshalMsg()
+}
+
+// withError turns resp into an error response echoing the request varbinds.
+func withError(resp, req *gosnmp.SnmpPacket, status gosnmp.SNMPError, index int) *gosnmp.SnmpPacket {
+	resp.Error = status
+	resp.ErrorIndex = uint8(min(index, 255))
+	resp.Variables = make([]gosnmp.SnmpPDU, len(req.Variables))
+	for i, v := range req.Variables {
+		resp.Variables[i] = gosnmp.SnmpPDU{Name: v.Name, Type: gosnmp.Null}
+	}
+	return resp
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// SNMPv3 (USM)
+// ─────────────────────────────────────────────────────────────────────────────
+
+// usmStatsUnknownEngineIDs is reported to clients that do not know our
+// engine ID yet, which is how they discover it (RFC 3414 §4).
+const usmStatsUnknownEngineIDs = ".1.3.6.1.6.3.15.1.1.4.0"
+
+// initUSM builds the v3 decoder. gosnmp's trap security table picks the
+// user's localized keys by the user name in the message header; the empty
+// name is registered without credentials so discovery requests decode.
+func (s *Simulator) initUSM() error {
+	var logger gosnmp.Logger
+	table := gosnmp.NewSnmpV3SecurityParametersTable(logger)
+	s.users = make(map[string]usmUser, len(s.opts.Users))
+	for _, u := range s.opts.Users {
+		if u.Name == "" {
+			return errors.New("snmptest: SNMPv3 user without a name")
+		}
+		sp := u.securityParameters(s.opts.EngineID)
+		if err := table.Add(u.Name, sp); err != nil {
+			return fmt.Errorf("snmptest: user %s: %w", u.Name, err)
+		}
+		s.users[u.Name] = usmUser{params: sp, level: u.msgFlags()}
+	}
+	if err := table.Add("", &gosnmp.UsmSecurityParameters{}); err != nil {
+		return fmt.Errorf("snmptest: discovery parameters: %w", err)
+	}
+	s.v3 = &gosnmp.GoSNMP{
+		Version:                     gosnmp.Version3,
+		SecurityModel:               gosnmp.UserSecurityModel,
+		TrapSecurityParametersTable: table,
+	}
+	return nil
+}
+
+// usmUser is a configured user with its keys localized to our engine ID.
+type usmUser struct {
+	params *gosnmp.UsmSecurityParameters
+	level  gosnmp.SnmpV3MsgFlags
+}
+
+// handleV3 authenticates and decrypts a v3 request and answers it, or
+// reports our engine ID to a client that has not discovered it. Messages of
+// unknown users, with wrong keys or with another security level than the
+// user's are dropped, so the client times out. It returns the decoded
+// request along with the response.
+func (s *Simulator) handleV3(msg []byte, addr net.Addr) (*gosnmp.SnmpPacket, *gosnmp.SnmpPacket) {
+	if s.v3 == nil {
+		s.logger.Debug("snmptest: SNMPv3 request without configured users", "from", addr.String())
+		return nil, nil
+	}
+	req, err := s.v3.UnmarshalTrap(msg, false)
+	if err != nil {
+		s.logger.Debug("snmptest: SNMPv3 request rejected", "from", addr.String(), "error", err.Error())
+		return nil, nil
+	}
+	sp, ok := req.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		return nil, nil
+	}
+
+	if sp.AuthoritativeEngineID != s.opts.EngineID {
+		report := *req
+		report.PDUType = gosnmp.Report
+		report.MsgFlags = gosnmp.NoAuthNoPriv
+		report.ContextEngineID = s.opts.EngineID
+		report.SecurityParameters = &gosnmp.UsmSecurityParameters{
+			UserName:                 sp.UserName,
+			AuthoritativeEngineID:    s.opts.EngineID,
+			AuthoritativeEngineBoots: 1,
+			AuthoritativeEngineTime:  s.uptime() / 100,
+		}
+		report.Variables = []gosnmp.SnmpPDU{{
+			Name:  usmStatsUnknownEngineIDs,
+			Type:  gosnmp.Counter32,
+			Value: s.unknownEngines.Add(1),
+		}}
+		return req, &report
+	}
+
+	user, ok := s.users[sp.UserName]
+	if !ok || req.MsgFlags&gosnmp.AuthPriv != user.level {
+		s.logger.Debug("snmptest: SNMPv3 request rejected", "from", addr.String(), "user", sp.UserName)
+		return nil, nil
+	}
+
+	resp := s.respond(req)
+	if resp == nil {
+		return nil, nil
+	}
+	resp.MsgFlags &^= gosnmp.Reportable
+	out := sp.Copy().(*gosnmp.UsmSecurityParameters)
+	out.AuthoritativeEngineTime = s.uptime() / 100
+	resp.SecurityParameters = out
+	if err := user.params.InitPacket(resp); err != nil {
+		s.logger.Warn("snmptest: SNMPv3 salt allocation failed", "error", err.Error())
+		return nil, nil
+	}
+	return req, resp
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Notifications
+// ─────────────────────────────────────────────────────────────────────────────
+
+// OIDs prepended to SNMPv2 notifications.
+const (
+	oidSysUpTime   = ".1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID = ".1.3.6.1.6.3.1.1.4.1.0"
+)
+
+// Notification is a trap or inform sent by Notify.
+type Notification struct {
+	// Version is gosnmp.Version1, Version2c or Version3. gosnmp.Version1 is
+	// the zero value, so a notification without an Enterprise is sent as
+	// Version2c unless another version is set.
+	Version gosnmp.SnmpVersion
+
+	// Community defaults to the first Options.Communities entry.
+	Community string
+
+	// User names one of Options.Users for SNMPv3.
+	User string
+
+	// Inform sends an InformRequest and waits for the acknowledgement
+	// (v2c and v3 only).
+	Inform bool
+
+	// TrapOID is sent as snmpTrapOID.0 after sysUpTime.0 (v2c and v3).
+	TrapOID string
+
+	// Varbinds follow sysUpTime.0 and snmpTrapOID.0.
+	Varbinds []gosnmp.SnmpPDU
+
+	// Enterprise, GenericTrap and SpecificTrap form the SNMPv1 trap header.
+	Enterprise   string
+	GenericTrap  int
+	SpecificTrap int
+
+	// Timeout is how long an inform waits for its acknowledgement
+	// (default 2 s); Retries is how often it is resent.
+	Timeout time.Duration
+	Retries int
+}
+
+// SendTrap sends an SNMPv2c trap with the given trapOID and varbinds to target
+// ("host:port", port 162 when omitted).
+func (s *Simulator) SendTrap(target, trapOID string, varbinds ...gosnmp.SnmpPDU) error {
+	return s.Notify(target, Notification{TrapOID: trapOID, Varbinds: varbinds})
+}
+
+// SendInform sends an SNMPv2c inform like SendTrap and waits for the
+// receiver's acknowledgement.
+func (s *Simulator) SendInform(target, trapOID string, varbinds ...gosnmp.SnmpPDU) error {
+	return s.Notify(target, Notification{TrapOID: trapOID, Varbinds: varbinds, Inform: true})
+}
+
+// Notify sends n to target ("host:port", port 162 when omitted). sysUpTime.0
+// is the Simulator's uptime. An SNMPv3 trap is sent with the Simulator as the
+// authoritative engine; an SNMPv3 inform first discovers the receiver's.
+func (s *Simulator) Notify(target string, n Notification) error {
+	host, port, err := splitHostPort(target)
+	if err != nil {
+		return err
+	}
+	if n.Version == gosnmp.Version1 && n.Enterprise == "" {
+		n.Version = gosnmp.Version2c
+	}
+	if n.Timeout <= 0 {
+		n.Timeout = 2 * time.Second
+	}
+	g := &gosnmp.GoSNMP{
+		Target:    host,
+		Port:      port,
+		Version:   n.Version,
+		Community: n.Community,
+		Timeout:   n.Timeout,
+		Retries:   n.Retries,
+	}
+	if g.Community == "" {
+		g.Community = s.opts.Communities[0]
+	}
+	if n.Version == gosnmp.Version3 {
+		var user *User
+		for i := range s.opts.Users {
+			if s.opts.Users[i].Name == n.User {
+				user = &s.opts.Users[i]
+			}
+		}
+		if user == nil {
+			return fmt.Errorf("snmptest: unknown SNMPv3 user %q", n.User)
+		}
+		engineID := s.opts.EngineID
+		if n.Inform {
+			engineID = ""
+		}
+		sp := user.securityParameters(engineID)
+		sp.AuthoritativeEngineTime = s.uptime() / 100
+		g.SecurityModel = gosnmp.UserSecurityModel
+		g.MsgFlags = user.msgFlags()
+		g.SecurityParameters = sp
+	}
+	if err := g.Connect(); err != nil {
+		return fmt.Errorf("snmptest: connect %s: %w", target, err)
+	}
+	defer g.Conn.Close()
+
+	trap := gosnmp.SnmpTrap{IsInform: n.Inform}
+	if n.Version == gosnmp.Version1 {
+		trap.Variables = n.Varbinds
+		trap.Enterprise = strings.TrimPrefix(n.Enterprise, ".")
+		trap.AgentAddress = "127.0.0.1"
+		if agent, _, err := net.SplitHostPort(s.Address()); err == nil {
+			if ip := net.ParseIP(agent); ip != nil && ip.To4() != nil && !ip.IsUnspecified() {
+				trap.AgentAddress = ip.String()
+			}
+		}
+		trap.GenericTrap = n.GenericTrap
+		trap.SpecificTrap = n.SpecificTrap
+		trap.Timestamp = uint(s.uptime())
+	} else {
+		trap.Variables = append([]gosnmp.SnmpPDU{
+			{Name: oidSysUpTime, Type: gosnmp.TimeTicks, Value: s.uptime()},
+			{Name: oidSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: n.TrapOID},
+		}, n.Varbinds...)
+	}
+	if _, err := g.SendTrap(trap); err != nil {
+		return fmt.Errorf("snmptest: send to %s: %w", target, err)
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// peekVersion reads the version field at the start of an SNMP message.
+func peekVersion(msg []byte) (gosnmp.SnmpVersion, bool) {
+	if len(msg) < 2 || msg[0] != 0x30 {
+		return 0, false
+	}
+	i := 2
+	if msg[1]&0x80 != 0 {
+		i += int(msg[1] & 0x7f)
+	}
+	if len(msg) < i+3 || msg[i] != 0x02 || msg[i+1] != 0x01 {
+		return 0, false
+	}
+	switch v := gosnmp.SnmpVersion(msg[i+2]); v {
+	case gosnmp.Version1, gosnmp.Version2c, gosnmp.Version3:
+		return v, true
+	}
+	return 0, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHostPort splits a "host:port" target, defaulting the port to 162.
+func splitHostPort(target string) (string, uint16, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return target, 162, nil
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("snmptest: target %q: bad port", target)
+	}
+	return host, uint16(port), nil
+}
+
+// noopWriter discards log output when no logger is given.
+type noopWriter struct{}
+
+func (noopWriter) Write(b []byte) (int, error) { return len(b), nil }